@@ -198,7 +198,7 @@ func (e *appExaminer) AppStatus(appName string) (AppInfo, error) {
 		return AppInfo{}, errors.New(AppNotFoundErrorMessage)
 	}
 
-	containerMetrics, err := e.noaaConsumer.GetContainerMetrics(appName, "")
+	containerMetrics, err := e.noaaConsumer.GetContainerMetrics(appName)
 	if err != nil {
 		return *appInfoPtr, nil
 	}
@@ -612,9 +612,7 @@ var _ = Describe("AppExaminer", func() {
 				Expect(fakeReceptorClient.ActualLRPsByProcessGuidArgsForCall(0)).To(Equal("peekaboo-app"))
 
 				Expect(fakeNoaaConsumer.GetContainerMetricsCallCount()).To(Equal(1))
-				appGuid, token := fakeNoaaConsumer.GetContainerMetricsArgsForCall(0)
-				Expect(appGuid).To(Equal("peekaboo-app"))
-				Expect(token).To(BeEmpty())
+				Expect(fakeNoaaConsumer.GetContainerMetricsArgsForCall(0)).To(Equal("peekaboo-app"))
 			})
 
 			Describe("Monitors", func() {
@@ -757,9 +755,7 @@ var _ = Describe("AppExaminer", func() {
 					Expect(fakeReceptorClient.ActualLRPsByProcessGuidArgsForCall(0)).To(Equal("peekaboo-app"))
 
 					Expect(fakeNoaaConsumer.GetContainerMetricsCallCount()).To(Equal(1))
-					appGuid, token := fakeNoaaConsumer.GetContainerMetricsArgsForCall(0)
-					Expect(appGuid).To(Equal("peekaboo-app"))
-					Expect(token).To(BeEmpty())
+					Expect(fakeNoaaConsumer.GetContainerMetricsArgsForCall(0)).To(Equal("peekaboo-app"))
 				})
 			})
 
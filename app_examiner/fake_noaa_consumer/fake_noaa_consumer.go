@@ -9,11 +9,10 @@ import (
 )
 
 type FakeNoaaConsumer struct {
-	GetContainerMetricsStub        func(string, string) ([]*events.ContainerMetric, error)
+	GetContainerMetricsStub        func(appGuid string) ([]*events.ContainerMetric, error)
 	getContainerMetricsMutex       sync.RWMutex
 	getContainerMetricsArgsForCall []struct {
-		arg1 string
-		arg2 string
+		appGuid string
 	}
 	getContainerMetricsReturns struct {
 		result1 []*events.ContainerMetric
@@ -21,15 +20,14 @@ type FakeNoaaConsumer struct {
 	}
 }
 
-func (fake *FakeNoaaConsumer) GetContainerMetrics(arg1 string, arg2 string) ([]*events.ContainerMetric, error) {
+func (fake *FakeNoaaConsumer) GetContainerMetrics(appGuid string) ([]*events.ContainerMetric, error) {
 	fake.getContainerMetricsMutex.Lock()
 	fake.getContainerMetricsArgsForCall = append(fake.getContainerMetricsArgsForCall, struct {
-		arg1 string
-		arg2 string
-	}{arg1, arg2})
+		appGuid string
+	}{appGuid})
 	fake.getContainerMetricsMutex.Unlock()
 	if fake.GetContainerMetricsStub != nil {
-		return fake.GetContainerMetricsStub(arg1, arg2)
+		return fake.GetContainerMetricsStub(appGuid)
 	} else {
 		return fake.getContainerMetricsReturns.result1, fake.getContainerMetricsReturns.result2
 	}
@@ -41,10 +39,10 @@ func (fake *FakeNoaaConsumer) GetContainerMetricsCallCount() int {
 	return len(fake.getContainerMetricsArgsForCall)
 }
 
-func (fake *FakeNoaaConsumer) GetContainerMetricsArgsForCall(i int) (string, string) {
+func (fake *FakeNoaaConsumer) GetContainerMetricsArgsForCall(i int) string {
 	fake.getContainerMetricsMutex.RLock()
 	defer fake.getContainerMetricsMutex.RUnlock()
-	return fake.getContainerMetricsArgsForCall[i].arg1, fake.getContainerMetricsArgsForCall[i].arg2
+	return fake.getContainerMetricsArgsForCall[i].appGuid
 }
 
 func (fake *FakeNoaaConsumer) GetContainerMetricsReturns(result1 []*events.ContainerMetric, result2 error) {
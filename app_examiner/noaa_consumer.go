@@ -1,25 +1,36 @@
 package app_examiner
 
 import (
-	"github.com/cloudfoundry/noaa"
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	"github.com/cloudfoundry/sonde-go/events"
 )
 
 //go:generate counterfeiter -o fake_noaa_consumer/fake_noaa_consumer.go . NoaaConsumer
 type NoaaConsumer interface {
-	GetContainerMetrics(appGuid, token string) ([]*events.ContainerMetric, error)
+	GetContainerMetrics(appGuid string) ([]*events.ContainerMetric, error)
+}
+
+type metricsConsumer interface {
+	ContainerMetrics(appGuid, authToken string) ([]*events.ContainerMetric, error)
 }
 
 type noaaConsumer struct {
-	consumer *noaa.Consumer
+	consumer    metricsConsumer
+	tokenSource auth.TokenSource
 }
 
-func NewNoaaConsumer(consumer *noaa.Consumer) NoaaConsumer {
+func NewNoaaConsumer(consumer metricsConsumer, tokenSource auth.TokenSource) NoaaConsumer {
 	return &noaaConsumer{
-		consumer: consumer,
+		consumer:    consumer,
+		tokenSource: tokenSource,
 	}
 }
 
-func (n *noaaConsumer) GetContainerMetrics(appGuid, token string) ([]*events.ContainerMetric, error) {
-	return n.consumer.ContainerMetrics(appGuid, token)
+func (n *noaaConsumer) GetContainerMetrics(appGuid string) ([]*events.ContainerMetric, error) {
+	token, err := n.tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return n.consumer.ContainerMetrics(appGuid, auth.AuthorizationHeader(token))
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/cloudfoundry-incubator/ltc/logs/reserved_app_ids"
 	"github.com/cloudfoundry-incubator/ltc/route_helpers"
 	"github.com/cloudfoundry-incubator/receptor"
+	"github.com/pivotal-golang/clock"
 )
 
 type MonitorMethod int
@@ -22,6 +23,7 @@ type AppRunner interface {
 	ScaleApp(name string, instances int) error
 	UpdateAppRoutes(name string, routes RouteOverrides) error
 	UpdateApp(updateAppParams UpdateAppParams) error
+	ReplaceApp(params CreateAppParams) error
 	RemoveApp(name string) error
 }
 
@@ -96,16 +98,22 @@ const (
 	CustomMonitor
 
 	AttemptedToCreateLatticeDebugErrorMessage = reserved_app_ids.LatticeDebugLogStreamAppId + " is a reserved app name. It is used internally to stream debug logs for lattice components."
+
+	// ReplaceApp runs the new LRP under the app's name with this suffix
+	// until it can replace the app's own LRP.
+	replacementSuffix     = "-replacement"
+	defaultReplaceTimeout = 2 * time.Minute
 )
 
 type appRunner struct {
 	receptorClient receptor.Client
 	systemDomain   string
 	keygen         KeyGenerator
+	clock          clock.Clock
 }
 
-func New(receptorClient receptor.Client, systemDomain string, keygen KeyGenerator) AppRunner {
-	return &appRunner{receptorClient, systemDomain, keygen}
+func New(receptorClient receptor.Client, systemDomain string, keygen KeyGenerator, clock clock.Clock) AppRunner {
+	return &appRunner{receptorClient, systemDomain, keygen, clock}
 }
 
 func (appRunner *appRunner) CreateApp(params CreateAppParams) error {
@@ -186,6 +194,83 @@ func (appRunner *appRunner) UpdateApp(params UpdateAppParams) error {
 	return err
 }
 
+// ReplaceApp replaces the desired LRP of a running app with one built from
+// params without stopping the app.  The receptor can't change the actions of
+// a desired LRP in place, so the new LRP is first desired under a temporary
+// process guid, with the app's routes and logs, and the app's own LRP is
+// replaced only once the temporary one is running.  The temporary LRP is
+// removed once the replaced LRP is running too.
+func (appRunner *appRunner) ReplaceApp(params CreateAppParams) error {
+	if exists, err := appRunner.desiredLRPExists(params.Name); err != nil {
+		return err
+	} else if !exists {
+		return newAppNotStartedError(params.Name)
+	}
+
+	req, err := appRunner.desiredLRPCreateRequest(params)
+	if err != nil {
+		return err
+	}
+
+	timeout := params.Timeout
+	if timeout == 0 {
+		timeout = defaultReplaceTimeout
+	}
+
+	replacementGuid := params.Name + replacementSuffix
+	replacementReq := req
+	replacementReq.ProcessGuid = replacementGuid
+	if err := appRunner.receptorClient.CreateDesiredLRP(replacementReq); err != nil {
+		return err
+	}
+
+	if err := appRunner.waitForRunningInstances(replacementGuid, params.Instances, timeout); err != nil {
+		appRunner.receptorClient.DeleteDesiredLRP(replacementGuid)
+		return err
+	}
+
+	if err := appRunner.receptorClient.DeleteDesiredLRP(params.Name); err != nil {
+		appRunner.receptorClient.DeleteDesiredLRP(replacementGuid)
+		return err
+	}
+
+	if err := appRunner.receptorClient.CreateDesiredLRP(req); err != nil {
+		return fmt.Errorf("%s is served by %s until it is launched again: %s", params.Name, replacementGuid, err)
+	}
+
+	if err := appRunner.waitForRunningInstances(params.Name, params.Instances, timeout); err != nil {
+		return fmt.Errorf("%s is also served by %s until it starts: %s", params.Name, replacementGuid, err)
+	}
+
+	return appRunner.receptorClient.DeleteDesiredLRP(replacementGuid)
+}
+
+// waitForRunningInstances polls the receptor until the desired LRP has the
+// given number of running instances.
+func (appRunner *appRunner) waitForRunningInstances(processGuid string, instances int, timeout time.Duration) error {
+	startingTime := appRunner.clock.Now()
+	for startingTime.Add(timeout).After(appRunner.clock.Now()) {
+		actualLRPs, err := appRunner.receptorClient.ActualLRPsByProcessGuid(processGuid)
+		if err != nil {
+			return err
+		}
+
+		running := 0
+		for _, actualLRP := range actualLRPs {
+			if actualLRP.State == receptor.ActualLRPStateRunning {
+				running++
+			}
+		}
+		if running >= instances {
+			return nil
+		}
+
+		appRunner.clock.Sleep(1 * time.Second)
+	}
+
+	return fmt.Errorf("timed out waiting for %s to start", processGuid)
+}
+
 func (appRunner *appRunner) RemoveApp(name string) error {
 	if lrpExists, err := appRunner.desiredLRPExists(name); err != nil {
 		return err
@@ -257,11 +342,20 @@ func (appRunner *appRunner) buildRoutesWithDefaults(params CreateAppParams, prim
 }
 
 func (appRunner *appRunner) desireLrp(params CreateAppParams) error {
+	req, err := appRunner.desiredLRPCreateRequest(params)
+	if err != nil {
+		return err
+	}
+
+	return appRunner.receptorClient.CreateDesiredLRP(req)
+}
+
+func (appRunner *appRunner) desiredLRPCreateRequest(params CreateAppParams) (receptor.DesiredLRPCreateRequest, error) {
 	primaryPort := route_helpers.GetPrimaryPort(params.Monitor.Port, params.ExposedPorts)
 
 	private, public, err := appRunner.keygen.GenerateRSAKeyPair(2048)
 	if err != nil {
-		return err
+		return receptor.DesiredLRPCreateRequest{}, err
 	}
 
 	routes := appRunner.buildRoutesWithDefaults(params, primaryPort)
@@ -295,7 +389,7 @@ func (appRunner *appRunner) desireLrp(params CreateAppParams) error {
 
 	vcapAppBytes, err := json.Marshal(vcapApplication)
 	if err != nil {
-		return err
+		return receptor.DesiredLRPCreateRequest{}, err
 	}
 
 	envVars := buildEnvironmentVariables(params.EnvironmentVariables)
@@ -327,7 +421,7 @@ func (appRunner *appRunner) desireLrp(params CreateAppParams) error {
 
 	hostKey, err := appRunner.keygen.GenerateRSAPrivateKey(2048)
 	if err != nil {
-		return err
+		return receptor.DesiredLRPCreateRequest{}, err
 	}
 
 	req := receptor.DesiredLRPCreateRequest{
@@ -406,7 +500,7 @@ func (appRunner *appRunner) desireLrp(params CreateAppParams) error {
 		}
 	}
 
-	return appRunner.receptorClient.CreateDesiredLRP(req)
+	return req, nil
 }
 
 func (appRunner *appRunner) updateLrpInstances(name string, instances int) error {
@@ -16,6 +16,7 @@ import (
 	"github.com/cloudfoundry-incubator/ltc/route_helpers"
 	"github.com/cloudfoundry-incubator/receptor"
 	"github.com/cloudfoundry-incubator/receptor/fake_receptor"
+	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 var _ = Describe("AppRunner", func() {
@@ -23,13 +24,15 @@ var _ = Describe("AppRunner", func() {
 	var (
 		fakeReceptorClient *fake_receptor.FakeClient
 		fakeKeyGenerator   *fake_keygen.FakeKeyGenerator
+		fakeClock          *fakeclock.FakeClock
 		appRunner          app_runner.AppRunner
 	)
 
 	BeforeEach(func() {
 		fakeReceptorClient = &fake_receptor.FakeClient{}
 		fakeKeyGenerator = &fake_keygen.FakeKeyGenerator{}
-		appRunner = app_runner.New(fakeReceptorClient, "myDiegoInstall.com", fakeKeyGenerator)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		appRunner = app_runner.New(fakeReceptorClient, "myDiegoInstall.com", fakeKeyGenerator, fakeClock)
 
 		fakeKeyGenerator.GenerateRSAPrivateKeyReturns("THIS IS A PRIVATE HOST KEY", nil)
 		fakeKeyGenerator.GenerateRSAKeyPairReturns("THIS IS A PRIVATE KEY", "THIS IS A PUBLIC KEY", nil)
@@ -1049,6 +1052,123 @@ var _ = Describe("AppRunner", func() {
 		})
 	})
 
+	Describe("ReplaceApp", func() {
+		var replaceAppParams app_runner.CreateAppParams
+
+		BeforeEach(func() {
+			replaceAppParams = app_runner.CreateAppParams{
+				AppEnvironmentParams: app_runner.AppEnvironmentParams{
+					Instances:    2,
+					ExposedPorts: []uint16{8080},
+					User:         "vcap",
+				},
+				Name:         "americano-app",
+				StartCommand: "/app-run-statement",
+				Annotation:   "new annotation",
+				Timeout:      time.Minute,
+			}
+
+			fakeReceptorClient.DesiredLRPsReturns([]receptor.DesiredLRPResponse{
+				{ProcessGuid: "americano-app", Instances: 1},
+			}, nil)
+			fakeReceptorClient.ActualLRPsByProcessGuidReturns([]receptor.ActualLRPResponse{
+				{State: receptor.ActualLRPStateRunning},
+				{State: receptor.ActualLRPStateRunning},
+			}, nil)
+		})
+
+		It("replaces the desired LRP of the app once the new LRP is running", func() {
+			Expect(appRunner.ReplaceApp(replaceAppParams)).To(Succeed())
+
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(2))
+			replacementReq := fakeReceptorClient.CreateDesiredLRPArgsForCall(0)
+			Expect(replacementReq.ProcessGuid).To(Equal("americano-app-replacement"))
+			Expect(replacementReq.LogGuid).To(Equal("americano-app"))
+			Expect(replacementReq.Instances).To(Equal(2))
+			Expect(replacementReq.Annotation).To(Equal("new annotation"))
+			req := fakeReceptorClient.CreateDesiredLRPArgsForCall(1)
+			Expect(req.ProcessGuid).To(Equal("americano-app"))
+			Expect(req.Routes).To(Equal(replacementReq.Routes))
+
+			Expect(fakeReceptorClient.ActualLRPsByProcessGuidCallCount()).To(Equal(2))
+			Expect(fakeReceptorClient.ActualLRPsByProcessGuidArgsForCall(0)).To(Equal("americano-app-replacement"))
+			Expect(fakeReceptorClient.ActualLRPsByProcessGuidArgsForCall(1)).To(Equal("americano-app"))
+
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(2))
+			Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(0)).To(Equal("americano-app"))
+			Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(1)).To(Equal("americano-app-replacement"))
+		})
+
+		It("returns an error if the app is not started", func() {
+			replaceAppParams.Name = "app-not-running"
+
+			err := appRunner.ReplaceApp(replaceAppParams)
+			Expect(err).To(MatchError("app-not-running is not started."))
+
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(BeZero())
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("keeps the app when the new desired LRP can't be built", func() {
+			fakeKeyGenerator.GenerateRSAKeyPairReturns("", "", errors.New("no entropy"))
+
+			err := appRunner.ReplaceApp(replaceAppParams)
+			Expect(err).To(MatchError("no entropy"))
+
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(BeZero())
+		})
+
+		It("keeps the app when the new LRP doesn't start in time", func() {
+			fakeReceptorClient.ActualLRPsByProcessGuidReturns([]receptor.ActualLRPResponse{
+				{State: receptor.ActualLRPStateRunning},
+				{State: receptor.ActualLRPStateClaimed},
+			}, nil)
+
+			errChan := make(chan error)
+			go func() {
+				errChan <- appRunner.ReplaceApp(replaceAppParams)
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.IncrementBySeconds(61)
+
+			Eventually(errChan).Should(Receive(MatchError("timed out waiting for americano-app-replacement to start")))
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(0)).To(Equal("americano-app-replacement"))
+		})
+
+		It("keeps serving the app from the new LRP when the app's LRP can't be desired again", func() {
+			fakeReceptorClient.CreateDesiredLRPStub = func(req receptor.DesiredLRPCreateRequest) error {
+				if req.ProcessGuid == "americano-app" {
+					return errors.New("creating failed")
+				}
+				return nil
+			}
+
+			err := appRunner.ReplaceApp(replaceAppParams)
+			Expect(err).To(MatchError("americano-app is served by americano-app-replacement until it is launched again: creating failed"))
+
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(0)).To(Equal("americano-app"))
+		})
+
+		It("removes the new LRP when the app's LRP can't be deleted", func() {
+			fakeReceptorClient.DeleteDesiredLRPStub = func(processGuid string) error {
+				if processGuid == "americano-app" {
+					return errors.New("deleting failed")
+				}
+				return nil
+			}
+
+			err := appRunner.ReplaceApp(replaceAppParams)
+			Expect(err).To(MatchError("deleting failed"))
+
+			Expect(fakeReceptorClient.CreateDesiredLRPCallCount()).To(Equal(1))
+			Expect(fakeReceptorClient.DeleteDesiredLRPCallCount()).To(Equal(2))
+			Expect(fakeReceptorClient.DeleteDesiredLRPArgsForCall(1)).To(Equal("americano-app-replacement"))
+		})
+	})
+
 	Describe("RemoveApp", func() {
 		It("Removes a Docker App", func() {
 			desiredLRPs := []receptor.DesiredLRPResponse{
@@ -51,6 +51,14 @@ type FakeAppRunner struct {
 	updateAppReturns struct {
 		result1 error
 	}
+	ReplaceAppStub        func(params app_runner.CreateAppParams) error
+	replaceAppMutex       sync.RWMutex
+	replaceAppArgsForCall []struct {
+		params app_runner.CreateAppParams
+	}
+	replaceAppReturns struct {
+		result1 error
+	}
 	RemoveAppStub        func(name string) error
 	removeAppMutex       sync.RWMutex
 	removeAppArgsForCall []struct {
@@ -224,6 +232,38 @@ func (fake *FakeAppRunner) UpdateAppReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppRunner) ReplaceApp(params app_runner.CreateAppParams) error {
+	fake.replaceAppMutex.Lock()
+	fake.replaceAppArgsForCall = append(fake.replaceAppArgsForCall, struct {
+		params app_runner.CreateAppParams
+	}{params})
+	fake.replaceAppMutex.Unlock()
+	if fake.ReplaceAppStub != nil {
+		return fake.ReplaceAppStub(params)
+	} else {
+		return fake.replaceAppReturns.result1
+	}
+}
+
+func (fake *FakeAppRunner) ReplaceAppCallCount() int {
+	fake.replaceAppMutex.RLock()
+	defer fake.replaceAppMutex.RUnlock()
+	return len(fake.replaceAppArgsForCall)
+}
+
+func (fake *FakeAppRunner) ReplaceAppArgsForCall(i int) app_runner.CreateAppParams {
+	fake.replaceAppMutex.RLock()
+	defer fake.replaceAppMutex.RUnlock()
+	return fake.replaceAppArgsForCall[i].params
+}
+
+func (fake *FakeAppRunner) ReplaceAppReturns(result1 error) {
+	fake.ReplaceAppStub = nil
+	fake.replaceAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAppRunner) RemoveApp(name string) error {
 	fake.removeAppMutex.Lock()
 	fake.removeAppArgsForCall = append(fake.removeAppArgsForCall, struct {
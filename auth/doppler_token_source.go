@@ -0,0 +1,22 @@
+package auth
+
+import config_package "github.com/cloudfoundry-incubator/ltc/config"
+
+type dopplerTokenSource struct {
+	config      *config_package.Config
+	tokenSource TokenSource
+}
+
+// NewDopplerTokenSource returns the TokenSource for streaming from doppler,
+// which prefers the configured log-stream token over tokenSource.
+func NewDopplerTokenSource(config *config_package.Config, tokenSource TokenSource) TokenSource {
+	return &dopplerTokenSource{config, tokenSource}
+}
+
+func (d *dopplerTokenSource) Token() (string, error) {
+	if authToken := d.config.Doppler().AuthToken; authToken != "" {
+		return authToken, nil
+	}
+
+	return d.tokenSource.Token()
+}
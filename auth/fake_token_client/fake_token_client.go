@@ -0,0 +1,103 @@
+// This file was generated by counterfeiter
+package fake_token_client
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+)
+
+type FakeTokenClient struct {
+	PasswordGrantStub        func(endpoint, username, password string) (auth.Token, error)
+	passwordGrantMutex       sync.RWMutex
+	passwordGrantArgsForCall []struct {
+		endpoint string
+		username string
+		password string
+	}
+	passwordGrantReturns struct {
+		result1 auth.Token
+		result2 error
+	}
+	RefreshGrantStub        func(endpoint, refreshToken string) (auth.Token, error)
+	refreshGrantMutex       sync.RWMutex
+	refreshGrantArgsForCall []struct {
+		endpoint     string
+		refreshToken string
+	}
+	refreshGrantReturns struct {
+		result1 auth.Token
+		result2 error
+	}
+}
+
+func (fake *FakeTokenClient) PasswordGrant(endpoint string, username string, password string) (auth.Token, error) {
+	fake.passwordGrantMutex.Lock()
+	fake.passwordGrantArgsForCall = append(fake.passwordGrantArgsForCall, struct {
+		endpoint string
+		username string
+		password string
+	}{endpoint, username, password})
+	fake.passwordGrantMutex.Unlock()
+	if fake.PasswordGrantStub != nil {
+		return fake.PasswordGrantStub(endpoint, username, password)
+	} else {
+		return fake.passwordGrantReturns.result1, fake.passwordGrantReturns.result2
+	}
+}
+
+func (fake *FakeTokenClient) PasswordGrantCallCount() int {
+	fake.passwordGrantMutex.RLock()
+	defer fake.passwordGrantMutex.RUnlock()
+	return len(fake.passwordGrantArgsForCall)
+}
+
+func (fake *FakeTokenClient) PasswordGrantArgsForCall(i int) (string, string, string) {
+	fake.passwordGrantMutex.RLock()
+	defer fake.passwordGrantMutex.RUnlock()
+	return fake.passwordGrantArgsForCall[i].endpoint, fake.passwordGrantArgsForCall[i].username, fake.passwordGrantArgsForCall[i].password
+}
+
+func (fake *FakeTokenClient) PasswordGrantReturns(result1 auth.Token, result2 error) {
+	fake.PasswordGrantStub = nil
+	fake.passwordGrantReturns = struct {
+		result1 auth.Token
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTokenClient) RefreshGrant(endpoint string, refreshToken string) (auth.Token, error) {
+	fake.refreshGrantMutex.Lock()
+	fake.refreshGrantArgsForCall = append(fake.refreshGrantArgsForCall, struct {
+		endpoint     string
+		refreshToken string
+	}{endpoint, refreshToken})
+	fake.refreshGrantMutex.Unlock()
+	if fake.RefreshGrantStub != nil {
+		return fake.RefreshGrantStub(endpoint, refreshToken)
+	} else {
+		return fake.refreshGrantReturns.result1, fake.refreshGrantReturns.result2
+	}
+}
+
+func (fake *FakeTokenClient) RefreshGrantCallCount() int {
+	fake.refreshGrantMutex.RLock()
+	defer fake.refreshGrantMutex.RUnlock()
+	return len(fake.refreshGrantArgsForCall)
+}
+
+func (fake *FakeTokenClient) RefreshGrantArgsForCall(i int) (string, string) {
+	fake.refreshGrantMutex.RLock()
+	defer fake.refreshGrantMutex.RUnlock()
+	return fake.refreshGrantArgsForCall[i].endpoint, fake.refreshGrantArgsForCall[i].refreshToken
+}
+
+func (fake *FakeTokenClient) RefreshGrantReturns(result1 auth.Token, result2 error) {
+	fake.RefreshGrantStub = nil
+	fake.refreshGrantReturns = struct {
+		result1 auth.Token
+		result2 error
+	}{result1, result2}
+}
+
+var _ auth.TokenClient = new(FakeTokenClient)
@@ -0,0 +1,107 @@
+// This file was generated by counterfeiter
+package fake_token_manager
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+)
+
+type FakeTokenManager struct {
+	TokenStub        func() (string, error)
+	tokenMutex       sync.RWMutex
+	tokenArgsForCall []struct{}
+	tokenReturns     struct {
+		result1 string
+		result2 error
+	}
+	LoginStub        func(tokenEndpoint, username, password string) error
+	loginMutex       sync.RWMutex
+	loginArgsForCall []struct {
+		tokenEndpoint string
+		username      string
+		password      string
+	}
+	loginReturns struct {
+		result1 error
+	}
+	LogoutStub        func()
+	logoutMutex       sync.RWMutex
+	logoutArgsForCall []struct{}
+}
+
+func (fake *FakeTokenManager) Token() (string, error) {
+	fake.tokenMutex.Lock()
+	fake.tokenArgsForCall = append(fake.tokenArgsForCall, struct{}{})
+	fake.tokenMutex.Unlock()
+	if fake.TokenStub != nil {
+		return fake.TokenStub()
+	} else {
+		return fake.tokenReturns.result1, fake.tokenReturns.result2
+	}
+}
+
+func (fake *FakeTokenManager) TokenCallCount() int {
+	fake.tokenMutex.RLock()
+	defer fake.tokenMutex.RUnlock()
+	return len(fake.tokenArgsForCall)
+}
+
+func (fake *FakeTokenManager) TokenReturns(result1 string, result2 error) {
+	fake.TokenStub = nil
+	fake.tokenReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeTokenManager) Login(tokenEndpoint string, username string, password string) error {
+	fake.loginMutex.Lock()
+	fake.loginArgsForCall = append(fake.loginArgsForCall, struct {
+		tokenEndpoint string
+		username      string
+		password      string
+	}{tokenEndpoint, username, password})
+	fake.loginMutex.Unlock()
+	if fake.LoginStub != nil {
+		return fake.LoginStub(tokenEndpoint, username, password)
+	} else {
+		return fake.loginReturns.result1
+	}
+}
+
+func (fake *FakeTokenManager) LoginCallCount() int {
+	fake.loginMutex.RLock()
+	defer fake.loginMutex.RUnlock()
+	return len(fake.loginArgsForCall)
+}
+
+func (fake *FakeTokenManager) LoginArgsForCall(i int) (string, string, string) {
+	fake.loginMutex.RLock()
+	defer fake.loginMutex.RUnlock()
+	return fake.loginArgsForCall[i].tokenEndpoint, fake.loginArgsForCall[i].username, fake.loginArgsForCall[i].password
+}
+
+func (fake *FakeTokenManager) LoginReturns(result1 error) {
+	fake.LoginStub = nil
+	fake.loginReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTokenManager) Logout() {
+	fake.logoutMutex.Lock()
+	fake.logoutArgsForCall = append(fake.logoutArgsForCall, struct{}{})
+	fake.logoutMutex.Unlock()
+	if fake.LogoutStub != nil {
+		fake.LogoutStub()
+	}
+}
+
+func (fake *FakeTokenManager) LogoutCallCount() int {
+	fake.logoutMutex.RLock()
+	defer fake.logoutMutex.RUnlock()
+	return len(fake.logoutArgsForCall)
+}
+
+var _ auth.TokenManager = new(FakeTokenManager)
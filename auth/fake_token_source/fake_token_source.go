@@ -0,0 +1,45 @@
+// This file was generated by counterfeiter
+package fake_token_source
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+)
+
+type FakeTokenSource struct {
+	TokenStub        func() (string, error)
+	tokenMutex       sync.RWMutex
+	tokenArgsForCall []struct{}
+	tokenReturns     struct {
+		result1 string
+		result2 error
+	}
+}
+
+func (fake *FakeTokenSource) Token() (string, error) {
+	fake.tokenMutex.Lock()
+	fake.tokenArgsForCall = append(fake.tokenArgsForCall, struct{}{})
+	fake.tokenMutex.Unlock()
+	if fake.TokenStub != nil {
+		return fake.TokenStub()
+	} else {
+		return fake.tokenReturns.result1, fake.tokenReturns.result2
+	}
+}
+
+func (fake *FakeTokenSource) TokenCallCount() int {
+	fake.tokenMutex.RLock()
+	defer fake.tokenMutex.RUnlock()
+	return len(fake.tokenArgsForCall)
+}
+
+func (fake *FakeTokenSource) TokenReturns(result1 string, result2 error) {
+	fake.TokenStub = nil
+	fake.tokenReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+var _ auth.TokenSource = new(FakeTokenSource)
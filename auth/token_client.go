@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+const clientID = "ltc"
+
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+//go:generate counterfeiter -o fake_token_client/fake_token_client.go . TokenClient
+type TokenClient interface {
+	PasswordGrant(endpoint, username, password string) (Token, error)
+	RefreshGrant(endpoint, refreshToken string) (Token, error)
+}
+
+type tokenClient struct {
+	config *config_package.Config
+}
+
+// NewTokenClient returns a TokenClient that connects with the TLS settings
+// of config.
+func NewTokenClient(config *config_package.Config) TokenClient {
+	return &tokenClient{config}
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (t *tokenClient) PasswordGrant(endpoint, username, password string) (Token, error) {
+	return t.requestToken(endpoint, url.Values{
+		"grant_type": {"password"},
+		"username":   {username},
+		"password":   {password},
+	})
+}
+
+func (t *tokenClient) RefreshGrant(endpoint, refreshToken string) (Token, error) {
+	return t.requestToken(endpoint, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (t *tokenClient) requestToken(endpoint string, form url.Values) (Token, error) {
+	form.Set("client_id", clientID)
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	httpClient, err := t.config.TLS().HTTPClient()
+	if err != nil {
+		return Token{}, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+
+	var response tokenResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&response)
+
+	if resp.StatusCode != http.StatusOK {
+		if decodeErr == nil && response.Error != "" {
+			if response.ErrorDescription != "" {
+				return Token{}, fmt.Errorf("%s: %s (%s)", resp.Status, response.Error, response.ErrorDescription)
+			}
+			return Token{}, fmt.Errorf("%s: %s", resp.Status, response.Error)
+		}
+		return Token{}, fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	if decodeErr != nil {
+		return Token{}, fmt.Errorf("invalid token response: %s", decodeErr)
+	}
+
+	if response.AccessToken == "" {
+		return Token{}, fmt.Errorf("invalid token response: no access_token")
+	}
+
+	return Token{
+		AccessToken:  response.AccessToken,
+		RefreshToken: response.RefreshToken,
+		ExpiresIn:    time.Duration(response.ExpiresIn) * time.Second,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
+)
+
+var _ = Describe("TokenClient", func() {
+	var (
+		fakeServer  *ghttp.Server
+		tokenClient auth.TokenClient
+	)
+
+	verifyForm := func(expected url.Values) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.ParseForm()).To(Succeed())
+			Expect(req.PostForm).To(Equal(expected))
+		}
+	}
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		tokenClient = auth.NewTokenClient(config_package.New(persister.NewMemPersister()))
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	Describe("PasswordGrant", func() {
+		It("requests a token for the username and password", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/oauth/token"),
+				ghttp.VerifyHeader(http.Header{"Content-Type": []string{"application/x-www-form-urlencoded"}}),
+				verifyForm(url.Values{
+					"grant_type": {"password"},
+					"username":   {"some-user"},
+					"password":   {"some-password"},
+					"client_id":  {"ltc"},
+				}),
+				ghttp.RespondWith(http.StatusOK, `{"access_token":"some-token","refresh_token":"some-refresh-token","expires_in":600,"token_type":"bearer"}`),
+			))
+
+			token, err := tokenClient.PasswordGrant(fakeServer.URL()+"/oauth/token", "some-user", "some-password")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token).To(Equal(auth.Token{
+				AccessToken:  "some-token",
+				RefreshToken: "some-refresh-token",
+				ExpiresIn:    10 * time.Minute,
+			}))
+		})
+
+		It("returns OAuth errors from the token endpoint", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, `{"error":"invalid_grant","error_description":"Bad credentials"}`))
+
+			_, err := tokenClient.PasswordGrant(fakeServer.URL(), "some-user", "wrong-password")
+			Expect(err).To(MatchError("401 Unauthorized: invalid_grant (Bad credentials)"))
+		})
+
+		It("returns the status for other failures", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, "<html>"))
+
+			_, err := tokenClient.PasswordGrant(fakeServer.URL(), "some-user", "some-password")
+			Expect(err).To(MatchError("token endpoint returned 502 Bad Gateway"))
+		})
+
+		It("rejects responses without an access token", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{}`))
+
+			_, err := tokenClient.PasswordGrant(fakeServer.URL(), "some-user", "some-password")
+			Expect(err).To(MatchError("invalid token response: no access_token"))
+		})
+	})
+
+	Describe("RefreshGrant", func() {
+		It("exchanges the refresh token for a new token", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/oauth/token"),
+				verifyForm(url.Values{
+					"grant_type":    {"refresh_token"},
+					"refresh_token": {"some-refresh-token"},
+					"client_id":     {"ltc"},
+				}),
+				ghttp.RespondWith(http.StatusOK, `{"access_token":"new-token","expires_in":600}`),
+			))
+
+			token, err := tokenClient.RefreshGrant(fakeServer.URL()+"/oauth/token", "some-refresh-token")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token.AccessToken).To(Equal("new-token"))
+			Expect(token.RefreshToken).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/pivotal-golang/clock"
+)
+
+// tokens are refreshed this long before they expire so that they do not
+// expire in flight
+const expiryLeeway = 30 * time.Second
+
+var ErrNotLoggedIn = errors.New("Not logged in. Run ltc login.")
+
+//go:generate counterfeiter -o fake_token_source/fake_token_source.go . TokenSource
+type TokenSource interface {
+	// Token returns a current bearer token, or "" when the target does not
+	// use token auth.
+	Token() (string, error)
+}
+
+//go:generate counterfeiter -o fake_token_manager/fake_token_manager.go . TokenManager
+type TokenManager interface {
+	TokenSource
+	Login(tokenEndpoint, username, password string) error
+	Logout()
+}
+
+type tokenManager struct {
+	config      *config_package.Config
+	tokenClient TokenClient
+	clock       clock.Clock
+
+	// lock serializes access to the cached token so that concurrent callers
+	// share a single refresh
+	lock sync.Mutex
+}
+
+func NewTokenManager(config *config_package.Config, tokenClient TokenClient, clock clock.Clock) TokenManager {
+	return &tokenManager{config: config, tokenClient: tokenClient, clock: clock}
+}
+
+func (t *tokenManager) Token() (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	authConfig := t.config.Auth()
+	if !authConfig.TokenMode() {
+		return "", nil
+	}
+
+	if authConfig.AccessToken != "" && (authConfig.ExpiresAt.IsZero() || t.clock.Now().Add(expiryLeeway).Before(authConfig.ExpiresAt)) {
+		return authConfig.AccessToken, nil
+	}
+
+	if authConfig.RefreshToken == "" {
+		return "", ErrNotLoggedIn
+	}
+
+	token, err := t.tokenClient.RefreshGrant(authConfig.TokenEndpoint, authConfig.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("Failed to refresh token: %s. Run ltc login.", err)
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = authConfig.RefreshToken
+	}
+
+	// save the refreshed token so that later ltc invocations can use it
+	t.cacheToken(token)
+	if err := t.config.Save(); err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
+
+// Login switches the target to token auth and caches a token issued by
+// tokenEndpoint for username and password.  The config is not saved.
+func (t *tokenManager) Login(tokenEndpoint, username, password string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	token, err := t.tokenClient.PasswordGrant(tokenEndpoint, username, password)
+	if err != nil {
+		return err
+	}
+
+	t.config.SetAuthMode(config_package.TokenAuth, tokenEndpoint)
+	t.cacheToken(token)
+	return nil
+}
+
+// Logout discards the cached token.  The config is not saved.
+func (t *tokenManager) Logout() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.config.SetAuthToken("", "", time.Time{})
+}
+
+func (t *tokenManager) cacheToken(token Token) {
+	var expiresAt time.Time
+	if token.ExpiresIn > 0 {
+		expiresAt = t.clock.Now().Add(token.ExpiresIn)
+	}
+
+	t.config.SetAuthToken(token.AccessToken, token.RefreshToken, expiresAt)
+}
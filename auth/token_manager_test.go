@@ -0,0 +1,177 @@
+package auth_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_client"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("TokenManager", func() {
+	var (
+		configPersister persister.Persister
+		config          *config_package.Config
+		fakeTokenClient *fake_token_client.FakeTokenClient
+		fakeClock       *fakeclock.FakeClock
+		tokenManager    auth.TokenManager
+	)
+
+	savedConfig := func() *config_package.Config {
+		savedConfig := config_package.New(configPersister)
+		Expect(savedConfig.Load()).To(Succeed())
+		return savedConfig
+	}
+
+	BeforeEach(func() {
+		configPersister = persister.NewMemPersister()
+		config = config_package.New(configPersister)
+		config.SetTarget("lattice.example.com")
+		fakeTokenClient = &fake_token_client.FakeTokenClient{}
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		tokenManager = auth.NewTokenManager(config, fakeTokenClient, fakeClock)
+	})
+
+	Describe("Token", func() {
+		It("returns no token for targets using basic auth", func() {
+			token, err := tokenManager.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(BeEmpty())
+		})
+
+		Context("in token mode", func() {
+			BeforeEach(func() {
+				config.SetAuthMode(config_package.TokenAuth, "https://login.example.com/oauth/token")
+			})
+
+			It("returns the cached token until it is about to expire", func() {
+				config.SetAuthToken("some-token", "some-refresh-token", fakeClock.Now().Add(time.Minute))
+
+				token, err := tokenManager.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("some-token"))
+				Expect(fakeTokenClient.RefreshGrantCallCount()).To(BeZero())
+			})
+
+			It("refreshes and saves the token when it is about to expire", func() {
+				config.SetAuthToken("some-token", "some-refresh-token", fakeClock.Now().Add(10*time.Second))
+				fakeTokenClient.RefreshGrantReturns(auth.Token{AccessToken: "new-token", ExpiresIn: time.Hour}, nil)
+
+				token, err := tokenManager.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("new-token"))
+
+				endpoint, refreshToken := fakeTokenClient.RefreshGrantArgsForCall(0)
+				Expect(endpoint).To(Equal("https://login.example.com/oauth/token"))
+				Expect(refreshToken).To(Equal("some-refresh-token"))
+
+				authConfig := savedConfig().Auth()
+				Expect(authConfig.AccessToken).To(Equal("new-token"))
+				Expect(authConfig.RefreshToken).To(Equal("some-refresh-token"))
+				Expect(authConfig.ExpiresAt).To(BeTemporally("~", fakeClock.Now().Add(time.Hour), time.Second))
+			})
+
+			It("refreshes the token once for concurrent callers", func() {
+				config.SetAuthToken("some-token", "some-refresh-token", fakeClock.Now().Add(10*time.Second))
+				fakeTokenClient.RefreshGrantStub = func(string, string) (auth.Token, error) {
+					time.Sleep(10 * time.Millisecond)
+					return auth.Token{AccessToken: "new-token", ExpiresIn: time.Hour}, nil
+				}
+
+				tokens := make(chan string, 10)
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+
+						token, err := tokenManager.Token()
+						Expect(err).NotTo(HaveOccurred())
+						tokens <- token
+					}()
+				}
+				wg.Wait()
+				close(tokens)
+
+				Expect(fakeTokenClient.RefreshGrantCallCount()).To(Equal(1))
+				for token := range tokens {
+					Expect(token).To(Equal("new-token"))
+				}
+				Expect(savedConfig().Auth().AccessToken).To(Equal("new-token"))
+			})
+
+			It("reports refresh failures", func() {
+				config.SetAuthToken("", "some-refresh-token", time.Time{})
+				fakeTokenClient.RefreshGrantReturns(auth.Token{}, errors.New("401 Unauthorized: invalid_token"))
+
+				_, err := tokenManager.Token()
+				Expect(err).To(MatchError("Failed to refresh token: 401 Unauthorized: invalid_token. Run ltc login."))
+			})
+
+			It("asks the user to log in without a refresh token", func() {
+				_, err := tokenManager.Token()
+				Expect(err).To(Equal(auth.ErrNotLoggedIn))
+			})
+
+			It("uses the token of the target selected with --target", func() {
+				config.SaveProfile("current")
+				config.SetTarget("other.example.com")
+				config.SetAuthToken("other-token", "other-refresh-token", time.Time{})
+				config.SaveProfile("other")
+				Expect(config.UseProfile("current")).To(Succeed())
+				Expect(config.OverrideWithProfile("other")).To(Succeed())
+
+				token, err := tokenManager.Token()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token).To(Equal("other-token"))
+			})
+		})
+	})
+
+	Describe("Login", func() {
+		It("switches to token auth and caches the token", func() {
+			fakeTokenClient.PasswordGrantReturns(auth.Token{AccessToken: "some-token", RefreshToken: "some-refresh-token", ExpiresIn: time.Hour}, nil)
+
+			Expect(tokenManager.Login("https://login.example.com/oauth/token", "some-user", "some-password")).To(Succeed())
+
+			endpoint, username, password := fakeTokenClient.PasswordGrantArgsForCall(0)
+			Expect(endpoint).To(Equal("https://login.example.com/oauth/token"))
+			Expect(username).To(Equal("some-user"))
+			Expect(password).To(Equal("some-password"))
+
+			authConfig := config.Auth()
+			Expect(authConfig.TokenMode()).To(BeTrue())
+			Expect(authConfig.TokenEndpoint).To(Equal("https://login.example.com/oauth/token"))
+			Expect(authConfig.AccessToken).To(Equal("some-token"))
+			Expect(authConfig.ExpiresAt).To(Equal(fakeClock.Now().Add(time.Hour)))
+		})
+
+		It("returns errors from the token endpoint", func() {
+			fakeTokenClient.PasswordGrantReturns(auth.Token{}, errors.New("401 Unauthorized: invalid_grant"))
+
+			err := tokenManager.Login("https://login.example.com/oauth/token", "some-user", "wrong-password")
+			Expect(err).To(MatchError("401 Unauthorized: invalid_grant"))
+			Expect(config.Auth().TokenMode()).To(BeFalse())
+		})
+	})
+
+	Describe("Logout", func() {
+		It("discards the cached token", func() {
+			config.SetAuthMode(config_package.TokenAuth, "https://login.example.com/oauth/token")
+			config.SetAuthToken("some-token", "some-refresh-token", time.Time{})
+
+			tokenManager.Logout()
+
+			_, err := tokenManager.Token()
+			Expect(err).To(Equal(auth.ErrNotLoggedIn))
+		})
+	})
+})
@@ -0,0 +1,39 @@
+package auth
+
+import "net/http"
+
+// Transport adds the current bearer token from TokenSource to each request.
+type Transport struct {
+	Base        http.RoundTripper
+	TokenSource TokenSource
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.TokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		// RoundTrippers must not modify the request they are given
+		authorizedReq := *req
+		authorizedReq.Header = http.Header{}
+		for key, values := range req.Header {
+			authorizedReq.Header[key] = values
+		}
+		authorizedReq.Header.Set("Authorization", AuthorizationHeader(token))
+		req = &authorizedReq
+	}
+
+	return t.Base.RoundTrip(req)
+}
+
+// AuthorizationHeader returns the Authorization header value for token, or ""
+// when there is no token.
+func AuthorizationHeader(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	return "bearer " + token
+}
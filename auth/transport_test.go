@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_source"
+)
+
+var _ = Describe("Transport", func() {
+	var (
+		fakeServer      *ghttp.Server
+		fakeTokenSource *fake_token_source.FakeTokenSource
+		client          *http.Client
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		fakeTokenSource = &fake_token_source.FakeTokenSource{}
+		client = &http.Client{Transport: &auth.Transport{Base: http.DefaultTransport, TokenSource: fakeTokenSource}}
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+	})
+
+	It("adds the bearer token to requests", func() {
+		fakeTokenSource.TokenReturns("some-token", nil)
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyHeader(http.Header{"Authorization": []string{"bearer some-token"}}),
+			ghttp.RespondWith(http.StatusOK, ""),
+		))
+
+		_, err := client.Get(fakeServer.URL())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("leaves requests alone without a token", func() {
+		fakeServer.AppendHandlers(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.Header.Get("Authorization")).To(BeEmpty())
+		})
+
+		_, err := client.Get(fakeServer.URL())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("fails requests when no token can be obtained", func() {
+		fakeTokenSource.TokenReturns("", errors.New("Not logged in. Run ltc login."))
+
+		_, err := client.Get(fakeServer.URL())
+		Expect(err).To(MatchError(ContainSubstring("Not logged in. Run ltc login.")))
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+})
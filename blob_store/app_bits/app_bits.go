@@ -0,0 +1,172 @@
+package app_bits
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+// Manifest lists the files of an app's bits.  The contents of each regular
+// file are stored once in the blob store, at ResourcePath of their checksum,
+// so files that haven't changed since an earlier build aren't uploaded again.
+type Manifest struct {
+	Files []File `json:"files"`
+}
+
+// File is a directory, symlink or regular file of the app.  Only regular
+// files have a Checksum, and only symlinks have a Link.
+type File struct {
+	Path     string      `json:"path"`
+	Mode     os.FileMode `json:"mode"`
+	Size     int64       `json:"size,omitempty"`
+	Checksum string      `json:"sha256,omitempty"`
+	Link     string      `json:"link,omitempty"`
+}
+
+func ManifestPath(dropletName string) string {
+	return dropletName + "-bits.json"
+}
+
+func ResourcePath(checksum string) string {
+	return checksum + ".resource"
+}
+
+// ReadZip lists the files of a zip made by the droplet zipper, in the same
+// order as zipFiles, which keeps the modes and .cfignore handling of the zip.
+func ReadZip(zipFiles []*zip.File) (Manifest, error) {
+	manifest := Manifest{Files: []File{}}
+	for _, zipFile := range zipFiles {
+		file := File{
+			Path: strings.TrimSuffix(zipFile.Name, "/"),
+			Mode: zipFile.Mode(),
+		}
+		if !validPath(file.Path) {
+			return Manifest{}, fmt.Errorf("invalid path in app bits: %s", zipFile.Name)
+		}
+
+		if !file.Mode.IsDir() {
+			if err := readZipFile(zipFile, &file); err != nil {
+				return Manifest{}, err
+			}
+		}
+
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	return manifest, nil
+}
+
+func readZipFile(zipFile *zip.File, file *File) error {
+	reader, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if file.Mode&os.ModeSymlink != 0 {
+		link, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		file.Link = string(link)
+		return nil
+	}
+
+	hash := sha256.New()
+	if file.Size, err = io.Copy(hash, reader); err != nil {
+		return err
+	}
+	file.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	return nil
+}
+
+// Assemble recreates the app's files under destDir, reading the contents of
+// each regular file from fetch and checking them against their checksum.
+// Files under a symlink of the manifest are refused, since the symlink may
+// point outside of destDir.
+func (m Manifest) Assemble(destDir string, fetch func(checksum string) (io.ReadCloser, error)) error {
+	dirs := []File{}
+	links := map[string]bool{}
+	for _, file := range m.Files {
+		if !validPath(file.Path) || underLink(file.Path, links) {
+			return fmt.Errorf("invalid path in manifest: %s", file.Path)
+		}
+
+		filePath := filepath.Join(destDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return err
+		}
+
+		switch {
+		case file.Mode.IsDir():
+			if err := os.MkdirAll(filePath, 0755); err != nil {
+				return err
+			}
+			dirs = append(dirs, file)
+		case file.Mode&os.ModeSymlink != 0:
+			if err := os.Symlink(file.Link, filePath); err != nil {
+				return err
+			}
+			links[file.Path] = true
+		default:
+			if err := assembleFile(filePath, file, fetch); err != nil {
+				return fmt.Errorf("%s: %s", file.Path, err)
+			}
+		}
+	}
+
+	// directories get their modes last so that read-only ones can be filled
+	for _, dir := range dirs {
+		if err := os.Chmod(filepath.Join(destDir, filepath.FromSlash(dir.Path)), dir.Mode.Perm()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func assembleFile(filePath string, file File, fetch func(checksum string) (io.ReadCloser, error)) error {
+	reader, err := fetch(file.Checksum)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	destFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+
+	if _, err := io.Copy(destFile, blob.NewChecksumReader(reader, file.Checksum)); err != nil {
+		return err
+	}
+
+	// the mode is set explicitly since OpenFile's is subject to the umask
+	return destFile.Chmod(file.Mode.Perm())
+}
+
+// underLink reports whether filePath is, or is inside, one of the symlinks.
+func underLink(filePath string, links map[string]bool) bool {
+	for ; filePath != "."; filePath = path.Dir(filePath) {
+		if links[filePath] {
+			return true
+		}
+	}
+	return false
+}
+
+// validPath keeps files from being written outside of the app directory.
+func validPath(filePath string) bool {
+	return filePath != "" && !path.IsAbs(filePath) && path.Clean(filePath) == filePath && filePath != ".." && !strings.HasPrefix(filePath, "../")
+}
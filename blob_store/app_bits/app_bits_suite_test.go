@@ -0,0 +1,13 @@
+package app_bits_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAppBits(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AppBits Suite")
+}
@@ -0,0 +1,174 @@
+// +build !windows
+
+package app_bits_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
+)
+
+var _ = Describe("AppBits", func() {
+	// sha256 of "some contents"
+	const checksum = "b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832"
+
+	var zipFiles []*zip.File
+
+	BeforeEach(func() {
+		buffer := &bytes.Buffer{}
+		zipWriter := zip.NewWriter(buffer)
+
+		addEntry := func(name string, mode os.FileMode, contents string) {
+			header := &zip.FileHeader{Name: name}
+			header.SetMode(mode)
+			writer, err := zipWriter.CreateHeader(header)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = writer.Write([]byte(contents))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		addEntry("bin/", os.ModeDir|0750, "")
+		addEntry("bin/run", 0755, "some contents")
+		addEntry("bin/run-link", os.ModeSymlink|0777, "run")
+		addEntry("README", 0600, "some contents")
+		Expect(zipWriter.Close()).To(Succeed())
+
+		zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		zipFiles = zipReader.File
+	})
+
+	Describe("ReadZip", func() {
+		It("lists the files of the zip with their checksums", func() {
+			Expect(app_bits.ReadZip(zipFiles)).To(Equal(app_bits.Manifest{
+				Files: []app_bits.File{
+					{Path: "bin", Mode: os.ModeDir | 0750},
+					{Path: "bin/run", Mode: 0755, Size: 13, Checksum: checksum},
+					{Path: "bin/run-link", Mode: os.ModeSymlink | 0777, Link: "run"},
+					{Path: "README", Mode: 0600, Size: 13, Checksum: checksum},
+				},
+			}))
+		})
+
+		It("returns an error for a path outside of the app", func() {
+			buffer := &bytes.Buffer{}
+			zipWriter := zip.NewWriter(buffer)
+			_, err := zipWriter.Create("../escape")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zipWriter.Close()).To(Succeed())
+
+			zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = app_bits.ReadZip(zipReader.File)
+			Expect(err).To(MatchError("invalid path in app bits: ../escape"))
+		})
+	})
+
+	Describe("Assemble", func() {
+		var (
+			destDir   string
+			resources map[string]string
+			fetch     func(checksum string) (io.ReadCloser, error)
+		)
+
+		BeforeEach(func() {
+			var err error
+			destDir, err = ioutil.TempDir("", "app_bits")
+			Expect(err).NotTo(HaveOccurred())
+
+			resources = map[string]string{checksum: "some contents"}
+			fetch = func(checksum string) (io.ReadCloser, error) {
+				contents, ok := resources[checksum]
+				if !ok {
+					return nil, errors.New("not found")
+				}
+				return ioutil.NopCloser(strings.NewReader(contents)), nil
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(destDir)).To(Succeed())
+		})
+
+		It("recreates the files with their modes", func() {
+			oldUmask := syscall.Umask(022)
+			defer syscall.Umask(oldUmask)
+
+			manifest, err := app_bits.ReadZip(zipFiles)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manifest.Assemble(destDir, fetch)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(destDir, "bin", "run"))).To(BeEquivalentTo("some contents"))
+			Expect(ioutil.ReadFile(filepath.Join(destDir, "README"))).To(BeEquivalentTo("some contents"))
+			Expect(os.Readlink(filepath.Join(destDir, "bin", "run-link"))).To(Equal("run"))
+
+			binInfo, err := os.Stat(filepath.Join(destDir, "bin"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binInfo.Mode()).To(Equal(os.ModeDir | 0750))
+
+			runInfo, err := os.Stat(filepath.Join(destDir, "bin", "run"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runInfo.Mode()).To(Equal(os.FileMode(0755)))
+
+			readmeInfo, err := os.Stat(filepath.Join(destDir, "README"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readmeInfo.Mode()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("returns an error when a file doesn't match its checksum", func() {
+			resources[checksum] = "other contents"
+			manifest := app_bits.Manifest{Files: []app_bits.File{{Path: "README", Mode: 0644, Checksum: checksum}}}
+
+			err := manifest.Assemble(destDir, fetch)
+			Expect(err).To(MatchError(HavePrefix("README: checksum mismatch: expected " + checksum)))
+		})
+
+		It("returns an error when a file can't be fetched", func() {
+			manifest := app_bits.Manifest{Files: []app_bits.File{{Path: "README", Mode: 0644, Checksum: "missing"}}}
+
+			Expect(manifest.Assemble(destDir, fetch)).To(MatchError("README: not found"))
+		})
+
+		It("returns an error for a path outside of the app", func() {
+			manifest := app_bits.Manifest{Files: []app_bits.File{{Path: "/etc/passwd", Mode: 0644, Checksum: checksum}}}
+
+			Expect(manifest.Assemble(destDir, fetch)).To(MatchError("invalid path in manifest: /etc/passwd"))
+		})
+
+		It("returns an error for a path inside a symlink", func() {
+			outsideDir, err := ioutil.TempDir("", "outside")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(outsideDir)
+
+			manifest := app_bits.Manifest{Files: []app_bits.File{
+				{Path: "lib", Mode: os.ModeSymlink | 0777, Link: outsideDir},
+				{Path: "lib/evil", Mode: 0644, Checksum: checksum},
+			}}
+
+			Expect(manifest.Assemble(destDir, fetch)).To(MatchError("invalid path in manifest: lib/evil"))
+			Expect(filepath.Join(outsideDir, "evil")).NotTo(BeAnExistingFile())
+		})
+
+		It("returns an error for a directory at the path of a symlink", func() {
+			manifest := app_bits.Manifest{Files: []app_bits.File{
+				{Path: "lib", Mode: os.ModeSymlink | 0777, Link: "vendor"},
+				{Path: "lib", Mode: os.ModeDir | 0700},
+			}}
+
+			Expect(manifest.Assemble(destDir, fetch)).To(MatchError("invalid path in manifest: lib"))
+		})
+	})
+})
@@ -1,9 +1,22 @@
 package blob
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrExists is returned by Create when a blob is already stored at the path.
+var ErrExists = errors.New("blob already exists")
 
 type Blob struct {
 	Path    string
 	Created time.Time
 	Size    int64
 }
+
+// BuildpackPath is where a zipped buildpack uploaded for builds is stored.
+// Buildpacks are stored by the checksum of their zip, so the same buildpack
+// is only stored once.
+func BuildpackPath(checksum string) string {
+	return checksum + ".buildpack.zip"
+}
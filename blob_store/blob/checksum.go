@@ -0,0 +1,40 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// ChecksumPath is where the hex-encoded SHA-256 checksum of the blob at path
+// is stored, alongside the blob itself.
+func ChecksumPath(path string) string {
+	return path + ".sha256"
+}
+
+type checksumReader struct {
+	io.ReadCloser
+	hash     hash.Hash
+	expected string
+}
+
+// NewChecksumReader checks that the contents of reader have the SHA-256
+// checksum expected, returning an error in place of io.EOF when they don't.
+func NewChecksumReader(reader io.ReadCloser, expected string) io.ReadCloser {
+	return &checksumReader{reader, sha256.New(), expected}
+}
+
+func (c *checksumReader) Read(data []byte) (int, error) {
+	n, err := c.ReadCloser.Read(data)
+	c.hash.Write(data[:n])
+
+	if err == io.EOF {
+		if actual := hex.EncodeToString(c.hash.Sum(nil)); actual != c.expected {
+			return n, fmt.Errorf("checksum mismatch: expected %s, got %s", c.expected, actual)
+		}
+	}
+
+	return n, err
+}
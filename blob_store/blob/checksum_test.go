@@ -0,0 +1,38 @@
+package blob_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+var _ = Describe("Checksums", func() {
+	// sha256 of "some contents"
+	const checksum = "b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832"
+
+	Describe("ChecksumPath", func() {
+		It("stores the checksum alongside the blob", func() {
+			Expect(blob.ChecksumPath("droplet-name-droplet.tgz")).To(Equal("droplet-name-droplet.tgz.sha256"))
+		})
+	})
+
+	Describe("ChecksumReader", func() {
+		It("reads the contents when the checksum matches", func() {
+			reader := blob.NewChecksumReader(ioutil.NopCloser(strings.NewReader("some contents")), checksum)
+
+			Expect(ioutil.ReadAll(reader)).To(Equal([]byte("some contents")))
+			Expect(reader.Close()).To(Succeed())
+		})
+
+		It("returns an error at the end of contents that don't match", func() {
+			reader := blob.NewChecksumReader(ioutil.NopCloser(strings.NewReader("other contents")), checksum)
+
+			_, err := ioutil.ReadAll(reader)
+			Expect(err).To(MatchError(HavePrefix("checksum mismatch: expected " + checksum + ", got ")))
+		})
+	})
+})
@@ -0,0 +1,35 @@
+package blob
+
+import "io"
+
+// Progress is called as a transfer proceeds with the number of bytes
+// transferred so far and the total, which is -1 when it is unknown.  A
+// retried transfer may report fewer bytes than it did before.
+type Progress func(transferred, total int64)
+
+type progressReader struct {
+	reader      io.Reader
+	transferred int64
+	total       int64
+	progress    Progress
+}
+
+// NewProgressReader reports the bytes read from reader to progress, starting
+// from transferred.
+func NewProgressReader(reader io.Reader, transferred, total int64, progress Progress) io.Reader {
+	if progress == nil {
+		return reader
+	}
+
+	return &progressReader{reader, transferred, total, progress}
+}
+
+func (p *progressReader) Read(data []byte) (int, error) {
+	n, err := p.reader.Read(data)
+	if n > 0 {
+		p.transferred += int64(n)
+		p.progress(p.transferred, p.total)
+	}
+
+	return n, err
+}
@@ -0,0 +1,29 @@
+package blob_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+var _ = Describe("ProgressReader", func() {
+	It("reports the bytes read so far", func() {
+		var transferred, total int64
+		reader := blob.NewProgressReader(strings.NewReader("some contents"), 5, 18, func(t, tot int64) {
+			transferred, total = t, tot
+		})
+
+		Expect(ioutil.ReadAll(reader)).To(Equal([]byte("some contents")))
+		Expect(transferred).To(Equal(int64(18)))
+		Expect(total).To(Equal(int64(18)))
+	})
+
+	It("returns the reader itself without a progress func", func() {
+		reader := strings.NewReader("some contents")
+		Expect(blob.NewProgressReader(reader, 0, 13, nil)).To(BeIdenticalTo(reader))
+	})
+})
@@ -0,0 +1,54 @@
+package blob
+
+import "io"
+
+// OpenFunc opens a blob for reading from offset onwards.
+type OpenFunc func(offset int64) (io.ReadCloser, error)
+
+type resumableReader struct {
+	open     OpenFunc
+	body     io.ReadCloser
+	offset   int64
+	attempts int
+}
+
+// NewResumableReader opens a blob with open and, when reading fails partway,
+// reopens it at the failed offset up to attempts-1 times rather than
+// starting over.
+func NewResumableReader(open OpenFunc, attempts int) (io.ReadCloser, error) {
+	body, err := open(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resumableReader{open: open, body: body, attempts: attempts - 1}, nil
+}
+
+func (r *resumableReader) Read(data []byte) (int, error) {
+	n, err := r.body.Read(data)
+	r.offset += int64(n)
+	if err == nil || err == io.EOF || r.attempts == 0 {
+		return n, err
+	}
+
+	r.attempts--
+	r.body.Close()
+	r.body, err = r.open(r.offset)
+	if err != nil {
+		r.body = failedReader{err}
+		return n, err
+	}
+
+	return n, nil
+}
+
+func (r *resumableReader) Close() error {
+	return r.body.Close()
+}
+
+type failedReader struct {
+	err error
+}
+
+func (f failedReader) Read([]byte) (int, error) { return 0, f.err }
+func (f failedReader) Close() error             { return nil }
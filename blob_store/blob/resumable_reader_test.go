@@ -0,0 +1,91 @@
+package blob_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+type failingReader struct {
+	reader io.Reader
+	failAt int
+	read   int
+}
+
+func (f *failingReader) Read(data []byte) (int, error) {
+	if f.read >= f.failAt {
+		return 0, errors.New("connection reset")
+	}
+	if len(data) > f.failAt-f.read {
+		data = data[:f.failAt-f.read]
+	}
+	n, err := f.reader.Read(data)
+	f.read += n
+	return n, err
+}
+
+var _ = Describe("ResumableReader", func() {
+	var (
+		contents string
+		offsets  []int64
+	)
+
+	BeforeEach(func() {
+		contents = "some droplet contents"
+		offsets = []int64{}
+	})
+
+	open := func(failAt int) blob.OpenFunc {
+		return func(offset int64) (io.ReadCloser, error) {
+			offsets = append(offsets, offset)
+			return ioutil.NopCloser(&failingReader{reader: strings.NewReader(contents[offset:]), failAt: failAt}), nil
+		}
+	}
+
+	It("reopens the blob where reading failed", func() {
+		reader, err := blob.NewResumableReader(open(8), 3)
+		Expect(err).NotTo(HaveOccurred())
+		defer reader.Close()
+
+		Expect(ioutil.ReadAll(reader)).To(Equal([]byte(contents)))
+		Expect(offsets).To(Equal([]int64{0, 8, 16}))
+	})
+
+	It("returns the error once the attempts are used up", func() {
+		reader, err := blob.NewResumableReader(open(4), 2)
+		Expect(err).NotTo(HaveOccurred())
+		defer reader.Close()
+
+		_, err = ioutil.ReadAll(reader)
+		Expect(err).To(MatchError("connection reset"))
+		Expect(offsets).To(Equal([]int64{0, 4}))
+	})
+
+	It("returns an error when the blob cannot be reopened", func() {
+		opened := false
+		reader, err := blob.NewResumableReader(func(offset int64) (io.ReadCloser, error) {
+			if opened {
+				return nil, errors.New("not found")
+			}
+			opened = true
+			return ioutil.NopCloser(&failingReader{reader: strings.NewReader(contents), failAt: 4}), nil
+		}, 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ioutil.ReadAll(reader)
+		Expect(err).To(MatchError("not found"))
+	})
+
+	It("returns an error when the blob cannot be opened", func() {
+		_, err := blob.NewResumableReader(func(int64) (io.ReadCloser, error) {
+			return nil, errors.New("not found")
+		}, 3)
+		Expect(err).To(MatchError("not found"))
+	})
+})
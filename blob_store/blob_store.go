@@ -6,6 +6,7 @@ import (
 	"github.com/cloudfoundry-incubator/bbs/models"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/dav_blob_store"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/local_blob_store"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/s3_blob_store"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 )
@@ -13,17 +14,21 @@ import (
 type BlobStore interface {
 	List() ([]blob.Blob, error)
 	Delete(path string) error
-	Upload(path string, contents io.ReadSeeker) error
+	Upload(path string, contents io.ReadSeeker, progress blob.Progress) error
+	Create(path string, contents io.ReadSeeker) error
 	Download(path string) (io.ReadCloser, error)
 
 	DropletStore
 }
 
 type DropletStore interface {
-	DownloadAppBitsAction(dropletName string) *models.Action
+	DownloadAppBitsAction(dropletName, checksum string) *models.Action
 	DeleteAppBitsAction(dropletName string) *models.Action
 	UploadDropletAction(dropletName string) *models.Action
-	DownloadDropletAction(dropletName string) *models.Action
+	DownloadDropletAction(dropletName, checksum string) *models.Action
+	DownloadBuildCacheAction(dropletName string) *models.Action
+	UploadBuildCacheAction(dropletName string) *models.Action
+	DownloadBuildpackAction(checksum, destDir string) *models.Action
 }
 
 type Verifier interface {
@@ -31,19 +36,26 @@ type Verifier interface {
 }
 
 type BlobStoreVerifier struct {
-	DAVBlobStoreVerifier Verifier
-	S3BlobStoreVerifier  Verifier
+	DAVBlobStoreVerifier   Verifier
+	S3BlobStoreVerifier    Verifier
+	LocalBlobStoreVerifier Verifier
 }
 
 func New(config *config_package.Config) BlobStore {
 	switch config.ActiveBlobStore() {
-	case config_package.DAVBlobStore:
-		return dav_blob_store.New(config.BlobStore())
 	case config_package.S3BlobStore:
 		return s3_blob_store.New(config.S3BlobStore())
+	case config_package.LocalBlobStore:
+		return local_blob_store.New(config.LocalBlobStore())
+	}
+
+	davBlobStore := dav_blob_store.New(config.BlobStore())
+	davBlobStore.URL.Scheme = config.Scheme()
+	if client, err := config.TLS().HTTPClient(); err == nil {
+		davBlobStore.Client = client
 	}
 
-	return dav_blob_store.New(config.BlobStore())
+	return davBlobStore
 }
 
 func (v BlobStoreVerifier) Verify(config *config_package.Config) (authorized bool, err error) {
@@ -52,6 +64,8 @@ func (v BlobStoreVerifier) Verify(config *config_package.Config) (authorized boo
 		return v.DAVBlobStoreVerifier.Verify(config)
 	case config_package.S3BlobStore:
 		return v.S3BlobStoreVerifier.Verify(config)
+	case config_package.LocalBlobStore:
+		return v.LocalBlobStoreVerifier.Verify(config)
 	}
 
 	panic("unknown blob store type")
@@ -0,0 +1,79 @@
+package command_factory
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/local_blob_store"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/ltc/terminal"
+	"github.com/codegangsta/cli"
+)
+
+type BlobStoreCommandFactory struct {
+	config         *config_package.Config
+	ui             terminal.UI
+	exitHandler    exit_handler.ExitHandler
+	listenAndServe func(address string, handler http.Handler) error
+}
+
+func NewBlobStoreCommandFactory(config *config_package.Config, ui terminal.UI, exitHandler exit_handler.ExitHandler, listenAndServe func(address string, handler http.Handler) error) *BlobStoreCommandFactory {
+	return &BlobStoreCommandFactory{config, ui, exitHandler, listenAndServe}
+}
+
+func (factory *BlobStoreCommandFactory) MakeServeBlobsCommand() cli.Command {
+	var serveBlobsFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen, l",
+			Usage: "Address to listen on (defaults to the local droplet store's host and port)",
+		},
+	}
+
+	var serveBlobsCommand = cli.Command{
+		Name:  "serve-blobs",
+		Usage: "Serves the local droplet store to cells",
+		Description: `ltc serve-blobs [--listen <host:port>]
+
+   Cells fetch and store droplets through this server when the target uses a local droplet store (ltc target --local-blobs). Leave it running while building and launching droplets.`,
+		Action: factory.serveBlobs,
+		Flags:  serveBlobsFlags,
+	}
+
+	return serveBlobsCommand
+}
+
+func (factory *BlobStoreCommandFactory) serveBlobs(context *cli.Context) {
+	if factory.config.ActiveBlobStore() != config_package.LocalBlobStore {
+		factory.ui.SayLine("The target does not use a local droplet store. Run ltc target with --local-blobs.")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	localConfig := factory.config.LocalBlobStore()
+	if localConfig.Username == "" || localConfig.Password == "" {
+		factory.ui.SayLine("The local droplet store has no credentials. Run ltc target with --local-blobs again.")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	listenAddress := context.String("listen")
+	if listenAddress == "" {
+		listenHost := localConfig.Host
+		if listenHost == "" {
+			listenHost = "127.0.0.1"
+		}
+		listenAddress = net.JoinHostPort(listenHost, localConfig.Port)
+	}
+
+	blobStore := local_blob_store.New(localConfig)
+	factory.ui.SayLine(fmt.Sprintf("Serving %s on %s for %s ...", localConfig.Path, listenAddress, blobStore.URL))
+
+	if err := factory.listenAndServe(listenAddress, local_blob_store.NewServer(blobStore)); err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error serving droplets: ", err))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+}
@@ -0,0 +1,102 @@
+package command_factory_test
+
+import (
+	"errors"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/command_factory"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/ltc/terminal"
+	"github.com/cloudfoundry-incubator/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("BlobStoreCommandFactory", func() {
+	var (
+		outputBuffer      *gbytes.Buffer
+		config            *config_package.Config
+		fakeExitHandler   *fake_exit_handler.FakeExitHandler
+		listenAddresses   []string
+		listenErr         error
+		serveBlobsCommand cli.Command
+	)
+
+	BeforeEach(func() {
+		outputBuffer = gbytes.NewBuffer()
+		config = config_package.New(nil)
+		config.SetLocalBlobStore("/some/path", "192.168.11.1", "8445", "some-user", "some-password")
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		listenAddresses = []string{}
+		listenErr = nil
+
+		listenAndServe := func(address string, handler http.Handler) error {
+			listenAddresses = append(listenAddresses, address)
+			return listenErr
+		}
+
+		commandFactory := command_factory.NewBlobStoreCommandFactory(config, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler, listenAndServe)
+		serveBlobsCommand = commandFactory.MakeServeBlobsCommand()
+	})
+
+	Describe("ServeBlobsCommand", func() {
+		It("serves the local droplet store on its host and port", func() {
+			test_helpers.ExecuteCommandWithArgs(serveBlobsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Serving /some/path on 192.168.11.1:8445 for http://192.168.11.1:8445 ..."))
+			Expect(listenAddresses).To(Equal([]string{"192.168.11.1:8445"}))
+		})
+
+		It("listens on loopback when the local droplet store has no host", func() {
+			config.SetLocalBlobStore("/some/path", "", "8445", "some-user", "some-password")
+
+			test_helpers.ExecuteCommandWithArgs(serveBlobsCommand, []string{})
+
+			Expect(listenAddresses).To(Equal([]string{"127.0.0.1:8445"}))
+		})
+
+		It("listens on the address given with --listen", func() {
+			test_helpers.ExecuteCommandWithArgs(serveBlobsCommand, []string{"--listen", "127.0.0.1:9000"})
+
+			Expect(listenAddresses).To(Equal([]string{"127.0.0.1:9000"}))
+		})
+
+		It("exits when the server fails", func() {
+			listenErr = errors.New("address already in use")
+
+			test_helpers.ExecuteCommandWithArgs(serveBlobsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error serving droplets: address already in use"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		Context("when the local droplet store has no credentials", func() {
+			It("exits", func() {
+				config.SetLocalBlobStore("/some/path", "192.168.11.1", "8445", "", "")
+
+				test_helpers.ExecuteCommandWithArgs(serveBlobsCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("The local droplet store has no credentials. Run ltc target with --local-blobs again."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				Expect(listenAddresses).To(BeEmpty())
+			})
+		})
+
+		Context("when the target does not use a local droplet store", func() {
+			It("exits", func() {
+				config.SetBlobStore("some-host", "8444", "", "")
+
+				test_helpers.ExecuteCommandWithArgs(serveBlobsCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("The target does not use a local droplet store. Run ltc target with --local-blobs."))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				Expect(listenAddresses).To(BeEmpty())
+			})
+		})
+	})
+})
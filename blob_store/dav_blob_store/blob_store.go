@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 )
@@ -19,6 +20,16 @@ import (
 type BlobStore struct {
 	URL    *url.URL
 	Client *http.Client
+
+	// CellScheme is the scheme davtool reaches the blob store with from the
+	// cells, which stays http when ltc reaches it through a TLS-terminating
+	// load balancer, since davtool has no CA bundle to verify it with.
+	CellScheme string
+
+	// Attempts bounds how many times a transfer is tried, and RetryDelay is
+	// the wait before the first retry, doubling after each one.
+	Attempts   int
+	RetryDelay time.Duration
 }
 
 func New(config config_package.BlobStoreConfig) *BlobStore {
@@ -28,7 +39,10 @@ func New(config config_package.BlobStoreConfig) *BlobStore {
 			Host:   fmt.Sprintf("%s:%s", config.Host, config.Port),
 			User:   url.UserPassword(config.Username, config.Password),
 		},
-		Client: http.DefaultClient,
+		Client:     http.DefaultClient,
+		CellScheme: "http",
+		Attempts:   3,
+		RetryDelay: time.Second,
 	}
 }
 
@@ -107,7 +121,27 @@ func (b *BlobStore) List() ([]blob.Blob, error) {
 	return blobFiles, nil
 }
 
-func (b *BlobStore) Upload(path string, contents io.ReadSeeker) error {
+// Upload retries the whole PUT when it fails, as WebDAV has no way to resume
+// a partial upload.
+func (b *BlobStore) Upload(path string, contents io.ReadSeeker, progress blob.Progress) error {
+	length, err := contents.Seek(0, 2)
+	if err != nil {
+		return err
+	}
+
+	delay := b.RetryDelay
+	for attempt := 1; ; attempt++ {
+		err = b.put(path, contents, length, progress)
+		if err == nil || attempt >= b.Attempts {
+			return err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (b *BlobStore) put(path string, contents io.ReadSeeker, length int64, progress blob.Progress) error {
 	baseURL := &url.URL{
 		Scheme: b.URL.Scheme,
 		Host:   b.URL.Host,
@@ -115,13 +149,11 @@ func (b *BlobStore) Upload(path string, contents io.ReadSeeker) error {
 		Path:   "/blobs/" + path,
 	}
 
-	length, err := contents.Seek(0, 2)
-	if err != nil {
+	if _, err := contents.Seek(0, 0); err != nil {
 		return err
 	}
-	contents.Seek(0, 0)
 
-	req, err := http.NewRequest("PUT", baseURL.String(), contents)
+	req, err := http.NewRequest("PUT", baseURL.String(), blob.NewProgressReader(contents, 0, length, progress))
 	if err != nil {
 		return err
 	}
@@ -132,6 +164,7 @@ func (b *BlobStore) Upload(path string, contents io.ReadSeeker) error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		return errors.New(resp.Status)
@@ -140,7 +173,57 @@ func (b *BlobStore) Upload(path string, contents io.ReadSeeker) error {
 	return nil
 }
 
+// Create stores the blob only if there is none at path yet.  The PUT is sent
+// with If-None-Match: *, which the server refuses when the blob exists.
+func (b *BlobStore) Create(path string, contents io.ReadSeeker) error {
+	length, err := contents.Seek(0, 2)
+	if err != nil {
+		return err
+	}
+	if _, err := contents.Seek(0, 0); err != nil {
+		return err
+	}
+
+	baseURL := &url.URL{
+		Scheme: b.URL.Scheme,
+		Host:   b.URL.Host,
+		User:   b.URL.User,
+		Path:   "/blobs/" + path,
+	}
+
+	req, err := http.NewRequest("PUT", baseURL.String(), contents)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = length
+	req.Header.Set("If-None-Match", "*")
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusPreconditionFailed:
+		return blob.ErrExists
+	}
+
+	return errors.New(resp.Status)
+}
+
+// Download resumes from where it left off with a Range request when the
+// connection drops partway through.
 func (b *BlobStore) Download(path string) (io.ReadCloser, error) {
+	return blob.NewResumableReader(func(offset int64) (io.ReadCloser, error) {
+		return b.get(path, offset)
+	}, b.Attempts)
+}
+
+func (b *BlobStore) get(path string, offset int64) (io.ReadCloser, error) {
 	baseURL := &url.URL{
 		Scheme: b.URL.Scheme,
 		Host:   b.URL.Host,
@@ -153,12 +236,19 @@ func (b *BlobStore) Download(path string) (io.ReadCloser, error) {
 		return nil, err
 	}
 
+	expectedStatus := http.StatusOK
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		expectedStatus = http.StatusPartialContent
+	}
+
 	resp, err := b.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != expectedStatus {
+		resp.Body.Close()
 		return nil, errors.New(resp.Status)
 	}
 
@@ -190,10 +280,14 @@ func (b *BlobStore) Delete(path string) error {
 	return nil
 }
 
-func (b *BlobStore) DownloadAppBitsAction(dropletName string) *models.Action {
-	return models.WrapAction(&models.DownloadAction{
-		From:      b.URL.String() + "/blobs/" + dropletName + "-bits.zip",
-		To:        "/tmp/app",
+// DownloadAppBitsAction has davtool recreate the app from its manifest and
+// the resources it lists, checking the manifest against checksum when given.
+func (b *BlobStore) DownloadAppBitsAction(dropletName, checksum string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path:      "/tmp/davtool",
+		Dir:       "/",
+		Args:      []string{"get-app", b.davtoolURL(app_bits.ManifestPath(dropletName)), "/tmp/app"},
+		Env:       append(b.davtoolEnv(), &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: checksum}),
 		User:      "vcap",
 		LogSource: "DROPLET",
 	})
@@ -203,7 +297,8 @@ func (b *BlobStore) DeleteAppBitsAction(dropletName string) *models.Action {
 	return models.WrapAction(&models.RunAction{
 		Path:      "/tmp/davtool",
 		Dir:       "/",
-		Args:      []string{"delete", b.URL.String() + "/blobs/" + dropletName + "-bits.zip"},
+		Args:      []string{"delete", b.davtoolURL(app_bits.ManifestPath(dropletName))},
+		Env:       b.davtoolEnv(),
 		User:      "vcap",
 		LogSource: "DROPLET",
 	})
@@ -213,17 +308,124 @@ func (b *BlobStore) UploadDropletAction(dropletName string) *models.Action {
 	return models.WrapAction(&models.RunAction{
 		Path:      "/tmp/davtool",
 		Dir:       "/",
-		Args:      []string{"put", b.URL.String() + "/blobs/" + dropletName + "-droplet.tgz", "/tmp/droplet"},
+		Args:      []string{"put", b.davtoolURL(dropletName + "-droplet.tgz"), "/tmp/droplet"},
+		Env:       b.davtoolEnv(),
 		User:      "vcap",
 		LogSource: "DROPLET",
 	})
 }
 
-func (b *BlobStore) DownloadDropletAction(dropletName string) *models.Action {
-	return models.WrapAction(&models.DownloadAction{
-		From:      b.URL.String() + "/blobs/" + dropletName + "-droplet.tgz",
-		To:        "/home/vcap",
-		User:      "vcap",
+// DownloadDropletAction unpacks the droplet into /home/vcap.  It downloads
+// with davtool even without a checksum to check, which keeps the store's
+// credentials out of the action.
+func (b *BlobStore) DownloadDropletAction(dropletName, checksum string) *models.Action {
+	return models.WrapAction(&models.SerialAction{
+		LogSource: "DROPLET",
+		Actions: []*models.Action{
+			b.davtoolGetAction(dropletName+"-droplet.tgz", "/tmp/droplet.tgz", checksum),
+			models.WrapAction(&models.RunAction{
+				Path: "/bin/tar",
+				Args: []string{"zxf", "/tmp/droplet.tgz"},
+				Dir:  "/home/vcap",
+				User: "vcap",
+			}),
+		},
+	})
+}
+
+// DownloadBuildCacheAction unpacks the build artifacts cache stored by an
+// earlier build of the droplet into /tmp/cache.  The first build has no
+// cache to download, so failures are ignored.
+func (b *BlobStore) DownloadBuildCacheAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.TryAction{
+		LogSource: "DROPLET",
+		Action: models.WrapAction(&models.SerialAction{
+			Actions: []*models.Action{
+				models.WrapAction(&models.RunAction{
+					Path: "/tmp/davtool",
+					Dir:  "/",
+					Args: []string{"get", b.davtoolURL(dropletName + "-cache.tgz"), "/tmp/cache.tgz"},
+					Env:  b.davtoolEnv(),
+					User: "vcap",
+				}),
+				models.WrapAction(&models.RunAction{
+					Path: "/bin/mkdir",
+					Args: []string{"-p", "/tmp/cache"},
+					User: "vcap",
+				}),
+				models.WrapAction(&models.RunAction{
+					Path: "/bin/tar",
+					Args: []string{"zxf", "/tmp/cache.tgz"},
+					Dir:  "/tmp/cache",
+					User: "vcap",
+				}),
+			},
+		}),
+	})
+}
+
+// UploadBuildCacheAction stores the build artifacts cache left by the
+// builder for the next build of the droplet.  A failed upload doesn't fail
+// the build.
+func (b *BlobStore) UploadBuildCacheAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.TryAction{
+		LogSource: "DROPLET",
+		Action: models.WrapAction(&models.RunAction{
+			Path: "/tmp/davtool",
+			Dir:  "/",
+			Args: []string{"put", b.davtoolURL(dropletName + "-cache.tgz"), "/tmp/output-cache"},
+			Env:  b.davtoolEnv(),
+			User: "vcap",
+		}),
+	})
+}
+
+// DownloadBuildpackAction unzips the buildpack uploaded with the checksum
+// into destDir, checking the zip against the checksum.
+func (b *BlobStore) DownloadBuildpackAction(checksum, destDir string) *models.Action {
+	return models.WrapAction(&models.SerialAction{
 		LogSource: "DROPLET",
+		Actions: []*models.Action{
+			b.davtoolGetAction(blob.BuildpackPath(checksum), "/tmp/"+blob.BuildpackPath(checksum), checksum),
+			models.WrapAction(&models.RunAction{
+				Path: "/usr/bin/unzip",
+				Args: []string{"-q", "-o", "/tmp/" + blob.BuildpackPath(checksum), "-d", destDir},
+				User: "vcap",
+			}),
+		},
+	})
+}
+
+// davtoolGetAction downloads a blob with davtool, which verifies it against
+// checksum, where the DownloadAction can't.
+func (b *BlobStore) davtoolGetAction(blobPath, destPath, checksum string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path: "/tmp/davtool",
+		Dir:  "/",
+		Args: []string{"get", b.davtoolURL(blobPath), destPath},
+		Env:  append(b.davtoolEnv(), &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: checksum}),
+		User: "vcap",
 	})
 }
+
+// davtoolURL leaves the credentials out of the URL passed to davtool, which
+// reads them from davtoolEnv instead so they stay out of process listings.
+func (b *BlobStore) davtoolURL(blobPath string) string {
+	davtoolURL := *b.URL
+	davtoolURL.Scheme = b.CellScheme
+	davtoolURL.User = nil
+
+	return davtoolURL.String() + "/blobs/" + blobPath
+}
+
+func (b *BlobStore) davtoolEnv() []*models.EnvironmentVariable {
+	if b.URL.User == nil || b.URL.User.Username() == "" {
+		return nil
+	}
+
+	password, _ := b.URL.User.Password()
+	return []*models.EnvironmentVariable{
+		{Name: "DAV_USERNAME", Value: b.URL.User.Username()},
+		{Name: "DAV_PASSWORD", Value: password},
+	}
+}
@@ -44,6 +44,7 @@ var _ = Describe("BlobStore", func() {
 		}
 
 		blobStore = dav_blob_store.New(blobTargetInfo)
+		blobStore.RetryDelay = 0
 	})
 
 	AfterEach(func() {
@@ -212,29 +213,65 @@ var _ = Describe("BlobStore", func() {
 				ghttp.RespondWith(http.StatusCreated, "", http.Header{}),
 			))
 
-			Expect(blobStore.Upload("some-object", strings.NewReader("some data"))).To(Succeed())
+			Expect(blobStore.Upload("some-object", strings.NewReader("some data"), nil)).To(Succeed())
 
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("reports progress as the object is uploaded", func() {
+			fakeServer.RouteToHandler("PUT", "/blobs/some-object", ghttp.CombineHandlers(
+				func(_ http.ResponseWriter, request *http.Request) {
+					Expect(ioutil.ReadAll(request.Body)).To(Equal([]byte("some data")))
+				},
+				ghttp.RespondWith(http.StatusCreated, "", http.Header{}),
+			))
+
+			var transferred, total int64
+			progress := func(t, n int64) { transferred, total = t, n }
+
+			Expect(blobStore.Upload("some-object", strings.NewReader("some data"), progress)).To(Succeed())
+
+			Expect(transferred).To(BeEquivalentTo(9))
+			Expect(total).To(BeEquivalentTo(9))
+		})
+
+		It("retries a failed upload from the start", func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/blobs/some-object"),
+					ghttp.RespondWith(http.StatusBadGateway, "", http.Header{}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/blobs/some-object"),
+					func(_ http.ResponseWriter, request *http.Request) {
+						Expect(ioutil.ReadAll(request.Body)).To(Equal([]byte("some data")))
+					},
+					ghttp.RespondWith(http.StatusCreated, "", http.Header{}),
+				),
+			)
+
+			Expect(blobStore.Upload("some-object", strings.NewReader("some data"), nil)).To(Succeed())
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
 		It("returns an error when DAV fails to receive the object", func() {
-			fakeServer.AppendHandlers(ghttp.CombineHandlers(
-				ghttp.VerifyRequest("PUT", "/blobs/some-object"),
+			fakeServer.RouteToHandler("PUT", "/blobs/some-object", ghttp.CombineHandlers(
 				ghttp.VerifyBasicAuth("user", "pass"),
 				ghttp.RespondWith(http.StatusInternalServerError, "", http.Header{}),
 			))
 
-			err := blobStore.Upload("some-object", strings.NewReader("some data"))
+			err := blobStore.Upload("some-object", strings.NewReader("some data"), nil)
 			Expect(err).To(MatchError(ContainSubstring("500 Internal Server Error")))
 
-			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
 		})
 
 		It("returns an error when the DAV client cannot connect", func() {
 			fakeServer.Close()
 			fakeServer = nil
 
-			err := blobStore.Upload("some-object", strings.NewReader("some data"))
+			err := blobStore.Upload("some-object", strings.NewReader("some data"), nil)
 			Expect(reflect.TypeOf(err).String()).To(Equal("*url.Error"))
 		})
 	})
@@ -255,6 +292,35 @@ var _ = Describe("BlobStore", func() {
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("resumes a download that is cut off with a range request", func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/blobs/some-object"),
+					func(w http.ResponseWriter, _ *http.Request) {
+						w.Header().Set("Content-Length", "9")
+						w.WriteHeader(http.StatusOK)
+						w.Write([]byte("some "))
+						w.(http.Flusher).Flush()
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Expect(err).NotTo(HaveOccurred())
+						conn.Close()
+					},
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/blobs/some-object"),
+					ghttp.VerifyHeader(http.Header{"Range": []string{"bytes=5-"}}),
+					ghttp.RespondWith(http.StatusPartialContent, "data", http.Header{"Content-Length": []string{"4"}}),
+				),
+			)
+
+			pathReader, err := blobStore.Download("some-object")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.ReadAll(pathReader)).To(Equal([]byte("some data")))
+			Expect(pathReader.Close()).To(Succeed())
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
 		It("returns an error when DAV fails to retrieve the object", func() {
 			fakeServer.AppendHandlers(ghttp.CombineHandlers(
 				ghttp.VerifyRequest("GET", "/blobs/some-object"),
@@ -275,6 +341,44 @@ var _ = Describe("BlobStore", func() {
 		})
 	})
 
+	Describe("#Create", func() {
+		It("uploads the object only if none exists", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/blobs/some-object"),
+				ghttp.VerifyBasicAuth("user", "pass"),
+				ghttp.VerifyHeader(http.Header{"If-None-Match": []string{"*"}}),
+				func(_ http.ResponseWriter, request *http.Request) {
+					Expect(request.ContentLength).To(BeEquivalentTo(9))
+					Expect(ioutil.ReadAll(request.Body)).To(Equal([]byte("some data")))
+				},
+				ghttp.RespondWith(http.StatusCreated, "", http.Header{}),
+			))
+
+			Expect(blobStore.Create("some-object", strings.NewReader("some data"))).To(Succeed())
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns ErrExists when the object already exists", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/blobs/some-object"),
+				ghttp.RespondWith(http.StatusPreconditionFailed, "", http.Header{}),
+			))
+
+			Expect(blobStore.Create("some-object", strings.NewReader("some data"))).To(Equal(blob.ErrExists))
+		})
+
+		It("returns an error when DAV fails to receive the object", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/blobs/some-object"),
+				ghttp.RespondWith(http.StatusInternalServerError, "", http.Header{}),
+			))
+
+			err := blobStore.Create("some-object", strings.NewReader("some data"))
+			Expect(err).To(MatchError(ContainSubstring("500 Internal Server Error")))
+		})
+	})
+
 	Describe("#Delete", func() {
 		It("deletes the object at the provided path", func() {
 			fakeServer.AppendHandlers(ghttp.CombineHandlers(
@@ -307,17 +411,26 @@ var _ = Describe("BlobStore", func() {
 	})
 
 	Context("Droplet Actions", func() {
-		var dropletURL string
+		var (
+			davtoolURL string
+			davtoolEnv []*models.EnvironmentVariable
+		)
 
 		BeforeEach(func() {
-			dropletURL = fmt.Sprintf("http://%s:%s@%s:%s/blobs/droplet-name", blobTargetInfo.Username, blobTargetInfo.Password, blobTargetInfo.Host, blobTargetInfo.Port)
+			davtoolURL = fmt.Sprintf("http://%s:%s/blobs/droplet-name", blobTargetInfo.Host, blobTargetInfo.Port)
+			davtoolEnv = []*models.EnvironmentVariable{
+				{Name: "DAV_USERNAME", Value: "user"},
+				{Name: "DAV_PASSWORD", Value: "pass"},
+			}
 		})
 
 		Describe("#DownloadAppBitsAction", func() {
-			It("constructs the correct Action to download app bits", func() {
-				Expect(blobStore.DownloadAppBitsAction("droplet-name")).To(Equal(models.WrapAction(&models.DownloadAction{
-					From:      dropletURL + "-bits.zip",
-					To:        "/tmp/app",
+			It("has davtool recreate the app from its manifest", func() {
+				Expect(blobStore.DownloadAppBitsAction("droplet-name", "some-checksum")).To(Equal(models.WrapAction(&models.RunAction{
+					Path:      "/tmp/davtool",
+					Dir:       "/",
+					Args:      []string{"get-app", davtoolURL + "-bits.json", "/tmp/app"},
+					Env:       append(davtoolEnv, &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: "some-checksum"}),
 					User:      "vcap",
 					LogSource: "DROPLET",
 				})))
@@ -329,11 +442,27 @@ var _ = Describe("BlobStore", func() {
 				Expect(blobStore.DeleteAppBitsAction("droplet-name")).To(Equal(models.WrapAction(&models.RunAction{
 					Path:      "/tmp/davtool",
 					Dir:       "/",
-					Args:      []string{"delete", dropletURL + "-bits.zip"},
+					Args:      []string{"delete", davtoolURL + "-bits.json"},
+					Env:       davtoolEnv,
 					User:      "vcap",
 					LogSource: "DROPLET",
 				})))
 			})
+
+			It("does not pass credentials to davtool when the store has none", func() {
+				blobStore = dav_blob_store.New(config_package.BlobStoreConfig{Host: "some-host", Port: "8444"})
+
+				action := blobStore.DeleteAppBitsAction("droplet-name")
+				Expect(action.RunAction.Args).To(Equal([]string{"delete", "http://some-host:8444/blobs/droplet-name-bits.json"}))
+				Expect(action.RunAction.Env).To(BeNil())
+			})
+
+			It("keeps davtool on the cells' scheme when ltc reaches the store over https", func() {
+				blobStore.URL.Scheme = "https"
+
+				action := blobStore.DeleteAppBitsAction("droplet-name")
+				Expect(action.RunAction.Args).To(Equal([]string{"delete", davtoolURL + "-bits.json"}))
+			})
 		})
 
 		Describe("#UploadDropletAction", func() {
@@ -341,20 +470,122 @@ var _ = Describe("BlobStore", func() {
 				Expect(blobStore.UploadDropletAction("droplet-name")).To(Equal(models.WrapAction(&models.RunAction{
 					Path:      "/tmp/davtool",
 					Dir:       "/",
-					Args:      []string{"put", dropletURL + "-droplet.tgz", "/tmp/droplet"},
+					Args:      []string{"put", davtoolURL + "-droplet.tgz", "/tmp/droplet"},
+					Env:       davtoolEnv,
 					User:      "vcap",
 					LogSource: "DROPLET",
 				})))
 			})
 		})
 
+		Describe("#DownloadBuildCacheAction", func() {
+			It("constructs the correct Action to restore the build cache", func() {
+				Expect(blobStore.DownloadBuildCacheAction("droplet-name")).To(Equal(models.WrapAction(&models.TryAction{
+					LogSource: "DROPLET",
+					Action: models.WrapAction(&models.SerialAction{
+						Actions: []*models.Action{
+							models.WrapAction(&models.RunAction{
+								Path: "/tmp/davtool",
+								Dir:  "/",
+								Args: []string{"get", davtoolURL + "-cache.tgz", "/tmp/cache.tgz"},
+								Env:  davtoolEnv,
+								User: "vcap",
+							}),
+							models.WrapAction(&models.RunAction{
+								Path: "/bin/mkdir",
+								Args: []string{"-p", "/tmp/cache"},
+								User: "vcap",
+							}),
+							models.WrapAction(&models.RunAction{
+								Path: "/bin/tar",
+								Args: []string{"zxf", "/tmp/cache.tgz"},
+								Dir:  "/tmp/cache",
+								User: "vcap",
+							}),
+						},
+					}),
+				})))
+			})
+		})
+
+		Describe("#UploadBuildCacheAction", func() {
+			It("constructs the correct Action to store the build cache", func() {
+				Expect(blobStore.UploadBuildCacheAction("droplet-name")).To(Equal(models.WrapAction(&models.TryAction{
+					LogSource: "DROPLET",
+					Action: models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"put", davtoolURL + "-cache.tgz", "/tmp/output-cache"},
+						Env:  davtoolEnv,
+						User: "vcap",
+					}),
+				})))
+			})
+		})
+
+		Describe("#DownloadBuildpackAction", func() {
+			It("constructs the correct Action to unzip the buildpack", func() {
+				buildpackURL := fmt.Sprintf("http://%s:%s/blobs/some-checksum.buildpack.zip", blobTargetInfo.Host, blobTargetInfo.Port)
+				Expect(blobStore.DownloadBuildpackAction("some-checksum", "/tmp/buildpacks/some-dir")).To(Equal(models.WrapAction(&models.SerialAction{
+					LogSource: "DROPLET",
+					Actions: []*models.Action{
+						models.WrapAction(&models.RunAction{
+							Path: "/tmp/davtool",
+							Dir:  "/",
+							Args: []string{"get", buildpackURL, "/tmp/some-checksum.buildpack.zip"},
+							Env:  append(davtoolEnv, &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: "some-checksum"}),
+							User: "vcap",
+						}),
+						models.WrapAction(&models.RunAction{
+							Path: "/usr/bin/unzip",
+							Args: []string{"-q", "-o", "/tmp/some-checksum.buildpack.zip", "-d", "/tmp/buildpacks/some-dir"},
+							User: "vcap",
+						}),
+					},
+				})))
+			})
+		})
+
 		Describe("#DownloadDropletAction", func() {
-			It("constructs the correct Action to download the droplet", func() {
-				Expect(blobStore.DownloadDropletAction("droplet-name")).To(Equal(models.WrapAction(&models.DownloadAction{
-					From:      dropletURL + "-droplet.tgz",
-					To:        "/home/vcap",
-					User:      "vcap",
+			It("downloads the droplet with davtool, keeping the credentials out of the URL", func() {
+				Expect(blobStore.DownloadDropletAction("droplet-name", "")).To(Equal(models.WrapAction(&models.SerialAction{
+					LogSource: "DROPLET",
+					Actions: []*models.Action{
+						models.WrapAction(&models.RunAction{
+							Path: "/tmp/davtool",
+							Dir:  "/",
+							Args: []string{"get", davtoolURL + "-droplet.tgz", "/tmp/droplet.tgz"},
+							Env:  append(davtoolEnv, &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: ""}),
+							User: "vcap",
+						}),
+						models.WrapAction(&models.RunAction{
+							Path: "/bin/tar",
+							Args: []string{"zxf", "/tmp/droplet.tgz"},
+							Dir:  "/home/vcap",
+							User: "vcap",
+						}),
+					},
+				})))
+			})
+
+			It("downloads the droplet with davtool to verify it against a checksum", func() {
+				Expect(blobStore.DownloadDropletAction("droplet-name", "some-checksum")).To(Equal(models.WrapAction(&models.SerialAction{
 					LogSource: "DROPLET",
+					Actions: []*models.Action{
+						models.WrapAction(&models.RunAction{
+							Path: "/tmp/davtool",
+							Dir:  "/",
+							Args: []string{"get", davtoolURL + "-droplet.tgz", "/tmp/droplet.tgz"},
+							Env:  append(davtoolEnv, &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: "some-checksum"}),
+							User: "vcap",
+						}),
+						models.WrapAction(&models.RunAction{
+							Path: "/bin/tar",
+							Args: []string{"zxf", "/tmp/droplet.tgz"},
+							Dir:  "/home/vcap",
+							User: "vcap",
+						}),
+					},
 				})))
 			})
 		})
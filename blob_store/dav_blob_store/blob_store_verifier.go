@@ -12,7 +12,7 @@ type Verifier struct{}
 
 func (Verifier) Verify(config *config_package.Config) (authorized bool, err error) {
 	blobStoreURL := url.URL{
-		Scheme: "http",
+		Scheme: config.Scheme(),
 		Host:   fmt.Sprintf("%s:%s", config.BlobStore().Host, config.BlobStore().Port),
 		User:   url.UserPassword(config.BlobStore().Username, config.BlobStore().Password),
 	}
@@ -29,8 +29,13 @@ func (Verifier) Verify(config *config_package.Config) (authorized bool, err erro
 		return false, err
 	}
 
+	client, err := config.TLS().HTTPClient()
+	if err != nil {
+		return false, err
+	}
+
 	req.Header.Add("Depth", "1")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return false, err
 	}
@@ -131,6 +131,38 @@ var _ = Describe("BlobStore", func() {
 			})
 		})
 
+		Context("when the DAV blob store is served over https", func() {
+			BeforeEach(func() {
+				fakeServer.Close()
+				fakeServer = ghttp.NewTLSServer()
+
+				fakeServerURL, err := url.Parse(fakeServer.URL())
+				Expect(err).NotTo(HaveOccurred())
+				serverHost, serverPort, err = net.SplitHostPort(fakeServerURL.Host)
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeServer.RouteToHandler("PROPFIND", "/blobs/", ghttp.RespondWith(207, responseBodyRoot, http.Header{"Content-Type": []string{"text/xml"}}))
+
+				config.SetScheme("https")
+				config.SetBlobStore(serverHost, serverPort, "", "")
+			})
+
+			It("uses the target's TLS settings", func() {
+				config.SetTLS(config_package.TLSConfig{SkipVerify: true})
+
+				authorized, err := verifier.Verify(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authorized).To(BeTrue())
+
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			})
+
+			It("returns an error when the certificate is not trusted", func() {
+				_, err := verifier.Verify(config)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
 		Context("when the blob store is inaccessible", func() {
 			It("returns an error", func() {
 				config.SetBlobStore(serverHost, serverPort, "", "")
@@ -0,0 +1,318 @@
+package local_blob_store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+// partially written blobs are kept under this prefix until they are complete
+const tempFilePrefix = ".ltc-upload-"
+
+// BlobStore keeps blobs in the directory Path.  Cells reach them through
+// ltc serve-blobs at URL, which accepts requests only with Username and
+// Password.
+type BlobStore struct {
+	Path     string
+	URL      *url.URL
+	Username string
+	Password string
+}
+
+func New(config config_package.LocalBlobStoreConfig) *BlobStore {
+	return &BlobStore{
+		Path: config.Path,
+		URL: &url.URL{
+			Scheme: "http",
+			Host:   fmt.Sprintf("%s:%s", config.Host, config.Port),
+		},
+		Username: config.Username,
+		Password: config.Password,
+	}
+}
+
+func (b *BlobStore) List() ([]blob.Blob, error) {
+	blobs := []blob.Blob{}
+	err := filepath.Walk(b.Path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || strings.HasPrefix(info.Name(), tempFilePrefix) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(b.Path, filePath)
+		if err != nil {
+			return err
+		}
+
+		blobs = append(blobs, blob.Blob{
+			Path:    filepath.ToSlash(relativePath),
+			Created: info.ModTime(),
+			Size:    info.Size(),
+		})
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return blobs, nil
+	}
+
+	return blobs, err
+}
+
+func (b *BlobStore) Upload(path string, contents io.ReadSeeker, progress blob.Progress) error {
+	length, err := contents.Seek(0, 2)
+	if err != nil {
+		return err
+	}
+	if _, err := contents.Seek(0, 0); err != nil {
+		return err
+	}
+
+	return b.write(path, blob.NewProgressReader(contents, 0, length, progress))
+}
+
+// Create stores the blob only if there is none at path yet.  The blob is
+// linked into place, which fails rather than replacing an existing file.
+func (b *BlobStore) Create(path string, contents io.ReadSeeker) error {
+	filePath, err := b.filePath(path)
+	if err != nil {
+		return err
+	}
+
+	tempPath, err := b.writeTemp(filePath, contents)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempPath)
+
+	if err := os.Link(tempPath, filePath); err != nil {
+		if os.IsExist(err) {
+			return blob.ErrExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (b *BlobStore) Download(path string) (io.ReadCloser, error) {
+	filePath, err := b.filePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filePath)
+}
+
+func (b *BlobStore) Delete(path string) error {
+	filePath, err := b.filePath(path)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(filePath)
+}
+
+func (b *BlobStore) DownloadAppBitsAction(dropletName, checksum string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path:      "/tmp/davtool",
+		Dir:       "/",
+		Args:      []string{"get-app", b.URL.String() + "/blobs/" + app_bits.ManifestPath(dropletName), "/tmp/app"},
+		Env:       append([]*models.EnvironmentVariable{{Name: "EXPECTED_SHA256", Value: checksum}}, b.davtoolEnv()...),
+		User:      "vcap",
+		LogSource: "DROPLET",
+	})
+}
+
+func (b *BlobStore) DeleteAppBitsAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path:      "/tmp/davtool",
+		Dir:       "/",
+		Args:      []string{"delete", b.URL.String() + "/blobs/" + app_bits.ManifestPath(dropletName)},
+		Env:       b.davtoolEnv(),
+		User:      "vcap",
+		LogSource: "DROPLET",
+	})
+}
+
+func (b *BlobStore) UploadDropletAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path:      "/tmp/davtool",
+		Dir:       "/",
+		Args:      []string{"put", b.URL.String() + "/blobs/" + dropletName + "-droplet.tgz", "/tmp/droplet"},
+		Env:       b.davtoolEnv(),
+		User:      "vcap",
+		LogSource: "DROPLET",
+	})
+}
+
+// DownloadDropletAction unpacks the droplet into /home/vcap, checking it
+// against checksum unless that is empty.
+func (b *BlobStore) DownloadDropletAction(dropletName, checksum string) *models.Action {
+	return models.WrapAction(&models.SerialAction{
+		LogSource: "DROPLET",
+		Actions: []*models.Action{
+			b.davtoolGetAction(dropletName+"-droplet.tgz", "/tmp/droplet.tgz", checksum),
+			models.WrapAction(&models.RunAction{
+				Path: "/bin/tar",
+				Args: []string{"zxf", "/tmp/droplet.tgz"},
+				Dir:  "/home/vcap",
+				User: "vcap",
+			}),
+		},
+	})
+}
+
+// DownloadBuildCacheAction unpacks the build artifacts cache stored by an
+// earlier build of the droplet into /tmp/cache.  The first build has no
+// cache to download, so failures are ignored.
+func (b *BlobStore) DownloadBuildCacheAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.TryAction{
+		LogSource: "DROPLET",
+		Action: models.WrapAction(&models.SerialAction{
+			Actions: []*models.Action{
+				models.WrapAction(&models.RunAction{
+					Path: "/tmp/davtool",
+					Dir:  "/",
+					Args: []string{"get", b.URL.String() + "/blobs/" + dropletName + "-cache.tgz", "/tmp/cache.tgz"},
+					Env:  b.davtoolEnv(),
+					User: "vcap",
+				}),
+				models.WrapAction(&models.RunAction{
+					Path: "/bin/mkdir",
+					Args: []string{"-p", "/tmp/cache"},
+					User: "vcap",
+				}),
+				models.WrapAction(&models.RunAction{
+					Path: "/bin/tar",
+					Args: []string{"zxf", "/tmp/cache.tgz"},
+					Dir:  "/tmp/cache",
+					User: "vcap",
+				}),
+			},
+		}),
+	})
+}
+
+// UploadBuildCacheAction stores the build artifacts cache left by the
+// builder for the next build of the droplet.  A failed upload doesn't fail
+// the build.
+func (b *BlobStore) UploadBuildCacheAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.TryAction{
+		LogSource: "DROPLET",
+		Action: models.WrapAction(&models.RunAction{
+			Path: "/tmp/davtool",
+			Dir:  "/",
+			Args: []string{"put", b.URL.String() + "/blobs/" + dropletName + "-cache.tgz", "/tmp/output-cache"},
+			Env:  b.davtoolEnv(),
+			User: "vcap",
+		}),
+	})
+}
+
+// DownloadBuildpackAction unzips the buildpack uploaded with the checksum
+// into destDir, checking the zip against the checksum.
+func (b *BlobStore) DownloadBuildpackAction(checksum, destDir string) *models.Action {
+	return models.WrapAction(&models.SerialAction{
+		LogSource: "DROPLET",
+		Actions: []*models.Action{
+			b.davtoolGetAction(blob.BuildpackPath(checksum), "/tmp/"+blob.BuildpackPath(checksum), checksum),
+			models.WrapAction(&models.RunAction{
+				Path: "/usr/bin/unzip",
+				Args: []string{"-q", "-o", "/tmp/" + blob.BuildpackPath(checksum), "-d", destDir},
+				User: "vcap",
+			}),
+		},
+	})
+}
+
+// davtoolGetAction downloads a blob with davtool, which verifies it against
+// checksum, where the DownloadAction can't.
+func (b *BlobStore) davtoolGetAction(blobPath, destPath, checksum string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path: "/tmp/davtool",
+		Dir:  "/",
+		Args: []string{"get", b.URL.String() + "/blobs/" + blobPath, destPath},
+		Env:  append([]*models.EnvironmentVariable{{Name: "EXPECTED_SHA256", Value: checksum}}, b.davtoolEnv()...),
+		User: "vcap",
+	})
+}
+
+// davtoolEnv passes the credentials that ltc serve-blobs requires to
+// davtool.
+func (b *BlobStore) davtoolEnv() []*models.EnvironmentVariable {
+	if b.Username == "" {
+		return nil
+	}
+
+	return []*models.EnvironmentVariable{
+		{Name: "DAV_USERNAME", Value: b.Username},
+		{Name: "DAV_PASSWORD", Value: b.Password},
+	}
+}
+
+// write replaces the blob at path atomically so that cells never download a
+// partial blob.
+func (b *BlobStore) write(path string, contents io.Reader) error {
+	filePath, err := b.filePath(path)
+	if err != nil {
+		return err
+	}
+
+	tempPath, err := b.writeTemp(filePath, contents)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempPath)
+
+	return os.Rename(tempPath, filePath)
+}
+
+// writeTemp writes contents to a temp file next to filePath, which List
+// skips until it is moved into place.
+func (b *BlobStore) writeTemp(filePath string, contents io.Reader) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", err
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(filePath), tempFilePrefix)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(tempFile, contents); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return tempFile.Name(), nil
+}
+
+func (b *BlobStore) filePath(blobPath string) (string, error) {
+	// rooting the path before cleaning it keeps .. from escaping b.Path
+	cleanPath := path.Clean("/" + blobPath)
+	if cleanPath == "/" {
+		return "", errors.New("invalid blob path: " + blobPath)
+	}
+
+	return filepath.Join(b.Path, filepath.FromSlash(cleanPath)), nil
+}
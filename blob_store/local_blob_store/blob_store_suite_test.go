@@ -0,0 +1,13 @@
+package local_blob_store_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LocalBlobStore Suite")
+}
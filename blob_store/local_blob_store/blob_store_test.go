@@ -0,0 +1,298 @@
+package local_blob_store_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/local_blob_store"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+var _ = Describe("BlobStore", func() {
+	var (
+		storePath string
+		blobStore *local_blob_store.BlobStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		storePath, err = ioutil.TempDir("", "local-blob-store")
+		Expect(err).NotTo(HaveOccurred())
+
+		blobStore = local_blob_store.New(config_package.LocalBlobStoreConfig{
+			Path:     storePath,
+			Host:     "some-host",
+			Port:     "8445",
+			Username: "some-user",
+			Password: "some-password",
+		})
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(storePath)).To(Succeed())
+	})
+
+	Describe("Upload and Download", func() {
+		It("stores blobs in the directory", func() {
+			Expect(blobStore.Upload("some-droplet/bits.zip", strings.NewReader("some contents"), nil)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(storePath, "some-droplet", "bits.zip"))).To(Equal([]byte("some contents")))
+
+			reader, err := blobStore.Download("some-droplet/bits.zip")
+			Expect(err).NotTo(HaveOccurred())
+			defer reader.Close()
+			Expect(ioutil.ReadAll(reader)).To(Equal([]byte("some contents")))
+		})
+
+		It("replaces existing blobs", func() {
+			Expect(blobStore.Upload("blob", strings.NewReader("old contents"), nil)).To(Succeed())
+			Expect(blobStore.Upload("blob", strings.NewReader("new"), nil)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(storePath, "blob"))).To(Equal([]byte("new")))
+		})
+
+		It("reports progress as the blob is written", func() {
+			var transferred, total int64
+			progress := func(t, n int64) { transferred, total = t, n }
+
+			Expect(blobStore.Upload("blob", strings.NewReader("contents"), progress)).To(Succeed())
+
+			Expect(transferred).To(BeEquivalentTo(8))
+			Expect(total).To(BeEquivalentTo(8))
+		})
+
+		It("keeps blobs inside the directory", func() {
+			Expect(blobStore.Upload("../../escaped", strings.NewReader("contents"), nil)).To(Succeed())
+
+			Expect(filepath.Join(storePath, "escaped")).To(BeARegularFile())
+		})
+
+		It("returns an error for missing blobs", func() {
+			_, err := blobStore.Download("missing")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("List", func() {
+		It("lists the blobs in the directory", func() {
+			Expect(blobStore.Upload("droplet-a/bits.zip", strings.NewReader("a"), nil)).To(Succeed())
+			Expect(blobStore.Upload("droplet-b/droplet.tgz", strings.NewReader("bb"), nil)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(storePath, ".ltc-upload-123"), []byte("partial"), 0644)).To(Succeed())
+
+			blobs, err := blobStore.List()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(blobs).To(HaveLen(2))
+			Expect(blobs[0].Path).To(Equal("droplet-a/bits.zip"))
+			Expect(blobs[0].Size).To(Equal(int64(1)))
+			Expect(blobs[1].Path).To(Equal("droplet-b/droplet.tgz"))
+			Expect(blobs[1].Size).To(Equal(int64(2)))
+		})
+
+		It("returns no blobs when the directory does not exist yet", func() {
+			blobStore.Path = filepath.Join(storePath, "missing")
+
+			Expect(blobStore.List()).To(BeEmpty())
+		})
+	})
+
+	Describe("Create", func() {
+		It("stores a new blob", func() {
+			Expect(blobStore.Create("some-droplet/reserved", strings.NewReader("contents"))).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(storePath, "some-droplet", "reserved"))).To(Equal([]byte("contents")))
+		})
+
+		It("does not replace an existing blob", func() {
+			Expect(blobStore.Upload("blob", strings.NewReader("old contents"), nil)).To(Succeed())
+
+			Expect(blobStore.Create("blob", strings.NewReader("new"))).To(Equal(blob.ErrExists))
+
+			Expect(ioutil.ReadFile(filepath.Join(storePath, "blob"))).To(Equal([]byte("old contents")))
+			Expect(blobStore.List()).To(HaveLen(1))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the blob", func() {
+			Expect(blobStore.Upload("blob", strings.NewReader("contents"), nil)).To(Succeed())
+
+			Expect(blobStore.Delete("blob")).To(Succeed())
+
+			Expect(filepath.Join(storePath, "blob")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("Actions", func() {
+		It("downloads app bits from ltc serve-blobs", func() {
+			Expect(blobStore.DownloadAppBitsAction("droplet-name", "some-checksum")).To(Equal(models.WrapAction(&models.RunAction{
+				Path: "/tmp/davtool",
+				Dir:  "/",
+				Args: []string{"get-app", "http://some-host:8445/blobs/droplet-name-bits.json", "/tmp/app"},
+				Env: []*models.EnvironmentVariable{
+					{Name: "EXPECTED_SHA256", Value: "some-checksum"},
+					{Name: "DAV_USERNAME", Value: "some-user"},
+					{Name: "DAV_PASSWORD", Value: "some-password"},
+				},
+				User:      "vcap",
+				LogSource: "DROPLET",
+			})))
+		})
+
+		It("deletes app bits through ltc serve-blobs", func() {
+			Expect(blobStore.DeleteAppBitsAction("droplet-name")).To(Equal(models.WrapAction(&models.RunAction{
+				Path: "/tmp/davtool",
+				Dir:  "/",
+				Args: []string{"delete", "http://some-host:8445/blobs/droplet-name-bits.json"},
+				Env: []*models.EnvironmentVariable{
+					{Name: "DAV_USERNAME", Value: "some-user"},
+					{Name: "DAV_PASSWORD", Value: "some-password"},
+				},
+				User:      "vcap",
+				LogSource: "DROPLET",
+			})))
+		})
+
+		It("uploads droplets to ltc serve-blobs", func() {
+			Expect(blobStore.UploadDropletAction("droplet-name")).To(Equal(models.WrapAction(&models.RunAction{
+				Path: "/tmp/davtool",
+				Dir:  "/",
+				Args: []string{"put", "http://some-host:8445/blobs/droplet-name-droplet.tgz", "/tmp/droplet"},
+				Env: []*models.EnvironmentVariable{
+					{Name: "DAV_USERNAME", Value: "some-user"},
+					{Name: "DAV_PASSWORD", Value: "some-password"},
+				},
+				User:      "vcap",
+				LogSource: "DROPLET",
+			})))
+		})
+
+		It("restores the build cache from ltc serve-blobs", func() {
+			Expect(blobStore.DownloadBuildCacheAction("droplet-name")).To(Equal(models.WrapAction(&models.TryAction{
+				LogSource: "DROPLET",
+				Action: models.WrapAction(&models.SerialAction{
+					Actions: []*models.Action{
+						models.WrapAction(&models.RunAction{
+							Path: "/tmp/davtool",
+							Dir:  "/",
+							Args: []string{"get", "http://some-host:8445/blobs/droplet-name-cache.tgz", "/tmp/cache.tgz"},
+							Env: []*models.EnvironmentVariable{
+								{Name: "DAV_USERNAME", Value: "some-user"},
+								{Name: "DAV_PASSWORD", Value: "some-password"},
+							},
+							User: "vcap",
+						}),
+						models.WrapAction(&models.RunAction{
+							Path: "/bin/mkdir",
+							Args: []string{"-p", "/tmp/cache"},
+							User: "vcap",
+						}),
+						models.WrapAction(&models.RunAction{
+							Path: "/bin/tar",
+							Args: []string{"zxf", "/tmp/cache.tgz"},
+							Dir:  "/tmp/cache",
+							User: "vcap",
+						}),
+					},
+				}),
+			})))
+		})
+
+		It("stores the build cache in ltc serve-blobs", func() {
+			Expect(blobStore.UploadBuildCacheAction("droplet-name")).To(Equal(models.WrapAction(&models.TryAction{
+				LogSource: "DROPLET",
+				Action: models.WrapAction(&models.RunAction{
+					Path: "/tmp/davtool",
+					Dir:  "/",
+					Args: []string{"put", "http://some-host:8445/blobs/droplet-name-cache.tgz", "/tmp/output-cache"},
+					Env: []*models.EnvironmentVariable{
+						{Name: "DAV_USERNAME", Value: "some-user"},
+						{Name: "DAV_PASSWORD", Value: "some-password"},
+					},
+					User: "vcap",
+				}),
+			})))
+		})
+
+		It("unzips buildpacks from ltc serve-blobs", func() {
+			Expect(blobStore.DownloadBuildpackAction("some-checksum", "/tmp/buildpacks/some-dir")).To(Equal(models.WrapAction(&models.SerialAction{
+				LogSource: "DROPLET",
+				Actions: []*models.Action{
+					models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"get", "http://some-host:8445/blobs/some-checksum.buildpack.zip", "/tmp/some-checksum.buildpack.zip"},
+						Env: []*models.EnvironmentVariable{
+							{Name: "EXPECTED_SHA256", Value: "some-checksum"},
+							{Name: "DAV_USERNAME", Value: "some-user"},
+							{Name: "DAV_PASSWORD", Value: "some-password"},
+						},
+						User: "vcap",
+					}),
+					models.WrapAction(&models.RunAction{
+						Path: "/usr/bin/unzip",
+						Args: []string{"-q", "-o", "/tmp/some-checksum.buildpack.zip", "-d", "/tmp/buildpacks/some-dir"},
+						User: "vcap",
+					}),
+				},
+			})))
+		})
+
+		It("downloads droplets with davtool without a checksum", func() {
+			Expect(blobStore.DownloadDropletAction("droplet-name", "")).To(Equal(models.WrapAction(&models.SerialAction{
+				LogSource: "DROPLET",
+				Actions: []*models.Action{
+					models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"get", "http://some-host:8445/blobs/droplet-name-droplet.tgz", "/tmp/droplet.tgz"},
+						Env: []*models.EnvironmentVariable{
+							{Name: "EXPECTED_SHA256", Value: ""},
+							{Name: "DAV_USERNAME", Value: "some-user"},
+							{Name: "DAV_PASSWORD", Value: "some-password"},
+						},
+						User: "vcap",
+					}),
+					models.WrapAction(&models.RunAction{
+						Path: "/bin/tar",
+						Args: []string{"zxf", "/tmp/droplet.tgz"},
+						Dir:  "/home/vcap",
+						User: "vcap",
+					}),
+				},
+			})))
+		})
+
+		It("downloads droplets with davtool to verify them against a checksum", func() {
+			Expect(blobStore.DownloadDropletAction("droplet-name", "some-checksum")).To(Equal(models.WrapAction(&models.SerialAction{
+				LogSource: "DROPLET",
+				Actions: []*models.Action{
+					models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"get", "http://some-host:8445/blobs/droplet-name-droplet.tgz", "/tmp/droplet.tgz"},
+						Env: []*models.EnvironmentVariable{
+							{Name: "EXPECTED_SHA256", Value: "some-checksum"},
+							{Name: "DAV_USERNAME", Value: "some-user"},
+							{Name: "DAV_PASSWORD", Value: "some-password"},
+						},
+						User: "vcap",
+					}),
+					models.WrapAction(&models.RunAction{
+						Path: "/bin/tar",
+						Args: []string{"zxf", "/tmp/droplet.tgz"},
+						Dir:  "/home/vcap",
+						User: "vcap",
+					}),
+				},
+			})))
+		})
+	})
+})
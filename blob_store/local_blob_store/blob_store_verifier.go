@@ -0,0 +1,35 @@
+package local_blob_store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+type Verifier struct{}
+
+// Verify checks that blobs can be written to the local droplet store
+// directory.  It does not check that ltc serve-blobs is running.
+func (Verifier) Verify(config *config_package.Config) (authorized bool, err error) {
+	storePath := config.LocalBlobStore().Path
+	if storePath == "" {
+		return false, errors.New("no local droplet store directory")
+	}
+
+	if err := os.MkdirAll(storePath, 0755); err != nil {
+		return false, err
+	}
+
+	tempFile, err := ioutil.TempFile(storePath, tempFilePrefix)
+	if os.IsPermission(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	tempFile.Close()
+
+	return true, os.Remove(tempFile.Name())
+}
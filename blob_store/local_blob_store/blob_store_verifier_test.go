@@ -0,0 +1,67 @@
+package local_blob_store_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/local_blob_store"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+var _ = Describe("Verifier", func() {
+	var (
+		tempDir  string
+		config   *config_package.Config
+		verifier local_blob_store.Verifier
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "local-blob-store")
+		Expect(err).NotTo(HaveOccurred())
+
+		config = config_package.New(nil)
+		verifier = local_blob_store.Verifier{}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("creates the directory and returns authorized", func() {
+		storePath := filepath.Join(tempDir, "droplets")
+		config.SetLocalBlobStore(storePath, "some-host", "8445", "some-user", "some-password")
+
+		authorized, err := verifier.Verify(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorized).To(BeTrue())
+
+		Expect(storePath).To(BeADirectory())
+		Expect(ioutil.ReadDir(storePath)).To(BeEmpty())
+	})
+
+	It("returns unauthorized when the directory is not writable", func() {
+		if os.Getuid() == 0 {
+			Skip("root can write to any directory")
+		}
+
+		Expect(os.Chmod(tempDir, 0555)).To(Succeed())
+		defer os.Chmod(tempDir, 0755)
+		config.SetLocalBlobStore(tempDir, "some-host", "8445", "some-user", "some-password")
+
+		authorized, err := verifier.Verify(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorized).To(BeFalse())
+	})
+
+	It("returns an error when no directory is configured", func() {
+		config.SetLocalBlobStore("", "some-host", "8445", "some-user", "some-password")
+
+		_, err := verifier.Verify(config)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,102 @@
+package local_blob_store
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type server struct {
+	blobStore *BlobStore
+}
+
+// NewServer returns a handler that serves the blobs in blobStore under
+// /blobs/ with the subset of WebDAV that cells use to fetch and store
+// droplets.  Every request requires the blob store's username and password,
+// since droplets carry the apps' bits and environment.
+func NewServer(blobStore *BlobStore) http.Handler {
+	return &server{blobStore}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/blobs/") {
+		http.NotFound(w, r)
+		return
+	}
+	blobPath := strings.TrimPrefix(r.URL.Path, "/blobs/")
+
+	if !s.authorized(r) {
+		s.unauthorized(w)
+		return
+	}
+
+	switch r.Method {
+	case "GET", "HEAD":
+		s.get(w, r, blobPath)
+	case "PUT":
+		if err := s.blobStore.write(blobPath, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		s.delete(w, r, blobPath)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *server) get(w http.ResponseWriter, r *http.Request, blobPath string) {
+	filePath, err := s.blobStore.filePath(blobPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+func (s *server) delete(w http.ResponseWriter, r *http.Request, blobPath string) {
+	err := s.blobStore.Delete(blobPath)
+	switch {
+	case os.IsNotExist(err):
+		http.NotFound(w, r)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorized reports whether r carries the blob store's credentials.  A store
+// without credentials serves no requests.
+func (s *server) authorized(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok || s.blobStore.Username == "" {
+		return false
+	}
+
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(s.blobStore.Username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(password), []byte(s.blobStore.Password)) == 1
+	return usernameMatches && passwordMatches
+}
+
+func (s *server) unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="ltc serve-blobs"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
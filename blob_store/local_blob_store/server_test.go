@@ -0,0 +1,180 @@
+package local_blob_store_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/local_blob_store"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		storePath  string
+		blobStore  *local_blob_store.BlobStore
+		testServer *httptest.Server
+	)
+
+	doRequestAs := func(username, password, method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, testServer.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	doRequest := func(method, path, body string) *http.Response {
+		return doRequestAs("some-user", "some-password", method, path, body)
+	}
+
+	BeforeEach(func() {
+		var err error
+		storePath, err = ioutil.TempDir("", "local-blob-store")
+		Expect(err).NotTo(HaveOccurred())
+
+		blobStore = local_blob_store.New(config_package.LocalBlobStoreConfig{
+			Path:     storePath,
+			Username: "some-user",
+			Password: "some-password",
+		})
+		testServer = httptest.NewServer(local_blob_store.NewServer(blobStore))
+	})
+
+	AfterEach(func() {
+		testServer.Close()
+		Expect(os.RemoveAll(storePath)).To(Succeed())
+	})
+
+	It("stores PUT blobs", func() {
+		resp := doRequest("PUT", "/blobs/droplet-name-droplet.tgz", "some droplet")
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+		Expect(ioutil.ReadFile(filepath.Join(storePath, "droplet-name-droplet.tgz"))).To(Equal([]byte("some droplet")))
+	})
+
+	It("serves blobs to GET", func() {
+		Expect(blobStore.Upload("droplet-name-bits.zip", strings.NewReader("some bits"), nil)).To(Succeed())
+
+		resp := doRequest("GET", "/blobs/droplet-name-bits.zip", "")
+		defer resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(ioutil.ReadAll(resp.Body)).To(Equal([]byte("some bits")))
+	})
+
+	It("returns 404 for missing blobs", func() {
+		resp := doRequest("GET", "/blobs/missing", "")
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("deletes blobs", func() {
+		Expect(blobStore.Upload("droplet-name-bits.zip", strings.NewReader("some bits"), nil)).To(Succeed())
+
+		resp := doRequest("DELETE", "/blobs/droplet-name-bits.zip", "")
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+		Expect(filepath.Join(storePath, "droplet-name-bits.zip")).NotTo(BeAnExistingFile())
+
+		resp = doRequest("DELETE", "/blobs/droplet-name-bits.zip", "")
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	Context("without the blob store's credentials", func() {
+		It("rejects PUT", func() {
+			resp := doRequestAs("", "", "PUT", "/blobs/droplet-name-droplet.tgz", "some droplet")
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(resp.Header.Get("WWW-Authenticate")).To(HavePrefix("Basic"))
+			Expect(filepath.Join(storePath, "droplet-name-droplet.tgz")).NotTo(BeAnExistingFile())
+		})
+
+		It("rejects PUT with the wrong password", func() {
+			resp := doRequestAs("some-user", "wrong-password", "PUT", "/blobs/droplet-name-droplet.tgz", "some droplet")
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(filepath.Join(storePath, "droplet-name-droplet.tgz")).NotTo(BeAnExistingFile())
+		})
+
+		It("rejects DELETE", func() {
+			Expect(blobStore.Upload("droplet-name-bits.zip", strings.NewReader("some bits"), nil)).To(Succeed())
+
+			resp := doRequestAs("", "", "DELETE", "/blobs/droplet-name-bits.zip", "")
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(filepath.Join(storePath, "droplet-name-bits.zip")).To(BeAnExistingFile())
+		})
+
+		It("rejects GET", func() {
+			Expect(blobStore.Upload("droplet-name-bits.zip", strings.NewReader("some bits"), nil)).To(Succeed())
+
+			resp := doRequestAs("", "", "GET", "/blobs/droplet-name-bits.zip", "")
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(ioutil.ReadAll(resp.Body)).NotTo(ContainSubstring("some bits"))
+		})
+
+		It("rejects HEAD", func() {
+			Expect(blobStore.Upload("droplet-name-bits.zip", strings.NewReader("some bits"), nil)).To(Succeed())
+
+			resp := doRequestAs("", "", "HEAD", "/blobs/droplet-name-bits.zip", "")
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Context("when the blob store has no credentials", func() {
+		BeforeEach(func() {
+			blobStore.Username, blobStore.Password = "", ""
+		})
+
+		It("rejects writes", func() {
+			resp := doRequestAs("", "", "PUT", "/blobs/droplet-name-droplet.tgz", "some droplet")
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("rejects reads", func() {
+			resp := doRequestAs("", "", "GET", "/blobs/droplet-name-droplet.tgz", "")
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	It("only serves /blobs/", func() {
+		resp := doRequest("GET", "/etc/passwd", "")
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("rejects other methods", func() {
+		resp := doRequest("PROPFIND", "/blobs/", "")
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -1,74 +1,326 @@
 package s3_blob_store
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"net/http"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 
 	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 )
 
+const downloadAttempts = 3
+
+// createTokenKey is the object metadata Create tags its object with.
+const createTokenKey = "Ltc-Create-Token"
+
 type BlobStore struct {
-	Bucket     string
-	S3         *s3.S3
+	Bucket string
+	S3     *s3.S3
+
+	// Blobs larger than PartSize are uploaded in parts of that size.
+	PartSize int64
+
 	blobTarget config_package.S3BlobStoreConfig
 }
 
 func New(blobTarget config_package.S3BlobStoreConfig) *BlobStore {
-	client := s3.New(session.New(&aws.Config{
-		Credentials:      credentials.NewStaticCredentials(blobTarget.AccessKey, blobTarget.SecretKey, ""),
-		Region:           aws.String(blobTarget.Region),
-		S3ForcePathStyle: aws.Bool(true),
-	}))
-
 	return &BlobStore{
 		Bucket:     blobTarget.BucketName,
-		S3:         client,
+		S3:         newClient(blobTarget),
+		PartSize:   16 * 1024 * 1024,
 		blobTarget: blobTarget,
 	}
 }
 
+func newClient(blobTarget config_package.S3BlobStoreConfig) *s3.S3 {
+	awsConfig := &aws.Config{
+		Credentials:      credentials.NewStaticCredentials(blobTarget.AccessKey, blobTarget.SecretKey, blobTarget.SessionToken),
+		Region:           aws.String(blobTarget.Region),
+		S3ForcePathStyle: aws.Bool(blobTarget.PathStyle()),
+	}
+	if blobTarget.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(blobTarget.Endpoint)
+	}
+
+	return s3.New(session.New(awsConfig))
+}
+
+// List returns every blob in the bucket, following the listing across pages
+// since S3 returns at most 1000 keys at a time.
 func (b *BlobStore) List() ([]blob.Blob, error) {
-	objects, err := b.S3.ListObjects(&s3.ListObjectsInput{
+	blobs := []blob.Blob{}
+	err := b.S3.ListObjectsPages(&s3.ListObjectsInput{
 		Bucket: aws.String(b.Bucket),
+	}, func(output *s3.ListObjectsOutput, _ bool) bool {
+		for _, obj := range output.Contents {
+			blobs = append(blobs, blob.Blob{
+				Path:    *obj.Key,
+				Size:    *obj.Size,
+				Created: *obj.LastModified,
+			})
+		}
+		return true
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	blobs := []blob.Blob{}
-	for _, obj := range objects.Contents {
-		blobs = append(blobs, blob.Blob{
-			Path:    *obj.Key,
-			Size:    *obj.Size,
-			Created: *obj.LastModified,
-		})
+	return blobs, nil
+}
+
+func (b *BlobStore) Upload(path string, contents io.ReadSeeker, progress blob.Progress) error {
+	length, err := contents.Seek(0, 2)
+	if err != nil {
+		return err
+	}
+	if _, err := contents.Seek(0, 0); err != nil {
+		return err
 	}
 
-	return blobs, nil
+	if length > b.PartSize {
+		return b.uploadParts(path, contents, length, progress)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(b.Bucket),
+		ACL:                  aws.String("private"),
+		Key:                  aws.String(path),
+		Body:                 contents,
+		ServerSideEncryption: b.serverSideEncryption(),
+		SSEKMSKeyId:          b.sseKMSKeyID(),
+	}
+
+	if _, err := b.S3.PutObject(input); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress(length, length)
+	}
+	return nil
 }
 
-func (b *BlobStore) Upload(path string, contents io.ReadSeeker) error {
-	_, err := b.S3.PutObject(&s3.PutObjectInput{
+// Create stores the blob only if there is none at path yet.  The PUT is sent
+// with If-None-Match: *, which S3 refuses when the object exists.  Many
+// S3-compatible stores ignore the header and overwrite the object instead,
+// so Create also checks for the object before the PUT, and afterwards reads
+// back the token the PUT tagged the object with to make sure the stored
+// object is its own.  On such stores a create that overwrites another after
+// the other's read back can still succeed alongside it.
+func (b *BlobStore) Create(path string, contents io.ReadSeeker) error {
+	if _, err := b.createToken(path); err == nil {
+		return blob.ErrExists
+	} else if failure, ok := err.(awserr.RequestFailure); !ok || failure.StatusCode() != http.StatusNotFound {
+		return err
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	req, _ := b.S3.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:               aws.String(b.Bucket),
+		ACL:                  aws.String("private"),
+		Key:                  aws.String(path),
+		Body:                 contents,
+		Metadata:             map[string]*string{createTokenKey: aws.String(token)},
+		ServerSideEncryption: b.serverSideEncryption(),
+		SSEKMSKeyId:          b.sseKMSKeyID(),
+	})
+	req.HTTPRequest.Header.Set("If-None-Match", "*")
+
+	if err := req.Send(); err != nil {
+		// S3 answers 409 when a conflicting create is still in progress
+		if failure, ok := err.(awserr.RequestFailure); ok && (failure.StatusCode() == http.StatusPreconditionFailed || failure.StatusCode() == http.StatusConflict) {
+			return blob.ErrExists
+		}
+		return err
+	}
+
+	storedToken, err := b.createToken(path)
+	if err != nil {
+		return err
+	}
+	if storedToken != token {
+		return blob.ErrExists
+	}
+
+	return nil
+}
+
+// createToken returns the token Create tagged the object at path with.
+func (b *BlobStore) createToken(path string) (string, error) {
+	output, err := b.S3.HeadObject(&s3.HeadObjectInput{
 		Bucket: aws.String(b.Bucket),
-		ACL:    aws.String("private"),
 		Key:    aws.String(path),
-		Body:   contents,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for key, value := range output.Metadata {
+		if strings.EqualFold(key, createTokenKey) && value != nil {
+			return *value, nil
+		}
+	}
+	return "", nil
+}
+
+// uploadParts uploads a blob in parts, each retried by the client on its own.
+// A failed upload is left incomplete in the bucket, and uploading the same
+// blob again resumes it, skipping the parts already there.
+func (b *BlobStore) uploadParts(path string, contents io.Reader, length int64, progress blob.Progress) error {
+	uploadID, uploadedParts, err := b.incompleteUpload(path)
+	if err != nil {
+		return err
+	}
+
+	if uploadID == "" {
+		output, err := b.S3.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+			Bucket:               aws.String(b.Bucket),
+			ACL:                  aws.String("private"),
+			Key:                  aws.String(path),
+			ServerSideEncryption: b.serverSideEncryption(),
+			SSEKMSKeyId:          b.sseKMSKeyID(),
+		})
+		if err != nil {
+			return err
+		}
+		uploadID = *output.UploadId
+	}
+
+	completedParts := []*s3.CompletedPart{}
+	buffer := make([]byte, b.PartSize)
+	var transferred int64
+	for partNumber := int64(1); transferred < length; partNumber++ {
+		n, err := io.ReadFull(contents, buffer)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		part := buffer[:n]
+
+		etag := fmt.Sprintf(`"%x"`, md5.Sum(part))
+		if uploadedParts[partNumber] != etag {
+			output, err := b.S3.UploadPart(&s3.UploadPartInput{
+				Bucket:     aws.String(b.Bucket),
+				Key:        aws.String(path),
+				UploadId:   aws.String(uploadID),
+				PartNumber: aws.Int64(partNumber),
+				Body:       bytes.NewReader(part),
+			})
+			if err != nil {
+				return err
+			}
+			etag = *output.ETag
+		}
+
+		completedParts = append(completedParts, &s3.CompletedPart{
+			ETag:       aws.String(etag),
+			PartNumber: aws.Int64(partNumber),
+		})
+
+		transferred += int64(n)
+		if progress != nil {
+			progress(transferred, length)
+		}
+	}
+
+	_, err = b.S3.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(b.Bucket),
+		Key:             aws.String(path),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completedParts},
 	})
 	return err
 }
 
-func (b *BlobStore) Download(path string) (io.ReadCloser, error) {
-	output, err := b.S3.GetObject(&s3.GetObjectInput{
+// incompleteUpload finds an earlier upload of path that didn't complete, and
+// the ETags of the parts it uploaded.
+func (b *BlobStore) incompleteUpload(path string) (string, map[int64]string, error) {
+	uploads, err := b.S3.ListMultipartUploads(&s3.ListMultipartUploadsInput{
 		Bucket: aws.String(b.Bucket),
-		Key:    aws.String(path),
+		Prefix: aws.String(path),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	var uploadID string
+	for _, upload := range uploads.Uploads {
+		if *upload.Key == path {
+			uploadID = *upload.UploadId
+		}
+	}
+	if uploadID == "" {
+		return "", nil, nil
+	}
+
+	uploadedParts := map[int64]string{}
+	err = b.S3.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(b.Bucket),
+		Key:      aws.String(path),
+		UploadId: aws.String(uploadID),
+	}, func(output *s3.ListPartsOutput, _ bool) bool {
+		for _, part := range output.Parts {
+			uploadedParts[*part.PartNumber] = *part.ETag
+		}
+		return true
 	})
-	return output.Body, err
+	if err != nil {
+		return "", nil, err
+	}
+
+	return uploadID, uploadedParts, nil
+}
+
+func (b *BlobStore) serverSideEncryption() *string {
+	if b.blobTarget.ServerSideEncryption == "" {
+		return nil
+	}
+	return aws.String(b.blobTarget.ServerSideEncryption)
+}
+
+func (b *BlobStore) sseKMSKeyID() *string {
+	if b.blobTarget.SSEKMSKeyID == "" {
+		return nil
+	}
+	return aws.String(b.blobTarget.SSEKMSKeyID)
+}
+
+// Download resumes from where it left off with a ranged GET when the
+// connection drops partway through.
+func (b *BlobStore) Download(path string) (io.ReadCloser, error) {
+	return blob.NewResumableReader(func(offset int64) (io.ReadCloser, error) {
+		input := &s3.GetObjectInput{
+			Bucket: aws.String(b.Bucket),
+			Key:    aws.String(path),
+		}
+		if offset > 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+		}
+
+		output, err := b.S3.GetObject(input)
+		if err != nil {
+			return nil, err
+		}
+		return output.Body, nil
+	}, downloadAttempts)
 }
 
 func (b *BlobStore) Delete(path string) error {
@@ -79,36 +331,20 @@ func (b *BlobStore) Delete(path string) error {
 	return err
 }
 
-func (b *BlobStore) DownloadAppBitsAction(dropletName string) *models.Action {
-	return models.WrapAction(&models.SerialAction{
-		LogSource: "DROPLET",
-		Actions: []*models.Action{
-			models.WrapAction(&models.RunAction{
-				Path: "/tmp/s3tool",
-				Dir:  "/",
-				Args: []string{
-					"get",
-					b.blobTarget.AccessKey,
-					b.blobTarget.SecretKey,
-					b.Bucket,
-					b.blobTarget.Region,
-					"/" + dropletName + "-bits.zip",
-					"/tmp/bits.zip",
-				},
-				User: "vcap",
-			}),
-			models.WrapAction(&models.RunAction{
-				Path: "/bin/mkdir",
-				Args: []string{"/tmp/app"},
-				User: "vcap",
-			}),
-			models.WrapAction(&models.RunAction{
-				Path: "/usr/bin/unzip",
-				Dir:  "/tmp/app",
-				Args: []string{"-q", "/tmp/bits.zip"},
-				User: "vcap",
-			}),
+func (b *BlobStore) DownloadAppBitsAction(dropletName, checksum string) *models.Action {
+	return models.WrapAction(&models.RunAction{
+		Path: "/tmp/s3tool",
+		Dir:  "/",
+		Args: []string{
+			"get-app",
+			b.Bucket,
+			b.blobTarget.Region,
+			"/" + app_bits.ManifestPath(dropletName),
+			"/tmp/app",
 		},
+		Env:       b.s3toolGetEnv(checksum),
+		User:      "vcap",
+		LogSource: "DROPLET",
 	})
 }
 
@@ -118,12 +354,11 @@ func (b *BlobStore) DeleteAppBitsAction(dropletName string) *models.Action {
 		Dir:  "/",
 		Args: []string{
 			"delete",
-			b.blobTarget.AccessKey,
-			b.blobTarget.SecretKey,
 			b.Bucket,
 			b.blobTarget.Region,
-			"/" + dropletName + "-bits.zip",
+			"/" + app_bits.ManifestPath(dropletName),
 		},
+		Env:       b.s3toolEnv(),
 		User:      "vcap",
 		LogSource: "DROPLET",
 	})
@@ -135,19 +370,18 @@ func (b *BlobStore) UploadDropletAction(dropletName string) *models.Action {
 		Dir:  "/",
 		Args: []string{
 			"put",
-			b.blobTarget.AccessKey,
-			b.blobTarget.SecretKey,
 			b.Bucket,
 			b.blobTarget.Region,
 			"/" + dropletName + "-droplet.tgz",
 			"/tmp/droplet",
 		},
+		Env:       b.s3toolEnv(),
 		User:      "vcap",
 		LogSource: "DROPLET",
 	})
 }
 
-func (b *BlobStore) DownloadDropletAction(dropletName string) *models.Action {
+func (b *BlobStore) DownloadDropletAction(dropletName, checksum string) *models.Action {
 	return models.WrapAction(&models.SerialAction{
 		LogSource: "DROPLET",
 		Actions: []*models.Action{
@@ -156,13 +390,12 @@ func (b *BlobStore) DownloadDropletAction(dropletName string) *models.Action {
 				Dir:  "/",
 				Args: []string{
 					"get",
-					b.blobTarget.AccessKey,
-					b.blobTarget.SecretKey,
 					b.Bucket,
 					b.blobTarget.Region,
 					"/" + dropletName + "-droplet.tgz",
 					"/tmp/droplet.tgz",
 				},
+				Env:  b.s3toolGetEnv(checksum),
 				User: "vcap",
 			}),
 			models.WrapAction(&models.RunAction{
@@ -174,3 +407,123 @@ func (b *BlobStore) DownloadDropletAction(dropletName string) *models.Action {
 		},
 	})
 }
+
+// DownloadBuildCacheAction unpacks the build artifacts cache stored by an
+// earlier build of the droplet into /tmp/cache.  The first build has no
+// cache to download, so failures are ignored.
+func (b *BlobStore) DownloadBuildCacheAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.TryAction{
+		LogSource: "DROPLET",
+		Action: models.WrapAction(&models.SerialAction{
+			Actions: []*models.Action{
+				models.WrapAction(&models.RunAction{
+					Path: "/tmp/s3tool",
+					Dir:  "/",
+					Args: []string{
+						"get",
+						b.Bucket,
+						b.blobTarget.Region,
+						"/" + dropletName + "-cache.tgz",
+						"/tmp/cache.tgz",
+					},
+					Env:  b.s3toolEnv(),
+					User: "vcap",
+				}),
+				models.WrapAction(&models.RunAction{
+					Path: "/bin/mkdir",
+					Args: []string{"-p", "/tmp/cache"},
+					User: "vcap",
+				}),
+				models.WrapAction(&models.RunAction{
+					Path: "/bin/tar",
+					Args: []string{"zxf", "/tmp/cache.tgz"},
+					Dir:  "/tmp/cache",
+					User: "vcap",
+				}),
+			},
+		}),
+	})
+}
+
+// UploadBuildCacheAction stores the build artifacts cache left by the
+// builder for the next build of the droplet.  A failed upload doesn't fail
+// the build.
+func (b *BlobStore) UploadBuildCacheAction(dropletName string) *models.Action {
+	return models.WrapAction(&models.TryAction{
+		LogSource: "DROPLET",
+		Action: models.WrapAction(&models.RunAction{
+			Path: "/tmp/s3tool",
+			Dir:  "/",
+			Args: []string{
+				"put",
+				b.Bucket,
+				b.blobTarget.Region,
+				"/" + dropletName + "-cache.tgz",
+				"/tmp/output-cache",
+			},
+			Env:  b.s3toolEnv(),
+			User: "vcap",
+		}),
+	})
+}
+
+// DownloadBuildpackAction unzips the buildpack uploaded with the checksum
+// into destDir, checking the zip against the checksum.
+func (b *BlobStore) DownloadBuildpackAction(checksum, destDir string) *models.Action {
+	return models.WrapAction(&models.SerialAction{
+		LogSource: "DROPLET",
+		Actions: []*models.Action{
+			models.WrapAction(&models.RunAction{
+				Path: "/tmp/s3tool",
+				Dir:  "/",
+				Args: []string{
+					"get",
+					b.Bucket,
+					b.blobTarget.Region,
+					"/" + blob.BuildpackPath(checksum),
+					"/tmp/" + blob.BuildpackPath(checksum),
+				},
+				Env:  b.s3toolGetEnv(checksum),
+				User: "vcap",
+			}),
+			models.WrapAction(&models.RunAction{
+				Path: "/usr/bin/unzip",
+				Args: []string{"-q", "-o", "/tmp/" + blob.BuildpackPath(checksum), "-d", destDir},
+				User: "vcap",
+			}),
+		},
+	})
+}
+
+// s3toolEnv passes the credentials and optional S3 settings to s3tool in its
+// environment, which keeps the keys out of process listings on the cell.
+func (b *BlobStore) s3toolEnv() []*models.EnvironmentVariable {
+	env := []*models.EnvironmentVariable{
+		{Name: "AWS_ACCESS_KEY_ID", Value: b.blobTarget.AccessKey},
+		{Name: "AWS_SECRET_ACCESS_KEY", Value: b.blobTarget.SecretKey},
+	}
+	addEnv := func(name, value string) {
+		if value != "" {
+			env = append(env, &models.EnvironmentVariable{Name: name, Value: value})
+		}
+	}
+
+	addEnv("AWS_ENDPOINT_OVERRIDE", b.blobTarget.Endpoint)
+	addEnv("AWS_SESSION_TOKEN", b.blobTarget.SessionToken)
+	addEnv("S3_ADDRESSING_STYLE", b.blobTarget.AddressingStyle)
+	addEnv("S3_SERVER_SIDE_ENCRYPTION", b.blobTarget.ServerSideEncryption)
+	addEnv("S3_SSE_KMS_KEY_ID", b.blobTarget.SSEKMSKeyID)
+
+	return env
+}
+
+// s3toolGetEnv has s3tool verify the download against checksum, unless it
+// is empty.
+func (b *BlobStore) s3toolGetEnv(checksum string) []*models.EnvironmentVariable {
+	env := b.s3toolEnv()
+	if checksum != "" {
+		env = append(env, &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: checksum})
+	}
+
+	return env
+}
@@ -1,6 +1,8 @@
 package s3_blob_store_test
 
 import (
+	"crypto/md5"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -62,6 +64,26 @@ var _ = Describe("BlobStore", func() {
 			}))
 			Expect(blobStore.Bucket).To(Equal("bucket"))
 		})
+
+		It("applies the endpoint, addressing style and session token", func() {
+			blobStore = s3_blob_store.New(config.S3BlobStoreConfig{
+				AccessKey:       "some-access-key",
+				SecretKey:       "some-secret-key",
+				SessionToken:    "some-session-token",
+				BucketName:      "bucket",
+				Region:          "some-s3-region",
+				Endpoint:        "https://minio.example.com:9000",
+				AddressingStyle: config.S3VirtualHostStyle,
+			})
+
+			Expect(*blobStore.S3.Config.Endpoint).To(Equal("https://minio.example.com:9000"))
+			Expect(*blobStore.S3.Config.S3ForcePathStyle).To(BeFalse())
+			Expect(blobStore.S3.Config.Credentials.Get()).To(Equal(credentials.Value{
+				AccessKeyID:     "some-access-key",
+				SecretAccessKey: "some-secret-key",
+				SessionToken:    "some-session-token",
+			}))
+		})
 	})
 
 	Describe("#List", func() {
@@ -115,6 +137,61 @@ var _ = Describe("BlobStore", func() {
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("follows truncated listings to the following pages", func() {
+			firstPage := `
+				 <?xml version="1.0" encoding="UTF-8"?>
+				 <ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+					 <Name>bucket</Name>
+					 <Prefix/>
+					 <Marker/>
+					 <NextMarker>droplet-a-droplet.tgz</NextMarker>
+					 <MaxKeys>1</MaxKeys>
+					 <IsTruncated>true</IsTruncated>
+					 <Contents>
+						 <Key>droplet-a-droplet.tgz</Key>
+						 <LastModified>2009-10-12T17:50:30.000Z</LastModified>
+						 <Size>100</Size>
+					 </Contents>
+				 </ListBucketResult>
+			 `
+			secondPage := `
+				 <?xml version="1.0" encoding="UTF-8"?>
+				 <ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+					 <Name>bucket</Name>
+					 <Prefix/>
+					 <Marker>droplet-a-droplet.tgz</Marker>
+					 <MaxKeys>1</MaxKeys>
+					 <IsTruncated>false</IsTruncated>
+					 <Contents>
+						 <Key>droplet-b-droplet.tgz</Key>
+						 <LastModified>2009-10-12T17:50:30.000Z</LastModified>
+						 <Size>200</Size>
+					 </Contents>
+				 </ListBucketResult>
+			 `
+
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket"),
+					ghttp.RespondWith(http.StatusOK, firstPage, http.Header{"Content-Type": []string{"application/xml"}}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket", "marker=droplet-a-droplet.tgz"),
+					ghttp.RespondWith(http.StatusOK, secondPage, http.Header{"Content-Type": []string{"application/xml"}}),
+				),
+			)
+
+			expectedTime, err := time.Parse(time.RFC3339Nano, "2009-10-12T17:50:30.000Z")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(blobStore.List()).To(Equal([]blob.Blob{
+				{Path: "droplet-a-droplet.tgz", Size: 100, Created: expectedTime},
+				{Path: "droplet-b-droplet.tgz", Size: 200, Created: expectedTime},
+			}))
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
 		It("returns an error when we fail to retrieve the objects from S3", func() {
 			fakeServer.AppendHandlers(ghttp.CombineHandlers(
 				ghttp.VerifyRequest("GET", "/bucket"),
@@ -137,18 +214,257 @@ var _ = Describe("BlobStore", func() {
 				ghttp.RespondWith(http.StatusOK, "", http.Header{}),
 			))
 
-			Expect(blobStore.Upload("some-path/some-object", strings.NewReader("some data"))).To(Succeed())
+			Expect(blobStore.Upload("some-path/some-object", strings.NewReader("some data"), nil)).To(Succeed())
 
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("requests server-side encryption when configured", func() {
+			blobStore = s3_blob_store.New(config.S3BlobStoreConfig{
+				AccessKey:            "some-access-key",
+				SecretKey:            "some-secret-key",
+				BucketName:           "bucket",
+				Region:               "some-s3-region",
+				ServerSideEncryption: "aws:kms",
+				SSEKMSKeyID:          "some-key-id",
+			})
+			blobStore.S3.Retryer = nullRetryer{}
+			blobStore.S3.Endpoint = fakeServer.URL()
+
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/bucket/some-path/some-object"),
+				ghttp.VerifyHeader(http.Header{
+					"X-Amz-Server-Side-Encryption":                []string{"aws:kms"},
+					"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": []string{"some-key-id"},
+				}),
+				ghttp.RespondWith(http.StatusOK, "", http.Header{}),
+			))
+
+			Expect(blobStore.Upload("some-path/some-object", strings.NewReader("some data"), nil)).To(Succeed())
+		})
+
 		It("returns an error when S3 fail to receive the object", func() {
 			fakeServer.AppendHandlers(ghttp.CombineHandlers(
 				ghttp.VerifyRequest("PUT", "/bucket/some-path/some-object"),
 				ghttp.RespondWith(http.StatusInternalServerError, "", http.Header{}),
 			))
 
-			err := blobStore.Upload("some-path/some-object", strings.NewReader("some data"))
+			err := blobStore.Upload("some-path/some-object", strings.NewReader("some data"), nil)
+			Expect(err).To(MatchError(ContainSubstring("500 Internal Server Error")))
+		})
+
+		Context("when the blob is larger than a part", func() {
+			var (
+				listUploadsResponse string
+				uploadedParts       map[string]string
+			)
+
+			verifyQuery := func(key, value string) http.HandlerFunc {
+				return func(_ http.ResponseWriter, request *http.Request) {
+					Expect(request.URL.Query()).To(HaveKeyWithValue(key, []string{value}))
+				}
+			}
+
+			uploadPart := ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/bucket/some-object"),
+				verifyQuery("uploadId", "some-upload-id"),
+				func(w http.ResponseWriter, request *http.Request) {
+					contents, err := ioutil.ReadAll(request.Body)
+					Expect(err).NotTo(HaveOccurred())
+					uploadedParts[request.URL.Query().Get("partNumber")] = string(contents)
+					w.Header().Set("ETag", fmt.Sprintf(`"%x"`, md5.Sum(contents)))
+				},
+			)
+
+			BeforeEach(func() {
+				blobStore.PartSize = 4
+				uploadedParts = map[string]string{}
+				listUploadsResponse = `<ListMultipartUploadsResult><Bucket>bucket</Bucket></ListMultipartUploadsResult>`
+			})
+
+			JustBeforeEach(func() {
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket"),
+					verifyQuery("prefix", "some-object"),
+					ghttp.RespondWith(http.StatusOK, listUploadsResponse),
+				))
+			})
+
+			It("uploads it in parts and reports progress after each one", func() {
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/bucket/some-object"),
+						ghttp.VerifyHeader(http.Header{"X-Amz-Acl": []string{"private"}}),
+						ghttp.RespondWith(http.StatusOK, `<InitiateMultipartUploadResult><UploadId>some-upload-id</UploadId></InitiateMultipartUploadResult>`),
+					),
+					uploadPart,
+					uploadPart,
+					uploadPart,
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/bucket/some-object"),
+						verifyQuery("uploadId", "some-upload-id"),
+						ghttp.RespondWith(http.StatusOK, `<CompleteMultipartUploadResult><Key>some-object</Key></CompleteMultipartUploadResult>`),
+					),
+				)
+
+				reported := []int64{}
+				progress := func(transferred, total int64) {
+					Expect(total).To(BeEquivalentTo(9))
+					reported = append(reported, transferred)
+				}
+
+				Expect(blobStore.Upload("some-object", strings.NewReader("some data"), progress)).To(Succeed())
+
+				Expect(uploadedParts).To(Equal(map[string]string{"1": "some", "2": " dat", "3": "a"}))
+				Expect(reported).To(Equal([]int64{4, 8, 9}))
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(6))
+			})
+
+			It("resumes an incomplete upload, skipping the parts already uploaded", func() {
+				listUploadsResponse = `
+					<ListMultipartUploadsResult>
+						<Bucket>bucket</Bucket>
+						<Upload><Key>some-object-v2</Key><UploadId>other-upload-id</UploadId></Upload>
+						<Upload><Key>some-object</Key><UploadId>some-upload-id</UploadId></Upload>
+					</ListMultipartUploadsResult>
+				`
+				listPartsResponse := fmt.Sprintf(`
+					<ListPartsResult>
+						<Bucket>bucket</Bucket>
+						<Part><PartNumber>1</PartNumber><ETag>"%x"</ETag><Size>4</Size></Part>
+						<Part><PartNumber>2</PartNumber><ETag>"stale"</ETag><Size>4</Size></Part>
+					</ListPartsResult>
+				`, md5.Sum([]byte("some")))
+
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/bucket/some-object"),
+						verifyQuery("uploadId", "some-upload-id"),
+						ghttp.RespondWith(http.StatusOK, listPartsResponse),
+					),
+					uploadPart,
+					uploadPart,
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/bucket/some-object"),
+						verifyQuery("uploadId", "some-upload-id"),
+						ghttp.RespondWith(http.StatusOK, `<CompleteMultipartUploadResult><Key>some-object</Key></CompleteMultipartUploadResult>`),
+					),
+				)
+
+				Expect(blobStore.Upload("some-object", strings.NewReader("some data"), nil)).To(Succeed())
+
+				Expect(uploadedParts).To(Equal(map[string]string{"2": " dat", "3": "a"}))
+			})
+
+			It("returns an error when a part fails to upload", func() {
+				fakeServer.AppendHandlers(
+					ghttp.RespondWith(http.StatusOK, `<InitiateMultipartUploadResult><UploadId>some-upload-id</UploadId></InitiateMultipartUploadResult>`),
+					ghttp.RespondWith(http.StatusInternalServerError, ""),
+				)
+
+				err := blobStore.Upload("some-object", strings.NewReader("some data"), nil)
+				Expect(err).To(MatchError(ContainSubstring("500 Internal Server Error")))
+			})
+		})
+	})
+
+	Describe("#Create", func() {
+		var createToken string
+
+		notFound := ghttp.CombineHandlers(
+			ghttp.VerifyRequest("HEAD", "/bucket/some-path/some-object"),
+			ghttp.RespondWith(http.StatusNotFound, "", http.Header{}),
+		)
+		conditionalPut := func(statusCode int) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/bucket/some-path/some-object"),
+				ghttp.VerifyHeader(http.Header{
+					"X-Amz-Acl":     []string{"private"},
+					"If-None-Match": []string{"*"},
+				}),
+				func(_ http.ResponseWriter, request *http.Request) {
+					createToken = request.Header.Get("X-Amz-Meta-Ltc-Create-Token")
+					Expect(ioutil.ReadAll(request.Body)).To(Equal([]byte("some data")))
+				},
+				ghttp.RespondWith(statusCode, "", http.Header{}),
+			)
+		}
+		readBack := func(token func() string) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("HEAD", "/bucket/some-path/some-object"),
+				func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set("X-Amz-Meta-Ltc-Create-Token", token())
+				},
+			)
+		}
+
+		BeforeEach(func() {
+			createToken = ""
+		})
+
+		It("uploads the object once, only if none exists", func() {
+			fakeServer.AppendHandlers(
+				notFound,
+				conditionalPut(http.StatusOK),
+				readBack(func() string { return createToken }),
+			)
+
+			Expect(blobStore.Create("some-path/some-object", strings.NewReader("some data"))).To(Succeed())
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+			Expect(createToken).NotTo(BeEmpty())
+		})
+
+		It("returns ErrExists without uploading when the object already exists", func() {
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("HEAD", "/bucket/some-path/some-object"),
+				ghttp.RespondWith(http.StatusOK, "", http.Header{}),
+			))
+
+			Expect(blobStore.Create("some-path/some-object", strings.NewReader("some data"))).To(Equal(blob.ErrExists))
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns ErrExists when S3 refuses to overwrite the object", func() {
+			fakeServer.AppendHandlers(
+				notFound,
+				conditionalPut(http.StatusPreconditionFailed),
+			)
+
+			Expect(blobStore.Create("some-path/some-object", strings.NewReader("some data"))).To(Equal(blob.ErrExists))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		Context("when the store ignores If-None-Match", func() {
+			It("creates the object when the stored object is its own", func() {
+				fakeServer.AppendHandlers(
+					notFound,
+					conditionalPut(http.StatusOK),
+					readBack(func() string { return createToken }),
+				)
+
+				Expect(blobStore.Create("some-path/some-object", strings.NewReader("some data"))).To(Succeed())
+			})
+
+			It("returns ErrExists when another create overwrote the object", func() {
+				fakeServer.AppendHandlers(
+					notFound,
+					conditionalPut(http.StatusOK),
+					readBack(func() string { return "another-token" }),
+				)
+
+				Expect(blobStore.Create("some-path/some-object", strings.NewReader("some data"))).To(Equal(blob.ErrExists))
+			})
+		})
+
+		It("returns an error when S3 fails to receive the object", func() {
+			fakeServer.AppendHandlers(
+				notFound,
+				conditionalPut(http.StatusInternalServerError),
+			)
+
+			err := blobStore.Create("some-path/some-object", strings.NewReader("some data"))
 			Expect(err).To(MatchError(ContainSubstring("500 Internal Server Error")))
 		})
 	})
@@ -168,6 +484,35 @@ var _ = Describe("BlobStore", func() {
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("resumes a download that is cut off with a ranged request", func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket/some-path/some-object"),
+					func(w http.ResponseWriter, _ *http.Request) {
+						w.Header().Set("Content-Length", "9")
+						w.WriteHeader(http.StatusOK)
+						w.Write([]byte("some "))
+						w.(http.Flusher).Flush()
+						conn, _, err := w.(http.Hijacker).Hijack()
+						Expect(err).NotTo(HaveOccurred())
+						conn.Close()
+					},
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket/some-path/some-object"),
+					ghttp.VerifyHeader(http.Header{"Range": []string{"bytes=5-"}}),
+					ghttp.RespondWith(http.StatusPartialContent, "data", http.Header{"Content-Length": []string{"4"}}),
+				),
+			)
+
+			pathReader, err := blobStore.Download("some-path/some-object")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.ReadAll(pathReader)).To(Equal([]byte("some data")))
+			Expect(pathReader.Close()).To(Succeed())
+
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+		})
+
 		It("returns an error when S3 fails to retrieve the object", func() {
 			fakeServer.AppendHandlers(ghttp.CombineHandlers(
 				ghttp.VerifyRequest("GET", "/bucket/some-path/some-object"),
@@ -201,37 +546,30 @@ var _ = Describe("BlobStore", func() {
 	})
 
 	Context("Droplet Actions", func() {
+		var s3toolEnv []*models.EnvironmentVariable
+
+		BeforeEach(func() {
+			s3toolEnv = []*models.EnvironmentVariable{
+				{Name: "AWS_ACCESS_KEY_ID", Value: "some-access-key"},
+				{Name: "AWS_SECRET_ACCESS_KEY", Value: "some-secret-key"},
+			}
+		})
+
 		Describe("#DownloadAppBitsAction", func() {
 			It("constructs the correct Action to download app bits", func() {
-				Expect(blobStore.DownloadAppBitsAction("droplet-name")).To(Equal(models.WrapAction(&models.SerialAction{
-					LogSource: "DROPLET",
-					Actions: []*models.Action{
-						models.WrapAction(&models.RunAction{
-							Path: "/tmp/s3tool",
-							Dir:  "/",
-							Args: []string{
-								"get",
-								"some-access-key",
-								"some-secret-key",
-								"bucket",
-								"some-s3-region",
-								"/droplet-name-bits.zip",
-								"/tmp/bits.zip",
-							},
-							User: "vcap",
-						}),
-						models.WrapAction(&models.RunAction{
-							Path: "/bin/mkdir",
-							Args: []string{"/tmp/app"},
-							User: "vcap",
-						}),
-						models.WrapAction(&models.RunAction{
-							Path: "/usr/bin/unzip",
-							Dir:  "/tmp/app",
-							Args: []string{"-q", "/tmp/bits.zip"},
-							User: "vcap",
-						}),
+				Expect(blobStore.DownloadAppBitsAction("droplet-name", "")).To(Equal(models.WrapAction(&models.RunAction{
+					Path: "/tmp/s3tool",
+					Dir:  "/",
+					Args: []string{
+						"get-app",
+						"bucket",
+						"some-s3-region",
+						"/droplet-name-bits.json",
+						"/tmp/app",
 					},
+					Env:       s3toolEnv,
+					User:      "vcap",
+					LogSource: "DROPLET",
 				})))
 			})
 		})
@@ -243,12 +581,11 @@ var _ = Describe("BlobStore", func() {
 					Dir:  "/",
 					Args: []string{
 						"delete",
-						"some-access-key",
-						"some-secret-key",
 						"bucket",
 						"some-s3-region",
-						"/droplet-name-bits.zip",
+						"/droplet-name-bits.json",
 					},
+					Env:       s3toolEnv,
 					User:      "vcap",
 					LogSource: "DROPLET",
 				})))
@@ -262,22 +599,134 @@ var _ = Describe("BlobStore", func() {
 					Dir:  "/",
 					Args: []string{
 						"put",
-						"some-access-key",
-						"some-secret-key",
 						"bucket",
 						"some-s3-region",
 						"/droplet-name-droplet.tgz",
 						"/tmp/droplet",
 					},
+					Env:       s3toolEnv,
 					User:      "vcap",
 					LogSource: "DROPLET",
 				})))
 			})
 		})
 
+		Context("when optional S3 settings are configured", func() {
+			BeforeEach(func() {
+				blobStore = s3_blob_store.New(config.S3BlobStoreConfig{
+					AccessKey:            "some-access-key",
+					SecretKey:            "some-secret-key",
+					BucketName:           "bucket",
+					Region:               "some-s3-region",
+					Endpoint:             "https://minio.example.com:9000",
+					AddressingStyle:      config.S3PathStyle,
+					SessionToken:         "some-session-token",
+					ServerSideEncryption: "AES256",
+				})
+			})
+
+			It("passes them to s3tool in its environment", func() {
+				action := blobStore.UploadDropletAction("droplet-name")
+
+				Expect(action.RunAction.Env).To(Equal([]*models.EnvironmentVariable{
+					{Name: "AWS_ACCESS_KEY_ID", Value: "some-access-key"},
+					{Name: "AWS_SECRET_ACCESS_KEY", Value: "some-secret-key"},
+					{Name: "AWS_ENDPOINT_OVERRIDE", Value: "https://minio.example.com:9000"},
+					{Name: "AWS_SESSION_TOKEN", Value: "some-session-token"},
+					{Name: "S3_ADDRESSING_STYLE", Value: "path"},
+					{Name: "S3_SERVER_SIDE_ENCRYPTION", Value: "AES256"},
+				}))
+			})
+		})
+
+		Describe("#DownloadBuildCacheAction", func() {
+			It("constructs the correct Action to restore the build cache", func() {
+				Expect(blobStore.DownloadBuildCacheAction("droplet-name")).To(Equal(models.WrapAction(&models.TryAction{
+					LogSource: "DROPLET",
+					Action: models.WrapAction(&models.SerialAction{
+						Actions: []*models.Action{
+							models.WrapAction(&models.RunAction{
+								Path: "/tmp/s3tool",
+								Dir:  "/",
+								Args: []string{
+									"get",
+									"bucket",
+									"some-s3-region",
+									"/droplet-name-cache.tgz",
+									"/tmp/cache.tgz",
+								},
+								Env:  s3toolEnv,
+								User: "vcap",
+							}),
+							models.WrapAction(&models.RunAction{
+								Path: "/bin/mkdir",
+								Args: []string{"-p", "/tmp/cache"},
+								User: "vcap",
+							}),
+							models.WrapAction(&models.RunAction{
+								Path: "/bin/tar",
+								Args: []string{"zxf", "/tmp/cache.tgz"},
+								Dir:  "/tmp/cache",
+								User: "vcap",
+							}),
+						},
+					}),
+				})))
+			})
+		})
+
+		Describe("#UploadBuildCacheAction", func() {
+			It("constructs the correct Action to store the build cache", func() {
+				Expect(blobStore.UploadBuildCacheAction("droplet-name")).To(Equal(models.WrapAction(&models.TryAction{
+					LogSource: "DROPLET",
+					Action: models.WrapAction(&models.RunAction{
+						Path: "/tmp/s3tool",
+						Dir:  "/",
+						Args: []string{
+							"put",
+							"bucket",
+							"some-s3-region",
+							"/droplet-name-cache.tgz",
+							"/tmp/output-cache",
+						},
+						Env:  s3toolEnv,
+						User: "vcap",
+					}),
+				})))
+			})
+		})
+
+		Describe("#DownloadBuildpackAction", func() {
+			It("constructs the correct Action to unzip the buildpack", func() {
+				Expect(blobStore.DownloadBuildpackAction("some-checksum", "/tmp/buildpacks/some-dir")).To(Equal(models.WrapAction(&models.SerialAction{
+					LogSource: "DROPLET",
+					Actions: []*models.Action{
+						models.WrapAction(&models.RunAction{
+							Path: "/tmp/s3tool",
+							Dir:  "/",
+							Args: []string{
+								"get",
+								"bucket",
+								"some-s3-region",
+								"/some-checksum.buildpack.zip",
+								"/tmp/some-checksum.buildpack.zip",
+							},
+							Env:  append(s3toolEnv, &models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: "some-checksum"}),
+							User: "vcap",
+						}),
+						models.WrapAction(&models.RunAction{
+							Path: "/usr/bin/unzip",
+							Args: []string{"-q", "-o", "/tmp/some-checksum.buildpack.zip", "-d", "/tmp/buildpacks/some-dir"},
+							User: "vcap",
+						}),
+					},
+				})))
+			})
+		})
+
 		Describe("#DownloadDropletAction", func() {
 			It("constructs the correct Action to download the droplet", func() {
-				Expect(blobStore.DownloadDropletAction("droplet-name")).To(Equal(models.WrapAction(&models.SerialAction{
+				Expect(blobStore.DownloadDropletAction("droplet-name", "")).To(Equal(models.WrapAction(&models.SerialAction{
 					LogSource: "DROPLET",
 					Actions: []*models.Action{
 						models.WrapAction(&models.RunAction{
@@ -285,13 +734,12 @@ var _ = Describe("BlobStore", func() {
 							Dir:  "/",
 							Args: []string{
 								"get",
-								"some-access-key",
-								"some-secret-key",
 								"bucket",
 								"some-s3-region",
 								"/droplet-name-droplet.tgz",
 								"/tmp/droplet.tgz",
 							},
+							Env:  s3toolEnv,
 							User: "vcap",
 						}),
 						models.WrapAction(&models.RunAction{
@@ -303,6 +751,14 @@ var _ = Describe("BlobStore", func() {
 					},
 				})))
 			})
+
+			It("has s3tool verify the droplet against a checksum", func() {
+				action := blobStore.DownloadDropletAction("droplet-name", "some-checksum")
+
+				Expect(action.SerialAction.Actions[0].RunAction.Env).To(Equal(append(s3toolEnv,
+					&models.EnvironmentVariable{Name: "EXPECTED_SHA256", Value: "some-checksum"},
+				)))
+			})
 		})
 	})
 })
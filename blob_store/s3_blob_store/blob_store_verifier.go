@@ -1,26 +1,24 @@
 package s3_blob_store
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 )
 
+const verifyObjectKey = "ltc-verify"
+
 type Verifier struct {
 	Endpoint string
 }
 
 func (v Verifier) Verify(config *config_package.Config) (authorized bool, err error) {
 	blobStoreConfig := config.S3BlobStore()
-	client := s3.New(session.New(&aws.Config{
-		Credentials:      credentials.NewStaticCredentials(blobStoreConfig.AccessKey, blobStoreConfig.SecretKey, ""),
-		Region:           aws.String(blobStoreConfig.Region),
-		S3ForcePathStyle: aws.Bool(true),
-	}))
+	client := newClient(blobStoreConfig)
 	if v.Endpoint != "" {
 		client.Endpoint = v.Endpoint
 	}
@@ -28,11 +26,28 @@ func (v Verifier) Verify(config *config_package.Config) (authorized bool, err er
 		Bucket: aws.String(blobStoreConfig.BucketName),
 	})
 	if err != nil {
-		if awsErr, ok := err.(awserr.RequestFailure); ok && awsErr.StatusCode() == 403 {
-			return false, nil
-		}
+		return verifyResult(err)
+	}
 
-		return false, err
+	// a bucket policy or KMS key may refuse encrypted writes that reads do
+	// not exercise
+	if blobStoreConfig.ServerSideEncryption != "" {
+		blobStore := &BlobStore{Bucket: blobStoreConfig.BucketName, S3: client, blobTarget: blobStoreConfig}
+		if err := blobStore.Upload(verifyObjectKey, strings.NewReader(""), nil); err != nil {
+			return verifyResult(err)
+		}
+		if err := blobStore.Delete(verifyObjectKey); err != nil {
+			return verifyResult(err)
+		}
 	}
+
 	return true, nil
 }
+
+func verifyResult(err error) (bool, error) {
+	if awsErr, ok := err.(awserr.RequestFailure); ok && awsErr.StatusCode() == 403 {
+		return false, nil
+	}
+
+	return false, err
+}
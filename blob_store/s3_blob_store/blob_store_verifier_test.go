@@ -67,6 +67,77 @@ var _ = Describe("S3BlobStore", func() {
 			})
 		})
 
+		Context("when a custom endpoint is configured", func() {
+			It("lists the bucket at the endpoint with the session token", func() {
+				config.SetS3BlobStoreConfig(config_package.S3BlobStoreConfig{
+					AccessKey:    "some-access-key",
+					SecretKey:    "some-secret-key",
+					SessionToken: "some-session-token",
+					BucketName:   "bucket",
+					Region:       "some-region",
+					Endpoint:     fakeServer.URL(),
+				})
+				verifier = &s3_blob_store.Verifier{}
+
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket"),
+					ghttp.VerifyHeaderKV("X-Amz-Security-Token", "some-session-token"),
+					ghttp.RespondWith(http.StatusOK, `<ListBucketResult><Name>bucket</Name></ListBucketResult>`, http.Header{"Content-Type": []string{"application/xml"}}),
+				))
+
+				authorized, err := verifier.Verify(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authorized).To(BeTrue())
+			})
+		})
+
+		Context("when server-side encryption is configured", func() {
+			BeforeEach(func() {
+				config.SetS3BlobStoreConfig(config_package.S3BlobStoreConfig{
+					AccessKey:            "some-access-key",
+					SecretKey:            "some-secret-key",
+					BucketName:           "bucket",
+					Region:               "some-region",
+					ServerSideEncryption: "AES256",
+				})
+
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket"),
+					ghttp.RespondWith(http.StatusOK, `<ListBucketResult><Name>bucket</Name></ListBucketResult>`, http.Header{"Content-Type": []string{"application/xml"}}),
+				))
+			})
+
+			It("writes and deletes an encrypted test object", func() {
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/bucket/ltc-verify"),
+						ghttp.VerifyHeaderKV("X-Amz-Server-Side-Encryption", "AES256"),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/bucket/ltc-verify"),
+						ghttp.RespondWith(http.StatusNoContent, ""),
+					),
+				)
+
+				authorized, err := verifier.Verify(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authorized).To(BeTrue())
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+			})
+
+			It("returns authorized as false when the encrypted write is forbidden", func() {
+				fakeServer.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/bucket/ltc-verify"),
+					ghttp.RespondWith(http.StatusForbidden, nil),
+				))
+
+				authorized, err := verifier.Verify(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authorized).To(BeFalse())
+			})
+		})
+
 		Context("when the blob store is inaccessible", func() {
 			It("returns an error", func() {
 				fakeServer.AppendHandlers(ghttp.CombineHandlers(
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
 )
 
 func main() {
@@ -18,10 +28,14 @@ func main() {
 	switch action {
 	case "delete":
 		deleteAction(args[1:])
+	case "get":
+		getAction(args[1:])
+	case "get-app":
+		getAppAction(args[1:])
 	case "put":
 		putAction(args[1:])
 	default:
-		fmt.Println("Usage: davtool [put|delete] arguments...")
+		fmt.Println("Usage: davtool [get|get-app|put|delete] arguments...")
 		os.Exit(3)
 	}
 }
@@ -37,6 +51,18 @@ func sanitizeURL(u string) string {
 	return parsedURL.String()
 }
 
+// authorize sets basic auth from DAV_USERNAME and DAV_PASSWORD, which keep
+// the credentials out of process listings.  Credentials in the URL are
+// still honored when they are unset.
+func authorize(req *http.Request) {
+	username := os.Getenv("DAV_USERNAME")
+	if username == "" {
+		return
+	}
+
+	req.SetBasicAuth(username, os.Getenv("DAV_PASSWORD"))
+}
+
 func deleteAction(args []string) {
 	if len(args) != 1 {
 		fmt.Println("Usage: davtool delete url")
@@ -51,6 +77,8 @@ func deleteAction(args []string) {
 		os.Exit(2)
 	}
 
+	authorize(req)
+
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		fmt.Printf("Error deleting %s: %s\n", sanitizeURL(davURL), err)
@@ -65,6 +93,127 @@ func deleteAction(args []string) {
 	fmt.Printf("Deleted %s.\n", sanitizeURL(davURL))
 }
 
+// getAction saves the file at url, and when EXPECTED_SHA256 is set, removes
+// it again unless its checksum matches.
+func getAction(args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: davtool get url destinationFilePath")
+		os.Exit(3)
+	}
+
+	davURL, destPath := args[0], args[1]
+
+	body, err := download(davURL)
+	if err != nil {
+		fmt.Printf("Error downloading %s: %s\n", sanitizeURL(davURL), err)
+		os.Exit(2)
+	}
+	defer body.Close()
+
+	destFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		fmt.Printf("Error opening %s: %s\n", destPath, err)
+		os.Exit(2)
+	}
+	defer destFile.Close()
+
+	checksum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(destFile, checksum), body); err != nil {
+		fmt.Printf("Error writing response to %s: %s\n", destPath, err)
+		os.Exit(2)
+	}
+
+	if expected := os.Getenv("EXPECTED_SHA256"); expected != "" {
+		if actual := hex.EncodeToString(checksum.Sum(nil)); actual != expected {
+			destFile.Close()
+			os.Remove(destPath)
+			fmt.Printf("Checksum mismatch for %s: expected %s, got %s\n", sanitizeURL(davURL), expected, actual)
+			os.Exit(2)
+		}
+	}
+
+	fmt.Printf("Downloaded %s to %s.\n", sanitizeURL(davURL), destPath)
+}
+
+// getAppAction recreates an app under destinationDir from the app bits
+// manifest at url, fetching each file from the resources stored beside it.
+// When EXPECTED_SHA256 is set, the manifest must match it.
+func getAppAction(args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: davtool get-app manifestURL destinationDir")
+		os.Exit(3)
+	}
+
+	manifestURL, destDir := args[0], args[1]
+
+	body, err := download(manifestURL)
+	if err != nil {
+		fmt.Printf("Error downloading %s: %s\n", sanitizeURL(manifestURL), err)
+		os.Exit(2)
+	}
+	defer body.Close()
+
+	manifestJSON, err := ioutil.ReadAll(body)
+	if err != nil {
+		fmt.Printf("Error downloading %s: %s\n", sanitizeURL(manifestURL), err)
+		os.Exit(2)
+	}
+
+	if expected := os.Getenv("EXPECTED_SHA256"); expected != "" {
+		checksum := sha256.Sum256(manifestJSON)
+		if actual := hex.EncodeToString(checksum[:]); actual != expected {
+			fmt.Printf("Checksum mismatch for %s: expected %s, got %s\n", sanitizeURL(manifestURL), expected, actual)
+			os.Exit(2)
+		}
+	}
+
+	var manifest app_bits.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		fmt.Printf("Error reading %s: %s\n", sanitizeURL(manifestURL), err)
+		os.Exit(2)
+	}
+
+	resourceURL, err := url.Parse(manifestURL)
+	if err != nil {
+		fmt.Printf("Error downloading %s: %s\n", sanitizeURL(manifestURL), err)
+		os.Exit(2)
+	}
+	manifestDir := path.Dir(resourceURL.Path)
+
+	fetch := func(checksum string) (io.ReadCloser, error) {
+		resourceURL.Path = path.Join(manifestDir, app_bits.ResourcePath(checksum))
+		return download(resourceURL.String())
+	}
+
+	if err := manifest.Assemble(destDir, fetch); err != nil {
+		fmt.Printf("Error assembling app from %s: %s\n", sanitizeURL(manifestURL), err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("Downloaded %s to %s.\n", sanitizeURL(manifestURL), destDir)
+}
+
+func download(davURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", davURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	authorize(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
 func putAction(args []string) {
 	if len(args) != 2 {
 		fmt.Println("Usage: davtool put url fileToUpload")
@@ -80,9 +229,13 @@ func putAction(args []string) {
 	}
 	defer sourceFile.Close()
 
-	req, err := http.NewRequest("PUT", davURL, sourceFile)
-	if err != nil {
-		fmt.Printf("Error uploading %s: %s\n", sourcePath, err)
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, sourceFile); err != nil {
+		fmt.Printf("Error reading %s: %s\n", sourcePath, err)
+		os.Exit(2)
+	}
+	if _, err := sourceFile.Seek(0, 0); err != nil {
+		fmt.Printf("Error reading %s: %s\n", sourcePath, err)
 		os.Exit(2)
 	}
 
@@ -91,18 +244,41 @@ func putAction(args []string) {
 		fmt.Printf("Error uploading %s: %s\n", sourcePath, err)
 		os.Exit(2)
 	}
-	req.ContentLength = sourceFileInfo.Size()
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
+	if err := put(davURL, sourceFile, sourceFileInfo.Size()); err != nil {
 		fmt.Printf("Error uploading %s: %s\n", sourcePath, err)
 		os.Exit(2)
 	}
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		fmt.Printf("Error uploading %s: %s\n", sourcePath, resp.Status)
+	// the checksum is stored alongside the file for ltc and the cells that
+	// download it to verify it against
+	checksumHex := hex.EncodeToString(checksum.Sum(nil))
+	if err := put(davURL+".sha256", strings.NewReader(checksumHex), int64(len(checksumHex))); err != nil {
+		fmt.Printf("Error uploading checksum of %s: %s\n", sourcePath, err)
 		os.Exit(2)
 	}
 
 	fmt.Printf("Uploaded %s to %s.\n", sourcePath, sanitizeURL(davURL))
 }
+
+func put(davURL string, body io.Reader, length int64) error {
+	req, err := http.NewRequest("PUT", davURL, body)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = length
+	authorize(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	return nil
+}
@@ -3,11 +3,13 @@
 package main_test
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -36,7 +38,7 @@ var _ = Describe("davtool", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Eventually(session).Should(gexec.Exit(3))
-			Expect(session.Out).To(gbytes.Say("Usage: davtool \\[put\\|delete\\] arguments..."))
+			Expect(session.Out).To(gbytes.Say("Usage: davtool \\[get\\|get-app\\|put\\|delete\\] arguments..."))
 		})
 	})
 
@@ -74,6 +76,16 @@ var _ = Describe("davtool", func() {
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("reads the credentials from the environment", func() {
+			command := exec.Command(davtoolPath, "delete", sanitizedURL)
+			command.Env = []string{"DAV_USERNAME=user", "DAV_PASSWORD=pass"}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
 		Context("when the DAV delete fails", func() {
 			It("prints an error message and exits", func() {
 				httpStatusCode = 404
@@ -100,11 +112,207 @@ var _ = Describe("davtool", func() {
 		})
 	})
 
+	Describe("get", func() {
+		var (
+			fakeServer                  *ghttp.Server
+			tmpFile                     *os.File
+			fakeServerURL, sanitizedURL string
+		)
+
+		BeforeEach(func() {
+			fakeServer = ghttp.NewServer()
+			fakeServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/blobs/path"),
+				ghttp.VerifyBasicAuth("user", "pass"),
+				ghttp.RespondWith(http.StatusOK, "some-file-contents"),
+			))
+
+			fakeServerURL = fmt.Sprintf("http://%s:%s@%s%s", "user", "pass", fakeServer.Addr(), "/blobs/path")
+			sanitizedURL = fmt.Sprintf("http://%s%s", fakeServer.Addr(), "/blobs/path")
+
+			var err error
+			tmpFile, err = ioutil.TempFile(os.TempDir(), "downloadedFile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tmpFile.Close()).To(Succeed())
+		})
+
+		AfterEach(func() {
+			fakeServer.Close()
+			os.Remove(tmpFile.Name())
+		})
+
+		It("does a GET to the DAV server and saves the file", func() {
+			command := exec.Command(davtoolPath, "get", fakeServerURL, tmpFile.Name())
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("Downloaded %s to %s.", sanitizedURL, tmpFile.Name()))
+			Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
+		})
+
+		It("replaces a longer file already at the destination", func() {
+			Expect(ioutil.WriteFile(tmpFile.Name(), []byte("some-much-longer-stale-contents"), 0644)).To(Succeed())
+
+			command := exec.Command(davtoolPath, "get", fakeServerURL, tmpFile.Name())
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
+		})
+
+		It("saves a file that matches the expected checksum", func() {
+			command := exec.Command(davtoolPath, "get", sanitizedURL, tmpFile.Name())
+			command.Env = []string{
+				"DAV_USERNAME=user",
+				"DAV_PASSWORD=pass",
+				"EXPECTED_SHA256=" + fmt.Sprintf("%x", sha256.Sum256([]byte("some-file-contents"))),
+			}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
+		})
+
+		Context("when the file doesn't match the expected checksum", func() {
+			It("removes it, prints an error message and exits", func() {
+				command := exec.Command(davtoolPath, "get", fakeServerURL, tmpFile.Name())
+				command.Env = []string{"EXPECTED_SHA256=abc123"}
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(2))
+				Expect(session.Out).To(gbytes.Say("Checksum mismatch for %s: expected abc123, got ", sanitizedURL))
+				Expect(tmpFile.Name()).NotTo(BeAnExistingFile())
+			})
+		})
+
+		Context("when the DAV download fails", func() {
+			It("prints an error message and exits", func() {
+				fakeServer.SetHandler(0, ghttp.RespondWith(http.StatusNotFound, nil))
+
+				command := exec.Command(davtoolPath, "get", fakeServerURL, tmpFile.Name())
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(2))
+				Expect(session.Out).To(gbytes.Say("Error downloading %s: 404 Not Found", sanitizedURL))
+			})
+		})
+
+		Context("when the command is missing", func() {
+			It("prints an error message and exits", func() {
+				command := exec.Command(davtoolPath, "get", "invalid")
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(3))
+				Expect(session.Out).To(gbytes.Say("Usage: davtool get url destinationFilePath"))
+			})
+		})
+	})
+
+	Describe("get-app", func() {
+		const manifestJSON = `{"files": [
+			{"path": "bin", "mode": 2147484141},
+			{"path": "bin/run", "mode": 493, "size": 13, "sha256": "b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832"}
+		]}`
+
+		var (
+			fakeServer                  *ghttp.Server
+			destDir                     string
+			fakeServerURL, sanitizedURL string
+		)
+
+		BeforeEach(func() {
+			fakeServer = ghttp.NewServer()
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/blobs/app-bits.json"),
+					ghttp.VerifyBasicAuth("user", "pass"),
+					ghttp.RespondWith(http.StatusOK, manifestJSON),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/blobs/b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832.resource"),
+					ghttp.VerifyBasicAuth("user", "pass"),
+					ghttp.RespondWith(http.StatusOK, "some contents"),
+				),
+			)
+
+			fakeServerURL = fmt.Sprintf("http://%s:%s@%s%s", "user", "pass", fakeServer.Addr(), "/blobs/app-bits.json")
+			sanitizedURL = fmt.Sprintf("http://%s%s", fakeServer.Addr(), "/blobs/app-bits.json")
+
+			var err error
+			destDir, err = ioutil.TempDir("", "app")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			fakeServer.Close()
+			os.RemoveAll(destDir)
+		})
+
+		It("recreates the app from the resources listed in the manifest", func() {
+			command := exec.Command(davtoolPath, "get-app", fakeServerURL, destDir)
+			command.Env = []string{"EXPECTED_SHA256=" + fmt.Sprintf("%x", sha256.Sum256([]byte(manifestJSON)))}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("Downloaded %s to %s.", sanitizedURL, destDir))
+			Expect(ioutil.ReadFile(filepath.Join(destDir, "bin", "run"))).To(Equal([]byte("some contents")))
+
+			fileInfo, err := os.Stat(filepath.Join(destDir, "bin", "run"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileInfo.Mode()).To(Equal(os.FileMode(0755)))
+		})
+
+		Context("when the manifest doesn't match the expected checksum", func() {
+			It("prints an error message and exits", func() {
+				command := exec.Command(davtoolPath, "get-app", fakeServerURL, destDir)
+				command.Env = []string{"EXPECTED_SHA256=abc123"}
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(2))
+				Expect(session.Out).To(gbytes.Say("Checksum mismatch for %s: expected abc123, got ", sanitizedURL))
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when a resource doesn't match its checksum", func() {
+			It("prints an error message and exits", func() {
+				fakeServer.SetHandler(1, ghttp.RespondWith(http.StatusOK, "other contents"))
+
+				command := exec.Command(davtoolPath, "get-app", fakeServerURL, destDir)
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(2))
+				Expect(session.Out).To(gbytes.Say("Error assembling app from %s: bin/run: checksum mismatch", sanitizedURL))
+			})
+		})
+
+		Context("when the command is missing", func() {
+			It("prints an error message and exits", func() {
+				command := exec.Command(davtoolPath, "get-app", "invalid")
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(3))
+				Expect(session.Out).To(gbytes.Say("Usage: davtool get-app manifestURL destinationDir"))
+			})
+		})
+	})
+
 	Describe("put", func() {
 		var (
 			httpStatusCode              int
 			fakeServer                  *ghttp.Server
 			httpBody                    []byte
+			checksumBody                []byte
 			tmpFile                     *os.File
 			fakeServerURL, sanitizedURL string
 		)
@@ -123,6 +331,15 @@ var _ = Describe("davtool", func() {
 					Expect(err).NotTo(HaveOccurred())
 				},
 			))
+			fakeServer.RouteToHandler("PUT", "/blobs/path.sha256", ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("user", "pass"),
+				func(_ http.ResponseWriter, request *http.Request) {
+					var err error
+					checksumBody, err = ioutil.ReadAll(request.Body)
+					Expect(err).NotTo(HaveOccurred())
+				},
+				ghttp.RespondWith(http.StatusCreated, nil),
+			))
 
 			fakeServerURL = fmt.Sprintf("http://%s:%s@%s%s", "user", "pass", fakeServer.Addr(), "/blobs/path")
 			sanitizedURL = fmt.Sprintf("http://%s%s", fakeServer.Addr(), "/blobs/path")
@@ -148,7 +365,18 @@ var _ = Describe("davtool", func() {
 			Eventually(session).Should(gexec.Exit(0))
 			Expect(session.Out).To(gbytes.Say("Uploaded %s to %s.", tmpFile.Name(), sanitizedURL))
 
-			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
+			Expect(httpBody).To(Equal([]byte("some-file-contents")))
+		})
+
+		It("reads the credentials from the environment", func() {
+			command := exec.Command(davtoolPath, "put", sanitizedURL, tmpFile.Name())
+			command.Env = []string{"DAV_USERNAME=user", "DAV_PASSWORD=pass"}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
 			Expect(httpBody).To(Equal([]byte("some-file-contents")))
 		})
 
@@ -162,10 +390,19 @@ var _ = Describe("davtool", func() {
 			Eventually(session).Should(gexec.Exit(0))
 			Expect(session.Out).To(gbytes.Say("Uploaded %s to %s.", tmpFile.Name(), sanitizedURL))
 
-			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
 			Expect(httpBody).To(Equal([]byte("some-file-contents")))
 		})
 
+		It("stores the SHA-256 checksum of the file alongside it", func() {
+			command := exec.Command(davtoolPath, "put", fakeServerURL, tmpFile.Name())
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(checksumBody)).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("some-file-contents")))))
+		})
+
 		Context("when the DAV upload fails", func() {
 			It("prints an error message and exits", func() {
 				httpStatusCode = 404
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
 )
 
 func main() {
@@ -24,21 +31,23 @@ func main() {
 		deleteAction(args[1:])
 	case "get":
 		getAction(args[1:])
+	case "get-app":
+		getAppAction(args[1:])
 	case "put":
 		putAction(args[1:])
 	default:
-		fmt.Println("Usage: s3tool [get|put|delete] arguments...")
+		fmt.Println("Usage: s3tool [get|get-app|put|delete] arguments...")
 		os.Exit(3)
 	}
 }
 
 func deleteAction(args []string) {
-	if len(args) != 5 {
-		fmt.Println("Usage: s3tool delete s3AccessKey s3SecretKey s3Bucket s3Region s3Path")
-		os.Exit(3)
+	accessKey, secretKey, args, ok := credentialArgs(args, 3)
+	if !ok {
+		usage("delete s3Bucket s3Region s3Path")
 	}
 
-	accessKey, secretKey, bucket, region, path := args[0], args[1], args[2], args[3], args[4]
+	bucket, region, path := args[0], args[1], args[2]
 
 	client := connect(accessKey, secretKey, region)
 
@@ -54,12 +63,12 @@ func deleteAction(args []string) {
 }
 
 func getAction(args []string) {
-	if len(args) != 6 {
-		fmt.Println("Usage: s3tool get s3AccessKey s3SecretKey s3Bucket s3Region s3Path destinationFilePath")
-		os.Exit(3)
+	accessKey, secretKey, args, ok := credentialArgs(args, 4)
+	if !ok {
+		usage("get s3Bucket s3Region s3Path destinationFilePath")
 	}
 
-	accessKey, secretKey, bucket, region, path, destPath := args[0], args[1], args[2], args[3], args[4], args[5]
+	bucket, region, path, destPath := args[0], args[1], args[2], args[3]
 
 	client := connect(accessKey, secretKey, region)
 
@@ -72,28 +81,100 @@ func getAction(args []string) {
 		os.Exit(2)
 	}
 
-	destFile, err := os.OpenFile(destPath, os.O_RDWR|os.O_CREATE, 0644)
+	destFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		fmt.Printf("Error opening %s: %s\n", destPath, err)
 		os.Exit(2)
 	}
 	defer destFile.Close()
 
-	if _, err := io.Copy(destFile, output.Body); err != nil {
+	checksum := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(destFile, checksum), output.Body); err != nil {
 		fmt.Printf("Error writing response to %s: %s\n", destPath, err)
 		os.Exit(2)
 	}
 
+	if expected := os.Getenv("EXPECTED_SHA256"); expected != "" {
+		if actual := hex.EncodeToString(checksum.Sum(nil)); actual != expected {
+			destFile.Close()
+			os.Remove(destPath)
+			fmt.Printf("Checksum mismatch for s3://%s/%s: expected %s, got %s\n", bucket, path, expected, actual)
+			os.Exit(2)
+		}
+	}
+
 	fmt.Printf("Downloaded s3://%s/%s to %s.\n", bucket, path, destPath)
 }
 
+// getAppAction recreates an app under destinationDir from the app bits
+// manifest at s3Path, fetching each file from the resources stored beside it.
+// When EXPECTED_SHA256 is set, the manifest must match it.
+func getAppAction(args []string) {
+	accessKey, secretKey, args, ok := credentialArgs(args, 4)
+	if !ok {
+		usage("get-app s3Bucket s3Region s3ManifestPath destinationDir")
+	}
+
+	bucket, region, manifestPath, destDir := args[0], args[1], args[2], args[3]
+
+	client := connect(accessKey, secretKey, region)
+
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(manifestPath),
+	})
+	if err != nil {
+		fmt.Printf("Error downloading s3://%s/%s: %s\n", bucket, manifestPath, err)
+		os.Exit(2)
+	}
+	defer output.Body.Close()
+
+	manifestJSON, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		fmt.Printf("Error downloading s3://%s/%s: %s\n", bucket, manifestPath, err)
+		os.Exit(2)
+	}
+
+	if expected := os.Getenv("EXPECTED_SHA256"); expected != "" {
+		checksum := sha256.Sum256(manifestJSON)
+		if actual := hex.EncodeToString(checksum[:]); actual != expected {
+			fmt.Printf("Checksum mismatch for s3://%s/%s: expected %s, got %s\n", bucket, manifestPath, expected, actual)
+			os.Exit(2)
+		}
+	}
+
+	var manifest app_bits.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		fmt.Printf("Error reading s3://%s/%s: %s\n", bucket, manifestPath, err)
+		os.Exit(2)
+	}
+
+	fetch := func(checksum string) (io.ReadCloser, error) {
+		output, err := client.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(path.Join(path.Dir(manifestPath), app_bits.ResourcePath(checksum))),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return output.Body, nil
+	}
+
+	if err := manifest.Assemble(destDir, fetch); err != nil {
+		fmt.Printf("Error assembling app from s3://%s/%s: %s\n", bucket, manifestPath, err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("Downloaded s3://%s/%s to %s.\n", bucket, manifestPath, destDir)
+}
+
 func putAction(args []string) {
-	if len(args) != 6 {
-		fmt.Println("Usage: s3tool put s3AccessKey s3SecretKey s3Bucket s3Region s3Path fileToUpload")
-		os.Exit(3)
+	accessKey, secretKey, args, ok := credentialArgs(args, 4)
+	if !ok {
+		usage("put s3Bucket s3Region s3Path fileToUpload")
 	}
 
-	accessKey, secretKey, bucket, region, path, sourcePath := args[0], args[1], args[2], args[3], args[4], args[5]
+	bucket, region, path, sourcePath := args[0], args[1], args[2], args[3]
 
 	client := connect(accessKey, secretKey, region)
 
@@ -104,23 +185,73 @@ func putAction(args []string) {
 	}
 	defer sourceFile.Close()
 
-	if _, err := client.PutObject(&s3.PutObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(path),
-		Body:   sourceFile,
-	}); err != nil {
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, sourceFile); err != nil {
+		fmt.Printf("Error reading %s: %s\n", sourcePath, err)
+		os.Exit(2)
+	}
+	if _, err := sourceFile.Seek(0, 0); err != nil {
+		fmt.Printf("Error reading %s: %s\n", sourcePath, err)
+		os.Exit(2)
+	}
+
+	if err := putObject(client, bucket, path, sourceFile); err != nil {
 		fmt.Printf("Error uploading %s: %s\n", sourcePath, err)
 		os.Exit(2)
 	}
 
+	// the checksum is stored alongside the object for ltc and the cells that
+	// download it to verify it against
+	if err := putObject(client, bucket, path+".sha256", strings.NewReader(hex.EncodeToString(checksum.Sum(nil)))); err != nil {
+		fmt.Printf("Error uploading checksum of %s: %s\n", sourcePath, err)
+		os.Exit(2)
+	}
+
 	fmt.Printf("Uploaded %s to s3://%s/%s.\n", sourcePath, bucket, path)
 }
 
+func putObject(client *s3.S3, bucket, path string, body io.ReadSeeker) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(path),
+		Body:   body,
+	}
+	if sse := os.Getenv("S3_SERVER_SIDE_ENCRYPTION"); sse != "" {
+		input.ServerSideEncryption = aws.String(sse)
+	}
+	if kmsKeyID := os.Getenv("S3_SSE_KMS_KEY_ID"); kmsKeyID != "" {
+		input.SSEKMSKeyId = aws.String(kmsKeyID)
+	}
+
+	_, err := client.PutObject(input)
+	return err
+}
+
+// credentialArgs reads the access and secret keys from AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY, keeping them out of process listings, unless they
+// lead the arguments as in earlier versions of s3tool.
+func credentialArgs(args []string, argCount int) (accessKey, secretKey string, rest []string, ok bool) {
+	switch len(args) {
+	case argCount:
+		return os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), args, true
+	case argCount + 2:
+		return args[0], args[1], args[2:], true
+	}
+
+	return "", "", nil, false
+}
+
+func usage(arguments string) {
+	fmt.Println("Usage: s3tool " + arguments)
+	fmt.Println("Set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to the S3 credentials.")
+	os.Exit(3)
+}
+
 func connect(accessKey, secretKey, region string) *s3.S3 {
 	client := s3.New(session.New(&aws.Config{
-		Credentials:      credentials.NewStaticCredentials(accessKey, secretKey, ""),
+		Credentials:      credentials.NewStaticCredentials(accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN")),
 		Region:           aws.String(region),
-		S3ForcePathStyle: aws.Bool(true),
+		S3ForcePathStyle: aws.Bool(os.Getenv("S3_ADDRESSING_STYLE") != "virtual-host"),
 	}))
 
 	if override := os.Getenv("AWS_ENDPOINT_OVERRIDE"); override != "" {
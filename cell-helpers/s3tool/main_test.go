@@ -3,10 +3,13 @@
 package main_test
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -23,7 +26,7 @@ var _ = Describe("s3tool", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Eventually(session).Should(gexec.Exit(3))
-			Expect(session.Out).To(gbytes.Say("Usage: s3tool \\[get\\|put\\|delete\\] arguments..."))
+			Expect(session.Out).To(gbytes.Say("Usage: s3tool \\[get\\|get-app\\|put\\|delete\\] arguments..."))
 		})
 	})
 
@@ -62,6 +65,26 @@ var _ = Describe("s3tool", func() {
 			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
 		})
 
+		It("reads the credentials from the environment", func() {
+			fakeServer.SetHandler(0, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/bucket/key"),
+				verifyAccessKey("env-access"),
+			))
+
+			command := exec.Command(s3toolPath, "delete", "bucket", "region", "key")
+			command.Env = []string{
+				"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+				"AWS_ACCESS_KEY_ID=env-access",
+				"AWS_SECRET_ACCESS_KEY=env-secret",
+			}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("Deleted s3://bucket/key."))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
 		Context("when the S3 delete fails", func() {
 			It("prints an error message and exits", func() {
 				httpStatusCode = 404
@@ -83,7 +106,7 @@ var _ = Describe("s3tool", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				Eventually(session).Should(gexec.Exit(3))
-				Expect(session.Out).To(gbytes.Say("Usage: s3tool delete s3AccessKey s3SecretKey s3Bucket s3Region s3Path"))
+				Expect(session.Out).To(gbytes.Say("Usage: s3tool delete s3Bucket s3Region s3Path"))
 			})
 		})
 	})
@@ -132,6 +155,91 @@ var _ = Describe("s3tool", func() {
 			Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
 		})
 
+		It("replaces a longer file already at the destination", func() {
+			httpResponse = "some-file-contents"
+
+			tmpFile, err := ioutil.TempFile(os.TempDir(), "downloadedFile")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(tmpFile.Name())
+			Expect(ioutil.WriteFile(tmpFile.Name(), []byte("some-much-longer-stale-contents"), 0644)).To(Succeed())
+
+			command := exec.Command(s3toolPath, "get", "access", "secret", "bucket", "region", "key", tmpFile.Name())
+			command.Env = []string{"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL()}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
+		})
+
+		It("reads the credentials from the environment", func() {
+			httpResponse = "some-file-contents"
+			fakeServer.SetHandler(0, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/bucket/key"),
+				verifyAccessKey("env-access"),
+				ghttp.RespondWithPtr(&httpStatusCode, &httpResponse),
+			))
+
+			tmpFile, err := ioutil.TempFile(os.TempDir(), "downloadedFile")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(tmpFile.Name())
+
+			command := exec.Command(s3toolPath, "get", "bucket", "region", "key", tmpFile.Name())
+			command.Env = []string{
+				"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+				"AWS_ACCESS_KEY_ID=env-access",
+				"AWS_SECRET_ACCESS_KEY=env-secret",
+			}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
+		})
+
+		Context("when an expected checksum is given", func() {
+			var tmpFile *os.File
+
+			BeforeEach(func() {
+				httpResponse = "some-file-contents"
+
+				var err error
+				tmpFile, err = ioutil.TempFile(os.TempDir(), "downloadedFile")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.Remove(tmpFile.Name())
+			})
+
+			It("saves a download that matches it", func() {
+				command := exec.Command(s3toolPath, "get", "access", "secret", "bucket", "region", "key", tmpFile.Name())
+				command.Env = []string{
+					"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+					"EXPECTED_SHA256=" + fmt.Sprintf("%x", sha256.Sum256([]byte("some-file-contents"))),
+				}
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(ioutil.ReadFile(tmpFile.Name())).To(Equal([]byte("some-file-contents")))
+			})
+
+			It("removes a download that doesn't match it and exits", func() {
+				command := exec.Command(s3toolPath, "get", "access", "secret", "bucket", "region", "key", tmpFile.Name())
+				command.Env = []string{
+					"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+					"EXPECTED_SHA256=abc123",
+				}
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(2))
+				Expect(session.Out).To(gbytes.Say("Checksum mismatch for s3://bucket/key: expected abc123, got "))
+				Expect(tmpFile.Name()).NotTo(BeAnExistingFile())
+			})
+		})
+
 		Context("when the S3 download fails", func() {
 			It("prints an error message and exits", func() {
 				httpStatusCode = 404
@@ -168,7 +276,93 @@ var _ = Describe("s3tool", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				Eventually(session).Should(gexec.Exit(3))
-				Expect(session.Out).To(gbytes.Say("Usage: s3tool get s3AccessKey s3SecretKey s3Bucket s3Region s3Path destinationFilePath"))
+				Expect(session.Out).To(gbytes.Say("Usage: s3tool get s3Bucket s3Region s3Path destinationFilePath"))
+			})
+		})
+	})
+
+	Describe("get-app", func() {
+		const manifestJSON = `{"files": [
+			{"path": "bin", "mode": 2147484141},
+			{"path": "bin/run", "mode": 493, "size": 13, "sha256": "b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832"}
+		]}`
+
+		var (
+			fakeServer *ghttp.Server
+			destDir    string
+		)
+
+		BeforeEach(func() {
+			fakeServer = ghttp.NewServer()
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket/app-bits.json"),
+					verifyAccessKey("env-access"),
+					ghttp.RespondWith(http.StatusOK, manifestJSON),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket/b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832.resource"),
+					verifyAccessKey("env-access"),
+					ghttp.RespondWith(http.StatusOK, "some contents"),
+				),
+			)
+
+			var err error
+			destDir, err = ioutil.TempDir("", "app")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			fakeServer.Close()
+			os.RemoveAll(destDir)
+		})
+
+		It("recreates the app from the resources listed in the manifest", func() {
+			command := exec.Command(s3toolPath, "get-app", "bucket", "region", "app-bits.json", destDir)
+			command.Env = []string{
+				"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+				"AWS_ACCESS_KEY_ID=env-access",
+				"AWS_SECRET_ACCESS_KEY=env-secret",
+				"EXPECTED_SHA256=" + fmt.Sprintf("%x", sha256.Sum256([]byte(manifestJSON))),
+			}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("Downloaded s3://bucket/app-bits.json to %s.", destDir))
+			Expect(ioutil.ReadFile(filepath.Join(destDir, "bin", "run"))).To(Equal([]byte("some contents")))
+
+			fileInfo, err := os.Stat(filepath.Join(destDir, "bin", "run"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileInfo.Mode()).To(Equal(os.FileMode(0755)))
+		})
+
+		Context("when the manifest doesn't match the expected checksum", func() {
+			It("prints an error message and exits", func() {
+				command := exec.Command(s3toolPath, "get-app", "bucket", "region", "app-bits.json", destDir)
+				command.Env = []string{
+					"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+					"AWS_ACCESS_KEY_ID=env-access",
+					"AWS_SECRET_ACCESS_KEY=env-secret",
+					"EXPECTED_SHA256=abc123",
+				}
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(2))
+				Expect(session.Out).To(gbytes.Say("Checksum mismatch for s3://bucket/app-bits.json: expected abc123, got "))
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when the command is missing", func() {
+			It("prints an error message and exits", func() {
+				command := exec.Command(s3toolPath, "get-app", "invalid")
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(3))
+				Expect(session.Out).To(gbytes.Say("Usage: s3tool get-app s3Bucket s3Region s3ManifestPath destinationDir"))
 			})
 		})
 	})
@@ -178,6 +372,7 @@ var _ = Describe("s3tool", func() {
 			httpStatusCode int
 			fakeServer     *ghttp.Server
 			httpBody       []byte
+			checksumBody   []byte
 			tmpFile        *os.File
 		)
 
@@ -195,6 +390,11 @@ var _ = Describe("s3tool", func() {
 					Expect(err).NotTo(HaveOccurred())
 				},
 			))
+			fakeServer.RouteToHandler("PUT", "/bucket/key.sha256", func(_ http.ResponseWriter, req *http.Request) {
+				var err error
+				checksumBody, err = ioutil.ReadAll(req.Body)
+				Expect(err).NotTo(HaveOccurred())
+			})
 
 			var err error
 			tmpFile, err = ioutil.TempFile(os.TempDir(), "fileToUpload")
@@ -218,10 +418,66 @@ var _ = Describe("s3tool", func() {
 			Eventually(session).Should(gexec.Exit(0))
 			Expect(session.Out).To(gbytes.Say("Uploaded " + tmpFile.Name() + " to s3://bucket/key."))
 
-			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(2))
 			Expect(httpBody).To(Equal([]byte("some-file-contents")))
 		})
 
+		It("stores the SHA-256 checksum of the file alongside it", func() {
+			command := exec.Command(s3toolPath, "put", "access", "secret", "bucket", "region", "key", tmpFile.Name())
+			command.Env = []string{"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL()}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(string(checksumBody)).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("some-file-contents")))))
+		})
+
+		It("reads the credentials from the environment", func() {
+			fakeServer.SetHandler(0, ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/bucket/key"),
+				verifyAccessKey("env-access"),
+			))
+
+			command := exec.Command(s3toolPath, "put", "bucket", "region", "key", tmpFile.Name())
+			command.Env = []string{
+				"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+				"AWS_ACCESS_KEY_ID=env-access",
+				"AWS_SECRET_ACCESS_KEY=env-secret",
+			}
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("Uploaded " + tmpFile.Name() + " to s3://bucket/key."))
+		})
+
+		Context("when server-side encryption and a session token are configured", func() {
+			It("sends the encryption and security token headers", func() {
+				var header http.Header
+				fakeServer.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/bucket/key"),
+					func(_ http.ResponseWriter, req *http.Request) {
+						header = req.Header
+					},
+				))
+
+				command := exec.Command(s3toolPath, "put", "access", "secret", "bucket", "region", "key", tmpFile.Name())
+				command.Env = []string{
+					"AWS_ENDPOINT_OVERRIDE=" + fakeServer.URL(),
+					"AWS_SESSION_TOKEN=some-session-token",
+					"S3_SERVER_SIDE_ENCRYPTION=aws:kms",
+					"S3_SSE_KMS_KEY_ID=some-key-id",
+				}
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(header.Get("X-Amz-Security-Token")).To(Equal("some-session-token"))
+				Expect(header.Get("X-Amz-Server-Side-Encryption")).To(Equal("aws:kms"))
+				Expect(header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")).To(Equal("some-key-id"))
+			})
+		})
+
 		Context("when the S3 upload fails", func() {
 			It("prints an error message and exits", func() {
 				httpStatusCode = 404
@@ -254,8 +510,14 @@ var _ = Describe("s3tool", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				Eventually(session).Should(gexec.Exit(3))
-				Expect(session.Out).To(gbytes.Say("Usage: s3tool put s3AccessKey s3SecretKey s3Bucket s3Region s3Path fileToUpload"))
+				Expect(session.Out).To(gbytes.Say("Usage: s3tool put s3Bucket s3Region s3Path fileToUpload"))
 			})
 		})
 	})
 })
+
+func verifyAccessKey(accessKey string) http.HandlerFunc {
+	return func(_ http.ResponseWriter, req *http.Request) {
+		Expect(req.Header.Get("Authorization")).To(ContainSubstring("Credential=" + accessKey + "/"))
+	}
+}
@@ -67,6 +67,10 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("target"),
+					presentCommand("targets"),
+					presentCommand("login"),
+					presentCommand("logout"),
+					presentCommand("config"),
 				},
 			},
 		}, {
@@ -89,11 +93,19 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("build-droplet"),
+					presentCommand("clear-build-cache"),
+					presentCommand("droplet-info"),
+					presentCommand("droplet-history"),
 					presentCommand("export-droplet"),
+					presentCommand("gc-droplets"),
 					presentCommand("import-droplet"),
 					presentCommand("launch-droplet"),
 					presentCommand("list-droplets"),
+					presentCommand("migrate-droplets"),
+					presentCommand("push"),
 					presentCommand("remove-droplet"),
+					presentCommand("serve-blobs"),
+					presentCommand("tag-droplet"),
 				},
 			},
 		}, {
@@ -151,6 +163,7 @@ func newAppPresenter(app *cli.App) (presenter appPresenter) {
 			CommandSubGroups: [][]cmdPresenter{
 				{
 					presentCommand("debug-logs"),
+					presentCommand("doctor"),
 					presentCommand("help"),
 					presentCommand("test"),
 				},
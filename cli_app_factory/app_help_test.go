@@ -48,6 +48,7 @@ USAGE:
 			nil,
 			fakeReceptorClientCreator,
 			nil,
+			nil,
 			terminal.NewUI(nil, outputBuffer, nil),
 		)
 	})
@@ -5,23 +5,31 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/cloudfoundry-incubator/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/ltc/app_examiner/command_factory/graphical"
 	"github.com/cloudfoundry-incubator/ltc/app_runner"
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	"github.com/cloudfoundry-incubator/ltc/blob_store"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/dav_blob_store"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/local_blob_store"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/s3_blob_store"
 	"github.com/cloudfoundry-incubator/ltc/cluster_test"
 	"github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/ltc/config/target_verifier"
 	"github.com/cloudfoundry-incubator/ltc/docker_runner/docker_metadata_fetcher"
+	"github.com/cloudfoundry-incubator/ltc/doctor"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/cf_ignore"
 	zipper_package "github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/zipper"
 	"github.com/cloudfoundry-incubator/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/ltc/logs"
 	"github.com/cloudfoundry-incubator/ltc/logs/console_tailed_logs_outputter"
 	"github.com/cloudfoundry-incubator/ltc/receptor_client"
@@ -39,9 +47,11 @@ import (
 
 	app_examiner_command_factory "github.com/cloudfoundry-incubator/ltc/app_examiner/command_factory"
 	app_runner_command_factory "github.com/cloudfoundry-incubator/ltc/app_runner/command_factory"
+	blob_store_command_factory "github.com/cloudfoundry-incubator/ltc/blob_store/command_factory"
 	cluster_test_command_factory "github.com/cloudfoundry-incubator/ltc/cluster_test/command_factory"
 	config_command_factory "github.com/cloudfoundry-incubator/ltc/config/command_factory"
 	docker_runner_command_factory "github.com/cloudfoundry-incubator/ltc/docker_runner/command_factory"
+	doctor_command_factory "github.com/cloudfoundry-incubator/ltc/doctor/command_factory"
 	droplet_runner_command_factory "github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory"
 	logs_command_factory "github.com/cloudfoundry-incubator/ltc/logs/command_factory"
 	ssh_command_factory "github.com/cloudfoundry-incubator/ltc/ssh/command_factory"
@@ -52,8 +62,23 @@ import (
 
 var (
 	nonTargetVerifiedCommandNames = map[string]struct{}{
-		"target": {},
-		"help":   {},
+		"config":      {},
+		"doctor":      {},
+		"login":       {},
+		"logout":      {},
+		"serve-blobs": {},
+		"target":      {},
+		"targets":     {},
+		"help":        {},
+	}
+
+	// commands that can still fix or show TLS settings the target can't use
+	nonTLSCommandNames = map[string]struct{}{
+		"config":  {},
+		"doctor":  {},
+		"target":  {},
+		"targets": {},
+		"help":    {},
 	}
 
 	defaultAction = func(context *cli.Context) {
@@ -100,9 +125,13 @@ func MakeCliApp(
 	logger lager.Logger,
 	receptorClientCreator receptor_client.Creator,
 	targetVerifier target_verifier.TargetVerifier,
+	tokenManager auth.TokenManager,
 	cliStdout io.Writer,
 ) *cli.App {
-	config.Load()
+	configLoadErr := config.Load()
+	if !unusableConfigError(configLoadErr) {
+		configLoadErr = loadConfigOverrides(config)
+	}
 	app := cli.NewApp()
 	app.Name = AppName
 	app.Author = latticeCliAuthor
@@ -113,7 +142,28 @@ func MakeCliApp(
 	ui := terminal.NewUI(os.Stdin, cliStdout, terminal.NewPasswordReader())
 	app.Writer = ui
 
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "target",
+			Usage: "Runs the command against a saved target without changing the default",
+		},
+	}
+
 	app.Before = func(context *cli.Context) error {
+		if configLoadErr != nil && len(context.Args()) > 0 && !replacesCorruptConfig(configLoadErr, context.Args()) {
+			ui.SayLine(fmt.Sprintf("Error loading config: %s", configLoadErr))
+			return configLoadErr
+		}
+
+		if targetName := context.String("target"); targetName != "" {
+			if err := config.OverrideWithProfile(targetName); err != nil {
+				ui.SayLine(fmt.Sprintf("%s. Run ltc targets to list the saved targets.", err))
+				return err
+			}
+
+			rebindCommands(app.Commands, cliCommands(ltcConfigRoot, exitHandler, config, logger, receptorClientCreator, targetVerifier, tokenManager, ui, latticeVersion))
+		}
+
 		args := context.Args()
 		command := app.Command(args.First())
 
@@ -121,19 +171,35 @@ func MakeCliApp(
 			return nil
 		}
 
-		if _, ok := nonTargetVerifiedCommandNames[command.Name]; ok || len(args) == 0 {
-			return nil
-		}
-
 		for _, arg := range args {
 			if arg == "-h" || arg == "--help" {
 				return nil
 			}
 		}
 
+		if _, ok := nonTLSCommandNames[command.Name]; !ok {
+			if _, err := config.TLS().ClientConfig(); err != nil {
+				ui.SayLine(fmt.Sprint("Error loading TLS configuration: ", err))
+				exitHandler.Exit(exit_codes.BadTarget)
+				return err
+			}
+		}
+
+		if _, ok := nonTargetVerifiedCommandNames[command.Name]; ok || len(args) == 0 {
+			return nil
+		}
+
+		if _, err := tokenManager.Token(); err != nil {
+			ui.SayLine(err.Error())
+			return err
+		}
+
 		if receptorUp, authorized, err := targetVerifier.VerifyTarget(config.Receptor()); !receptorUp {
 			ui.SayLine(fmt.Sprintf("Error connecting to the receptor. Make sure your lattice target is set, and that lattice is up and running.\n\tUnderlying error: %s", err))
 			return err
+		} else if !authorized && config.Auth().TokenMode() {
+			ui.SayLine("Could not authenticate with the receptor. Please run ltc login.")
+			return errors.New("Could not authenticate with the receptor.")
 		} else if !authorized {
 			ui.SayLine("Could not authenticate with the receptor. Please run ltc target with the correct credentials.")
 			return errors.New("Could not authenticate with the receptor.")
@@ -146,18 +212,76 @@ func MakeCliApp(
 		ui.SayLine(fmt.Sprintf(unknownCommand, command))
 		exitHandler.Exit(1)
 	}
-	app.Commands = cliCommands(ltcConfigRoot, exitHandler, config, logger, receptorClientCreator, targetVerifier, ui, latticeVersion)
+	app.Commands = cliCommands(ltcConfigRoot, exitHandler, config, logger, receptorClientCreator, targetVerifier, tokenManager, ui, latticeVersion)
 	return app
 }
 
-func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, config *config.Config, logger lager.Logger, receptorClientCreator receptor_client.Creator, targetVerifier target_verifier.TargetVerifier, ui terminal.UI, latticeVersion string) []cli.Command {
-	receptorClient := receptorClientCreator.CreateReceptorClient(config.Receptor())
-	noaaConsumer := noaa.NewConsumer(LoggregatorUrl(config.Loggregator()), nil, nil)
-	appRunner := app_runner.New(receptorClient, config.Target(), &keygen_package.KeyGenerator{RandReader: rand.Reader})
+// rebindCommands points the app's commands at the actions of commands built
+// for the overridden config.  It updates the commands in place so that the
+// ones cli.App added while setting up, such as help, keep working.
+func rebindCommands(commands []cli.Command, reboundCommands []cli.Command) {
+	for i := range commands {
+		for _, reboundCommand := range reboundCommands {
+			if reboundCommand.Name == commands[i].Name {
+				commands[i].Action = reboundCommand.Action
+				commands[i].Subcommands = reboundCommand.Subcommands
+			}
+		}
+	}
+}
+
+// unusableConfigError reports whether err from loading the config means no
+// command should run against it.
+func unusableConfigError(err error) bool {
+	switch err.(type) {
+	case persister.DecryptError, persister.CorruptFileError, config.NewerSchemaError:
+		return true
+	}
+
+	return false
+}
+
+// replacesCorruptConfig reports whether args run a command that writes a
+// whole new config, which can run when the config file is corrupt.
+func replacesCorruptConfig(configLoadErr error, args cli.Args) bool {
+	if _, ok := configLoadErr.(persister.CorruptFileError); !ok {
+		return false
+	}
+
+	switch args.First() {
+	case "target":
+		return len(args) > 1
+	case "config":
+		return args.Get(1) == "import"
+	}
+
+	return false
+}
 
+// loadConfigOverrides layers the nearest project config and any LTC_*
+// environment variables over the user config.
+func loadConfigOverrides(config *config.Config) error {
+	workingDir, err := os.Getwd()
+	if err == nil {
+		if err := config.LoadProjectFile(workingDir); err != nil {
+			return err
+		}
+	}
+
+	return config.LoadEnvironment()
+}
+
+func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, config *config.Config, logger lager.Logger, receptorClientCreator receptor_client.Creator, targetVerifier target_verifier.TargetVerifier, tokenManager auth.TokenManager, ui terminal.UI, latticeVersion string) []cli.Command {
+	receptorClient := receptorClientCreator.CreateReceptorClient(config.Receptor())
+	// invalid TLS settings are reported by app.Before before any command runs
+	tlsConfig, _ := config.TLS().ClientConfig()
+	noaaConsumer := noaa.NewConsumer(LoggregatorUrl(config.Loggregator(), config.DopplerScheme() == "wss"), tlsConfig, nil)
+	dopplerTokenSource := auth.NewDopplerTokenSource(config, tokenManager)
 	clock := clock.NewClock()
 
-	logReader := logs.NewLogReader(noaaConsumer)
+	appRunner := app_runner.New(receptorClient, config.Target(), &keygen_package.KeyGenerator{RandReader: rand.Reader}, clock)
+
+	logReader := logs.NewLogReader(noaaConsumer, dopplerTokenSource)
 	tailedLogsOutputter := console_tailed_logs_outputter.NewConsoleTailedLogsOutputter(ui, logReader)
 
 	taskExaminer := task_examiner.New(receptorClient)
@@ -166,7 +290,7 @@ func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, con
 	taskRunner := task_runner.New(receptorClient, taskExaminer, clock)
 	taskRunnerCommandFactory := task_runner_command_factory.NewTaskRunnerCommandFactory(taskRunner, ui, exitHandler)
 
-	appExaminer := app_examiner.New(receptorClient, app_examiner.NewNoaaConsumer(noaaConsumer))
+	appExaminer := app_examiner.New(receptorClient, app_examiner.NewNoaaConsumer(noaaConsumer, dopplerTokenSource))
 	graphicalVisualizer := graphical.NewGraphicalVisualizer(appExaminer)
 	dockerTerminal := &app_examiner_command_factory.DockerTerminal{}
 	appExaminerCommandFactory := app_examiner_command_factory.NewAppExaminerCommandFactory(appExaminer, ui, dockerTerminal, clock, exitHandler, graphicalVisualizer, taskExaminer, config.Target())
@@ -206,22 +330,42 @@ func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, con
 
 	blobStore := blob_store.New(config)
 	blobStoreVerifier := blob_store.BlobStoreVerifier{
-		DAVBlobStoreVerifier: dav_blob_store.Verifier{},
-		S3BlobStoreVerifier:  s3_blob_store.Verifier{},
+		DAVBlobStoreVerifier:   dav_blob_store.Verifier{},
+		S3BlobStoreVerifier:    s3_blob_store.Verifier{},
+		LocalBlobStoreVerifier: local_blob_store.Verifier{},
 	}
+	blobStoreCommandFactory := blob_store_command_factory.NewBlobStoreCommandFactory(config, ui, exitHandler, http.ListenAndServe)
 
 	httpProxyConfReader := &droplet_runner.HTTPProxyConfReader{
-		URL: fmt.Sprintf("http://%s:8444/proxyconf.json", config.Target()),
+		URL: fmt.Sprintf("%s://%s:8444/proxyconf.json", config.Scheme(), config.Target()),
+	}
+	if client, err := config.TLS().HTTPClient(); err == nil {
+		httpProxyConfReader.Client = client
 	}
 	dropletRunner := droplet_runner.New(appRunner, taskRunner, config, blobStore, appExaminer, httpProxyConfReader)
 	cfIgnore := cf_ignore.New()
 	zipper := &zipper_package.DropletArtifactZipper{}
 	dropletRunnerCommandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(*appRunnerCommandFactory, blobStoreVerifier, taskExaminer, dropletRunner, cfIgnore, zipper, config)
 
-	versionManager := version.NewVersionManager(receptorClientCreator, &version.AppFileSwapper{}, defaultLatticeVersion(latticeVersion))
-	configCommandFactory := config_command_factory.NewConfigCommandFactory(config, ui, targetVerifier, blobStoreVerifier, exitHandler, versionManager)
-
-	sshCommandFactory := ssh_command_factory.NewSSHCommandFactory(config, ui, exitHandler, appExaminer, ssh.New(exitHandler))
+	versionManager := version.NewVersionManager(receptorClientCreator, &version.AppFileSwapper{}, defaultLatticeVersion(latticeVersion), tokenManager)
+	configCommandFactory := config_command_factory.NewConfigCommandFactory(config, ui, targetVerifier, blobStoreVerifier, logs.EndpointVerifier{TokenSource: dopplerTokenSource}, exitHandler, versionManager, tokenManager)
+
+	sshCommandFactory := ssh_command_factory.NewSSHCommandFactory(config, ui, exitHandler, appExaminer, ssh.New(exitHandler, tokenManager))
+
+	doctorLogConsumer := noaa.NewConsumer(LoggregatorUrl(config.Loggregator(), config.DopplerScheme() == "wss"), tlsConfig, nil)
+	doctorConfig := doctor.DoctorConfig{
+		Config:         config,
+		TargetVerifier: targetVerifier,
+		BlobStore:      blobStore,
+		AppExaminer:    appExaminer,
+		VersionManager: versionManager,
+		LogConsumer:    doctorLogConsumer,
+		LogTokenSource: dopplerTokenSource,
+		LookupHost:     net.LookupHost,
+		DialTimeout:    net.DialTimeout,
+		Timeout:        5 * time.Second,
+	}
+	doctorCommandFactory := doctor_command_factory.NewDoctorCommandFactory(doctor.New(doctorConfig), ui, exitHandler)
 
 	ltcPath, _ := osext.Executable()
 	versionCommandFactory := version_command_factory.NewVersionCommandFactory(config, ui, exitHandler, runtime.GOOS, ltcPath, versionManager)
@@ -246,18 +390,31 @@ func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, con
 		appExaminerCommandFactory.MakeStatusCommand(),
 		taskRunnerCommandFactory.MakeSubmitTaskCommand(),
 		configCommandFactory.MakeTargetCommand(),
+		configCommandFactory.MakeTargetsCommand(),
+		configCommandFactory.MakeLoginCommand(),
+		configCommandFactory.MakeLogoutCommand(),
+		configCommandFactory.MakeConfigCommand(),
 		taskExaminerCommandFactory.MakeTaskCommand(),
 		taskRunnerCommandFactory.MakeDeleteTaskCommand(),
 		taskRunnerCommandFactory.MakeCancelTaskCommand(),
 		clusterTestCommandFactory.MakeClusterTestCommand(),
+		doctorCommandFactory.MakeDoctorCommand(),
 		appRunnerCommandFactory.MakeUpdateCommand(),
 		appExaminerCommandFactory.MakeVisualizeCommand(),
 		dropletRunnerCommandFactory.MakeBuildDropletCommand(),
 		dropletRunnerCommandFactory.MakeListDropletsCommand(),
+		dropletRunnerCommandFactory.MakeDropletInfoCommand(),
+		dropletRunnerCommandFactory.MakeDropletHistoryCommand(),
+		dropletRunnerCommandFactory.MakeTagDropletCommand(),
 		dropletRunnerCommandFactory.MakeLaunchDropletCommand(),
+		dropletRunnerCommandFactory.MakePushCommand(),
 		dropletRunnerCommandFactory.MakeRemoveDropletCommand(),
 		dropletRunnerCommandFactory.MakeImportDropletCommand(),
 		dropletRunnerCommandFactory.MakeExportDropletCommand(),
+		dropletRunnerCommandFactory.MakeMigrateDropletsCommand(),
+		dropletRunnerCommandFactory.MakeGCDropletsCommand(),
+		dropletRunnerCommandFactory.MakeClearBuildCacheCommand(),
+		blobStoreCommandFactory.MakeServeBlobsCommand(),
 		sshCommandFactory.MakeSSHCommand(),
 		versionCommandFactory.MakeSyncCommand(),
 		versionCommandFactory.MakeVersionCommand(),
@@ -265,7 +422,11 @@ func cliCommands(ltcConfigRoot string, exitHandler exit_handler.ExitHandler, con
 	}
 }
 
-func LoggregatorUrl(loggregatorTarget string) string {
+func LoggregatorUrl(loggregatorTarget string, secure bool) string {
+	if secure {
+		return "wss://" + loggregatorTarget
+	}
+
 	return "ws://" + loggregatorTarget
 }
 
@@ -306,6 +467,7 @@ COMMANDS:
    {{range .}} {{.Name}}   {{.Description}}
    {{end}}{{end}}{{end}}
 GLOBAL OPTIONS:
+   --target <name>      Run the command against a saved target
    --version, -v        Print the version 
    --help, -h           Show help 
 `
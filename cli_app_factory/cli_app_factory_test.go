@@ -3,15 +3,21 @@ package cli_app_factory_test
 import (
 	"errors"
 	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_manager"
 	"github.com/cloudfoundry-incubator/ltc/cli_app_factory"
 	"github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/ltc/config/target_verifier/fake_target_verifier"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
 	"github.com/cloudfoundry-incubator/ltc/exit_handler/fake_exit_handler"
 	"github.com/cloudfoundry-incubator/ltc/receptor_client/fake_receptor_client_creator"
 	"github.com/cloudfoundry-incubator/ltc/terminal"
@@ -27,10 +33,12 @@ var _ = Describe("CliAppFactory", func() {
 		fakeTargetVerifier           *fake_target_verifier.FakeTargetVerifier
 		fakeReceptorClientCreator    *fake_receptor_client_creator.FakeCreator
 		fakeExitHandler              *fake_exit_handler.FakeExitHandler
+		fakeTokenManager             *fake_token_manager.FakeTokenManager
 		outputBuffer                 *gbytes.Buffer
 		terminalUI                   terminal.UI
 		cliApp                       *cli.App
 		cliConfig                    *config.Config
+		configPersister              persister.Persister
 		latticeVersion, diegoVersion string
 	)
 
@@ -38,10 +46,11 @@ var _ = Describe("CliAppFactory", func() {
 		fakeTargetVerifier = &fake_target_verifier.FakeTargetVerifier{}
 		fakeReceptorClientCreator = &fake_receptor_client_creator.FakeCreator{}
 		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
-		memPersister := persister.NewMemPersister()
+		fakeTokenManager = &fake_token_manager.FakeTokenManager{}
+		configPersister = persister.NewMemPersister()
 		outputBuffer = gbytes.NewBuffer()
 		terminalUI = terminal.NewUI(nil, outputBuffer, nil)
-		cliConfig = config.New(memPersister)
+		cliConfig = config.New(configPersister)
 		latticeVersion, diegoVersion = "v0.2.Test", "0.12345.0"
 
 		fakeReceptorClientCreator.CreateReceptorClientReturns(&fake_receptor.FakeClient{})
@@ -57,6 +66,7 @@ var _ = Describe("CliAppFactory", func() {
 			lager.NewLogger("test"),
 			fakeReceptorClientCreator,
 			fakeTargetVerifier,
+			fakeTokenManager,
 			terminalUI,
 		)
 	})
@@ -163,6 +173,57 @@ var _ = Describe("CliAppFactory", func() {
 				})
 			})
 
+			Context("when the config file is corrupt", func() {
+				var (
+					configDir  string
+					commandRan bool
+				)
+
+				BeforeEach(func() {
+					var err error
+					configDir, err = ioutil.TempDir("", "config_dir")
+					Expect(err).NotTo(HaveOccurred())
+
+					configPath := filepath.Join(configDir, "config.json")
+					Expect(ioutil.WriteFile(configPath, []byte(`{"target":`), 0600)).To(Succeed())
+					cliConfig = config.New(persister.NewFilePersister(configPath))
+
+					commandRan = false
+				})
+
+				JustBeforeEach(func() {
+					action := func(ctx *cli.Context) { commandRan = true }
+					cliApp.Commands = []cli.Command{
+						cli.Command{Name: "target", Action: action},
+						cli.Command{Name: "config", Action: action},
+						cli.Command{Name: "print-a-unicorn", Action: action},
+					}
+				})
+
+				AfterEach(func() {
+					Expect(os.RemoveAll(configDir)).To(Succeed())
+				})
+
+				It("lets ltc target replace it", func() {
+					Expect(cliApp.Run([]string{"ltc", "target", "my-lattice.example.com"})).To(Succeed())
+					Expect(commandRan).To(BeTrue())
+				})
+
+				It("lets ltc config import replace it", func() {
+					Expect(cliApp.Run([]string{"ltc", "config", "import", "config.json"})).To(Succeed())
+					Expect(commandRan).To(BeTrue())
+				})
+
+				It("prints the error for commands that read it", func() {
+					err := cliApp.Run([]string{"ltc", "target"})
+					Expect(err).To(BeAssignableToTypeOf(persister.CorruptFileError{}))
+					Expect(outputBuffer).To(test_helpers.Say("Error loading config: "))
+
+					Expect(cliApp.Run([]string{"ltc", "print-a-unicorn"})).NotTo(Succeed())
+					Expect(commandRan).To(BeFalse())
+				})
+			})
+
 			Context("when running the help command", func() {
 				It("does not verify the current target", func() {
 					cliConfig.SetTarget("my-lattice.example.com")
@@ -266,6 +327,60 @@ var _ = Describe("CliAppFactory", func() {
 					})
 				})
 
+				Context("when a saved target is selected with --target", func() {
+					BeforeEach(func() {
+						cliConfig.SetTarget("staging.example.com")
+						cliConfig.SaveProfile("staging")
+						cliConfig.SetTarget("my-lattice.example.com")
+						cliConfig.SaveProfile("default")
+						Expect(cliConfig.Save()).To(Succeed())
+					})
+
+					It("runs the command against the selected target without changing the default", func() {
+						fakeTargetVerifier.VerifyTargetReturns(true, true, nil)
+
+						err := cliApp.Run([]string{"ltc", "--target", "staging", "version"})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(Equal(1))
+						Expect(fakeTargetVerifier.VerifyTargetArgsForCall(0)).To(Equal("http://receptor.staging.example.com"))
+
+						lastCall := fakeReceptorClientCreator.CreateReceptorClientCallCount() - 1
+						Expect(fakeReceptorClientCreator.CreateReceptorClientArgsForCall(lastCall)).To(Equal("http://receptor.staging.example.com"))
+
+						savedConfig := config.New(configPersister)
+						Expect(savedConfig.Load()).To(Succeed())
+						Expect(savedConfig.CurrentProfile()).To(Equal("default"))
+						Expect(savedConfig.Target()).To(Equal("my-lattice.example.com"))
+					})
+
+					It("still shows help", func() {
+						err := cliApp.Run([]string{"ltc", "--target", "staging", "help"})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(outputBuffer).To(test_helpers.Say("ltc - Command line interface for Lattice."))
+						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(BeZero())
+					})
+
+					It("still shows help for a command", func() {
+						err := cliApp.Run([]string{"ltc", "--target", "staging", "help", "version"})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(outputBuffer).To(test_helpers.Say("version, vr - Returns CLI and server versions"))
+						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(BeZero())
+					})
+
+					Context("when the target has not been saved", func() {
+						It("prints an error and does not verify the target", func() {
+							err := cliApp.Run([]string{"ltc", "--target", "demo", "version"})
+							Expect(err).To(MatchError("Unknown target: demo"))
+
+							Expect(outputBuffer).To(test_helpers.SayLine("Unknown target: demo. Run ltc targets to list the saved targets."))
+							Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(BeZero())
+						})
+					})
+				})
+
 				Context("when targeted receptor is up and we are authorized", func() {
 					It("executes the command", func() {
 						fakeTargetVerifier.VerifyTargetReturns(true, true, nil)
@@ -315,6 +430,93 @@ var _ = Describe("CliAppFactory", func() {
 						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(Equal(1))
 						Expect(fakeTargetVerifier.VerifyTargetArgsForCall(0)).To(Equal("http://receptor.my-borked-lattice.example.com"))
 					})
+
+					Context("when the target uses token auth", func() {
+						It("asks the user to log in", func() {
+							fakeTargetVerifier.VerifyTargetReturns(true, false, nil)
+							cliConfig.SetTarget("my-borked-lattice.example.com")
+							cliConfig.SetAuthMode(config.TokenAuth, "https://login.example.com/oauth/token")
+							Expect(cliConfig.Save()).To(Succeed())
+
+							cliApp.Commands = []cli.Command{
+								cli.Command{
+									Name:   "print-a-unicorn",
+									Action: func(ctx *cli.Context) {},
+								},
+							}
+
+							err := cliApp.Run([]string{"ltc", "print-a-unicorn"})
+							Expect(err).To(MatchError("Could not authenticate with the receptor."))
+
+							Expect(outputBuffer).To(test_helpers.SayLine("Could not authenticate with the receptor. Please run ltc login."))
+						})
+					})
+				})
+
+				Context("when the TLS settings cannot be loaded", func() {
+					It("prints an error, exits and does not execute the command", func() {
+						cliConfig.SetTarget("my-lattice.example.com")
+						cliConfig.SetTLS(config.TLSConfig{CACertFile: "/nonexistent/ca.pem"})
+						Expect(cliConfig.Save()).To(Succeed())
+
+						commandRan := false
+						cliApp.Commands = []cli.Command{
+							cli.Command{
+								Name:   "print-a-unicorn",
+								Action: func(ctx *cli.Context) { commandRan = true },
+							},
+						}
+
+						err := cliApp.Run([]string{"ltc", "print-a-unicorn"})
+						Expect(err).To(MatchError(HavePrefix("failed to read CA bundle")))
+
+						Expect(outputBuffer).To(gbytes.Say("Error loading TLS configuration: failed to read CA bundle"))
+						Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadTarget}))
+						Expect(commandRan).To(BeFalse())
+						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(BeZero())
+					})
+
+					It("still runs doctor, which reports the TLS settings", func() {
+						cliConfig.SetTarget("my-lattice.example.com")
+						cliConfig.SetTLS(config.TLSConfig{CACertFile: "/nonexistent/ca.pem"})
+						Expect(cliConfig.Save()).To(Succeed())
+
+						commandRan := false
+						cliApp.Commands = []cli.Command{
+							cli.Command{
+								Name:   "doctor",
+								Action: func(ctx *cli.Context) { commandRan = true },
+							},
+						}
+
+						Expect(cliApp.Run([]string{"ltc", "doctor"})).To(Succeed())
+
+						Expect(commandRan).To(BeTrue())
+						Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+					})
+				})
+
+				Context("when no bearer token is available", func() {
+					It("Prints an error message and does not execute the command", func() {
+						fakeTokenManager.TokenReturns("", auth.ErrNotLoggedIn)
+						cliConfig.SetTarget("my-lattice.example.com")
+						Expect(cliConfig.Save()).To(Succeed())
+
+						commandRan := false
+						cliApp.Commands = []cli.Command{
+							cli.Command{
+								Name:   "print-a-unicorn",
+								Action: func(ctx *cli.Context) { commandRan = true },
+							},
+						}
+
+						err := cliApp.Run([]string{"ltc", "print-a-unicorn"})
+						Expect(err).To(Equal(auth.ErrNotLoggedIn))
+
+						Expect(outputBuffer).To(test_helpers.SayLine("Not logged in. Run ltc login."))
+						Expect(commandRan).To(BeFalse())
+						Expect(fakeTargetVerifier.VerifyTargetCallCount()).To(BeZero())
+					})
 				})
 
 				Context("when the receptor is down", func() {
@@ -349,8 +551,13 @@ var _ = Describe("CliAppFactory", func() {
 
 	Describe("LoggregatorUrl", func() {
 		It("returns loggregator url with the websocket scheme added", func() {
-			loggregatorUrl := cli_app_factory.LoggregatorUrl("doppler.diego.io")
+			loggregatorUrl := cli_app_factory.LoggregatorUrl("doppler.diego.io", false)
 			Expect(loggregatorUrl).To(Equal("ws://doppler.diego.io"))
 		})
+
+		It("returns loggregator url with the secure websocket scheme for https targets", func() {
+			loggregatorUrl := cli_app_factory.LoggregatorUrl("doppler.diego.io", true)
+			Expect(loggregatorUrl).To(Equal("wss://doppler.diego.io"))
+		})
 	})
 })
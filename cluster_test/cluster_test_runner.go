@@ -217,7 +217,7 @@ func defineTheGinkgoTests(runner *clusterTestRunner, timeout time.Duration) {
 				By("launching a build task")
 				runner.buildDroplet(timeout, dropletName, "https://github.com/cloudfoundry/go-buildpack.git", gitDir)
 
-				Eventually(runner.checkIfTaskCompleted("build-droplet-"+dropletName), timeout, 1).Should(BeTrue())
+				Eventually(runner.checkIfTaskCompleted("build-droplet-"+dropletName+"-v1"), timeout, 1).Should(BeTrue())
 
 				By("listing droplets")
 				runner.listDroplets(timeout, dropletName)
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	BasicAuth = "basic"
+	TokenAuth = "token"
+)
+
+// AuthConfig selects how ltc authenticates with the receptor, doppler and
+// the SSH proxy.  In token mode the cached bearer token is refreshed from
+// TokenEndpoint as it expires.
+type AuthConfig struct {
+	Mode          string    `json:"mode,omitempty"`
+	TokenEndpoint string    `json:"token_endpoint,omitempty"`
+	AccessToken   string    `json:"access_token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at,omitempty"`
+}
+
+func (a AuthConfig) TokenMode() bool {
+	return a.Mode == TokenAuth
+}
+
+func parseAuthMode(value string) (string, error) {
+	switch value {
+	case "", BasicAuth, TokenAuth:
+		return value, nil
+	}
+
+	return "", fmt.Errorf("invalid auth mode: %s", value)
+}
+
+func (c *Config) SetAuthMode(mode, tokenEndpoint string) {
+	c.clearOverrides("auth.")
+	c.updateProfile(func(profile *Profile) {
+		profile.Auth.Mode = mode
+		profile.Auth.TokenEndpoint = tokenEndpoint
+	})
+}
+
+// SetAuthToken caches a bearer token.  The change is persisted on the next
+// call to Save.
+func (c *Config) SetAuthToken(accessToken, refreshToken string, expiresAt time.Time) {
+	c.updateProfile(func(profile *Profile) {
+		profile.Auth.AccessToken = accessToken
+		profile.Auth.RefreshToken = refreshToken
+		profile.Auth.ExpiresAt = expiresAt
+	})
+}
+
+// Auth returns the effective auth settings along with the token cached for
+// the target in use.
+func (c *Config) Auth() AuthConfig {
+	authConfig := c.effective().Auth
+
+	if c.overrideProfile != "" {
+		cached := c.data.Profiles[c.overrideProfile].Auth
+		authConfig.AccessToken = cached.AccessToken
+		authConfig.RefreshToken = cached.RefreshToken
+		authConfig.ExpiresAt = cached.ExpiresAt
+	}
+
+	return authConfig
+}
@@ -2,7 +2,14 @@ package command_factory
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	"github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/config/target_verifier"
 	"github.com/cloudfoundry-incubator/ltc/exit_handler"
@@ -12,13 +19,17 @@ import (
 	"github.com/codegangsta/cli"
 )
 
+const defaultLocalBlobStorePort = "8445"
+
 type ConfigCommandFactory struct {
-	config            *config.Config
-	ui                terminal.UI
-	targetVerifier    target_verifier.TargetVerifier
-	blobStoreVerifier BlobStoreVerifier
-	exitHandler       exit_handler.ExitHandler
-	versionManager    version.VersionManager
+	config              *config.Config
+	ui                  terminal.UI
+	targetVerifier      target_verifier.TargetVerifier
+	blobStoreVerifier   BlobStoreVerifier
+	logEndpointVerifier LogEndpointVerifier
+	exitHandler         exit_handler.ExitHandler
+	versionManager      version.VersionManager
+	tokenManager        auth.TokenManager
 }
 
 //go:generate counterfeiter -o fake_blob_store_verifier/fake_blob_store_verifier.go . BlobStoreVerifier
@@ -26,8 +37,13 @@ type BlobStoreVerifier interface {
 	Verify(config *config.Config) (authorized bool, err error)
 }
 
-func NewConfigCommandFactory(config *config.Config, ui terminal.UI, targetVerifier target_verifier.TargetVerifier, blobStoreVerifier BlobStoreVerifier, exitHandler exit_handler.ExitHandler, versionManager version.VersionManager) *ConfigCommandFactory {
-	return &ConfigCommandFactory{config, ui, targetVerifier, blobStoreVerifier, exitHandler, versionManager}
+//go:generate counterfeiter -o fake_log_endpoint_verifier/fake_log_endpoint_verifier.go . LogEndpointVerifier
+type LogEndpointVerifier interface {
+	Verify(config *config.Config) (authorized bool, err error)
+}
+
+func NewConfigCommandFactory(config *config.Config, ui terminal.UI, targetVerifier target_verifier.TargetVerifier, blobStoreVerifier BlobStoreVerifier, logEndpointVerifier LogEndpointVerifier, exitHandler exit_handler.ExitHandler, versionManager version.VersionManager, tokenManager auth.TokenManager) *ConfigCommandFactory {
+	return &ConfigCommandFactory{config, ui, targetVerifier, blobStoreVerifier, logEndpointVerifier, exitHandler, versionManager, tokenManager}
 }
 
 func (factory *ConfigCommandFactory) MakeTargetCommand() cli.Command {
@@ -36,28 +52,251 @@ func (factory *ConfigCommandFactory) MakeTargetCommand() cli.Command {
 			Name:  "s3",
 			Usage: "Target an S3 bucket as the droplet store",
 		},
+		cli.StringFlag{
+			Name:  "local-blobs",
+			Usage: "Keeps droplets in the given local directory, served to cells by ltc serve-blobs",
+		},
 		cli.BoolFlag{
 			Name:  "domain, d",
 			Usage: "Print the currently targeted lattice deployment's domain name",
 		},
+		cli.StringFlag{
+			Name:  "name, n",
+			Usage: "Saves the target under the given name (defaults to the system domain)",
+		},
+		cli.StringFlag{
+			Name:  "use",
+			Usage: "Switches to a previously saved target",
+		},
+		cli.StringFlag{
+			Name:  "scheme",
+			Usage: "Scheme used to reach the receptor, doppler and droplet store (http or https)",
+			Value: "http",
+		},
+		cli.StringFlag{
+			Name:  "ca-cert",
+			Usage: "Path to a PEM bundle of CA certificates to trust for https targets",
+		},
+		cli.StringFlag{
+			Name:  "client-cert",
+			Usage: "Path to a PEM client certificate to present to https targets",
+		},
+		cli.StringFlag{
+			Name:  "client-key",
+			Usage: "Path to the PEM private key for --client-cert",
+		},
+		cli.BoolFlag{
+			Name:  "skip-verify",
+			Usage: "Skips verification of the target's TLS certificate",
+		},
+		cli.StringFlag{
+			Name:  "token-endpoint",
+			Usage: "Authenticates with bearer tokens issued by the given OAuth token endpoint",
+		},
+		cli.StringFlag{
+			Name:  "log-endpoint",
+			Usage: "Streams logs from the given doppler endpoint (ws[s]://host[:port]) instead of doppler.<system-domain>",
+		},
+		cli.BoolFlag{
+			Name:  "log-token",
+			Usage: "Prompts for a token to send to the log endpoint",
+		},
 	}
 
 	var targetCommand = cli.Command{
-		Name:        "target",
-		Aliases:     []string{"ta"},
-		Usage:       "Targets a lattice cluster",
-		Description: "ltc target <system-domain> (e.g., 192.168.11.11.xip.io)",
-		Action:      factory.target,
-		Flags:       targetFlags,
+		Name:    "target",
+		Aliases: []string{"ta"},
+		Usage:   "Targets a lattice cluster",
+		Description: `ltc target <system-domain> (e.g., 192.168.11.11.xip.io)
+
+   To save the target under a name:
+   ltc target <system-domain> --name <target-name>
+
+   To switch to a previously saved target:
+   ltc target --use <target-name>
+
+   To target a lattice behind a TLS-terminating load balancer:
+   ltc target <system-domain> --scheme https [--ca-cert <ca-bundle-path>] [--client-cert <cert-path> --client-key <key-path>]
+
+   To authenticate with bearer tokens instead of a shared password:
+   ltc target <system-domain> --token-endpoint <token-endpoint-url>
+
+   To keep droplets on this machine instead of the lattice brain:
+   ltc target <system-domain> --local-blobs <directory>
+
+   To stream logs from a doppler that is not at doppler.<system-domain>:
+   ltc target <system-domain> --log-endpoint wss://<doppler-host>[:<port>] [--log-token]`,
+		Action: factory.target,
+		Flags:  targetFlags,
 	}
 
 	return targetCommand
 }
 
+func (factory *ConfigCommandFactory) MakeTargetsCommand() cli.Command {
+	var targetsCommand = cli.Command{
+		Name:        "targets",
+		Usage:       "Lists the saved lattice targets",
+		Description: "ltc targets",
+		Action:      factory.targets,
+	}
+
+	return targetsCommand
+}
+
+func (factory *ConfigCommandFactory) MakeLoginCommand() cli.Command {
+	var loginFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "token-endpoint",
+			Usage: "OAuth token endpoint to log in with (defaults to the target's saved endpoint)",
+		},
+	}
+
+	var loginCommand = cli.Command{
+		Name:  "login",
+		Usage: "Logs in to the target with a bearer token",
+		Description: `ltc login [--token-endpoint <token-endpoint-url>]
+
+   Requests a token from the token endpoint and sends it to the receptor, doppler and the SSH proxy in place of the target's username and password. The token is refreshed automatically until ltc logout.`,
+		Action: factory.login,
+		Flags:  loginFlags,
+	}
+
+	return loginCommand
+}
+
+func (factory *ConfigCommandFactory) MakeLogoutCommand() cli.Command {
+	var logoutCommand = cli.Command{
+		Name:        "logout",
+		Usage:       "Discards the target's cached bearer token",
+		Description: "ltc logout",
+		Action:      factory.logout,
+	}
+
+	return logoutCommand
+}
+
+func (factory *ConfigCommandFactory) MakeConfigCommand() cli.Command {
+	var lockFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "key-file, k",
+			Usage: "Derives the encryption key from the contents of a file instead of a passphrase",
+		},
+	}
+
+	var noVerifyFlag = cli.BoolFlag{
+		Name:  "no-verify",
+		Usage: "Saves the settings without verifying the target, droplet store and log endpoint",
+	}
+
+	var configCommand = cli.Command{
+		Name:  "config",
+		Usage: "Manages the ltc configuration",
+		Description: `ltc config show [--sources]
+   ltc config get <key>
+   ltc config set <key> <value> [--no-verify]
+   ltc config export [--include-secrets]
+   ltc config import <file> [--no-verify]
+   ltc config lock [--key-file <path>]
+   ltc config unlock`,
+		Subcommands: []cli.Command{
+			{
+				Name:  "show",
+				Usage: "Shows the effective configuration",
+				Description: `ltc config show [--sources]
+
+   Settings are resolved from, in order of precedence:
+     --target <name>
+     LTC_* environment variables (e.g., LTC_TARGET, LTC_USERNAME, LTC_DAV_BLOB_STORE_HOST)
+     the nearest .ltc.json in the current directory or its parents
+     the user config in $LATTICE_CLI_HOME/.lattice/config.json`,
+				Action: factory.showConfig,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "sources",
+						Usage: "Shows where each setting came from",
+					},
+				},
+			},
+			{
+				Name:        "get",
+				Usage:       "Prints the effective value of a setting",
+				Description: "ltc config get <key>",
+				Action:      factory.getConfig,
+			},
+			{
+				Name:  "set",
+				Usage: "Changes a setting",
+				Description: `ltc config set <key> <value> [--no-verify]
+
+   Keys are those listed by ltc config show.`,
+				Action: factory.setConfig,
+				Flags:  []cli.Flag{noVerifyFlag},
+			},
+			{
+				Name:  "export",
+				Usage: "Prints the settings as JSON",
+				Description: `ltc config export [--include-secrets]
+
+   The output can be restored with ltc config import, or used as a .ltc.json project config.`,
+				Action: factory.exportConfig,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "include-secrets",
+						Usage: "Includes passwords, keys and tokens in the output",
+					},
+				},
+			},
+			{
+				Name:        "import",
+				Usage:       "Changes the settings given in a JSON file",
+				Description: "ltc config import <file> [--no-verify]",
+				Action:      factory.importConfig,
+				Flags:       []cli.Flag{noVerifyFlag},
+			},
+			{
+				Name:  "lock",
+				Usage: "Encrypts the stored credentials",
+				Description: `ltc config lock [--key-file <path>]
+
+   Once locked, ltc prompts for the passphrase (or reads LTC_CONFIG_PASSPHRASE) whenever it loads the config.`,
+				Action: factory.lockConfig,
+				Flags:  lockFlags,
+			},
+			{
+				Name:        "unlock",
+				Usage:       "Stores the credentials unencrypted",
+				Description: "ltc config unlock",
+				Action:      factory.unlockConfig,
+			},
+		},
+	}
+
+	return configCommand
+}
+
 func (factory *ConfigCommandFactory) target(context *cli.Context) {
 	target := context.Args().First()
 	s3Enabled := context.Bool("s3")
+	localBlobsFlag := context.String("local-blobs")
 	domainOnlyFlag := context.Bool("domain")
+	nameFlag := context.String("name")
+	useFlag := context.String("use")
+	schemeFlag := context.String("scheme")
+	tokenEndpointFlag := context.String("token-endpoint")
+	logEndpointFlag := context.String("log-endpoint")
+	logTokenFlag := context.Bool("log-token")
+	tlsConfig := config.TLSConfig{
+		CACertFile:     context.String("ca-cert"),
+		ClientCertFile: context.String("client-cert"),
+		ClientKeyFile:  context.String("client-key"),
+		SkipVerify:     context.Bool("skip-verify"),
+	}
+
+	if useFlag != "" {
+		factory.useTarget(useFlag)
+		return
+	}
 
 	if target == "" {
 		if domainOnlyFlag {
@@ -71,19 +310,68 @@ func (factory *ConfigCommandFactory) target(context *cli.Context) {
 		return
 	}
 
+	if schemeFlag != "http" && schemeFlag != "https" {
+		factory.ui.SayIncorrectUsage("--scheme must be http or https")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	dopplerConfig, err := parseLogEndpoint(logEndpointFlag)
+	if err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if _, err := tlsConfig.ClientConfig(); err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error loading TLS configuration: ", err))
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	profileName := nameFlag
+	if profileName == "" {
+		profileName = target
+	}
+
+	// with --target, the saved target is reconfigured in place
+	if overrideProfile := factory.config.OverrideProfile(); overrideProfile != "" {
+		if nameFlag != "" && nameFlag != overrideProfile {
+			factory.ui.SayIncorrectUsage("--name cannot be used to save a target applied with --target under another name")
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		profileName = overrideProfile
+	}
+
 	factory.config.SetTarget(target)
+	factory.config.SetScheme(schemeFlag)
+	factory.config.SetTLS(tlsConfig)
 	factory.config.SetLogin("", "")
+	factory.config.SetAuthMode("", "")
+	factory.config.SetAuthToken("", "", time.Time{})
+
+	if logTokenFlag {
+		dopplerConfig.AuthToken = factory.ui.PromptForPassword("Log Stream Token")
+	}
+	factory.config.SetDoppler(dopplerConfig)
 
 	if s3Enabled {
-		accessKey := factory.ui.Prompt("S3 Access Key")
-		secretKey := factory.ui.PromptForPassword("S3 Secret Key")
-		bucketName := factory.ui.Prompt("S3 Bucket")
-		region := factory.ui.Prompt("S3 Region")
-		factory.config.SetS3BlobStore(accessKey, secretKey, bucketName, region)
+		if !factory.setS3BlobStore() {
+			return
+		}
+	} else if localBlobsFlag != "" {
+		if !factory.setLocalBlobStore(localBlobsFlag) {
+			return
+		}
 	} else {
 		factory.config.SetBlobStore(target, "8444", "", "")
 	}
 
+	if tokenEndpointFlag != "" && !factory.requestToken(tokenEndpointFlag) {
+		return
+	}
+
 	_, authorized, err := factory.targetVerifier.VerifyTarget(factory.config.Receptor())
 	if err != nil {
 		factory.ui.SayLine(fmt.Sprint("Error verifying target: ", err))
@@ -91,13 +379,19 @@ func (factory *ConfigCommandFactory) target(context *cli.Context) {
 		return
 	}
 	if authorized {
-		if !factory.verifyBlobStore() {
+		if !factory.verifyBlobStore() || !factory.verifyLogEndpoint() {
 			factory.exitHandler.Exit(exit_codes.BadTarget)
 			return
 		}
 
 		factory.checkVersions()
-		factory.save()
+		factory.save(profileName)
+		return
+	}
+
+	if tokenEndpointFlag != "" {
+		factory.ui.SayLine("Could not authorize target.")
+		factory.exitHandler.Exit(exit_codes.BadTarget)
 		return
 	}
 
@@ -105,7 +399,9 @@ func (factory *ConfigCommandFactory) target(context *cli.Context) {
 	password := factory.ui.PromptForPassword("Password")
 
 	factory.config.SetLogin(username, password)
-	factory.config.SetBlobStore(target, "8444", username, password)
+	if factory.config.ActiveBlobStore() == config.DAVBlobStore {
+		factory.config.SetBlobStore(target, "8444", username, password)
+	}
 
 	_, authorized, err = factory.targetVerifier.VerifyTarget(factory.config.Receptor())
 	if err != nil {
@@ -125,8 +421,350 @@ func (factory *ConfigCommandFactory) target(context *cli.Context) {
 		return
 	}
 
+	if !factory.verifyLogEndpoint() {
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
 	factory.checkVersions()
-	factory.save()
+	factory.save(profileName)
+}
+
+func (factory *ConfigCommandFactory) setS3BlobStore() bool {
+	s3Config := config.S3BlobStoreConfig{
+		AccessKey:       factory.ui.Prompt("S3 Access Key"),
+		SecretKey:       factory.ui.PromptForPassword("S3 Secret Key"),
+		BucketName:      factory.ui.Prompt("S3 Bucket"),
+		Region:          factory.ui.Prompt("S3 Region"),
+		Endpoint:        factory.ui.Prompt("S3 Endpoint (blank for AWS)"),
+		AddressingStyle: factory.ui.Prompt("S3 Addressing Style (path or virtual-host, blank for path)"),
+		SessionToken:    factory.ui.PromptForPassword("S3 Session Token (optional)"),
+	}
+	s3Config.ServerSideEncryption = factory.ui.Prompt("S3 Server-Side Encryption (AES256, aws:kms or blank)")
+	if s3Config.ServerSideEncryption == "aws:kms" {
+		s3Config.SSEKMSKeyID = factory.ui.Prompt("S3 KMS Key ID")
+	}
+
+	if err := s3Config.Validate(); err != nil {
+		factory.ui.SayIncorrectUsage(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return false
+	}
+
+	factory.config.SetS3BlobStoreConfig(s3Config)
+	return true
+}
+
+func (factory *ConfigCommandFactory) setLocalBlobStore(directory string) bool {
+	storePath, err := filepath.Abs(directory)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return false
+	}
+
+	address := factory.ui.Prompt("Droplet Server Address (host[:port] reachable from cells)")
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, defaultLocalBlobStorePort
+	}
+	if host == "" {
+		factory.ui.SayIncorrectUsage("a droplet server address is required with --local-blobs")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return false
+	}
+
+	username := factory.ui.Prompt("Droplet Server Username")
+	password := factory.ui.PromptForPassword("Droplet Server Password")
+	if username == "" || password == "" {
+		factory.ui.SayIncorrectUsage("a droplet server username and password are required with --local-blobs")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return false
+	}
+
+	factory.config.SetLocalBlobStore(storePath, host, port, username, password)
+	return true
+}
+
+func (factory *ConfigCommandFactory) useTarget(name string) {
+	if err := factory.config.UseProfile(name); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	if err := factory.config.Save(); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Switched to target %s (%s).", name, factory.config.Target()))
+}
+
+func (factory *ConfigCommandFactory) login(context *cli.Context) {
+	tokenEndpoint := context.String("token-endpoint")
+	if tokenEndpoint == "" {
+		tokenEndpoint = factory.config.Auth().TokenEndpoint
+	}
+
+	if factory.config.Target() == "" {
+		factory.ui.SayLine("Target not set.")
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	if tokenEndpoint == "" {
+		factory.ui.SayIncorrectUsage("--token-endpoint is required for targets without a saved token endpoint")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.requestToken(tokenEndpoint) {
+		return
+	}
+
+	if err := factory.config.Save(); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Logged in to %s.", factory.config.Target()))
+}
+
+func (factory *ConfigCommandFactory) logout(context *cli.Context) {
+	factory.tokenManager.Logout()
+
+	if err := factory.config.Save(); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine("Logged out.")
+}
+
+func (factory *ConfigCommandFactory) requestToken(tokenEndpoint string) bool {
+	username := factory.ui.Prompt("Username")
+	password := factory.ui.PromptForPassword("Password")
+
+	if err := factory.tokenManager.Login(tokenEndpoint, username, password); err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error logging in: ", err))
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return false
+	}
+
+	return true
+}
+
+func (factory *ConfigCommandFactory) targets(context *cli.Context) {
+	profileNames := factory.config.Profiles()
+	if len(profileNames) == 0 {
+		factory.ui.SayLine("No saved targets.")
+		return
+	}
+
+	w := &tabwriter.Writer{}
+	w.Init(factory.ui, 12, 8, 1, '\t', 0)
+
+	fmt.Fprintln(w, "\tName\tTarget\tDroplet Store")
+	for _, name := range profileNames {
+		profile, _ := factory.config.Profile(name)
+
+		current := ""
+		if name == factory.config.CurrentProfile() {
+			current = "*"
+		}
+
+		target := profile.Target
+		if profile.Username != "" {
+			target = fmt.Sprintf("%s@%s", profile.Username, target)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, target, profile.ActiveBlobStore)
+	}
+
+	w.Flush()
+}
+
+func (factory *ConfigCommandFactory) showConfig(context *cli.Context) {
+	sourcesFlag := context.Bool("sources")
+
+	w := &tabwriter.Writer{}
+	w.Init(factory.ui, 12, 8, 1, '\t', 0)
+
+	if sourcesFlag {
+		fmt.Fprintln(w, "Key\tValue\tSource")
+	} else {
+		fmt.Fprintln(w, "Key\tValue")
+	}
+
+	for _, setting := range factory.config.Settings() {
+		value := setting.Value
+		if value != "" && config.IsSecretSetting(setting.Key) {
+			value = "********"
+		}
+
+		if !sourcesFlag {
+			fmt.Fprintf(w, "%s\t%s\n", setting.Key, value)
+			continue
+		}
+
+		source := setting.Source
+		if setting.Detail != "" {
+			source = fmt.Sprintf("%s (%s)", setting.Source, setting.Detail)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, value, source)
+	}
+
+	w.Flush()
+}
+
+func (factory *ConfigCommandFactory) getConfig(context *cli.Context) {
+	key := context.Args().First()
+	if key == "" || len(context.Args()) > 1 {
+		factory.ui.SayIncorrectUsage("<key> required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	value, err := factory.config.Get(key)
+	if err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	factory.ui.SayLine(value)
+}
+
+func (factory *ConfigCommandFactory) setConfig(context *cli.Context) {
+	if len(context.Args()) != 2 {
+		factory.ui.SayIncorrectUsage("<key> and <value> required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	key, value := context.Args()[0], context.Args()[1]
+
+	if err := factory.config.Set(key, value); err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error setting ", key, ": ", err))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !context.Bool("no-verify") && !factory.verifyConfig() {
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	if err := factory.config.Save(); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Set %s.", key))
+}
+
+func (factory *ConfigCommandFactory) exportConfig(context *cli.Context) {
+	jsonBytes, err := factory.config.Export(context.Bool("include-secrets"))
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error exporting config: ", err))
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	factory.ui.SayLine(string(jsonBytes))
+}
+
+func (factory *ConfigCommandFactory) importConfig(context *cli.Context) {
+	importPath := context.Args().First()
+	if importPath == "" {
+		factory.ui.SayIncorrectUsage("<file> required")
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	jsonBytes, err := ioutil.ReadFile(importPath)
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error reading ", importPath, ": ", err))
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	if err := factory.config.Import(jsonBytes); err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error importing ", importPath, ": ", err))
+		factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !context.Bool("no-verify") && !factory.verifyConfig() {
+		factory.exitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	if err := factory.config.Save(); err != nil {
+		factory.ui.SayLine(err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine(fmt.Sprintf("Imported settings from %s.", importPath))
+}
+
+func (factory *ConfigCommandFactory) lockConfig(context *cli.Context) {
+	keyFileFlag := context.String("key-file")
+
+	var err error
+	if keyFileFlag != "" {
+		keyFilePath, absErr := filepath.Abs(keyFileFlag)
+		if absErr != nil {
+			factory.ui.SayLine(absErr.Error())
+			factory.exitHandler.Exit(exit_codes.FileSystemError)
+			return
+		}
+
+		err = factory.config.LockWithKeyFile(keyFilePath)
+	} else {
+		passphrase := factory.ui.PromptForPassword("New passphrase")
+		if passphrase == "" {
+			factory.ui.SayIncorrectUsage("passphrase cannot be empty")
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+
+		if factory.ui.PromptForPassword("Confirm passphrase") != passphrase {
+			factory.ui.SayLine("Passphrases do not match.")
+			factory.exitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+
+		err = factory.config.LockWithPassphrase(passphrase)
+	}
+
+	if err != nil {
+		factory.ui.SayLine("Error locking config: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine("Config locked.")
+}
+
+func (factory *ConfigCommandFactory) unlockConfig(context *cli.Context) {
+	if !factory.config.Locked() {
+		factory.ui.SayLine("Config is not locked.")
+		return
+	}
+
+	if err := factory.config.Unlock(); err != nil {
+		factory.ui.SayLine("Error unlocking config: " + err.Error())
+		factory.exitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	factory.ui.SayLine("Config unlocked.")
 }
 
 func (factory *ConfigCommandFactory) verifyBlobStore() bool {
@@ -142,6 +780,54 @@ func (factory *ConfigCommandFactory) verifyBlobStore() bool {
 	return true
 }
 
+// verifyConfig runs the checks ltc target makes against the configured
+// target, droplet store and log endpoint.
+func (factory *ConfigCommandFactory) verifyConfig() bool {
+	_, authorized, err := factory.targetVerifier.VerifyTarget(factory.config.Receptor())
+	if err != nil {
+		factory.ui.SayLine(fmt.Sprint("Error verifying target: ", err))
+		return false
+	}
+	if !authorized {
+		factory.ui.SayLine("Could not authorize target.")
+		return false
+	}
+
+	return factory.verifyBlobStore() && factory.verifyLogEndpoint()
+}
+
+func (factory *ConfigCommandFactory) verifyLogEndpoint() bool {
+	authorized, err := factory.logEndpointVerifier.Verify(factory.config)
+	if err != nil {
+		factory.ui.SayLine("Could not connect to the log endpoint.")
+		return false
+	}
+	if !authorized {
+		factory.ui.SayLine("Could not authenticate with the log endpoint.")
+		return false
+	}
+	return true
+}
+
+func parseLogEndpoint(logEndpoint string) (config.DopplerConfig, error) {
+	if logEndpoint == "" {
+		return config.DopplerConfig{}, nil
+	}
+
+	endpointURL, err := url.Parse(logEndpoint)
+	if err != nil || (endpointURL.Scheme != "ws" && endpointURL.Scheme != "wss") || endpointURL.Host == "" {
+		return config.DopplerConfig{}, fmt.Errorf("--log-endpoint must be of the form ws[s]://host[:port]")
+	}
+
+	dopplerConfig := config.DopplerConfig{Host: endpointURL.Host, Scheme: endpointURL.Scheme}
+	if host, port, err := net.SplitHostPort(endpointURL.Host); err == nil {
+		dopplerConfig.Host = host
+		dopplerConfig.Port = port
+	}
+
+	return dopplerConfig, nil
+}
+
 func (f *ConfigCommandFactory) checkVersions() {
 	ltcMatchesServer, err := f.versionManager.LtcMatchesServer(f.config.Receptor())
 	if !ltcMatchesServer {
@@ -154,7 +840,9 @@ func (f *ConfigCommandFactory) checkVersions() {
 
 }
 
-func (factory *ConfigCommandFactory) save() {
+func (factory *ConfigCommandFactory) save(profileName string) {
+	factory.config.SaveProfile(profileName)
+
 	err := factory.config.Save()
 	if err != nil {
 		factory.ui.SayLine(err.Error())
@@ -179,9 +867,13 @@ func (factory *ConfigCommandFactory) printTarget() {
 
 func (factory *ConfigCommandFactory) printBlobTarget() {
 	var endpoint string
-	if factory.config.ActiveBlobStore() == config.S3BlobStore {
+	switch factory.config.ActiveBlobStore() {
+	case config.S3BlobStore:
 		endpoint = fmt.Sprintf("s3://%s (%s)", factory.config.S3BlobStore().BucketName, factory.config.S3BlobStore().Region)
-	} else {
+	case config.LocalBlobStore:
+		localBlobStore := factory.config.LocalBlobStore()
+		endpoint = fmt.Sprintf("%s (served at %s:%s)", localBlobStore.Path, localBlobStore.Host, localBlobStore.Port)
+	default:
 		blobStore := factory.config.BlobStore()
 		if blobStore.Host == "" {
 			factory.ui.SayLine("\tNo droplet store specified.")
@@ -1,14 +1,24 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
 	"github.com/cloudfoundry-incubator/ltc/config/persister"
 )
 
+// SecretFields are the JSON keys whose values are encrypted when the config
+// is locked.
+var SecretFields = []string{"password", "secret_key", "session_token", "access_token", "refresh_token", "auth_token"}
+
 type BlobStoreType int
 
 const (
 	DAVBlobStore BlobStoreType = iota
 	S3BlobStore
+	LocalBlobStore
 )
 
 func (b BlobStoreType) String() string {
@@ -17,6 +27,8 @@ func (b BlobStoreType) String() string {
 		return "dav"
 	case S3BlobStore:
 		return "s3"
+	case LocalBlobStore:
+		return "local"
 	}
 
 	return "invalid"
@@ -29,26 +41,84 @@ type BlobStoreConfig struct {
 	Password string `json:"password,omitempty"`
 }
 
+const (
+	S3PathStyle        = "path"
+	S3VirtualHostStyle = "virtual-host"
+)
+
+// S3BlobStoreConfig locates the S3 bucket used as the droplet store.  Endpoint
+// points ltc at S3-compatible stores such as MinIO or Ceph RGW, which
+// usually need path-style addressing, the default.
 type S3BlobStoreConfig struct {
 	Region     string `json:"region,omitempty"`
 	AccessKey  string `json:"access_key,omitempty"`
 	SecretKey  string `json:"secret_key,omitempty"`
 	BucketName string `json:"bucket_name,omitempty"`
+
+	Endpoint             string `json:"endpoint,omitempty"`
+	AddressingStyle      string `json:"addressing_style,omitempty"`
+	SessionToken         string `json:"session_token,omitempty"`
+	ServerSideEncryption string `json:"server_side_encryption,omitempty"`
+	SSEKMSKeyID          string `json:"sse_kms_key_id,omitempty"`
 }
 
-type Data struct {
+func (s S3BlobStoreConfig) PathStyle() bool {
+	return s.AddressingStyle != S3VirtualHostStyle
+}
+
+func (s S3BlobStoreConfig) Validate() error {
+	if _, err := parseS3AddressingStyle(s.AddressingStyle); err != nil {
+		return err
+	}
+	_, err := parseS3ServerSideEncryption(s.ServerSideEncryption)
+	return err
+}
+
+// LocalBlobStoreConfig stores blobs in Path, which ltc serve-blobs makes
+// available to cells at Host:Port.  Cells write blobs with Username and
+// Password.
+type LocalBlobStoreConfig struct {
+	Path     string `json:"path,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type Profile struct {
 	Target          string        `json:"target"`
+	Scheme          string        `json:"scheme,omitempty"`
+	TLS             TLSConfig     `json:"tls,omitempty"`
 	Username        string        `json:"username,omitempty"`
 	Password        string        `json:"password,omitempty"`
+	Auth            AuthConfig    `json:"auth,omitempty"`
+	Doppler         DopplerConfig `json:"doppler,omitempty"`
 	ActiveBlobStore BlobStoreType `json:"active_blob_store"`
 
-	BlobStore   BlobStoreConfig   `json:"dav_blob_store,omitempty"`
-	S3BlobStore S3BlobStoreConfig `json:"s3_blob_store,omitempty"`
+	BlobStore      BlobStoreConfig      `json:"dav_blob_store,omitempty"`
+	S3BlobStore    S3BlobStoreConfig    `json:"s3_blob_store,omitempty"`
+	LocalBlobStore LocalBlobStoreConfig `json:"local_blob_store,omitempty"`
+}
+
+type Data struct {
+	SchemaVersion int `json:"schema_version"`
+
+	Profile
+
+	CurrentProfile string             `json:"current_profile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles,omitempty"`
 }
 
 type Config struct {
 	persister persister.Persister
 	data      *Data
+	layers    []layer
+
+	overrideProfile string
+	changedProfiles map[string]bool
+
+	// set when the file could not be parsed, so that Save replaces it
+	corruptFile bool
 }
 
 func New(persister persister.Persister) *Config {
@@ -56,70 +126,316 @@ func New(persister persister.Persister) *Config {
 }
 
 func (c *Config) SetTarget(target string) {
-	c.data.Target = target
+	c.clearOverrides("target")
+	c.updateProfile(func(profile *Profile) {
+		profile.Target = target
+	})
 }
 
 func (c *Config) SetLogin(username, password string) {
-	c.data.Username = username
-	c.data.Password = password
+	c.clearOverrides("username", "password")
+	c.updateProfile(func(profile *Profile) {
+		profile.Username = username
+		profile.Password = password
+	})
+}
+
+func (c *Config) SetScheme(scheme string) {
+	c.clearOverrides("scheme")
+	c.updateProfile(func(profile *Profile) {
+		profile.Scheme = scheme
+	})
+}
+
+func (c *Config) SetTLS(tlsConfig TLSConfig) {
+	c.clearOverrides("tls.")
+	c.updateProfile(func(profile *Profile) {
+		profile.TLS = tlsConfig
+	})
 }
 
 func (c *Config) Target() string {
-	return c.data.Target
+	return c.effective().Target
 }
 
-func (c *Config) Username() string {
-	return c.data.Username
+func (c *Config) Scheme() string {
+	scheme := c.effective().Scheme
+	if scheme == "" {
+		return "http"
+	}
+
+	return scheme
 }
 
-func (c *Config) Password() string {
-	return c.data.Password
+func (c *Config) TLS() TLSConfig {
+	return c.effective().TLS
+}
+
+func (c *Config) Username() string {
+	return c.effective().Username
 }
 
-func (c *Config) Loggregator() string {
-	return "doppler." + c.data.Target
+func (c *Config) Password() string {
+	return c.effective().Password
 }
 
 func (c *Config) Receptor() string {
-	if c.data.Username == "" {
-		return "http://receptor." + c.data.Target
+	profile := c.effective()
+	if profile.Username == "" || profile.Auth.TokenMode() {
+		return c.Scheme() + "://receptor." + profile.Target
 	}
 
-	return "http://" + c.data.Username + ":" + c.data.Password + "@receptor." + c.data.Target
+	return c.Scheme() + "://" + profile.Username + ":" + profile.Password + "@receptor." + profile.Target
 }
 
 func (c *Config) Load() error {
-	return c.persister.Load(c.data)
+	var raw map[string]interface{}
+	if err := c.persister.Load(&raw); err != nil {
+		_, c.corruptFile = err.(persister.CorruptFileError)
+		return err
+	}
+	c.corruptFile = false
+
+	return decodeData(raw, c.data)
 }
 
 func (c *Config) Save() error {
-	return c.persister.Save(c.data)
+	switch {
+	case c.data.CurrentProfile != "":
+		c.storeProfile(c.data.CurrentProfile, c.data.Profile)
+	case c.data.Target != "":
+		c.storeProfile(c.data.Target, c.data.Profile)
+		c.data.CurrentProfile = c.data.Target
+	}
+
+	c.data.SchemaVersion = CurrentSchemaVersion
+
+	// a corrupt file has no targets to keep, so it is replaced outright
+	updater, ok := c.persister.(persister.Updater)
+	if !ok || c.corruptFile {
+		if err := c.persister.Save(c.data); err != nil {
+			return err
+		}
+		c.corruptFile = false
+		return nil
+	}
+
+	// keep targets saved by other ltc processes since this config was loaded
+	var raw map[string]interface{}
+	return updater.Update(&raw, func() error {
+		latest := &Data{}
+		if err := decodeData(raw, latest); err != nil {
+			return err
+		}
+
+		for name, profile := range latest.Profiles {
+			if c.data.Profiles == nil {
+				c.data.Profiles = map[string]Profile{}
+			}
+
+			if !c.changedProfiles[name] {
+				c.data.Profiles[name] = profile
+			}
+		}
+
+		raw = nil
+		return remarshal(c.data, &raw)
+	})
+}
+
+func remarshal(from, to interface{}) error {
+	jsonBytes, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(jsonBytes, to)
+}
+
+// LockWithPassphrase encrypts stored credentials with a key derived from
+// passphrase and saves the config.
+func (c *Config) LockWithPassphrase(passphrase string) error {
+	locker, err := c.locker()
+	if err != nil {
+		return err
+	}
+
+	if err := locker.LockWithPassphrase(passphrase); err != nil {
+		return err
+	}
+
+	return c.Save()
+}
+
+// LockWithKeyFile encrypts stored credentials with a key derived from the
+// contents of keyFilePath and saves the config.
+func (c *Config) LockWithKeyFile(keyFilePath string) error {
+	locker, err := c.locker()
+	if err != nil {
+		return err
+	}
+
+	if err := locker.LockWithKeyFile(keyFilePath); err != nil {
+		return err
+	}
+
+	return c.Save()
+}
+
+func (c *Config) Unlock() error {
+	locker, err := c.locker()
+	if err != nil {
+		return err
+	}
+
+	locker.Unlock()
+
+	return c.Save()
+}
+
+func (c *Config) Locked() bool {
+	locker, err := c.locker()
+	return err == nil && locker.Locked()
+}
+
+func (c *Config) locker() (persister.Locker, error) {
+	locker, ok := c.persister.(persister.Locker)
+	if !ok {
+		return nil, errors.New("config storage does not support encryption")
+	}
+
+	return locker, nil
+}
+
+func (c *Config) CurrentProfile() string {
+	return c.data.CurrentProfile
+}
+
+func (c *Config) Profiles() []string {
+	names := []string{}
+	for name := range c.data.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *Config) Profile(name string) (Profile, bool) {
+	profile, ok := c.data.Profiles[name]
+	return profile, ok
+}
+
+// ProfileConfig returns a Config holding only the target saved under name,
+// for commands that work with two targets at once.  It cannot be saved.
+func (c *Config) ProfileConfig(name string) (*Config, error) {
+	profile, ok := c.data.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown target: %s", name)
+	}
+
+	return &Config{data: &Data{Profile: profile}}, nil
+}
+
+// SaveProfile records the current target settings under name and makes it the
+// current profile.  When a saved target is applied with --target, its
+// settings are recorded instead and the current profile is left alone.  The
+// change is persisted on the next call to Save.
+func (c *Config) SaveProfile(name string) {
+	if c.overrideProfile != "" {
+		c.storeProfile(name, c.data.Profiles[c.overrideProfile])
+		return
+	}
+
+	c.storeProfile(name, c.data.Profile)
+	c.data.CurrentProfile = name
+}
+
+// OverrideProfile returns the name of the saved target applied with
+// --target, or "" when the current target is in effect.
+func (c *Config) OverrideProfile() string {
+	return c.overrideProfile
+}
+
+func (c *Config) storeProfile(name string, profile Profile) {
+	if c.data.Profiles == nil {
+		c.data.Profiles = map[string]Profile{}
+	}
+
+	if c.changedProfiles == nil {
+		c.changedProfiles = map[string]bool{}
+	}
+
+	c.data.Profiles[name] = profile
+	c.changedProfiles[name] = true
+}
+
+// UseProfile replaces the current target settings with those saved under
+// name.  The change is persisted on the next call to Save.
+func (c *Config) UseProfile(name string) error {
+	profile, ok := c.data.Profiles[name]
+	if !ok {
+		return fmt.Errorf("Unknown target: %s", name)
+	}
+
+	c.data.Profile = profile
+	c.data.CurrentProfile = name
+
+	return nil
 }
 
 func (c *Config) SetBlobStore(host, port, username, password string) {
-	c.data.BlobStore.Host = host
-	c.data.BlobStore.Port = port
-	c.data.BlobStore.Username = username
-	c.data.BlobStore.Password = password
-	c.data.ActiveBlobStore = DAVBlobStore
+	c.clearOverrides("dav_blob_store.", "active_blob_store")
+	c.updateProfile(func(profile *Profile) {
+		profile.BlobStore.Host = host
+		profile.BlobStore.Port = port
+		profile.BlobStore.Username = username
+		profile.BlobStore.Password = password
+		profile.ActiveBlobStore = DAVBlobStore
+	})
 }
 
 func (c *Config) SetS3BlobStore(accessKey, secretKey, bucketName, region string) {
-	c.data.S3BlobStore.AccessKey = accessKey
-	c.data.S3BlobStore.BucketName = bucketName
-	c.data.S3BlobStore.SecretKey = secretKey
-	c.data.S3BlobStore.Region = region
-	c.data.ActiveBlobStore = S3BlobStore
+	c.SetS3BlobStoreConfig(S3BlobStoreConfig{
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		BucketName: bucketName,
+		Region:     region,
+	})
+}
+
+func (c *Config) SetS3BlobStoreConfig(s3Config S3BlobStoreConfig) {
+	c.clearOverrides("s3_blob_store.", "active_blob_store")
+	c.updateProfile(func(profile *Profile) {
+		profile.S3BlobStore = s3Config
+		profile.ActiveBlobStore = S3BlobStore
+	})
+}
+
+func (c *Config) SetLocalBlobStore(path, host, port, username, password string) {
+	c.clearOverrides("local_blob_store.", "active_blob_store")
+	c.updateProfile(func(profile *Profile) {
+		profile.LocalBlobStore.Path = path
+		profile.LocalBlobStore.Host = host
+		profile.LocalBlobStore.Port = port
+		profile.LocalBlobStore.Username = username
+		profile.LocalBlobStore.Password = password
+		profile.ActiveBlobStore = LocalBlobStore
+	})
 }
 
 func (c *Config) BlobStore() BlobStoreConfig {
-	return c.data.BlobStore
+	return c.effective().BlobStore
 }
 
 func (c *Config) S3BlobStore() S3BlobStoreConfig {
-	return c.data.S3BlobStore
+	return c.effective().S3BlobStore
+}
+
+func (c *Config) LocalBlobStore() LocalBlobStoreConfig {
+	return c.effective().LocalBlobStore
 }
 
 func (c *Config) ActiveBlobStore() BlobStoreType {
-	return c.data.ActiveBlobStore
+	return c.effective().ActiveBlobStore
 }
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// DopplerConfig overrides where ltc streams logs and container metrics from,
+// which defaults to doppler.<target> over ws (or wss for https targets).
+type DopplerConfig struct {
+	Host      string `json:"host,omitempty"`
+	Port      string `json:"port,omitempty"`
+	Scheme    string `json:"scheme,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+func (c *Config) SetDoppler(dopplerConfig DopplerConfig) {
+	c.clearOverrides("doppler.")
+	c.updateProfile(func(profile *Profile) {
+		profile.Doppler = dopplerConfig
+	})
+}
+
+func (c *Config) Doppler() DopplerConfig {
+	return c.effective().Doppler
+}
+
+// Loggregator returns the host[:port] of the doppler log endpoint.
+func (c *Config) Loggregator() string {
+	doppler := c.Doppler()
+
+	host := doppler.Host
+	if host == "" {
+		host = "doppler." + c.Target()
+	}
+
+	if doppler.Port == "" {
+		return host
+	}
+
+	return net.JoinHostPort(host, doppler.Port)
+}
+
+// DopplerScheme returns ws or wss.
+func (c *Config) DopplerScheme() string {
+	if scheme := c.Doppler().Scheme; scheme != "" {
+		return scheme
+	}
+
+	if c.Scheme() == "https" {
+		return "wss"
+	}
+
+	return "ws"
+}
+
+func parseDopplerScheme(value string) (string, error) {
+	switch value {
+	case "", "ws", "wss":
+		return value, nil
+	}
+
+	return "", fmt.Errorf("invalid doppler scheme: %s", value)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Export returns the effective settings that differ from the defaults as
+// JSON in the layout of a project config file.  Secrets are left out unless
+// includeSecrets is set.
+func (c *Config) Export(includeSecrets bool) ([]byte, error) {
+	exported := map[string]interface{}{}
+	for _, setting := range c.Settings() {
+		if setting.Source == DefaultSource || setting.Value == "" {
+			continue
+		}
+		if IsSecretSetting(setting.Key) && !includeSecrets {
+			continue
+		}
+
+		nestSetting(exported, setting.Key, setting.Value)
+	}
+
+	return json.MarshalIndent(exported, "", "  ")
+}
+
+// Import sets each setting in jsonBytes, which is in the layout written by
+// Export.  Nothing is set unless every setting is valid.
+func (c *Config) Import(jsonBytes []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &raw); err != nil {
+		return err
+	}
+
+	values := map[string]string{}
+	flattenSettings("", raw, values)
+
+	keys := []string{}
+	for key, value := range values {
+		s, ok := lookupSetting(key)
+		if !ok {
+			return fmt.Errorf("Unknown setting: %s", key)
+		}
+		if err := s.set(&Profile{}, value); err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := c.Set(key, values[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func nestSetting(into map[string]interface{}, key, value string) {
+	path := strings.Split(key, ".")
+	for _, name := range path[:len(path)-1] {
+		nested, ok := into[name].(map[string]interface{})
+		if !ok {
+			nested = map[string]interface{}{}
+			into[name] = nested
+		}
+		into = nested
+	}
+
+	into[path[len(path)-1]] = value
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
+)
+
+var _ = Describe("Export", func() {
+	var testConfig *config.Config
+
+	BeforeEach(func() {
+		testConfig = config.New(persister.NewMemPersister())
+		testConfig.SetTarget("lattice.example.com")
+		testConfig.SetLogin("some-user", "some-password")
+		testConfig.SetS3BlobStore("some-access-key", "some-secret-key", "some-bucket", "some-region")
+	})
+
+	exported := func(includeSecrets bool) map[string]interface{} {
+		jsonBytes, err := testConfig.Export(includeSecrets)
+		Expect(err).NotTo(HaveOccurred())
+
+		var raw map[string]interface{}
+		Expect(json.Unmarshal(jsonBytes, &raw)).To(Succeed())
+		return raw
+	}
+
+	It("exports the settings that are not defaults without secrets", func() {
+		Expect(exported(false)).To(Equal(map[string]interface{}{
+			"target":            "lattice.example.com",
+			"username":          "some-user",
+			"active_blob_store": "s3",
+			"s3_blob_store": map[string]interface{}{
+				"region":      "some-region",
+				"access_key":  "some-access-key",
+				"bucket_name": "some-bucket",
+			},
+		}))
+	})
+
+	It("exports secrets when asked to", func() {
+		raw := exported(true)
+
+		Expect(raw).To(HaveKeyWithValue("password", "some-password"))
+		Expect(raw["s3_blob_store"]).To(HaveKeyWithValue("secret_key", "some-secret-key"))
+	})
+
+	Describe("Import", func() {
+		It("restores exported settings", func() {
+			jsonBytes, err := testConfig.Export(true)
+			Expect(err).NotTo(HaveOccurred())
+
+			importedConfig := config.New(persister.NewMemPersister())
+			Expect(importedConfig.Import(jsonBytes)).To(Succeed())
+
+			Expect(importedConfig.Target()).To(Equal("lattice.example.com"))
+			Expect(importedConfig.Password()).To(Equal("some-password"))
+			Expect(importedConfig.ActiveBlobStore()).To(Equal(config.S3BlobStore))
+			Expect(importedConfig.S3BlobStore()).To(Equal(testConfig.S3BlobStore()))
+		})
+
+		It("leaves settings that are not in the file alone", func() {
+			Expect(testConfig.Import([]byte(`{"dav_blob_store": {"host": "blobs.example.com"}}`))).To(Succeed())
+
+			Expect(testConfig.Target()).To(Equal("lattice.example.com"))
+			Expect(testConfig.BlobStore().Host).To(Equal("blobs.example.com"))
+		})
+
+		It("changes nothing when a setting is invalid", func() {
+			err := testConfig.Import([]byte(`{"target": "other.example.com", "tls": {"skip_verify": "maybe"}}`))
+			Expect(err).To(MatchError(HavePrefix("tls.skip_verify: ")))
+
+			Expect(testConfig.Target()).To(Equal("lattice.example.com"))
+		})
+
+		It("returns an error for unknown settings", func() {
+			err := testConfig.Import([]byte(`{"nonsense": "value"}`))
+			Expect(err).To(MatchError("Unknown setting: nonsense"))
+		})
+
+		It("returns an error for invalid JSON", func() {
+			Expect(testConfig.Import([]byte(`{`))).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,377 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const ProjectConfigFileName = ".ltc.json"
+
+const (
+	DefaultSource       = "default"
+	UserFileSource      = "user config"
+	ProjectFileSource   = "project config"
+	EnvironmentSource   = "environment"
+	FlagSource          = "flag"
+	environmentVarStart = "LTC_"
+)
+
+// Setting is a single effective config value and where it came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Detail string
+}
+
+type layer struct {
+	source string
+	detail func(key string) string
+	values map[string]string
+}
+
+type setting struct {
+	key string
+	get func(p *Profile) string
+	set func(p *Profile, value string) error
+}
+
+var settings = []setting{
+	{"target", func(p *Profile) string { return p.Target }, func(p *Profile, v string) error { p.Target = v; return nil }},
+	{"scheme", func(p *Profile) string { return p.Scheme }, func(p *Profile, v string) error { p.Scheme = v; return nil }},
+	{"username", func(p *Profile) string { return p.Username }, func(p *Profile, v string) error { p.Username = v; return nil }},
+	{"password", func(p *Profile) string { return p.Password }, func(p *Profile, v string) error { p.Password = v; return nil }},
+	{"auth.mode", func(p *Profile) string { return p.Auth.Mode }, func(p *Profile, v string) (err error) {
+		p.Auth.Mode, err = parseAuthMode(v)
+		return err
+	}},
+	{"auth.token_endpoint", func(p *Profile) string { return p.Auth.TokenEndpoint }, func(p *Profile, v string) error { p.Auth.TokenEndpoint = v; return nil }},
+	{"doppler.host", func(p *Profile) string { return p.Doppler.Host }, func(p *Profile, v string) error { p.Doppler.Host = v; return nil }},
+	{"doppler.port", func(p *Profile) string { return p.Doppler.Port }, func(p *Profile, v string) error { p.Doppler.Port = v; return nil }},
+	{"doppler.scheme", func(p *Profile) string { return p.Doppler.Scheme }, func(p *Profile, v string) (err error) {
+		p.Doppler.Scheme, err = parseDopplerScheme(v)
+		return err
+	}},
+	{"doppler.auth_token", func(p *Profile) string { return p.Doppler.AuthToken }, func(p *Profile, v string) error { p.Doppler.AuthToken = v; return nil }},
+	{"tls.ca_cert_file", func(p *Profile) string { return p.TLS.CACertFile }, func(p *Profile, v string) error { p.TLS.CACertFile = v; return nil }},
+	{"tls.client_cert_file", func(p *Profile) string { return p.TLS.ClientCertFile }, func(p *Profile, v string) error { p.TLS.ClientCertFile = v; return nil }},
+	{"tls.client_key_file", func(p *Profile) string { return p.TLS.ClientKeyFile }, func(p *Profile, v string) error { p.TLS.ClientKeyFile = v; return nil }},
+	{"tls.skip_verify", func(p *Profile) string { return strconv.FormatBool(p.TLS.SkipVerify) }, func(p *Profile, v string) (err error) {
+		p.TLS.SkipVerify, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"active_blob_store", func(p *Profile) string { return p.ActiveBlobStore.String() }, func(p *Profile, v string) (err error) {
+		p.ActiveBlobStore, err = parseBlobStoreType(v)
+		return err
+	}},
+	{"dav_blob_store.host", func(p *Profile) string { return p.BlobStore.Host }, func(p *Profile, v string) error { p.BlobStore.Host = v; return nil }},
+	{"dav_blob_store.port", func(p *Profile) string { return p.BlobStore.Port }, func(p *Profile, v string) error { p.BlobStore.Port = v; return nil }},
+	{"dav_blob_store.username", func(p *Profile) string { return p.BlobStore.Username }, func(p *Profile, v string) error { p.BlobStore.Username = v; return nil }},
+	{"dav_blob_store.password", func(p *Profile) string { return p.BlobStore.Password }, func(p *Profile, v string) error { p.BlobStore.Password = v; return nil }},
+	{"s3_blob_store.region", func(p *Profile) string { return p.S3BlobStore.Region }, func(p *Profile, v string) error { p.S3BlobStore.Region = v; return nil }},
+	{"s3_blob_store.access_key", func(p *Profile) string { return p.S3BlobStore.AccessKey }, func(p *Profile, v string) error { p.S3BlobStore.AccessKey = v; return nil }},
+	{"s3_blob_store.secret_key", func(p *Profile) string { return p.S3BlobStore.SecretKey }, func(p *Profile, v string) error { p.S3BlobStore.SecretKey = v; return nil }},
+	{"s3_blob_store.bucket_name", func(p *Profile) string { return p.S3BlobStore.BucketName }, func(p *Profile, v string) error { p.S3BlobStore.BucketName = v; return nil }},
+	{"s3_blob_store.endpoint", func(p *Profile) string { return p.S3BlobStore.Endpoint }, func(p *Profile, v string) error { p.S3BlobStore.Endpoint = v; return nil }},
+	{"s3_blob_store.addressing_style", func(p *Profile) string { return p.S3BlobStore.AddressingStyle }, func(p *Profile, v string) (err error) {
+		p.S3BlobStore.AddressingStyle, err = parseS3AddressingStyle(v)
+		return err
+	}},
+	{"s3_blob_store.session_token", func(p *Profile) string { return p.S3BlobStore.SessionToken }, func(p *Profile, v string) error { p.S3BlobStore.SessionToken = v; return nil }},
+	{"s3_blob_store.server_side_encryption", func(p *Profile) string { return p.S3BlobStore.ServerSideEncryption }, func(p *Profile, v string) (err error) {
+		p.S3BlobStore.ServerSideEncryption, err = parseS3ServerSideEncryption(v)
+		return err
+	}},
+	{"s3_blob_store.sse_kms_key_id", func(p *Profile) string { return p.S3BlobStore.SSEKMSKeyID }, func(p *Profile, v string) error { p.S3BlobStore.SSEKMSKeyID = v; return nil }},
+	{"local_blob_store.path", func(p *Profile) string { return p.LocalBlobStore.Path }, func(p *Profile, v string) error { p.LocalBlobStore.Path = v; return nil }},
+	{"local_blob_store.host", func(p *Profile) string { return p.LocalBlobStore.Host }, func(p *Profile, v string) error { p.LocalBlobStore.Host = v; return nil }},
+	{"local_blob_store.port", func(p *Profile) string { return p.LocalBlobStore.Port }, func(p *Profile, v string) error { p.LocalBlobStore.Port = v; return nil }},
+	{"local_blob_store.username", func(p *Profile) string { return p.LocalBlobStore.Username }, func(p *Profile, v string) error { p.LocalBlobStore.Username = v; return nil }},
+	{"local_blob_store.password", func(p *Profile) string { return p.LocalBlobStore.Password }, func(p *Profile, v string) error { p.LocalBlobStore.Password = v; return nil }},
+}
+
+func parseBlobStoreType(value string) (BlobStoreType, error) {
+	switch value {
+	case "dav", "0":
+		return DAVBlobStore, nil
+	case "s3", "1":
+		return S3BlobStore, nil
+	case "local", "2":
+		return LocalBlobStore, nil
+	}
+
+	return DAVBlobStore, fmt.Errorf("invalid blob store type: %s", value)
+}
+
+func parseS3AddressingStyle(value string) (string, error) {
+	switch value {
+	case "", S3PathStyle, S3VirtualHostStyle:
+		return value, nil
+	}
+
+	return "", fmt.Errorf("invalid addressing style: %s", value)
+}
+
+func parseS3ServerSideEncryption(value string) (string, error) {
+	switch value {
+	case "", "AES256", "aws:kms":
+		return value, nil
+	}
+
+	return "", fmt.Errorf("invalid server-side encryption: %s", value)
+}
+
+// EnvironmentVariable returns the variable that overrides key, e.g.
+// LTC_DAV_BLOB_STORE_HOST for dav_blob_store.host.
+func EnvironmentVariable(key string) string {
+	return environmentVarStart + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+// LoadEnvironment overrides settings with any LTC_* environment variables
+// that are set.
+func (c *Config) LoadEnvironment() error {
+	values := map[string]string{}
+	for _, s := range settings {
+		if value := os.Getenv(EnvironmentVariable(s.key)); value != "" {
+			values[s.key] = value
+		}
+	}
+
+	return c.addLayer(EnvironmentSource, EnvironmentVariable, values)
+}
+
+// LoadProjectFile overrides settings with those in the nearest .ltc.json
+// found in dir or one of its parents.
+func (c *Config) LoadProjectFile(dir string) error {
+	projectFilePath := findProjectFile(dir)
+	if projectFilePath == "" {
+		return nil
+	}
+
+	jsonBytes, err := ioutil.ReadFile(projectFilePath)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &raw); err != nil {
+		return fmt.Errorf("invalid project config %s: %s", projectFilePath, err)
+	}
+
+	values := map[string]string{}
+	flattenSettings("", raw, values)
+
+	detail := func(string) string { return projectFilePath }
+	if err := c.addLayer(ProjectFileSource, detail, values); err != nil {
+		return fmt.Errorf("invalid project config %s: %s", projectFilePath, err)
+	}
+
+	return nil
+}
+
+// OverrideWithProfile applies the target saved under name for the lifetime of
+// this Config without changing the saved default.
+func (c *Config) OverrideWithProfile(name string) error {
+	profile, ok := c.data.Profiles[name]
+	if !ok {
+		return fmt.Errorf("Unknown target: %s", name)
+	}
+
+	values := map[string]string{}
+	for _, s := range settings {
+		values[s.key] = s.get(&profile)
+	}
+
+	detail := func(string) string { return "--target " + name }
+	if err := c.addLayer(FlagSource, detail, values); err != nil {
+		return err
+	}
+
+	c.overrideProfile = name
+	return nil
+}
+
+// Get returns the effective value of the setting key.
+func (c *Config) Get(key string) (string, error) {
+	s, ok := lookupSetting(key)
+	if !ok {
+		return "", fmt.Errorf("Unknown setting: %s", key)
+	}
+
+	profile := c.effective()
+	return s.get(&profile), nil
+}
+
+// Set stores value as the user's setting for key, overriding any layered
+// value for the lifetime of this Config.
+func (c *Config) Set(key, value string) error {
+	s, ok := lookupSetting(key)
+	if !ok {
+		return fmt.Errorf("Unknown setting: %s", key)
+	}
+
+	if err := s.set(&Profile{}, value); err != nil {
+		return fmt.Errorf("%s: %s", key, err)
+	}
+
+	c.clearOverrides(key)
+
+	var err error
+	c.updateProfile(func(profile *Profile) {
+		err = s.set(profile, value)
+	})
+	return err
+}
+
+// IsSecretSetting reports whether key holds one of the SecretFields.
+func IsSecretSetting(key string) bool {
+	for _, secretField := range SecretFields {
+		if key == secretField || strings.HasSuffix(key, "."+secretField) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Settings returns every effective setting along with its source.
+func (c *Config) Settings() []Setting {
+	profile := c.effective()
+
+	result := []Setting{}
+	for _, s := range settings {
+		setting := Setting{Key: s.key, Source: DefaultSource}
+
+		if value := s.get(&c.data.Profile); value != s.get(&Profile{}) {
+			setting.Source = UserFileSource
+		}
+
+		for _, l := range c.layers {
+			if _, ok := l.values[s.key]; ok {
+				setting.Source = l.source
+				setting.Detail = l.detail(s.key)
+			}
+		}
+
+		setting.Value = s.get(&profile)
+		result = append(result, setting)
+	}
+
+	return result
+}
+
+func (c *Config) addLayer(source string, detail func(string) string, values map[string]string) error {
+	for _, s := range settings {
+		if value, ok := values[s.key]; ok {
+			if err := s.set(&Profile{}, value); err != nil {
+				return fmt.Errorf("%s: %s", s.key, err)
+			}
+		}
+	}
+
+	c.layers = append(c.layers, layer{source, detail, values})
+	return nil
+}
+
+// updateProfile applies update to the target in use, which is the target
+// saved under the name given to OverrideWithProfile if there is one.
+func (c *Config) updateProfile(update func(profile *Profile)) {
+	if c.overrideProfile == "" {
+		update(&c.data.Profile)
+		return
+	}
+
+	profile := c.data.Profiles[c.overrideProfile]
+	update(&profile)
+	c.data.Profiles[c.overrideProfile] = profile
+
+	if c.changedProfiles == nil {
+		c.changedProfiles = map[string]bool{}
+	}
+	c.changedProfiles[c.overrideProfile] = true
+
+	// Save copies the current target over its saved profile
+	if c.overrideProfile == c.data.CurrentProfile {
+		update(&c.data.Profile)
+	}
+
+	for _, l := range c.layers {
+		if l.source == FlagSource {
+			for _, s := range settings {
+				l.values[s.key] = s.get(&profile)
+			}
+		}
+	}
+}
+
+// clearOverrides drops any layered values for keys so that values set
+// explicitly through the Config take effect.
+func (c *Config) clearOverrides(keyPrefixes ...string) {
+	for _, l := range c.layers {
+		for key := range l.values {
+			for _, prefix := range keyPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					delete(l.values, key)
+				}
+			}
+		}
+	}
+}
+
+func (c *Config) effective() Profile {
+	profile := c.data.Profile
+	for _, l := range c.layers {
+		for _, s := range settings {
+			if value, ok := l.values[s.key]; ok {
+				s.set(&profile, value)
+			}
+		}
+	}
+
+	return profile
+}
+
+func lookupSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+
+	return setting{}, false
+}
+
+func findProjectFile(dir string) string {
+	for {
+		projectFilePath := filepath.Join(dir, ProjectConfigFileName)
+		if _, err := os.Stat(projectFilePath); err == nil {
+			return projectFilePath
+		}
+
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir {
+			return ""
+		}
+		dir = parentDir
+	}
+}
+
+func flattenSettings(prefix string, raw map[string]interface{}, values map[string]string) {
+	for key, value := range raw {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			flattenSettings(prefix+key+".", typedValue, values)
+		case string:
+			values[prefix+key] = typedValue
+		case bool:
+			values[prefix+key] = strconv.FormatBool(typedValue)
+		case float64:
+			values[prefix+key] = strconv.FormatFloat(typedValue, 'f', -1, 64)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// migrations upgrade the persisted config one schema version at a time:
+// migrations[n] upgrades a version n config to version n+1.  Migrations
+// operate on the raw JSON so that they keep working as Data changes.
+var migrations = []func(raw map[string]interface{}){
+	migrateProfiles,
+}
+
+// CurrentSchemaVersion is the version of the config written by this ltc.
+var CurrentSchemaVersion = len(migrations)
+
+// NewerSchemaError is returned when the config was written by a newer ltc.
+type NewerSchemaError struct {
+	Version int
+}
+
+func (e NewerSchemaError) Error() string {
+	return fmt.Sprintf("config schema version %d is newer than this ltc supports (%d). Please upgrade ltc.", e.Version, CurrentSchemaVersion)
+}
+
+// decodeData migrates raw to the current schema version and decodes it into
+// data.
+func decodeData(raw map[string]interface{}, data *Data) error {
+	if raw == nil {
+		return nil
+	}
+
+	version := 0
+	if rawVersion, ok := raw["schema_version"].(float64); ok {
+		version = int(rawVersion)
+	}
+
+	if version > CurrentSchemaVersion {
+		return NewerSchemaError{version}
+	}
+
+	for _, migrate := range migrations[version:] {
+		migrate(raw)
+	}
+	raw["schema_version"] = CurrentSchemaVersion
+
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(jsonBytes, data)
+}
+
+// migrateProfiles saves the single target of configs written before named
+// targets were supported as a target named after its domain.
+func migrateProfiles(raw map[string]interface{}) {
+	target, _ := raw["target"].(string)
+	if target == "" {
+		return
+	}
+
+	profile := map[string]interface{}{}
+	for _, key := range []string{"target", "username", "password", "active_blob_store", "dav_blob_store", "s3_blob_store"} {
+		if value, ok := raw[key]; ok {
+			profile[key] = value
+		}
+	}
+
+	raw["profiles"] = map[string]interface{}{target: profile}
+	raw["current_profile"] = target
+}
@@ -0,0 +1,110 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
+)
+
+var _ = Describe("Migrations", func() {
+	var (
+		memPersister persister.Persister
+		testConfig   *config.Config
+	)
+
+	saveRaw := func(json map[string]interface{}) {
+		Expect(memPersister.Save(json)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		memPersister = persister.NewMemPersister()
+		testConfig = config.New(memPersister)
+	})
+
+	It("saves the current schema version", func() {
+		testConfig.SetTarget("lattice.example.com")
+		Expect(testConfig.Save()).To(Succeed())
+
+		var raw map[string]interface{}
+		Expect(memPersister.Load(&raw)).To(Succeed())
+		Expect(raw).To(HaveKeyWithValue("schema_version", float64(config.CurrentSchemaVersion)))
+	})
+
+	Context("from version 0", func() {
+		It("saves the target as a named target", func() {
+			saveRaw(map[string]interface{}{
+				"target":            "lattice.example.com",
+				"username":          "some-user",
+				"password":          "some-password",
+				"active_blob_store": 1,
+				"s3_blob_store":     map[string]interface{}{"bucket_name": "some-bucket"},
+			})
+
+			Expect(testConfig.Load()).To(Succeed())
+
+			Expect(testConfig.Profiles()).To(Equal([]string{"lattice.example.com"}))
+			Expect(testConfig.CurrentProfile()).To(Equal("lattice.example.com"))
+
+			profile, _ := testConfig.Profile("lattice.example.com")
+			Expect(profile.Username).To(Equal("some-user"))
+			Expect(profile.Password).To(Equal("some-password"))
+			Expect(profile.ActiveBlobStore).To(Equal(config.S3BlobStore))
+			Expect(profile.S3BlobStore.BucketName).To(Equal("some-bucket"))
+		})
+
+		It("keeps the dav blob store of configs without an active blob store", func() {
+			saveRaw(map[string]interface{}{
+				"target":         "lattice.example.com",
+				"dav_blob_store": map[string]interface{}{"host": "blobs.example.com", "port": "8444"},
+			})
+
+			Expect(testConfig.Load()).To(Succeed())
+
+			profile, _ := testConfig.Profile("lattice.example.com")
+			Expect(profile.ActiveBlobStore).To(Equal(config.DAVBlobStore))
+			Expect(profile.BlobStore.Host).To(Equal("blobs.example.com"))
+		})
+
+		It("does nothing without a target", func() {
+			saveRaw(map[string]interface{}{})
+
+			Expect(testConfig.Load()).To(Succeed())
+
+			Expect(testConfig.Profiles()).To(BeEmpty())
+		})
+	})
+
+	Context("from the current version", func() {
+		It("leaves the named targets alone", func() {
+			saveRaw(map[string]interface{}{
+				"schema_version":  config.CurrentSchemaVersion,
+				"target":          "lattice.example.com",
+				"current_profile": "staging",
+				"profiles": map[string]interface{}{
+					"staging": map[string]interface{}{"target": "lattice.example.com"},
+				},
+			})
+
+			Expect(testConfig.Load()).To(Succeed())
+
+			Expect(testConfig.Profiles()).To(Equal([]string{"staging"}))
+			Expect(testConfig.CurrentProfile()).To(Equal("staging"))
+		})
+	})
+
+	Context("from a newer version", func() {
+		It("refuses to load the config", func() {
+			saveRaw(map[string]interface{}{
+				"schema_version": config.CurrentSchemaVersion + 1,
+				"target":         "lattice.example.com",
+			})
+
+			err := testConfig.Load()
+			Expect(err).To(Equal(config.NewerSchemaError{Version: config.CurrentSchemaVersion + 1}))
+			Expect(err.Error()).To(ContainSubstring("Please upgrade ltc."))
+			Expect(testConfig.Target()).To(BeEmpty())
+		})
+	})
+})
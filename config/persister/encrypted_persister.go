@@ -0,0 +1,399 @@
+package persister
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	encryptionField      = "encryption"
+	encryptedValuePrefix = "encrypted:"
+	encryptionCheckValue = "ltc"
+
+	// keyIterations makes guessing a passphrase take about a tenth of a
+	// second per guess.
+	keyIterations = 100000
+	keySize       = 32
+)
+
+// DecryptError is returned by Load when a locked config cannot be unlocked.
+type DecryptError struct {
+	Reason string
+}
+
+func (e DecryptError) Error() string {
+	return "unable to decrypt config: " + e.Reason
+}
+
+var errIncorrectKey = DecryptError{"incorrect passphrase or key file"}
+
+// Locker is implemented by persisters that can encrypt secret values at rest.
+// Lock and Unlock take effect on the next call to Save.
+type Locker interface {
+	LockWithPassphrase(passphrase string) error
+	LockWithKeyFile(keyFilePath string) error
+	Unlock()
+	Locked() bool
+}
+
+type encryption struct {
+	Salt    string `json:"salt"`
+	KeyFile string `json:"key_file,omitempty"`
+	Check   string `json:"check"`
+}
+
+type encryptedPersister struct {
+	persister      Persister
+	secretFields   map[string]bool
+	passphraseFunc func() string
+
+	encryption *encryption
+	key        []byte
+	loadErr    error
+
+	// the encryption settings last read from or written to the persister
+	storedEncryption *encryption
+	storedKey        []byte
+}
+
+// NewEncryptedPersister wraps persister so that the string values of any
+// secretFields JSON keys are stored encrypted once the persister is locked.
+// passphraseFunc is called to unlock configs encrypted with a passphrase.
+func NewEncryptedPersister(persister Persister, secretFields []string, passphraseFunc func() string) Persister {
+	secretFieldSet := map[string]bool{}
+	for _, field := range secretFields {
+		secretFieldSet[field] = true
+	}
+
+	return &encryptedPersister{
+		persister:      persister,
+		secretFields:   secretFieldSet,
+		passphraseFunc: passphraseFunc,
+	}
+}
+
+func (e *encryptedPersister) Load(data interface{}) error {
+	e.loadErr = nil
+
+	var raw map[string]interface{}
+	if err := e.persister.Load(&raw); err != nil {
+		return err
+	}
+
+	configEncryption, key, err := e.decode(raw, data)
+	if err != nil {
+		if _, ok := err.(DecryptError); ok {
+			e.loadErr = err
+		}
+		return err
+	}
+
+	if configEncryption != nil {
+		e.encryption = configEncryption
+		e.key = key
+		e.storedEncryption = configEncryption
+		e.storedKey = key
+	}
+
+	return nil
+}
+
+func (e *encryptedPersister) Save(data interface{}) error {
+	// never overwrite a config we were unable to decrypt
+	if e.loadErr != nil {
+		return e.loadErr
+	}
+
+	raw, err := e.encode(data)
+	if err != nil {
+		return err
+	}
+
+	if err := e.persister.Save(raw); err != nil {
+		return err
+	}
+
+	e.storedEncryption = e.encryption
+	e.storedKey = e.key
+	return nil
+}
+
+// Update decrypts the latest stored data into data, calls modify and saves
+// data under the wrapped persister's lock when it supports one.
+func (e *encryptedPersister) Update(data interface{}, modify func() error) error {
+	if e.loadErr != nil {
+		return e.loadErr
+	}
+
+	updater, ok := e.persister.(Updater)
+	if !ok {
+		if err := modify(); err != nil {
+			return err
+		}
+		return e.Save(data)
+	}
+
+	var raw map[string]interface{}
+	if err := updater.Update(&raw, func() error {
+		if _, _, err := e.decode(raw, data); err != nil {
+			return err
+		}
+
+		if err := modify(); err != nil {
+			return err
+		}
+
+		var err error
+		raw, err = e.encode(data)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	e.storedEncryption = e.encryption
+	e.storedKey = e.key
+	return nil
+}
+
+// decode decrypts raw into data, returning the encryption settings and key
+// when raw was saved locked.
+func (e *encryptedPersister) decode(raw map[string]interface{}, data interface{}) (*encryption, []byte, error) {
+	var configEncryption *encryption
+	var key []byte
+
+	if encryptionData, ok := raw[encryptionField]; ok {
+		delete(raw, encryptionField)
+
+		encryptionBytes, err := json.Marshal(encryptionData)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		configEncryption = &encryption{}
+		if err := json.Unmarshal(encryptionBytes, configEncryption); err != nil {
+			return nil, nil, err
+		}
+
+		if e.storedEncryption != nil && e.storedEncryption.Salt == configEncryption.Salt {
+			key = e.storedKey
+		} else if key, err = e.unlockKey(configEncryption); err != nil {
+			return nil, nil, err
+		}
+
+		if err := e.transformSecrets(raw, func(value string) (string, error) {
+			if !strings.HasPrefix(value, encryptedValuePrefix) {
+				return value, nil
+			}
+			return decrypt(key, strings.TrimPrefix(value, encryptedValuePrefix))
+		}); err != nil {
+			return nil, nil, errIncorrectKey
+		}
+	}
+
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return configEncryption, key, json.Unmarshal(jsonBytes, data)
+}
+
+// encode returns data as a JSON object with secrets encrypted when locked.
+func (e *encryptedPersister) encode(data interface{}) (map[string]interface{}, error) {
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &raw); err != nil {
+		return nil, err
+	}
+
+	if e.encryption == nil {
+		return raw, nil
+	}
+
+	if err := e.transformSecrets(raw, func(value string) (string, error) {
+		if value == "" {
+			return value, nil
+		}
+
+		encryptedValue, err := encrypt(e.key, value)
+		if err != nil {
+			return "", err
+		}
+		return encryptedValuePrefix + encryptedValue, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	raw[encryptionField] = e.encryption
+
+	return raw, nil
+}
+
+func (e *encryptedPersister) LockWithPassphrase(passphrase string) error {
+	return e.lock([]byte(passphrase), "")
+}
+
+func (e *encryptedPersister) LockWithKeyFile(keyFilePath string) error {
+	secret, err := ioutil.ReadFile(keyFilePath)
+	if err != nil {
+		return err
+	}
+
+	return e.lock(secret, keyFilePath)
+}
+
+func (e *encryptedPersister) Unlock() {
+	e.encryption = nil
+	e.key = nil
+}
+
+func (e *encryptedPersister) Locked() bool {
+	return e.encryption != nil
+}
+
+func (e *encryptedPersister) lock(secret []byte, keyFilePath string) error {
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+
+	key := deriveKey(secret, salt, keyIterations)
+
+	check, err := encrypt(key, encryptionCheckValue)
+	if err != nil {
+		return err
+	}
+
+	e.encryption = &encryption{
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		KeyFile: keyFilePath,
+		Check:   check,
+	}
+	e.key = key
+
+	return nil
+}
+
+func (e *encryptedPersister) unlockKey(configEncryption *encryption) ([]byte, error) {
+	var secret []byte
+	if configEncryption.KeyFile != "" {
+		var err error
+		secret, err = ioutil.ReadFile(configEncryption.KeyFile)
+		if err != nil {
+			return nil, DecryptError{err.Error()}
+		}
+	} else {
+		if e.passphraseFunc == nil {
+			return nil, DecryptError{"no passphrase available"}
+		}
+		secret = []byte(e.passphraseFunc())
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(configEncryption.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	key := deriveKey(secret, salt, keyIterations)
+
+	if check, err := decrypt(key, configEncryption.Check); err != nil || check != encryptionCheckValue {
+		return nil, errIncorrectKey
+	}
+
+	return key, nil
+}
+
+func (e *encryptedPersister) transformSecrets(value interface{}, transform func(string) (string, error)) error {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range typedValue {
+			if stringValue, ok := fieldValue.(string); ok && e.secretFields[field] {
+				transformedValue, err := transform(stringValue)
+				if err != nil {
+					return err
+				}
+				typedValue[field] = transformedValue
+				continue
+			}
+
+			if err := e.transformSecrets(fieldValue, transform); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, element := range typedValue {
+			if err := e.transformSecrets(element, transform); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deriveKey stretches the passphrase or key file contents into an AES-256
+// key with PBKDF2-HMAC-SHA256.
+func deriveKey(secret, salt []byte, iterations int) []byte {
+	return pbkdf2.Key(secret, salt, iterations, keySize, sha256.New)
+}
+
+func encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func decrypt(key []byte, encoded string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
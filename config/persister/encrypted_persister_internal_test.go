@@ -0,0 +1,20 @@
+package persister
+
+import (
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deriveKey", func() {
+	// PBKDF2-HMAC-SHA256 test vectors from RFC 7914, section 11, truncated
+	// to the AES-256 key size.
+	It("derives the key for a single iteration", func() {
+		Expect(hex.EncodeToString(deriveKey([]byte("passwd"), []byte("salt"), 1))).To(Equal("55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"))
+	})
+
+	It("derives the key for many iterations", func() {
+		Expect(hex.EncodeToString(deriveKey([]byte("Password"), []byte("NaCl"), 80000))).To(Equal("4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"))
+	})
+})
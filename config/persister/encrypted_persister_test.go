@@ -0,0 +1,168 @@
+package persister_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
+)
+
+var _ = Describe("EncryptedPersister", func() {
+	type credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	type data struct {
+		Target      string                 `json:"target"`
+		Credentials credentials            `json:"credentials"`
+		Profiles    map[string]credentials `json:"profiles"`
+	}
+
+	var (
+		memPersister       persister.Persister
+		encryptedPersister persister.Persister
+		passphrase         string
+		savedData          *data
+	)
+
+	newEncryptedPersister := func() persister.Persister {
+		return persister.NewEncryptedPersister(memPersister, []string{"password"}, func() string {
+			return passphrase
+		})
+	}
+
+	rawData := func() map[string]interface{} {
+		var raw map[string]interface{}
+		Expect(memPersister.Load(&raw)).To(Succeed())
+		return raw
+	}
+
+	BeforeEach(func() {
+		memPersister = persister.NewMemPersister()
+		encryptedPersister = newEncryptedPersister()
+		passphrase = "some-passphrase"
+		savedData = &data{
+			Target:      "some-target",
+			Credentials: credentials{"some-user", "some-password"},
+			Profiles: map[string]credentials{
+				"other": {"other-user", "other-password"},
+			},
+		}
+	})
+
+	Context("when unlocked", func() {
+		It("stores the data unchanged", func() {
+			Expect(encryptedPersister.Save(savedData)).To(Succeed())
+
+			Expect(rawData()["credentials"]).To(HaveKeyWithValue("password", "some-password"))
+			Expect(rawData()).ToNot(HaveKey("encryption"))
+
+			loadedData := &data{}
+			Expect(newEncryptedPersister().Load(loadedData)).To(Succeed())
+			Expect(loadedData).To(Equal(savedData))
+		})
+	})
+
+	Context("when locked with a passphrase", func() {
+		BeforeEach(func() {
+			Expect(encryptedPersister.(persister.Locker).LockWithPassphrase(passphrase)).To(Succeed())
+			Expect(encryptedPersister.Save(savedData)).To(Succeed())
+		})
+
+		It("encrypts the secret fields", func() {
+			raw := rawData()
+			Expect(raw).To(HaveKeyWithValue("target", "some-target"))
+			Expect(raw["credentials"]).To(HaveKeyWithValue("username", "some-user"))
+			Expect(raw["credentials"].(map[string]interface{})["password"]).To(HavePrefix("encrypted:"))
+			Expect(raw["profiles"].(map[string]interface{})["other"].(map[string]interface{})["password"]).To(HavePrefix("encrypted:"))
+			Expect(raw).To(HaveKey("encryption"))
+		})
+
+		It("decrypts the secret fields on load", func() {
+			reloadedPersister := newEncryptedPersister()
+			loadedData := &data{}
+			Expect(reloadedPersister.Load(loadedData)).To(Succeed())
+
+			Expect(loadedData).To(Equal(savedData))
+			Expect(reloadedPersister.(persister.Locker).Locked()).To(BeTrue())
+		})
+
+		It("stays locked when saved again", func() {
+			reloadedPersister := newEncryptedPersister()
+			Expect(reloadedPersister.Load(&data{})).To(Succeed())
+			Expect(reloadedPersister.Save(savedData)).To(Succeed())
+
+			Expect(rawData()["credentials"].(map[string]interface{})["password"]).To(HavePrefix("encrypted:"))
+		})
+
+		Context("with the wrong passphrase", func() {
+			It("returns an error and refuses to overwrite the config", func() {
+				passphrase = "wrong-passphrase"
+				reloadedPersister := newEncryptedPersister()
+
+				err := reloadedPersister.Load(&data{})
+				Expect(err).To(MatchError("unable to decrypt config: incorrect passphrase or key file"))
+				Expect(err).To(BeAssignableToTypeOf(persister.DecryptError{}))
+
+				Expect(reloadedPersister.Save(&data{})).To(HaveOccurred())
+				Expect(rawData()["credentials"].(map[string]interface{})["password"]).To(HavePrefix("encrypted:"))
+			})
+		})
+
+		It("stores the data unencrypted after unlocking", func() {
+			reloadedPersister := newEncryptedPersister()
+			Expect(reloadedPersister.Load(&data{})).To(Succeed())
+
+			reloadedPersister.(persister.Locker).Unlock()
+			Expect(reloadedPersister.Save(savedData)).To(Succeed())
+
+			Expect(rawData()["credentials"]).To(HaveKeyWithValue("password", "some-password"))
+			Expect(rawData()).ToNot(HaveKey("encryption"))
+		})
+	})
+
+	Context("when locked with a key file", func() {
+		var keyFile *os.File
+
+		BeforeEach(func() {
+			var err error
+			keyFile, err = ioutil.TempFile("", "key_file")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = keyFile.Write([]byte("some-key-material"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keyFile.Close()).To(Succeed())
+
+			Expect(encryptedPersister.(persister.Locker).LockWithKeyFile(keyFile.Name())).To(Succeed())
+			Expect(encryptedPersister.Save(savedData)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(keyFile.Name())).To(Succeed())
+		})
+
+		It("decrypts using the key file without asking for a passphrase", func() {
+			passphraseRequested := false
+			reloadedPersister := persister.NewEncryptedPersister(memPersister, []string{"password"}, func() string {
+				passphraseRequested = true
+				return ""
+			})
+
+			loadedData := &data{}
+			Expect(reloadedPersister.Load(loadedData)).To(Succeed())
+
+			Expect(loadedData).To(Equal(savedData))
+			Expect(passphraseRequested).To(BeFalse())
+		})
+
+		It("returns an error when the key file is missing", func() {
+			Expect(os.RemoveAll(keyFile.Name())).To(Succeed())
+
+			err := newEncryptedPersister().Load(&data{})
+			Expect(err).To(BeAssignableToTypeOf(persister.DecryptError{}))
+		})
+	})
+})
@@ -1,12 +1,27 @@
 package persister
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/ltc/file_helpers"
 )
 
+// CorruptFileError is returned by Load when the file exists but does not
+// contain valid JSON.  An empty file loads as empty data.
+type CorruptFileError struct {
+	Path string
+	Err  error
+}
+
+func (e CorruptFileError) Error() string {
+	return fmt.Sprintf("%s is corrupt (%s). Copy it aside to keep it (e.g., cp %s %s.bak), then run ltc target or ltc config import to replace it.", e.Path, e.Err, e.Path, e.Path)
+}
+
 type filePersister struct {
 	filePath string
 }
@@ -16,34 +31,154 @@ func NewFilePersister(filepath string) Persister {
 }
 
 func (f *filePersister) Load(i interface{}) error {
-	jsonBytes, err := ioutil.ReadFile(f.filePath)
-	if err != nil {
+	if _, err := os.Stat(filepath.Dir(f.filePath)); os.IsNotExist(err) {
 		return nil
 	}
 
-	err = json.Unmarshal(jsonBytes, i)
+	lock, err := f.readLock()
 	if err != nil {
 		return err
 	}
+	if lock != nil {
+		defer unlockFile(lock)
+	}
 
-	return nil
+	return f.load(i)
 }
 
 func (f *filePersister) Save(i interface{}) error {
-	err := os.MkdirAll(filepath.Dir(f.filePath), 0700)
+	jsonBytes, err := json.Marshal(i)
 	if err != nil {
 		return err
 	}
 
-	jsonBytes, err := json.Marshal(i)
+	if err := os.MkdirAll(filepath.Dir(f.filePath), 0700); err != nil {
+		return err
+	}
+
+	lock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	return f.write(jsonBytes)
+}
+
+// Update holds an exclusive lock while it loads the file into i, calls
+// modify and saves i, so that concurrent updates are not lost.
+func (f *filePersister) Update(i interface{}, modify func() error) error {
+	if err := os.MkdirAll(filepath.Dir(f.filePath), 0700); err != nil {
+		return err
+	}
+
+	lock, err := f.lock()
 	if err != nil {
 		return err
 	}
+	defer unlockFile(lock)
+
+	if err := f.load(i); err != nil {
+		return err
+	}
+
+	if err := modify(); err != nil {
+		return err
+	}
 
-	err = ioutil.WriteFile(f.filePath, jsonBytes, 0700)
+	jsonBytes, err := json.Marshal(i)
 	if err != nil {
 		return err
 	}
 
+	return f.write(jsonBytes)
+}
+
+func (f *filePersister) load(i interface{}) error {
+	jsonBytes, err := ioutil.ReadFile(f.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(jsonBytes)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(jsonBytes, i); err != nil {
+		return CorruptFileError{f.filePath, err}
+	}
+
 	return nil
 }
+
+// write replaces the file by renaming a fully written temp file over it, so
+// readers never see a partially written config.
+func (f *filePersister) write(jsonBytes []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(f.filePath), filepath.Base(f.filePath)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(jsonBytes); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpFile.Name(), 0600); err != nil {
+		return err
+	}
+
+	return file_helpers.ReplaceFile(tmpFile.Name(), f.filePath)
+}
+
+// lock takes an exclusive lock for writing, creating the lock file next to
+// the file when needed.
+func (f *filePersister) lock() (*os.File, error) {
+	lockFile, err := os.OpenFile(f.filePath+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.lockHandle(lockFile, true)
+}
+
+// readLock takes a shared lock for reading when the lock file exists, and
+// returns a nil file otherwise.  It never creates the lock file, so configs
+// in read-only directories can still be read.  Writers replace the file with
+// a rename, so reading without the lock never sees a partial file.
+func (f *filePersister) readLock() (*os.File, error) {
+	lockFile, err := os.Open(f.filePath + ".lock")
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return f.lockHandle(lockFile, false)
+}
+
+func (f *filePersister) lockHandle(lockFile *os.File, exclusive bool) (*os.File, error) {
+	if err := lockFileHandle(lockFile, exclusive); err != nil {
+		lockFile.Close()
+		return nil, fmt.Errorf("failed to lock %s: %s", f.filePath, err)
+	}
+
+	return lockFile, nil
+}
+
+func unlockFile(lockFile *os.File) {
+	unlockFileHandle(lockFile)
+	lockFile.Close()
+}
@@ -1,11 +1,13 @@
 package persister_test
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -34,6 +36,7 @@ var _ = Describe("filePersister", func() {
 	AfterEach(func() {
 		tmpFile.Close()
 		Expect(os.RemoveAll(tmpFile.Name())).To(Succeed())
+		Expect(os.RemoveAll(tmpFile.Name() + ".lock")).To(Succeed())
 	})
 
 	Describe("Load", func() {
@@ -53,9 +56,17 @@ var _ = Describe("filePersister", func() {
 		})
 
 		It("Loads empty data from an empty file", func() {
+			Expect(err).NotTo(HaveOccurred())
 			Expect(dataToRead.Value).To(BeEmpty())
 		})
 
+		It("does not create a lock file", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			_, statErr := os.Stat(tmpFile.Name() + ".lock")
+			Expect(os.IsNotExist(statErr)).To(BeTrue())
+		})
+
 		Context("when the file already exists", func() {
 			BeforeEach(func() {
 				err := ioutil.WriteFile(tmpFile.Name(), []byte(`{"Value":"test value"}`), 0700)
@@ -73,19 +84,44 @@ var _ = Describe("filePersister", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			It("returns errors from invalid JSON", func() {
-				Expect(reflect.TypeOf(err).String()).To(Equal("*json.SyntaxError"))
+			It("returns a corrupt file error with recovery instructions", func() {
+				Expect(err).To(BeAssignableToTypeOf(persister.CorruptFileError{}))
+				Expect(reflect.TypeOf(err.(persister.CorruptFileError).Err).String()).To(Equal("*json.SyntaxError"))
+				Expect(err.Error()).To(ContainSubstring(tmpFile.Name() + " is corrupt"))
+				Expect(err.Error()).To(ContainSubstring("then run ltc target or ltc config import to replace it"))
+			})
+		})
+
+		Context("when the file only contains whitespace", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(tmpFile.Name(), []byte(" \n"), 0600)).To(Succeed())
+			})
+
+			It("loads empty data", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dataToRead.Value).To(BeEmpty())
 			})
 		})
 
 		Context("when reading the file returns an error", func() {
+			var configDir string
+
 			BeforeEach(func() {
-				err := ioutil.WriteFile(tmpFile.Name(), []byte(""), 0000)
-				Expect(err).ToNot(HaveOccurred())
+				configDir, err = ioutil.TempDir("", "config_dir")
+				Expect(err).NotTo(HaveOccurred())
+
+				filePath := filepath.Join(configDir, "config.json")
+				Expect(os.Mkdir(filePath, 0700)).To(Succeed())
+
+				filePersister = persister.NewFilePersister(filePath)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(configDir)).To(Succeed())
 			})
 
 			It("returns errors from reading the file", func() {
-				Expect(reflect.TypeOf(err).String()).To(Equal("*json.SyntaxError"))
+				Expect(reflect.TypeOf(err).String()).To(Equal("*os.PathError"))
 			})
 		})
 
@@ -170,15 +206,101 @@ var _ = Describe("filePersister", func() {
 			})
 		})
 
+		It("replaces the file without leaving temp files behind", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			fileInfo, err := os.Stat(tmpFile.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileInfo.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			tmpFiles, err := filepath.Glob(tmpFile.Name() + ".tmp*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tmpFiles).To(BeEmpty())
+		})
+
 		Context("when writing the file returns errors", func() {
+			var configDir string
+
 			BeforeEach(func() {
-				filePersister = persister.NewFilePersister(tmpDir)
+				configDir, err = ioutil.TempDir("", "config_dir")
+				Expect(err).NotTo(HaveOccurred())
+
+				filePath := filepath.Join(configDir, "config.json")
+				Expect(os.MkdirAll(filepath.Join(filePath, "not_a_file"), 0700)).To(Succeed())
+
+				filePersister = persister.NewFilePersister(filePath)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(configDir)).To(Succeed())
 			})
 
 			It("returns errors from writing the file", func() {
-				Expect(reflect.TypeOf(err).String()).To(Equal("*os.PathError"))
+				Expect(err).To(HaveOccurred())
+				_, statErr := os.Stat(filepath.Join(configDir, "config.json", "not_a_file"))
+				Expect(statErr).NotTo(HaveOccurred())
 			})
 		})
 
 	})
+
+	Describe("Update", func() {
+		var filePersister persister.Persister
+
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(tmpFile.Name(), []byte(`{"Value":"0"}`), 0600)).To(Succeed())
+			filePersister = persister.NewFilePersister(tmpFile.Name())
+		})
+
+		It("loads the latest data, modifies it and saves it", func() {
+			updatedData := &data{}
+			Expect(filePersister.(persister.Updater).Update(updatedData, func() error {
+				Expect(updatedData.Value).To(Equal("0"))
+				updatedData.Value = "1"
+				return nil
+			})).To(Succeed())
+
+			jsonBytes, err := ioutil.ReadFile(tmpFile.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jsonBytes).To(MatchJSON(`{"Value":"1"}`))
+		})
+
+		It("does not save when modify returns an error", func() {
+			updatedData := &data{}
+			err := filePersister.(persister.Updater).Update(updatedData, func() error {
+				updatedData.Value = "1"
+				return errors.New("boom")
+			})
+			Expect(err).To(MatchError("boom"))
+
+			jsonBytes, err := ioutil.ReadFile(tmpFile.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jsonBytes).To(MatchJSON(`{"Value":"0"}`))
+		})
+
+		It("serializes concurrent updates", func() {
+			done := make(chan error)
+			for i := 0; i < 10; i++ {
+				go func() {
+					updatedData := &data{}
+					done <- persister.NewFilePersister(tmpFile.Name()).(persister.Updater).Update(updatedData, func() error {
+						count, err := strconv.Atoi(updatedData.Value)
+						if err != nil {
+							return err
+						}
+						updatedData.Value = strconv.Itoa(count + 1)
+						return nil
+					})
+				}()
+			}
+
+			for i := 0; i < 10; i++ {
+				Expect(<-done).To(Succeed())
+			}
+
+			loadedData := &data{}
+			Expect(filePersister.Load(loadedData)).To(Succeed())
+			Expect(loadedData.Value).To(Equal("10"))
+		})
+	})
 })
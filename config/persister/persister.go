@@ -4,3 +4,9 @@ type Persister interface {
 	Load(interface{}) error
 	Save(interface{}) error
 }
+
+// Updater is implemented by persisters that can hold a lock across a
+// load-modify-save cycle.
+type Updater interface {
+	Update(data interface{}, modify func() error) error
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+type TLSConfig struct {
+	CACertFile     string `json:"ca_cert_file,omitempty"`
+	ClientCertFile string `json:"client_cert_file,omitempty"`
+	ClientKeyFile  string `json:"client_key_file,omitempty"`
+	SkipVerify     bool   `json:"skip_verify,omitempty"`
+}
+
+func (t TLSConfig) ClientConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: t.SkipVerify}
+
+	if t.CACertFile != "" {
+		caCertBytes, err := ioutil.ReadFile(t.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %s", err)
+		}
+
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCertBytes) {
+			return nil, errors.New("failed to read CA bundle: no PEM certificates found in " + t.CACertFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if t.ClientCertFile != "" || t.ClientKeyFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(t.ClientCertFile, t.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (t TLSConfig) HTTPClient() (*http.Client, error) {
+	tlsConfig, err := t.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
@@ -0,0 +1,113 @@
+package config_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/ltc/config"
+)
+
+var _ = Describe("TLSConfig", func() {
+	var (
+		fakeServer *ghttp.Server
+		tmpDir     string
+		caCertFile string
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewTLSServer()
+		fakeServer.RouteToHandler("GET", "/", ghttp.RespondWith(200, "ok"))
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "tls_config")
+		Expect(err).NotTo(HaveOccurred())
+
+		caCertFile = filepath.Join(tmpDir, "ca.pem")
+		serverCert := fakeServer.HTTPTestServer.TLS.Certificates[0].Certificate[0]
+		Expect(ioutil.WriteFile(caCertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert}), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		fakeServer.Close()
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("ClientConfig", func() {
+		It("trusts the certificates in the CA bundle", func() {
+			tlsConfig, err := config.TLSConfig{CACertFile: caCertFile}.ClientConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			_, err = client.Get(fakeServer.URL())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("does not trust unknown certificates", func() {
+			tlsConfig, err := config.TLSConfig{}.ClientConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			_, err = client.Get(fakeServer.URL())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("skips verification when asked to", func() {
+			tlsConfig, err := config.TLSConfig{SkipVerify: true}.ClientConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tlsConfig.InsecureSkipVerify).To(BeTrue())
+		})
+
+		It("loads the client certificate", func() {
+			clientKeyFile := filepath.Join(tmpDir, "key.pem")
+			keyBytes, err := x509.MarshalPKCS8PrivateKey(fakeServer.HTTPTestServer.TLS.Certificates[0].PrivateKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(clientKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600)).To(Succeed())
+
+			tlsConfig, err := config.TLSConfig{ClientCertFile: caCertFile, ClientKeyFile: clientKeyFile}.ClientConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tlsConfig.Certificates).To(HaveLen(1))
+		})
+
+		Context("when the CA bundle does not exist", func() {
+			It("returns an error", func() {
+				_, err := config.TLSConfig{CACertFile: filepath.Join(tmpDir, "missing.pem")}.ClientConfig()
+				Expect(err).To(MatchError(HavePrefix("failed to read CA bundle")))
+			})
+		})
+
+		Context("when the CA bundle has no certificates", func() {
+			It("returns an error", func() {
+				Expect(ioutil.WriteFile(caCertFile, []byte("garbage"), 0600)).To(Succeed())
+
+				_, err := config.TLSConfig{CACertFile: caCertFile}.ClientConfig()
+				Expect(err).To(MatchError("failed to read CA bundle: no PEM certificates found in " + caCertFile))
+			})
+		})
+
+		Context("when the client key is missing", func() {
+			It("returns an error", func() {
+				_, err := config.TLSConfig{ClientCertFile: caCertFile}.ClientConfig()
+				Expect(err).To(MatchError(HavePrefix("failed to load client certificate")))
+			})
+		})
+	})
+
+	Describe("HTTPClient", func() {
+		It("returns a client that trusts the CA bundle", func() {
+			client, err := config.TLSConfig{CACertFile: caCertFile}.HTTPClient()
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := client.Get(fakeServer.URL())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(200))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package command_factory
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/ltc/doctor"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/ltc/terminal"
+	"github.com/cloudfoundry-incubator/ltc/terminal/colors"
+	"github.com/codegangsta/cli"
+)
+
+type DoctorCommandFactory struct {
+	doctor      doctor.Doctor
+	ui          terminal.UI
+	exitHandler exit_handler.ExitHandler
+}
+
+type doctorReport struct {
+	Passed bool           `json:"passed"`
+	Checks []doctor.Check `json:"checks"`
+}
+
+func NewDoctorCommandFactory(doctor doctor.Doctor, ui terminal.UI, exitHandler exit_handler.ExitHandler) *DoctorCommandFactory {
+	return &DoctorCommandFactory{doctor, ui, exitHandler}
+}
+
+func (factory *DoctorCommandFactory) MakeDoctorCommand() cli.Command {
+	var doctorFlags = []cli.Flag{
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Prints the report as JSON",
+		},
+	}
+
+	var doctorCommand = cli.Command{
+		Name:  "doctor",
+		Usage: "Diagnoses connectivity to the targeted lattice",
+		Description: `ltc doctor [--json]
+
+   Checks DNS, the SSH proxy, receptor credentials, the doppler websocket,
+   droplet store read/write access, registered cells and ltc version skew.`,
+		Action: factory.runDoctor,
+		Flags:  doctorFlags,
+	}
+
+	return doctorCommand
+}
+
+func (factory *DoctorCommandFactory) runDoctor(context *cli.Context) {
+	jsonFlag := context.Bool("json")
+
+	report := doctorReport{Passed: true, Checks: factory.doctor.Diagnose()}
+	for _, check := range report.Checks {
+		if !check.Passed {
+			report.Passed = false
+		}
+	}
+
+	if jsonFlag {
+		jsonBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			factory.ui.SayLine(err.Error())
+			factory.exitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+		factory.ui.SayLine(string(jsonBytes))
+	} else {
+		factory.printReport(report)
+	}
+
+	if !report.Passed {
+		factory.exitHandler.Exit(exit_codes.CommandFailed)
+	}
+}
+
+func (factory *DoctorCommandFactory) printReport(report doctorReport) {
+	failures := 0
+	for _, check := range report.Checks {
+		if check.Passed {
+			factory.ui.SayLine(fmt.Sprintf("[%s] %s: %s", colors.Green("PASS"), check.Name, check.Message))
+			continue
+		}
+
+		failures++
+		factory.ui.SayLine(fmt.Sprintf("[%s] %s: %s", colors.Red("FAIL"), check.Name, check.Message))
+		if check.Fix != "" {
+			factory.ui.SayLine("       " + check.Fix)
+		}
+	}
+
+	factory.ui.SayNewLine()
+	if failures == 0 {
+		factory.ui.SayLine(colors.Green("All checks passed."))
+	} else {
+		factory.ui.SayLine(colors.Red(fmt.Sprintf("%d of %d checks failed.", failures, len(report.Checks))))
+	}
+}
@@ -0,0 +1,89 @@
+package command_factory_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/ltc/doctor"
+	"github.com/cloudfoundry-incubator/ltc/doctor/command_factory"
+	"github.com/cloudfoundry-incubator/ltc/doctor/fake_doctor"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/ltc/exit_handler/fake_exit_handler"
+	"github.com/cloudfoundry-incubator/ltc/terminal"
+	"github.com/cloudfoundry-incubator/ltc/terminal/colors"
+	"github.com/cloudfoundry-incubator/ltc/test_helpers"
+	"github.com/codegangsta/cli"
+)
+
+var _ = Describe("DoctorCommandFactory", func() {
+	var (
+		fakeDoctor      *fake_doctor.FakeDoctor
+		fakeExitHandler *fake_exit_handler.FakeExitHandler
+		outputBuffer    *gbytes.Buffer
+		doctorCommand   cli.Command
+	)
+
+	BeforeEach(func() {
+		fakeDoctor = &fake_doctor.FakeDoctor{}
+		fakeExitHandler = &fake_exit_handler.FakeExitHandler{}
+		outputBuffer = gbytes.NewBuffer()
+
+		commandFactory := command_factory.NewDoctorCommandFactory(fakeDoctor, terminal.NewUI(nil, outputBuffer, nil), fakeExitHandler)
+		doctorCommand = commandFactory.MakeDoctorCommand()
+	})
+
+	Context("when every check passes", func() {
+		BeforeEach(func() {
+			fakeDoctor.DiagnoseReturns([]doctor.Check{
+				{Name: "Receptor", Passed: true, Message: "Connected and authorized"},
+				{Name: "Cells", Passed: true, Message: "3 cell(s) registered"},
+			})
+		})
+
+		It("prints a passing report", func() {
+			test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(fmt.Sprintf("[%s] Receptor: Connected and authorized", colors.Green("PASS"))))
+			Expect(outputBuffer).To(test_helpers.SayLine(fmt.Sprintf("[%s] Cells: 3 cell(s) registered", colors.Green("PASS"))))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Green("All checks passed.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
+		})
+	})
+
+	Context("when a check fails", func() {
+		BeforeEach(func() {
+			fakeDoctor.DiagnoseReturns([]doctor.Check{
+				{Name: "Receptor", Passed: true, Message: "Connected and authorized"},
+				{Name: "Cells", Message: "No cells are registered with the receptor.", Fix: "Make sure the lattice cells are running."},
+			})
+		})
+
+		It("prints the failure with its suggested fix and exits", func() {
+			test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayLine(fmt.Sprintf("[%s] Cells: No cells are registered with the receptor.", colors.Red("FAIL"))))
+			Expect(outputBuffer).To(test_helpers.SayLine("       Make sure the lattice cells are running."))
+			Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("1 of 2 checks failed.")))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints the report as JSON with --json", func() {
+			test_helpers.ExecuteCommandWithArgs(doctorCommand, []string{"--json"})
+
+			var report struct {
+				Passed bool           `json:"passed"`
+				Checks []doctor.Check `json:"checks"`
+			}
+			Expect(json.Unmarshal(outputBuffer.Contents(), &report)).To(Succeed())
+
+			Expect(report.Passed).To(BeFalse())
+			Expect(report.Checks).To(HaveLen(2))
+			Expect(report.Checks[1].Fix).To(Equal("Make sure the lattice cells are running."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+	})
+})
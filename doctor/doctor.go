@@ -0,0 +1,285 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/target_verifier"
+	"github.com/cloudfoundry-incubator/ltc/version"
+	"github.com/cloudfoundry/sonde-go/events"
+)
+
+const (
+	sshProxyPort      = "2222"
+	roundTripBlobPath = "ltc-doctor-round-trip"
+	roundTripContents = "ltc doctor round-trip test"
+)
+
+type Check struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+//go:generate counterfeiter -o fake_doctor/fake_doctor.go . Doctor
+type Doctor interface {
+	Diagnose() []Check
+}
+
+type BlobStore interface {
+	Upload(path string, contents io.ReadSeeker, progress blob.Progress) error
+	Download(path string) (io.ReadCloser, error)
+	Delete(path string) error
+}
+
+//go:generate counterfeiter -o fake_log_consumer/fake_log_consumer.go . LogConsumer
+type LogConsumer interface {
+	SetOnConnectCallback(cb func())
+	TailingLogs(appGuid string, authToken string, outputChan chan<- *events.LogMessage, errorChan chan<- error)
+	Close() error
+}
+
+type DoctorConfig struct {
+	Config         *config_package.Config
+	TargetVerifier target_verifier.TargetVerifier
+	BlobStore      BlobStore
+	AppExaminer    app_examiner.AppExaminer
+	VersionManager version.VersionManager
+	LogConsumer    LogConsumer
+	LogTokenSource auth.TokenSource
+
+	LookupHost  func(host string) ([]string, error)
+	DialTimeout func(network, address string, timeout time.Duration) (net.Conn, error)
+	Timeout     time.Duration
+}
+
+type doctor struct {
+	DoctorConfig
+}
+
+func New(config DoctorConfig) Doctor {
+	return &doctor{config}
+}
+
+func (d *doctor) Diagnose() []Check {
+	target := d.Config.Target()
+	if target == "" {
+		return []Check{{
+			Name:    "Target",
+			Message: "No lattice target is set.",
+			Fix:     "Run ltc target <system-domain>.",
+		}}
+	}
+
+	checks := []Check{}
+	if _, err := d.Config.TLS().ClientConfig(); err != nil {
+		checks = append(checks, Check{
+			Name:    "TLS settings",
+			Message: fmt.Sprintf("Could not load the TLS settings: %s", err),
+			Fix:     "Run ltc target with readable --ca-cert, --client-cert and --client-key files.",
+		})
+	}
+
+	return append(checks,
+		d.checkDNS("receptor."+target),
+		d.checkDNS(d.dopplerHost()),
+		d.checkSSHProxy(target),
+		d.checkReceptor(),
+		d.checkDoppler(),
+		d.checkBlobStore(),
+		d.checkCells(),
+		d.checkVersion(),
+	)
+}
+
+func (d *doctor) checkDNS(host string) Check {
+	check := Check{Name: "DNS " + host}
+
+	addresses, err := d.LookupHost(host)
+	if err != nil {
+		check.Message = fmt.Sprintf("Could not resolve %s: %s", host, err)
+		check.Fix = "Make sure the system domain resolves to your lattice brain (e.g., use <brain-ip>.xip.io) and that your DNS server is reachable."
+		return check
+	}
+
+	check.Passed = true
+	check.Message = "Resolves to " + strings.Join(addresses, ", ")
+	return check
+}
+
+// dopplerHost is the host ltc streams logs from, which is doppler.<target>
+// unless a log endpoint is configured.
+func (d *doctor) dopplerHost() string {
+	host, _, err := net.SplitHostPort(d.Config.Loggregator())
+	if err != nil {
+		return d.Config.Loggregator()
+	}
+	return host
+}
+
+func (d *doctor) checkSSHProxy(target string) Check {
+	address := net.JoinHostPort(target, sshProxyPort)
+	check := Check{Name: "SSH proxy " + address}
+
+	conn, err := d.DialTimeout("tcp", address, d.Timeout)
+	if err != nil {
+		check.Message = fmt.Sprintf("Could not connect: %s", err)
+		check.Fix = "Make sure port " + sshProxyPort + " on the lattice brain is reachable from this machine."
+		return check
+	}
+	conn.Close()
+
+	check.Passed = true
+	check.Message = "Accepting connections"
+	return check
+}
+
+func (d *doctor) checkReceptor() Check {
+	check := Check{Name: "Receptor"}
+
+	up, authorized, err := d.TargetVerifier.VerifyTarget(d.Config.Receptor())
+	switch {
+	case !up:
+		check.Message = fmt.Sprintf("Could not connect to the receptor: %s", err)
+		check.Fix = "Make sure lattice is up and running and that ltc target points at its system domain."
+	case !authorized:
+		check.Message = "The receptor rejected the configured credentials."
+		check.Fix = "Run ltc target with the correct username and password."
+	default:
+		check.Passed = true
+		check.Message = "Connected and authorized"
+	}
+
+	return check
+}
+
+func (d *doctor) checkDoppler() Check {
+	check := Check{Name: "Doppler websocket"}
+
+	token, err := d.LogTokenSource.Token()
+	if err != nil {
+		check.Message = fmt.Sprintf("Could not get a log-stream token: %s", err)
+		check.Fix = "Run ltc login, or run ltc target with --log-token."
+		return check
+	}
+
+	connected := make(chan struct{}, 1)
+	d.LogConsumer.SetOnConnectCallback(func() {
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+	})
+
+	outputChan := make(chan *events.LogMessage, 1)
+	errorChan := make(chan error, 1)
+	go d.LogConsumer.TailingLogs("ltc-doctor", auth.AuthorizationHeader(token), outputChan, errorChan)
+	defer d.LogConsumer.Close()
+
+	// only a completed handshake or a log message shows that doppler streams
+	select {
+	case <-connected:
+		check.Passed = true
+		check.Message = "Streaming from " + d.Config.Loggregator()
+	case <-outputChan:
+		check.Passed = true
+		check.Message = "Streaming from " + d.Config.Loggregator()
+	case err, ok := <-errorChan:
+		if !ok {
+			err = errors.New("connection closed")
+		}
+		check.Message = fmt.Sprintf("Could not stream logs from %s: %s", d.Config.Loggregator(), err)
+		check.Fix = "Make sure doppler is running on the lattice brain and that websockets are not blocked by a proxy."
+	case <-time.After(d.Timeout):
+		check.Message = fmt.Sprintf("No websocket connection to %s within %s", d.Config.Loggregator(), d.Timeout)
+		check.Fix = "Make sure doppler is running on the lattice brain and that websockets are not blocked by a proxy."
+	}
+
+	return check
+}
+
+func (d *doctor) checkBlobStore() Check {
+	check := Check{Name: "Droplet store (" + d.Config.ActiveBlobStore().String() + ")"}
+	check.Fix = "Run ltc target to check the droplet store settings, and make sure the credentials allow reading and writing."
+
+	if err := d.BlobStore.Upload(roundTripBlobPath, strings.NewReader(roundTripContents), nil); err != nil {
+		check.Message = fmt.Sprintf("Could not write a test blob: %s", err)
+		return check
+	}
+	defer d.BlobStore.Delete(roundTripBlobPath)
+
+	reader, err := d.BlobStore.Download(roundTripBlobPath)
+	if err != nil {
+		check.Message = fmt.Sprintf("Could not read back the test blob: %s", err)
+		return check
+	}
+	defer reader.Close()
+
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		check.Message = fmt.Sprintf("Could not read back the test blob: %s", err)
+		return check
+	}
+
+	if !bytes.Equal(contents, []byte(roundTripContents)) {
+		check.Message = "The test blob read back did not match what was written."
+		return check
+	}
+
+	check.Passed = true
+	check.Message = "Test blob written, read back and deleted"
+	check.Fix = ""
+	return check
+}
+
+func (d *doctor) checkCells() Check {
+	check := Check{Name: "Cells"}
+
+	cells, err := d.AppExaminer.ListCells()
+	if err != nil {
+		check.Message = fmt.Sprintf("Could not list cells: %s", err)
+		check.Fix = "Make sure the receptor is reachable (see above)."
+		return check
+	}
+
+	if len(cells) == 0 {
+		check.Message = "No cells are registered with the receptor."
+		check.Fix = "Make sure the lattice cells are running and can reach the brain."
+		return check
+	}
+
+	check.Passed = true
+	check.Message = fmt.Sprintf("%d cell(s) registered", len(cells))
+	return check
+}
+
+func (d *doctor) checkVersion() Check {
+	check := Check{Name: "Version"}
+
+	matches, err := d.VersionManager.LtcMatchesServer(d.Config.Receptor())
+	switch {
+	case err != nil:
+		check.Message = fmt.Sprintf("Could not fetch the lattice version: %s", err)
+		check.Fix = "Make sure the receptor is reachable (see above)."
+	case !matches:
+		serverVersions, _ := d.VersionManager.ServerVersions(d.Config.Receptor())
+		check.Message = fmt.Sprintf("ltc (%s) does not match the target's lattice version (%s).", d.VersionManager.LatticeVersion(), serverVersions.LatticeRelease)
+		check.Fix = "Run ltc sync to replace ltc with the target's version."
+	default:
+		check.Passed = true
+		check.Message = fmt.Sprintf("ltc %s matches the target", d.VersionManager.LatticeVersion())
+	}
+
+	return check
+}
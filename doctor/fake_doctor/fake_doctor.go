@@ -0,0 +1,43 @@
+// This file was generated by counterfeiter
+package fake_doctor
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/ltc/doctor"
+)
+
+type FakeDoctor struct {
+	DiagnoseStub        func() []doctor.Check
+	diagnoseMutex       sync.RWMutex
+	diagnoseArgsForCall []struct{}
+	diagnoseReturns     struct {
+		result1 []doctor.Check
+	}
+}
+
+func (fake *FakeDoctor) Diagnose() []doctor.Check {
+	fake.diagnoseMutex.Lock()
+	fake.diagnoseArgsForCall = append(fake.diagnoseArgsForCall, struct{}{})
+	fake.diagnoseMutex.Unlock()
+	if fake.DiagnoseStub != nil {
+		return fake.DiagnoseStub()
+	} else {
+		return fake.diagnoseReturns.result1
+	}
+}
+
+func (fake *FakeDoctor) DiagnoseCallCount() int {
+	fake.diagnoseMutex.RLock()
+	defer fake.diagnoseMutex.RUnlock()
+	return len(fake.diagnoseArgsForCall)
+}
+
+func (fake *FakeDoctor) DiagnoseReturns(result1 []doctor.Check) {
+	fake.DiagnoseStub = nil
+	fake.diagnoseReturns = struct {
+		result1 []doctor.Check
+	}{result1}
+}
+
+var _ doctor.Doctor = new(FakeDoctor)
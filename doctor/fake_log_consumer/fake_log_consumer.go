@@ -0,0 +1,106 @@
+// This file was generated by counterfeiter
+package fake_log_consumer
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/ltc/doctor"
+	"github.com/cloudfoundry/sonde-go/events"
+)
+
+type FakeLogConsumer struct {
+	SetOnConnectCallbackStub        func(cb func())
+	setOnConnectCallbackMutex       sync.RWMutex
+	setOnConnectCallbackArgsForCall []struct {
+		cb func()
+	}
+	TailingLogsStub        func(appGuid string, authToken string, outputChan chan<- *events.LogMessage, errorChan chan<- error)
+	tailingLogsMutex       sync.RWMutex
+	tailingLogsArgsForCall []struct {
+		appGuid    string
+		authToken  string
+		outputChan chan<- *events.LogMessage
+		errorChan  chan<- error
+	}
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct{}
+	closeReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeLogConsumer) SetOnConnectCallback(cb func()) {
+	fake.setOnConnectCallbackMutex.Lock()
+	fake.setOnConnectCallbackArgsForCall = append(fake.setOnConnectCallbackArgsForCall, struct {
+		cb func()
+	}{cb})
+	fake.setOnConnectCallbackMutex.Unlock()
+	if fake.SetOnConnectCallbackStub != nil {
+		fake.SetOnConnectCallbackStub(cb)
+	}
+}
+
+func (fake *FakeLogConsumer) SetOnConnectCallbackCallCount() int {
+	fake.setOnConnectCallbackMutex.RLock()
+	defer fake.setOnConnectCallbackMutex.RUnlock()
+	return len(fake.setOnConnectCallbackArgsForCall)
+}
+
+func (fake *FakeLogConsumer) SetOnConnectCallbackArgsForCall(i int) func() {
+	fake.setOnConnectCallbackMutex.RLock()
+	defer fake.setOnConnectCallbackMutex.RUnlock()
+	return fake.setOnConnectCallbackArgsForCall[i].cb
+}
+
+func (fake *FakeLogConsumer) TailingLogs(appGuid string, authToken string, outputChan chan<- *events.LogMessage, errorChan chan<- error) {
+	fake.tailingLogsMutex.Lock()
+	fake.tailingLogsArgsForCall = append(fake.tailingLogsArgsForCall, struct {
+		appGuid    string
+		authToken  string
+		outputChan chan<- *events.LogMessage
+		errorChan  chan<- error
+	}{appGuid, authToken, outputChan, errorChan})
+	fake.tailingLogsMutex.Unlock()
+	if fake.TailingLogsStub != nil {
+		fake.TailingLogsStub(appGuid, authToken, outputChan, errorChan)
+	}
+}
+
+func (fake *FakeLogConsumer) TailingLogsCallCount() int {
+	fake.tailingLogsMutex.RLock()
+	defer fake.tailingLogsMutex.RUnlock()
+	return len(fake.tailingLogsArgsForCall)
+}
+
+func (fake *FakeLogConsumer) TailingLogsArgsForCall(i int) (string, string, chan<- *events.LogMessage, chan<- error) {
+	fake.tailingLogsMutex.RLock()
+	defer fake.tailingLogsMutex.RUnlock()
+	return fake.tailingLogsArgsForCall[i].appGuid, fake.tailingLogsArgsForCall[i].authToken, fake.tailingLogsArgsForCall[i].outputChan, fake.tailingLogsArgsForCall[i].errorChan
+}
+
+func (fake *FakeLogConsumer) Close() error {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct{}{})
+	fake.closeMutex.Unlock()
+	if fake.CloseStub != nil {
+		return fake.CloseStub()
+	} else {
+		return fake.closeReturns.result1
+	}
+}
+
+func (fake *FakeLogConsumer) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeLogConsumer) CloseReturns(result1 error) {
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ doctor.LogConsumer = new(FakeLogConsumer)
@@ -0,0 +1,245 @@
+package app_manifest
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-golang/bytefmt"
+	"gopkg.in/yaml.v2"
+)
+
+// Application is an app of a Cloud Foundry manifest.yml, with its sizes in
+// MB and its path resolved against the directory of the manifest.
+type Application struct {
+	Name                    string
+	Path                    string
+	Buildpacks              []string
+	Command                 string
+	MemoryMB                int
+	DiskMB                  int
+	Instances               int
+	Env                     map[string]string
+	Routes                  []string
+	NoRoute                 bool
+	HealthCheckType         string
+	HealthCheckHTTPEndpoint string
+}
+
+type manifest struct {
+	Applications []application `yaml:"applications"`
+}
+
+type application struct {
+	Name                    string                 `yaml:"name"`
+	Path                    string                 `yaml:"path"`
+	Buildpack               string                 `yaml:"buildpack"`
+	Buildpacks              []string               `yaml:"buildpacks"`
+	Command                 string                 `yaml:"command"`
+	Memory                  string                 `yaml:"memory"`
+	DiskQuota               string                 `yaml:"disk_quota"`
+	Instances               *int                   `yaml:"instances"`
+	Env                     map[string]interface{} `yaml:"env"`
+	Routes                  []route                `yaml:"routes"`
+	NoRoute                 bool                   `yaml:"no-route"`
+	HealthCheckType         string                 `yaml:"health-check-type"`
+	HealthCheckHTTPEndpoint string                 `yaml:"health-check-http-endpoint"`
+}
+
+type route struct {
+	Route string `yaml:"route"`
+}
+
+var variablePattern = regexp.MustCompile(`\(\(([-\w.]+)\)\)`)
+
+// Parse reads the applications of a manifest, replacing each ((variable)) in
+// it with its value from vars.
+func Parse(manifestYAML []byte, baseDir string, vars map[string]interface{}) ([]Application, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(manifestYAML, &document); err != nil {
+		return nil, err
+	}
+
+	missing := map[string]bool{}
+	document = interpolate(document, vars, missing)
+	if len(missing) > 0 {
+		names := []string{}
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("no value for variables: %s", strings.Join(names, ", "))
+	}
+
+	interpolated, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := manifest{}
+	if err := yaml.Unmarshal(interpolated, &parsed); err != nil {
+		return nil, err
+	}
+
+	if len(parsed.Applications) == 0 {
+		return nil, errors.New("no applications in manifest")
+	}
+
+	applications := []Application{}
+	for _, app := range parsed.Applications {
+		application, err := app.resolve(baseDir)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, application)
+	}
+
+	return applications, nil
+}
+
+// ParseVar reads a <name>=<value> variable.  Values that YAML reads as
+// numbers or booleans keep that type, so they can fill in instances and the
+// like.
+func ParseVar(variable string) (string, interface{}, error) {
+	parts := strings.SplitN(variable, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, fmt.Errorf("invalid variable %s: expected <name>=<value>", variable)
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(parts[1]), &value); err == nil {
+		switch value.(type) {
+		case int, float64, bool:
+			return parts[0], value, nil
+		}
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// ParseVarsFile reads the variables of a YAML file of names and values.
+func ParseVarsFile(varsYAML []byte) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	if err := yaml.Unmarshal(varsYAML, &vars); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+func interpolate(node interface{}, vars map[string]interface{}, missing map[string]bool) interface{} {
+	switch node := node.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range node {
+			node[key] = interpolate(value, vars, missing)
+		}
+	case []interface{}:
+		for index, value := range node {
+			node[index] = interpolate(value, vars, missing)
+		}
+	case string:
+		// a value that is just a variable takes the type of the variable's value
+		if match := variablePattern.FindStringSubmatch(node); match != nil && match[0] == node {
+			if value, ok := vars[match[1]]; ok {
+				return value
+			}
+			missing[match[1]] = true
+			return node
+		}
+
+		return variablePattern.ReplaceAllStringFunc(node, func(variable string) string {
+			name := variablePattern.FindStringSubmatch(variable)[1]
+			if value, ok := vars[name]; ok {
+				return fmt.Sprint(value)
+			}
+			missing[name] = true
+			return variable
+		})
+	}
+
+	return node
+}
+
+func (app application) resolve(baseDir string) (Application, error) {
+	if app.Name == "" {
+		return Application{}, errors.New("application without a name in manifest")
+	}
+
+	application := Application{
+		Name:                    app.Name,
+		Path:                    baseDir,
+		Buildpacks:              []string{},
+		Command:                 app.Command,
+		Instances:               1,
+		Env:                     map[string]string{},
+		Routes:                  []string{},
+		NoRoute:                 app.NoRoute,
+		HealthCheckType:         app.HealthCheckType,
+		HealthCheckHTTPEndpoint: app.HealthCheckHTTPEndpoint,
+	}
+
+	if app.Buildpack != "" && len(app.Buildpacks) > 0 {
+		return Application{}, fmt.Errorf("both buildpack and buildpacks given for %s", app.Name)
+	}
+	if app.Buildpack != "" {
+		application.Buildpacks = []string{app.Buildpack}
+	}
+	application.Buildpacks = append(application.Buildpacks, app.Buildpacks...)
+
+	if app.Path != "" {
+		application.Path = app.Path
+		if !filepath.IsAbs(app.Path) {
+			application.Path = filepath.Join(baseDir, app.Path)
+		}
+	}
+
+	if app.Memory != "" {
+		memoryMB, err := bytefmt.ToMegabytes(app.Memory)
+		if err != nil {
+			return Application{}, fmt.Errorf("invalid memory for %s: %s", app.Name, app.Memory)
+		}
+		application.MemoryMB = int(memoryMB)
+	}
+
+	if app.DiskQuota != "" {
+		diskMB, err := bytefmt.ToMegabytes(app.DiskQuota)
+		if err != nil {
+			return Application{}, fmt.Errorf("invalid disk_quota for %s: %s", app.Name, app.DiskQuota)
+		}
+		application.DiskMB = int(diskMB)
+	}
+
+	if app.Instances != nil {
+		if *app.Instances < 0 {
+			return Application{}, fmt.Errorf("invalid instances for %s: %d", app.Name, *app.Instances)
+		}
+		application.Instances = *app.Instances
+	}
+
+	for name, value := range app.Env {
+		if value == nil {
+			value = ""
+		}
+		application.Env[name] = fmt.Sprint(value)
+	}
+
+	for _, r := range app.Routes {
+		application.Routes = append(application.Routes, r.Route)
+	}
+
+	switch app.HealthCheckType {
+	case "", "port", "process", "none":
+	case "http":
+		if application.HealthCheckHTTPEndpoint == "" {
+			application.HealthCheckHTTPEndpoint = "/"
+		}
+	default:
+		return Application{}, fmt.Errorf("invalid health-check-type for %s: %s", app.Name, app.HealthCheckType)
+	}
+
+	return application, nil
+}
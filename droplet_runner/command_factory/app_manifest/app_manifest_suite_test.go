@@ -0,0 +1,13 @@
+package app_manifest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestAppManifest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AppManifest Suite")
+}
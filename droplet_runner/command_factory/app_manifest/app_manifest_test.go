@@ -0,0 +1,155 @@
+package app_manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/app_manifest"
+)
+
+var _ = Describe("AppManifest", func() {
+	Describe("Parse", func() {
+		It("reads the applications of the manifest", func() {
+			manifestYAML := `---
+applications:
+- name: web
+  path: src
+  buildpack: ruby
+  command: bundle exec rackup
+  memory: 512M
+  disk_quota: 1G
+  instances: 3
+  env:
+    RACK_ENV: production
+    WORKERS: 4
+  routes:
+  - route: web
+  - route: web.example.com
+  health-check-type: http
+- name: worker
+  path: /abs/worker
+  buildpacks:
+  - go
+  - https://github.com/cloudfoundry/binary-buildpack.git
+  no-route: true
+  health-check-type: process
+`
+
+			Expect(app_manifest.Parse([]byte(manifestYAML), "/base", nil)).To(Equal([]app_manifest.Application{
+				{
+					Name:                    "web",
+					Path:                    "/base/src",
+					Buildpacks:              []string{"ruby"},
+					Command:                 "bundle exec rackup",
+					MemoryMB:                512,
+					DiskMB:                  1024,
+					Instances:               3,
+					Env:                     map[string]string{"RACK_ENV": "production", "WORKERS": "4"},
+					Routes:                  []string{"web", "web.example.com"},
+					HealthCheckType:         "http",
+					HealthCheckHTTPEndpoint: "/",
+				},
+				{
+					Name:            "worker",
+					Path:            "/abs/worker",
+					Buildpacks:      []string{"go", "https://github.com/cloudfoundry/binary-buildpack.git"},
+					Instances:       1,
+					Env:             map[string]string{},
+					Routes:          []string{},
+					NoRoute:         true,
+					HealthCheckType: "process",
+				},
+			}))
+		})
+
+		It("replaces variables with their values", func() {
+			manifestYAML := `---
+applications:
+- name: ((name))
+  instances: ((instances))
+  routes:
+  - route: ((name))-((env))
+`
+			vars := map[string]interface{}{"name": "web", "instances": 2, "env": "staging"}
+
+			applications, err := app_manifest.Parse([]byte(manifestYAML), "/base", vars)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applications[0].Name).To(Equal("web"))
+			Expect(applications[0].Instances).To(Equal(2))
+			Expect(applications[0].Routes).To(Equal([]string{"web-staging"}))
+		})
+
+		It("returns an error naming the variables without values", func() {
+			manifestYAML := "applications:\n- name: ((name))\n  memory: ((memory))\n"
+
+			_, err := app_manifest.Parse([]byte(manifestYAML), "/base", map[string]interface{}{"other": "x"})
+			Expect(err).To(MatchError("no value for variables: memory, name"))
+		})
+
+		It("returns an error for a manifest without applications", func() {
+			_, err := app_manifest.Parse([]byte("---\n"), "/base", nil)
+			Expect(err).To(MatchError("no applications in manifest"))
+		})
+
+		It("returns an error for an application without a name", func() {
+			_, err := app_manifest.Parse([]byte("applications:\n- memory: 1G\n"), "/base", nil)
+			Expect(err).To(MatchError("application without a name in manifest"))
+		})
+
+		It("returns an error for invalid sizes", func() {
+			_, err := app_manifest.Parse([]byte("applications:\n- name: web\n  memory: lots\n"), "/base", nil)
+			Expect(err).To(MatchError("invalid memory for web: lots"))
+
+			_, err = app_manifest.Parse([]byte("applications:\n- name: web\n  disk_quota: 1X\n"), "/base", nil)
+			Expect(err).To(MatchError("invalid disk_quota for web: 1X"))
+		})
+
+		It("returns an error when both buildpack and buildpacks are given", func() {
+			_, err := app_manifest.Parse([]byte("applications:\n- name: web\n  buildpack: go\n  buildpacks: [ruby]\n"), "/base", nil)
+			Expect(err).To(MatchError("both buildpack and buildpacks given for web"))
+		})
+
+		It("returns an error for an unknown health check type", func() {
+			_, err := app_manifest.Parse([]byte("applications:\n- name: web\n  health-check-type: ping\n"), "/base", nil)
+			Expect(err).To(MatchError("invalid health-check-type for web: ping"))
+		})
+
+		It("returns an error for invalid YAML", func() {
+			_, err := app_manifest.Parse([]byte("applications: [\n"), "/base", nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ParseVar", func() {
+		It("reads the name and value of the variable", func() {
+			name, value, err := app_manifest.ParseVar("route=web:8080")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("route"))
+			Expect(value).To(Equal("web:8080"))
+		})
+
+		It("keeps numbers and booleans typed", func() {
+			_, value, err := app_manifest.ParseVar("instances=3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(3))
+
+			_, value, err = app_manifest.ParseVar("no-route=true")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(true))
+		})
+
+		It("returns an error for a variable without a value", func() {
+			_, _, err := app_manifest.ParseVar("instances")
+			Expect(err).To(MatchError("invalid variable instances: expected <name>=<value>"))
+		})
+	})
+
+	Describe("ParseVarsFile", func() {
+		It("reads the variables in the file", func() {
+			Expect(app_manifest.ParseVarsFile([]byte("name: web\ninstances: 2\n"))).To(Equal(map[string]interface{}{
+				"name":      "web",
+				"instances": 2,
+			}))
+		})
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,12 +15,16 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-incubator/ltc/app_runner"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/app_manifest"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/cf_ignore"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/zipper"
 	"github.com/cloudfoundry-incubator/ltc/exit_handler/exit_codes"
+	"github.com/cloudfoundry-incubator/ltc/file_helpers"
 	"github.com/cloudfoundry-incubator/ltc/task_examiner"
+	"github.com/cloudfoundry-incubator/ltc/terminal"
 	"github.com/cloudfoundry-incubator/ltc/terminal/colors"
 	"github.com/codegangsta/cli"
 	"github.com/pivotal-golang/bytefmt"
@@ -27,7 +32,15 @@ import (
 	app_runner_command_factory "github.com/cloudfoundry-incubator/ltc/app_runner/command_factory"
 )
 
-var knownBuildpacks map[string]string
+const autoDetectBuildpacks = "auto"
+
+var (
+	knownBuildpacks map[string]string
+
+	// autoDetectOrder is the order in which the known buildpacks are
+	// tried when detecting the buildpack of an app.
+	autoDetectOrder = []string{"staticfile", "java", "ruby", "nodejs", "go", "python", "php", "binary"}
+)
 
 func init() {
 	knownBuildpacks = map[string]string{
@@ -89,13 +102,63 @@ func (factory *DropletRunnerCommandFactory) MakeListDropletsCommand() cli.Comman
 		Name:        "list-droplets",
 		Aliases:     []string{"lsd"},
 		Usage:       "Lists the droplets in the droplet store",
-		Description: "ltc list-droplets",
+		Description: "ltc list-droplets [--checksums] [--columns <column>,...]",
 		Action:      factory.listDroplets,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "checksums",
+				Usage: "Show the SHA-256 checksum stored with each droplet",
+			},
+			cli.StringFlag{
+				Name:  "columns",
+				Usage: "Metadata to show: buildpack, detected-buildpack, start-command, builder and/or source-checksum",
+			},
+		},
 	}
 
 	return listDropletsCommand
 }
 
+func (factory *DropletRunnerCommandFactory) MakeDropletInfoCommand() cli.Command {
+	var dropletInfoCommand = cli.Command{
+		Name:        "droplet-info",
+		Aliases:     []string{"di"},
+		Usage:       "Shows how a droplet was built",
+		Description: "ltc droplet-info <droplet-name>[@<version>|@<tag>]",
+		Action:      factory.dropletInfo,
+	}
+
+	return dropletInfoCommand
+}
+
+func (factory *DropletRunnerCommandFactory) MakeDropletHistoryCommand() cli.Command {
+	var dropletHistoryCommand = cli.Command{
+		Name:        "droplet-history",
+		Aliases:     []string{"dh"},
+		Usage:       "Lists the versions of a droplet",
+		Description: "ltc droplet-history <droplet-name>",
+		Action:      factory.dropletHistory,
+	}
+
+	return dropletHistoryCommand
+}
+
+func (factory *DropletRunnerCommandFactory) MakeTagDropletCommand() cli.Command {
+	var tagDropletCommand = cli.Command{
+		Name:    "tag-droplet",
+		Aliases: []string{"td"},
+		Usage:   "Points a tag at a version of a droplet",
+		Description: `ltc tag-droplet <droplet-name>@<version> <tag>
+
+   Droplets are launched from the version tagged latest unless another version or tag is given.
+   To roll back to an earlier version:
+     ltc tag-droplet myapp@v2 latest`,
+		Action: factory.tagDroplet,
+	}
+
+	return tagDropletCommand
+}
+
 func (factory *DropletRunnerCommandFactory) MakeBuildDropletCommand() cli.Command {
 	var launchFlags = []cli.Flag{
 		cli.StringFlag{
@@ -103,6 +166,10 @@ func (factory *DropletRunnerCommandFactory) MakeBuildDropletCommand() cli.Comman
 			Usage: "Path to droplet source",
 			Value: ".",
 		},
+		cli.StringFlag{
+			Name:  "buildpack-path",
+			Usage: "Builds with the buildpack directory or .zip at this path, uploaded in place of <buildpack-uri>",
+		},
 		cli.IntFlag{
 			Name:  "cpu-weight, c",
 			Usage: "Relative CPU weight for the container (valid values: 1-100)",
@@ -128,15 +195,29 @@ func (factory *DropletRunnerCommandFactory) MakeBuildDropletCommand() cli.Comman
 			Usage: "Polling timeout for app to start",
 			Value: app_runner_command_factory.DefaultPollingTimeout,
 		},
+		cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Builds without the build cache kept from earlier builds of the droplet",
+		},
 	}
 
 	var buildDropletCommand = cli.Command{
-		Name:        "build-droplet",
-		Aliases:     []string{"bd"},
-		Usage:       "Builds app bits into a droplet using a CF buildpack",
-		Description: "ltc build-droplet <droplet-name> <buildpack-uri>",
-		Action:      factory.buildDroplet,
-		Flags:       launchFlags,
+		Name:    "build-droplet",
+		Aliases: []string{"bd"},
+		Usage:   "Builds app bits into a droplet using a CF buildpack",
+		Description: `ltc build-droplet <droplet-name> <buildpack-uri>[,<buildpack-uri>...]
+
+   A buildpack is a buildpack URL or the name of a known buildpack: ` + strings.Join(autoDetectOrder, ", ") + `.
+
+   When several buildpacks are given, the droplet is built with the first one that detects the app.
+   Use "` + autoDetectBuildpacks + `" to detect the buildpack among all the known buildpacks.
+
+   To build with a local buildpack, for cells that can't fetch buildpacks:
+   ltc build-droplet <droplet-name> --buildpack-path <buildpack-dir-or-zip>
+
+   The buildpack is uploaded to the blob store, unless the same buildpack was uploaded before.`,
+		Action: factory.buildDroplet,
+		Flags:  launchFlags,
 	}
 
 	return buildDropletCommand
@@ -203,6 +284,10 @@ func (factory *DropletRunnerCommandFactory) MakeLaunchDropletCommand() cli.Comma
 			Name:  "no-routes",
 			Usage: "Registers no routes for the app",
 		},
+		cli.StringFlag{
+			Name:  "process",
+			Usage: "Runs the start command the build detected for this process type instead of web",
+		},
 		cli.DurationFlag{
 			Name:  "timeout, t",
 			Usage: "Polling timeout for app to start",
@@ -222,7 +307,13 @@ func (factory *DropletRunnerCommandFactory) MakeLaunchDropletCommand() cli.Comma
 		Name:    "launch-droplet",
 		Aliases: []string{"ld"},
 		Usage:   "Launches a droplet as an app running on lattice",
-		Description: `ltc launch-droplet <app-name> <droplet-name>
+		Description: `ltc launch-droplet <app-name> <droplet-name>[@<version>|@<tag>]
+
+   The droplet version tagged latest is launched unless another version or tag is given.
+
+   The app runs the web process the build detected, from the app's Procfile or the buildpack's defaults.
+   To run another process type of the Procfile:
+   ltc launch-droplet <app-name> <droplet-name> --process <process-type>
 
    To provide a custom command:
    ltc launch-droplet <app-name> <droplet-name> [<optional flags>] -- <start-command> <start-command-arg1> <start-command-arg2> ...
@@ -245,24 +336,84 @@ func (factory *DropletRunnerCommandFactory) MakeLaunchDropletCommand() cli.Comma
 	return launchDropletCommand
 }
 
+func (factory *DropletRunnerCommandFactory) MakePushCommand() cli.Command {
+	var pushFlags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "manifest, f",
+			Usage: "Path to the manifest",
+			Value: "manifest.yml",
+		},
+		cli.StringSliceFlag{
+			Name:  "var",
+			Usage: "Value of a ((variable)) in the manifest, as <name>=<value> (can be passed multiple times)",
+			Value: &cli.StringSlice{},
+		},
+		cli.StringFlag{
+			Name:  "vars-file",
+			Usage: "Path to a YAML file of variable names and values",
+		},
+		cli.DurationFlag{
+			Name:  "timeout, t",
+			Usage: "Polling timeout for each build and app start",
+			Value: app_runner_command_factory.DefaultPollingTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Builds without the build caches kept from earlier builds of the apps",
+		},
+	}
+
+	var pushCommand = cli.Command{
+		Name:  "push",
+		Usage: "Builds and launches the apps of a CF manifest",
+		Description: `ltc push [<app-name> ...]
+
+   Each app in the manifest is built into a droplet of the same name and then launched,
+   replacing the app when it is already running. Only the named apps are pushed when
+   app names are given.
+
+   The manifest supports the name, path, buildpack, buildpacks, command, memory, disk_quota,
+   instances, env, routes, no-route, health-check-type and health-check-http-endpoint
+   keys of Cloud Foundry manifests. Routes are forwarded to container port 8080 and must be
+   hostnames under the system domain, or names that are prefixed to it.
+
+   ((variable)) placeholders are filled in from --var and --vars-file.`,
+		Action: factory.push,
+		Flags:  pushFlags,
+	}
+
+	return pushCommand
+}
+
 func (factory *DropletRunnerCommandFactory) MakeRemoveDropletCommand() cli.Command {
 	var removeDropletCommand = cli.Command{
 		Name:        "remove-droplet",
 		Aliases:     []string{"rd"},
 		Usage:       "Removes a droplet from the droplet store",
-		Description: "ltc remove-droplet <droplet-name>",
+		Description: "ltc remove-droplet <droplet-name>[@<version>]",
 		Action:      factory.removeDroplet,
 	}
 
 	return removeDropletCommand
 }
 
+func (factory *DropletRunnerCommandFactory) MakeClearBuildCacheCommand() cli.Command {
+	var clearBuildCacheCommand = cli.Command{
+		Name:        "clear-build-cache",
+		Usage:       "Removes the build cache kept between builds of a droplet",
+		Description: "ltc clear-build-cache <droplet-name>",
+		Action:      factory.clearBuildCache,
+	}
+
+	return clearBuildCacheCommand
+}
+
 func (factory *DropletRunnerCommandFactory) MakeImportDropletCommand() cli.Command {
 	var importDropletCommand = cli.Command{
 		Name:        "import-droplet",
 		Aliases:     []string{"id"},
 		Usage:       "Imports a droplet from disk to the droplet store",
-		Description: "ltc import-droplet <droplet-name> <droplet-path> <metadata-path>",
+		Description: "ltc import-droplet <droplet-name> <droplet-path>",
 		Action:      factory.importDroplet,
 	}
 
@@ -278,12 +429,41 @@ func (factory *DropletRunnerCommandFactory) importDroplet(context *cli.Context)
 		return
 	}
 
-	if err := factory.dropletRunner.ImportDroplet(dropletName, dropletPath); err != nil {
+	if strings.Contains(dropletName, "@") {
+		factory.UI.SayIncorrectUsage("<droplet-name> cannot contain @")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	dropletVersion, err := factory.dropletRunner.NewDropletVersion(dropletName)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error versioning %s: %s", dropletName, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	progress := terminal.NewProgress(factory.UI)
+	err = factory.dropletRunner.ImportDroplet(dropletVersion, dropletPath, progress.Update)
+	progress.Done()
+	if err != nil {
 		factory.UI.SayLine(fmt.Sprintf("Error importing %s: %s", dropletName, err))
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
 		return
 	}
-	factory.UI.SayLine("Imported " + dropletName)
+	factory.UI.SayLine("Imported " + dropletVersion)
+
+	factory.tagLatest(dropletVersion)
+}
+
+// tagLatest moves the latest tag to a newly stored droplet version, so that
+// launching the droplet by name launches that version.
+func (factory *DropletRunnerCommandFactory) tagLatest(dropletVersion string) {
+	if err := factory.dropletRunner.TagDroplet(dropletVersion, droplet_runner.LatestTag); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error tagging %s: %s", dropletVersion, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+	factory.UI.SayLine(fmt.Sprintf("Tagged %s as %s", dropletVersion, droplet_runner.LatestTag))
 }
 
 func (factory *DropletRunnerCommandFactory) MakeExportDropletCommand() cli.Command {
@@ -291,14 +471,237 @@ func (factory *DropletRunnerCommandFactory) MakeExportDropletCommand() cli.Comma
 		Name:        "export-droplet",
 		Aliases:     []string{"ed"},
 		Usage:       "Exports a droplet from the droplet store to disk",
-		Description: "ltc export-droplet <droplet-name>",
+		Description: "ltc export-droplet <droplet-name>[@<version>|@<tag>]",
 		Action:      factory.exportDroplet,
 	}
 
 	return exportDropletCommand
 }
 
+func (factory *DropletRunnerCommandFactory) MakeMigrateDropletsCommand() cli.Command {
+	var migrateDropletsCommand = cli.Command{
+		Name:        "migrate-droplets",
+		Usage:       "Copies droplets to the droplet store of another target",
+		Description: "ltc migrate-droplets [--from <target>] [--droplet <droplet-name>]... [--dry-run] <target>",
+		Action:      factory.migrateDroplets,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "from",
+				Usage: "Saved target to copy droplets from, instead of the current target",
+			},
+			cli.StringSliceFlag{
+				Name:  "droplet",
+				Usage: "Droplet to copy (can be passed multiple times, defaults to all droplets)",
+				Value: &cli.StringSlice{},
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Lists the droplet blobs that would be copied without copying them",
+			},
+		},
+	}
+
+	return migrateDropletsCommand
+}
+
+func (factory *DropletRunnerCommandFactory) migrateDroplets(context *cli.Context) {
+	destinationName := context.Args().First()
+	fromFlag := context.String("from")
+	dryRunFlag := context.Bool("dry-run")
+	if destinationName == "" {
+		factory.UI.SayIncorrectUsage("<target> is required")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	sourceConfig := factory.config
+	if fromFlag != "" {
+		var err error
+		sourceConfig, err = factory.config.ProfileConfig(fromFlag)
+		if err != nil {
+			factory.UI.SayLine(err.Error())
+			factory.ExitHandler.Exit(exit_codes.BadTarget)
+			return
+		}
+	}
+
+	destinationConfig, err := factory.config.ProfileConfig(destinationName)
+	if err != nil {
+		factory.UI.SayLine(err.Error())
+		factory.ExitHandler.Exit(exit_codes.BadTarget)
+		return
+	}
+
+	if !factory.verifyBlobStore(sourceConfig, "droplet store") || !factory.verifyBlobStore(destinationConfig, "droplet store of "+destinationName) {
+		return
+	}
+
+	copied, skipped := 0, 0
+	options := droplet_runner.MigrateOptions{
+		DropletNames: context.StringSlice("droplet"),
+		DryRun:       dryRunFlag,
+		Progress: func(migration droplet_runner.BlobMigration) {
+			size := bytefmt.ByteSize(uint64(migration.Size))
+			switch {
+			case migration.Skipped:
+				skipped++
+				factory.UI.SayLine(fmt.Sprintf("Skipped %s (already in %s)", migration.Path, destinationName))
+			case dryRunFlag:
+				copied++
+				factory.UI.SayLine(fmt.Sprintf("Would copy %s (%s)", migration.Path, size))
+			default:
+				copied++
+				factory.UI.SayLine(fmt.Sprintf("Copied %s (%s)", migration.Path, size))
+			}
+		},
+	}
+
+	if err := factory.dropletRunner.MigrateDroplets(sourceConfig, destinationConfig, options); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error migrating droplets: %s", err))
+		if copied > 0 {
+			factory.UI.SayLine("Run the command again to resume; copied droplet blobs will be skipped.")
+		}
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	if dryRunFlag {
+		factory.UI.SayLine(fmt.Sprintf("Dry run: %d droplet blobs to copy to %s, %d already there.", copied, destinationName, skipped))
+		return
+	}
+
+	factory.UI.SayLine(fmt.Sprintf("Copied %d droplet blobs to %s, skipped %d.", copied, destinationName, skipped))
+}
+
+func (factory *DropletRunnerCommandFactory) MakeGCDropletsCommand() cli.Command {
+	var gcDropletsCommand = cli.Command{
+		Name:        "gc-droplets",
+		Usage:       "Removes unneeded droplets and app bits from the droplet store",
+		Description: "ltc gc-droplets [--orphaned-bits] [--unused-files] [--unreferenced] [--older-than <duration>] [--keep <count>] [--dry-run]",
+		Action:      factory.gcDroplets,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "orphaned-bits",
+				Usage: "Removes app bits left behind by failed or abandoned builds",
+			},
+			cli.BoolFlag{
+				Name:  "unused-files",
+				Usage: "Removes stored app files and uploaded buildpacks no pending build or droplet uses",
+			},
+			cli.BoolFlag{
+				Name:  "unreferenced",
+				Usage: "Removes droplets not used by any app",
+			},
+			cli.DurationFlag{
+				Name:  "older-than",
+				Usage: "Removes droplets older than the duration (e.g. 720h)",
+			},
+			cli.IntFlag{
+				Name:  "keep",
+				Usage: "Removes all but the newest versions of each droplet",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Lists the droplet blobs that would be removed without removing them",
+			},
+		},
+	}
+
+	return gcDropletsCommand
+}
+
+func (factory *DropletRunnerCommandFactory) gcDroplets(context *cli.Context) {
+	orphanedBitsFlag := context.Bool("orphaned-bits")
+	unusedFilesFlag := context.Bool("unused-files")
+	unreferencedFlag := context.Bool("unreferenced")
+	olderThanFlag := context.Duration("older-than")
+	keepFlag := context.Int("keep")
+	dryRunFlag := context.Bool("dry-run")
+
+	if olderThanFlag < 0 || keepFlag < 0 {
+		factory.UI.SayIncorrectUsage("--older-than and --keep must not be negative")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+	if !orphanedBitsFlag && !unusedFilesFlag && !unreferencedFlag && olderThanFlag == 0 && keepFlag == 0 {
+		factory.UI.SayIncorrectUsage("at least one of --orphaned-bits, --unused-files, --unreferenced, --older-than or --keep is required")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.ensureBlobStoreVerified() {
+		return
+	}
+
+	tasks, err := factory.taskExaminer.ListTasks()
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error listing build tasks: %s", err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	buildingDroplets := []string{}
+	for _, task := range tasks {
+		if task.State == "COMPLETED" || task.State == "RESOLVING" {
+			continue
+		}
+		if dropletName, ok := droplet_runner.BuildTaskDroplet(task.TaskGuid); ok {
+			buildingDroplets = append(buildingDroplets, dropletName)
+		}
+	}
+
+	removed, reclaimed := 0, int64(0)
+	options := droplet_runner.GCOptions{
+		OrphanedBits:     orphanedBitsFlag,
+		UnusedFiles:      unusedFilesFlag,
+		Unreferenced:     unreferencedFlag,
+		OlderThan:        olderThanFlag,
+		KeepNewest:       keepFlag,
+		BuildingDroplets: buildingDroplets,
+		DryRun:           dryRunFlag,
+		Progress: func(garbage droplet_runner.GarbageBlob) {
+			removed++
+			reclaimed += garbage.Size
+
+			size := bytefmt.ByteSize(uint64(garbage.Size))
+			if dryRunFlag {
+				factory.UI.SayLine(fmt.Sprintf("Would remove %s (%s): %s", garbage.Path, size, garbage.Reason))
+			} else {
+				factory.UI.SayLine(fmt.Sprintf("Removed %s (%s): %s", garbage.Path, size, garbage.Reason))
+			}
+		},
+	}
+
+	if err := factory.dropletRunner.GCDroplets(options); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error removing droplets: %s", err))
+		if removed > 0 {
+			factory.UI.SayLine(fmt.Sprintf("Removed %d droplet blobs, reclaimed %s.", removed, bytefmt.ByteSize(uint64(reclaimed))))
+		}
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	if dryRunFlag {
+		factory.UI.SayLine(fmt.Sprintf("Dry run: %d droplet blobs to remove, reclaiming %s.", removed, bytefmt.ByteSize(uint64(reclaimed))))
+		return
+	}
+
+	factory.UI.SayLine(fmt.Sprintf("Removed %d droplet blobs, reclaimed %s.", removed, bytefmt.ByteSize(uint64(reclaimed))))
+}
+
 func (factory *DropletRunnerCommandFactory) listDroplets(context *cli.Context) {
+	columns := []string{}
+	if columnsFlag := context.String("columns"); columnsFlag != "" {
+		columns = strings.Split(columnsFlag, ",")
+		for _, column := range columns {
+			if _, ok := metadataColumns[column]; !ok {
+				factory.UI.SayIncorrectUsage(fmt.Sprintf("unknown column %s", column))
+				factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+				return
+			}
+		}
+	}
+
 	if !factory.ensureBlobStoreVerified() {
 		return
 	}
@@ -310,33 +713,259 @@ func (factory *DropletRunnerCommandFactory) listDroplets(context *cli.Context) {
 		return
 	}
 
+	showChecksums := context.Bool("checksums")
+	var checksums map[string]string
+	if showChecksums {
+		checksums, err = factory.dropletRunner.DropletChecksums()
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Error listing droplet checksums: %s", err))
+			factory.ExitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+	}
+
+	var allMetadata map[string]droplet_runner.DropletMetadata
+	if len(columns) > 0 {
+		allMetadata, err = factory.dropletRunner.ListDropletMetadata()
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Error listing droplet metadata: %s", err))
+			factory.ExitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+	}
+
 	sort.Sort(dropletSliceSortedByCreated(droplets))
 
 	w := &tabwriter.Writer{}
 	w.Init(factory.UI, 12, 8, 1, '\t', 0)
 
-	fmt.Fprintln(w, "Droplet\tCreated At\tSize")
+	header := []string{"Droplet", "Created At", "Size"}
+	if showChecksums {
+		header = append(header, "SHA-256")
+	}
+	for _, column := range columns {
+		header = append(header, metadataColumns[column].header)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
 	for _, droplet := range droplets {
-		size := bytefmt.ByteSize(uint64(droplet.Size))
+		created := ""
 		if !droplet.Created.IsZero() {
-			fmt.Fprintf(w, "%s\t%s\t%s\n", droplet.Name, droplet.Created.Format("01/02 15:04:05.00"), size)
-		} else {
-			fmt.Fprintf(w, "%s\t\t%s\n", droplet.Name, size)
+			created = droplet.Created.Format("01/02 15:04:05.00")
+		}
+
+		row := []string{droplet.FullName(), created, bytefmt.ByteSize(uint64(droplet.Size))}
+		if showChecksums {
+			row = append(row, valueOrDash(checksums[droplet.FullName()]))
+		}
+		for _, column := range columns {
+			row = append(row, valueOrDash(metadataColumns[column].value(allMetadata[droplet.FullName()])))
 		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
 	}
 
 	w.Flush()
 }
 
+var metadataColumns = map[string]struct {
+	header string
+	value  func(droplet_runner.DropletMetadata) string
+}{
+	"buildpack": {"Buildpack", func(m droplet_runner.DropletMetadata) string {
+		return strings.Join(m.BuildpackURLs, ",")
+	}},
+	"detected-buildpack": {"Detected Buildpack", func(m droplet_runner.DropletMetadata) string {
+		return m.DetectedBuildpack
+	}},
+	"start-command": {"Start Command", func(m droplet_runner.DropletMetadata) string {
+		return m.StartCommand
+	}},
+	"builder": {"Builder", func(m droplet_runner.DropletMetadata) string {
+		return m.Builder
+	}},
+	"source-checksum": {"Source SHA-256", func(m droplet_runner.DropletMetadata) string {
+		return m.SourceChecksum
+	}},
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func (factory *DropletRunnerCommandFactory) dropletInfo(context *cli.Context) {
+	dropletRef := context.Args().First()
+	if dropletRef == "" {
+		factory.UI.SayIncorrectUsage("<droplet-name> is required")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.ensureBlobStoreVerified() {
+		return
+	}
+
+	droplet, ok := factory.findDroplet(dropletRef)
+	if !ok {
+		return
+	}
+
+	metadata, err := factory.dropletRunner.DropletMetadata(droplet.FullName())
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error reading metadata of %s: %s", droplet.FullName(), err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	w := tabwriter.NewWriter(factory.UI, 9, 8, 1, '\t', 0)
+
+	fmt.Fprintf(w, "%s\t%s\n", "Droplet", droplet.FullName())
+	if len(droplet.Tags) > 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Tags", strings.Join(droplet.Tags, ", "))
+	}
+	if !droplet.Created.IsZero() {
+		fmt.Fprintf(w, "%s\t%s\n", "Created At", droplet.Created.Format("01/02 15:04:05.00"))
+	}
+	fmt.Fprintf(w, "%s\t%s\n", "Size", bytefmt.ByteSize(uint64(droplet.Size)))
+
+	if metadata == nil {
+		w.Flush()
+		factory.UI.SayLine("No metadata was recorded for this droplet.")
+		return
+	}
+
+	if metadata.Imported {
+		fmt.Fprintf(w, "%s\t%s\n", "Imported By", valueOrDash(metadata.Builder))
+	} else {
+		fmt.Fprintf(w, "%s\t%s\n", "Built By", valueOrDash(metadata.Builder))
+		fmt.Fprintf(w, "%s\t%s\n", "Buildpack", valueOrDash(strings.Join(metadata.BuildpackURLs, ", ")))
+		fmt.Fprintf(w, "%s\t%s\n", "Detected Buildpack", valueOrDash(metadata.DetectedBuildpack))
+		fmt.Fprintf(w, "%s\t%s\n", "Start Command", valueOrDash(metadata.StartCommand))
+		fmt.Fprintf(w, "%s\t%s\n", "Process Types", valueOrDash(strings.Join(processTypes(metadata.ProcessTypes), ", ")))
+		fmt.Fprintf(w, "%s\t%s\n", "Environment", valueOrDash(strings.Join(metadata.EnvironmentKeys, ", ")))
+	}
+	fmt.Fprintf(w, "%s\t%s\n", "Source SHA-256", valueOrDash(metadata.SourceChecksum))
+
+	w.Flush()
+}
+
+func processTypes(processes map[string]string) []string {
+	types := []string{}
+	for processType := range processes {
+		types = append(types, processType)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// findDroplet resolves the droplet reference and finds the version it refers
+// to among the droplet's history.
+func (factory *DropletRunnerCommandFactory) findDroplet(dropletRef string) (droplet_runner.Droplet, bool) {
+	resolved, err := factory.dropletRunner.ResolveDroplet(dropletRef)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error resolving droplet %s: %s", dropletRef, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return droplet_runner.Droplet{}, false
+	}
+
+	dropletName := strings.SplitN(resolved, "@", 2)[0]
+	droplets, err := factory.dropletRunner.DropletHistory(dropletName)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error listing versions of %s: %s", dropletName, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return droplet_runner.Droplet{}, false
+	}
+
+	for _, droplet := range droplets {
+		if droplet.FullName() == resolved {
+			return droplet, true
+		}
+	}
+
+	factory.UI.SayLine(fmt.Sprintf("Droplet not found: %s", dropletRef))
+	factory.ExitHandler.Exit(exit_codes.CommandFailed)
+	return droplet_runner.Droplet{}, false
+}
+
+func (factory *DropletRunnerCommandFactory) dropletHistory(context *cli.Context) {
+	dropletName := context.Args().First()
+	if dropletName == "" {
+		factory.UI.SayIncorrectUsage("<droplet-name> is required")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.ensureBlobStoreVerified() {
+		return
+	}
+
+	droplets, err := factory.dropletRunner.DropletHistory(dropletName)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error listing versions of %s: %s", dropletName, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	w := &tabwriter.Writer{}
+	w.Init(factory.UI, 12, 8, 1, '\t', 0)
+
+	fmt.Fprintln(w, "Version\tCreated At\tSize\tID\tTags")
+	for _, droplet := range droplets {
+		created := ""
+		if !droplet.Created.IsZero() {
+			created = droplet.Created.Format("01/02 15:04:05.00")
+		}
+
+		id := droplet.Checksum
+		if len(id) > 12 {
+			id = id[:12]
+		}
+
+		row := []string{droplet.FullName(), created, bytefmt.ByteSize(uint64(droplet.Size)), valueOrDash(id), strings.Join(droplet.Tags, ", ")}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	w.Flush()
+}
+
+func (factory *DropletRunnerCommandFactory) tagDroplet(context *cli.Context) {
+	dropletRef := context.Args().First()
+	tag := context.Args().Get(1)
+	if dropletRef == "" || tag == "" {
+		factory.UI.SayIncorrectUsage("<droplet-name>@<version> and <tag> are required")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.ensureBlobStoreVerified() {
+		return
+	}
+
+	if err := factory.dropletRunner.TagDroplet(dropletRef, tag); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error tagging %s: %s", dropletRef, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	factory.UI.SayLine(fmt.Sprintf("Tagged %s as %s", dropletRef, tag))
+}
+
 func (factory *DropletRunnerCommandFactory) ensureBlobStoreVerified() bool {
-	authorized, err := factory.blobStoreVerifier.Verify(factory.config)
+	return factory.verifyBlobStore(factory.config, "droplet store")
+}
+
+func (factory *DropletRunnerCommandFactory) verifyBlobStore(config *config_package.Config, description string) bool {
+	authorized, err := factory.blobStoreVerifier.Verify(config)
 	if err != nil {
-		factory.UI.SayLine("Error verifying droplet store: " + err.Error())
+		factory.UI.SayLine("Error verifying " + description + ": " + err.Error())
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
 		return false
 	}
 	if !authorized {
-		factory.UI.SayLine("Error verifying droplet store: unauthorized")
+		factory.UI.SayLine("Error verifying " + description + ": unauthorized")
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
 		return false
 	}
@@ -345,31 +974,45 @@ func (factory *DropletRunnerCommandFactory) ensureBlobStoreVerified() bool {
 
 func (factory *DropletRunnerCommandFactory) buildDroplet(context *cli.Context) {
 	pathFlag := context.String("path")
+	buildpackPathFlag := context.String("buildpack-path")
 	cpuWeightFlag := context.Int("cpu-weight")
 	memoryMBFlag := context.Int("memory-mb")
 	diskMBFlag := context.Int("disk-mb")
 	envFlag := context.StringSlice("env")
 	timeoutFlag := context.Duration("timeout")
+	noCacheFlag := context.Bool("no-cache")
 	dropletName := context.Args().First()
 	buildpack := context.Args().Get(1)
 
-	if dropletName == "" || buildpack == "" {
+	if dropletName == "" || (buildpack == "" && buildpackPathFlag == "") {
 		factory.UI.SayIncorrectUsage("<droplet-name> and <buildpack-uri> are required")
 		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
 
-	var buildpackUrl string
-	if knownBuildpackUrl, ok := knownBuildpacks[buildpack]; ok {
-		buildpackUrl = knownBuildpackUrl
-	} else if _, err := url.ParseRequestURI(buildpack); err == nil {
-		buildpackUrl = buildpack
-	} else {
-		factory.UI.SayIncorrectUsage(fmt.Sprintf("invalid buildpack %s", buildpack))
+	if buildpack != "" && buildpackPathFlag != "" {
+		factory.UI.SayIncorrectUsage("<buildpack-uri> can't be given with --buildpack-path")
 		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
 		return
 	}
 
+	if strings.Contains(dropletName, "@") {
+		factory.UI.SayIncorrectUsage("<droplet-name> cannot contain @")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	var buildpackUrls []string
+	if buildpack != "" {
+		var err error
+		buildpackUrls, err = resolveBuildpacks(buildpack)
+		if err != nil {
+			factory.UI.SayIncorrectUsage(err.Error())
+			factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+	}
+
 	if cpuWeightFlag < 1 || cpuWeightFlag > 100 {
 		factory.UI.SayIncorrectUsage("invalid CPU Weight")
 		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
@@ -380,97 +1023,211 @@ func (factory *DropletRunnerCommandFactory) buildDroplet(context *cli.Context) {
 		return
 	}
 
-	var archivePath string
-	var err error
-
-	if factory.zipper.IsZipFile(pathFlag) {
-		tmpDir, err := ioutil.TempDir("", "rezip")
-		if err != nil {
-			factory.UI.SayLine(fmt.Sprintf("Error re-archiving %s: %s", pathFlag, err))
-			factory.ExitHandler.Exit(exit_codes.FileSystemError)
+	if buildpackPathFlag != "" {
+		buildpackName, ok := factory.uploadBuildpack(buildpackPathFlag)
+		if !ok {
 			return
 		}
-		defer os.RemoveAll(tmpDir)
+		buildpackUrls = []string{buildpackName}
+	}
 
-		if err := factory.zipper.Unzip(pathFlag, tmpDir); err != nil {
-			factory.UI.SayLine(fmt.Sprintf("Error unarchiving %s: %s", pathFlag, err))
-			factory.ExitHandler.Exit(exit_codes.FileSystemError)
-			return
-		}
+	archivePath, ok := factory.archiveBits(pathFlag)
+	if !ok {
+		return
+	}
+	defer os.Remove(archivePath)
+
+	environment := factory.AppRunnerCommandFactory.BuildEnvironment(envFlag)
+	factory.runBuild(dropletName, buildpackUrls, archivePath, environment, memoryMBFlag, cpuWeightFlag, diskMBFlag, !noCacheFlag, timeoutFlag)
+}
 
-		archivePath, err = factory.zipper.Zip(tmpDir, factory.cfIgnore)
+// uploadBuildpack stores the buildpack directory or .zip at path for builds
+// to use, returning the name to build with.
+func (factory *DropletRunnerCommandFactory) uploadBuildpack(path string) (string, bool) {
+	zipPath := path
+	if !factory.zipper.IsZipFile(path) {
+		archivePath, err := factory.zipper.Zip(path, cf_ignore.New())
 		if err != nil {
-			factory.UI.SayLine(fmt.Sprintf("Error re-archiving %s: %s", pathFlag, err))
+			factory.UI.SayLine(fmt.Sprintf("Error archiving buildpack %s: %s", path, err))
 			factory.ExitHandler.Exit(exit_codes.FileSystemError)
-			return
+			return "", false
 		}
 		defer os.Remove(archivePath)
-	} else {
-		archivePath, err = factory.zipper.Zip(pathFlag, factory.cfIgnore)
+		zipPath = archivePath
+	}
+
+	factory.UI.SayLine("Uploading buildpack...")
+
+	progress := terminal.NewProgress(factory.UI)
+	buildpackName, err := factory.dropletRunner.UploadBuildpack(zipPath, progress.Update)
+	progress.Done()
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error uploading buildpack %s: %s", path, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return "", false
+	}
+
+	factory.UI.SayLine("Uploaded.")
+
+	return buildpackName, true
+}
+
+// resolveBuildpacks returns the URLs of a comma-separated list of known
+// buildpack names and buildpack URLs, or of every known buildpack in
+// detection order for auto-detection.
+func resolveBuildpacks(buildpacks string) ([]string, error) {
+	buildpackUrls := []string{}
+
+	if buildpacks == autoDetectBuildpacks {
+		for _, name := range autoDetectOrder {
+			buildpackUrls = append(buildpackUrls, knownBuildpacks[name])
+		}
+		return buildpackUrls, nil
+	}
+
+	for _, buildpack := range strings.Split(buildpacks, ",") {
+		if knownBuildpackUrl, ok := knownBuildpacks[buildpack]; ok {
+			buildpackUrls = append(buildpackUrls, knownBuildpackUrl)
+		} else if _, err := url.ParseRequestURI(buildpack); err == nil {
+			buildpackUrls = append(buildpackUrls, buildpack)
+		} else {
+			return nil, fmt.Errorf("invalid buildpack %s", buildpack)
+		}
+	}
+
+	return buildpackUrls, nil
+}
+
+// archiveBits zips the app bits at path, re-archiving a .zip so that
+// .cfignore applies to its contents as well.
+func (factory *DropletRunnerCommandFactory) archiveBits(path string) (string, bool) {
+	if !factory.zipper.IsZipFile(path) {
+		archivePath, err := factory.zipper.Zip(path, factory.cfIgnore)
 		if err != nil {
-			factory.UI.SayLine(fmt.Sprintf("Error archiving %s: %s", pathFlag, err))
+			factory.UI.SayLine(fmt.Sprintf("Error archiving %s: %s", path, err))
 			factory.ExitHandler.Exit(exit_codes.FileSystemError)
-			return
+			return "", false
 		}
-		defer os.Remove(archivePath)
+		return archivePath, true
+	}
+
+	tmpDir, err := ioutil.TempDir("", "rezip")
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error re-archiving %s: %s", path, err))
+		factory.ExitHandler.Exit(exit_codes.FileSystemError)
+		return "", false
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := factory.zipper.Unzip(path, tmpDir); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error unarchiving %s: %s", path, err))
+		factory.ExitHandler.Exit(exit_codes.FileSystemError)
+		return "", false
+	}
+
+	archivePath, err := factory.zipper.Zip(tmpDir, factory.cfIgnore)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error re-archiving %s: %s", path, err))
+		factory.ExitHandler.Exit(exit_codes.FileSystemError)
+		return "", false
+	}
+	return archivePath, true
+}
+
+// runBuild uploads the archived bits as a new version of the droplet, builds
+// it and tags it latest, reporting whether the build completed.
+func (factory *DropletRunnerCommandFactory) runBuild(dropletName string, buildpackUrls []string, archivePath string, environment map[string]string, memoryMB, cpuWeight, diskMB int, useBuildCache bool, timeout time.Duration) bool {
+	dropletVersion, err := factory.dropletRunner.NewDropletVersion(dropletName)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error versioning %s: %s", dropletName, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return false
 	}
 
 	factory.UI.SayLine("Uploading application bits...")
 
-	if err := factory.dropletRunner.UploadBits(dropletName, archivePath); err != nil {
+	progress := terminal.NewProgress(factory.UI)
+	err = factory.dropletRunner.UploadBits(dropletVersion, archivePath, progress.Update)
+	progress.Done()
+	if err != nil {
 		factory.UI.SayLine(fmt.Sprintf("Error uploading %s: %s", dropletName, err))
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
-		return
+		return false
 	}
 
 	factory.UI.SayLine("Uploaded.")
 
-	environment := factory.AppRunnerCommandFactory.BuildEnvironment(envFlag)
+	environmentKeys := []string{}
+	for key := range environment {
+		environmentKeys = append(environmentKeys, key)
+	}
 
-	taskName := "build-droplet-" + dropletName
-	if err := factory.dropletRunner.BuildDroplet(taskName, dropletName, buildpackUrl, environment, memoryMBFlag, cpuWeightFlag, diskMBFlag); err != nil {
+	taskName := droplet_runner.BuildTaskGuid(dropletVersion)
+	if err := factory.dropletRunner.BuildDroplet(taskName, dropletVersion, buildpackUrls, environment, memoryMB, cpuWeight, diskMB, useBuildCache); err != nil {
 		factory.UI.SayLine(fmt.Sprintf("Error submitting build of %s: %s", dropletName, err))
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
-		return
+		return false
 	}
 
-	factory.UI.SayLine("Submitted build of " + dropletName)
+	factory.UI.SayLine("Submitted build of " + dropletVersion)
 
 	go factory.TailedLogsOutputter.OutputTailedLogs(taskName)
 	defer factory.TailedLogsOutputter.StopOutputting()
 
-	ok, taskState := factory.waitForBuildTask(timeoutFlag, taskName)
-	if ok {
-		if taskState.Failed {
-			factory.UI.SayLine("Build failed: " + taskState.FailureReason)
-			factory.ExitHandler.Exit(exit_codes.CommandFailed)
-		} else {
-			factory.UI.SayLine("Build completed")
-		}
-	} else {
+	ok, taskState, err := factory.waitForBuildTask(timeout, taskName)
+	if err != nil {
+		factory.UI.SayLine(colors.Red(fmt.Sprintf("Error requesting task status: %s", err)))
+		factory.UI.SayLine(fmt.Sprintf("To view status:\n\tltc status %s", taskName))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return false
+	}
+	if !ok {
 		factory.UI.SayLine(colors.Red("Timed out waiting for the build to complete."))
 		factory.UI.SayLine("Lattice is still building your application in the background.")
 
 		factory.UI.SayLine(fmt.Sprintf("To view logs:\n\tltc logs %s", taskName))
 		factory.UI.SayLine(fmt.Sprintf("To view status:\n\tltc status %s", taskName))
+		factory.UI.SayLine(fmt.Sprintf("To launch it by name once built:\n\tltc tag-droplet %s %s", dropletVersion, droplet_runner.LatestTag))
 		factory.UI.SayNewLine()
+		return false
+	}
+
+	if taskState.Failed {
+		factory.UI.SayLine("Build failed: " + taskState.FailureReason)
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return false
+	}
+
+	factory.UI.SayLine("Build completed")
+
+	if staging, err := droplet_runner.ParseStagingResult(taskState.Result); err == nil && staging.DetectedBuildpack != "" {
+		factory.UI.SayLine("Detected buildpack: " + staging.DetectedBuildpack)
+	}
+
+	if err := factory.dropletRunner.RecordBuild(dropletVersion, buildpackUrls, environmentKeys, taskState.Result); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error recording droplet metadata: %s", err))
 	}
+
+	factory.tagLatest(dropletVersion)
+	return true
 }
 
-func (factory *DropletRunnerCommandFactory) waitForBuildTask(pollTimeout time.Duration, taskName string) (bool, task_examiner.TaskInfo) {
+// waitForBuildTask polls the build task until it finishes or pollTimeout
+// passes.  An error requesting the task's status ends the wait, since the
+// outcome of the build is then unknown.
+func (factory *DropletRunnerCommandFactory) waitForBuildTask(pollTimeout time.Duration, taskName string) (bool, task_examiner.TaskInfo, error) {
 	var taskInfo task_examiner.TaskInfo
+	var err error
 	ok := factory.pollUntilSuccess(pollTimeout, func() bool {
-		var err error
 		taskInfo, err = factory.taskExaminer.TaskStatus(taskName)
 		if err != nil {
-			factory.UI.SayLine(colors.Red("Error requesting task status: %s"), err)
 			return true
 		}
 
 		return taskInfo.State != "RUNNING" && taskInfo.State != "PENDING"
 	})
 
-	return ok, taskInfo
+	return ok, taskInfo, err
 }
 
 func (factory *DropletRunnerCommandFactory) pollUntilSuccess(pollTimeout time.Duration, pollingFunc func() bool) (ok bool) {
@@ -499,6 +1256,7 @@ func (factory *DropletRunnerCommandFactory) launchDroplet(context *cli.Context)
 	httpRouteFlag := context.StringSlice("http-route")
 	tcpRouteFlag := context.StringSlice("tcp-route")
 	noRoutesFlag := context.Bool("no-routes")
+	processFlag := context.String("process")
 	timeoutFlag := context.Duration("timeout")
 	appName := context.Args().Get(0)
 	dropletName := context.Args().Get(1)
@@ -516,6 +1274,10 @@ func (factory *DropletRunnerCommandFactory) launchDroplet(context *cli.Context)
 		factory.UI.SayIncorrectUsage("'--' Required before start command")
 		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
 		return
+	case startCommand != "" && processFlag != "":
+		factory.UI.SayIncorrectUsage("--process can't be used with a start command")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
 	case len(context.Args()) > 4:
 		startArgs = context.Args()[4:]
 	case cpuWeightFlag < 1 || cpuWeightFlag > 100:
@@ -583,6 +1345,15 @@ func (factory *DropletRunnerCommandFactory) launchDroplet(context *cli.Context)
 
 	appEnvironmentParams.EnvironmentVariables["MEMORY_LIMIT"] = fmt.Sprintf("%dM", memoryMBFlag)
 
+	if processFlag != "" {
+		startCommand, err = factory.dropletRunner.ProcessCommand(dropletName, processFlag)
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Error launching app %s from droplet %s: %s", appName, dropletName, err))
+			factory.ExitHandler.Exit(exit_codes.CommandFailed)
+			return
+		}
+	}
+
 	if err := factory.dropletRunner.LaunchDroplet(appName, dropletName, startCommand, startArgs, appEnvironmentParams); err != nil {
 		factory.UI.SayLine(fmt.Sprintf("Error launching app %s from droplet %s: %s", appName, dropletName, err))
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
@@ -592,6 +1363,220 @@ func (factory *DropletRunnerCommandFactory) launchDroplet(context *cli.Context)
 	factory.WaitForAppCreation(appName, timeoutFlag, instancesFlag)
 }
 
+func (factory *DropletRunnerCommandFactory) push(context *cli.Context) {
+	manifestFlag := context.String("manifest")
+	varFlag := context.StringSlice("var")
+	varsFileFlag := context.String("vars-file")
+	timeoutFlag := context.Duration("timeout")
+	noCacheFlag := context.Bool("no-cache")
+	appNames := context.Args()
+
+	vars := map[string]interface{}{}
+	if varsFileFlag != "" {
+		varsYAML, err := ioutil.ReadFile(varsFileFlag)
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Error reading vars file %s: %s", varsFileFlag, err))
+			factory.ExitHandler.Exit(exit_codes.FileSystemError)
+			return
+		}
+
+		vars, err = app_manifest.ParseVarsFile(varsYAML)
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Error parsing vars file %s: %s", varsFileFlag, err))
+			factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+	}
+
+	for _, variable := range varFlag {
+		name, value, err := app_manifest.ParseVar(variable)
+		if err != nil {
+			factory.UI.SayIncorrectUsage(err.Error())
+			factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		vars[name] = value
+	}
+
+	manifestYAML, err := ioutil.ReadFile(manifestFlag)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error reading manifest %s: %s", manifestFlag, err))
+		factory.ExitHandler.Exit(exit_codes.FileSystemError)
+		return
+	}
+
+	applications, err := app_manifest.Parse(manifestYAML, filepath.Dir(manifestFlag), vars)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error parsing manifest %s: %s", manifestFlag, err))
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if len(appNames) > 0 {
+		selected := []app_manifest.Application{}
+		for _, appName := range appNames {
+			found := false
+			for _, application := range applications {
+				if application.Name == appName {
+					selected = append(selected, application)
+					found = true
+				}
+			}
+			if !found {
+				factory.UI.SayLine(fmt.Sprintf("App %s is not in manifest %s", appName, manifestFlag))
+				factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+				return
+			}
+		}
+		applications = selected
+	}
+
+	buildpackUrls := map[string][]string{}
+	routeOverrides := map[string]app_runner.RouteOverrides{}
+	for _, application := range applications {
+		if strings.Contains(application.Name, "@") {
+			factory.UI.SayLine(fmt.Sprintf("Invalid app %s: app names cannot contain @", application.Name))
+			factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+
+		appRouteOverrides, err := factory.routeOverrides(application.Routes)
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Invalid app %s: %s", application.Name, err))
+			factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		routeOverrides[application.Name] = appRouteOverrides
+
+		buildpacks := autoDetectBuildpacks
+		if len(application.Buildpacks) > 0 {
+			buildpacks = strings.Join(application.Buildpacks, ",")
+		}
+
+		appBuildpackUrls, err := resolveBuildpacks(buildpacks)
+		if err != nil {
+			factory.UI.SayLine(fmt.Sprintf("Invalid app %s: %s", application.Name, err))
+			factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+			return
+		}
+		buildpackUrls[application.Name] = appBuildpackUrls
+	}
+
+	if !factory.ensureBlobStoreVerified() {
+		return
+	}
+
+	for _, application := range applications {
+		if !factory.pushApplication(application, buildpackUrls[application.Name], routeOverrides[application.Name], !noCacheFlag, timeoutFlag) {
+			return
+		}
+	}
+}
+
+// pushApplication builds the app of a manifest into the droplet of the same
+// name, then launches it or replaces the running app.
+func (factory *DropletRunnerCommandFactory) pushApplication(application app_manifest.Application, buildpackUrls []string, routeOverrides app_runner.RouteOverrides, useBuildCache bool, timeout time.Duration) bool {
+	factory.UI.SayLine("Pushing " + application.Name + "...")
+
+	archivePath, ok := factory.archiveBits(application.Path)
+	if !ok {
+		return false
+	}
+	defer os.Remove(archivePath)
+
+	buildMemoryMB := application.MemoryMB
+	if buildMemoryMB == 0 {
+		buildMemoryMB = 1024
+	}
+
+	buildEnvironment := map[string]string{}
+	for name, value := range application.Env {
+		buildEnvironment[name] = value
+	}
+
+	if !factory.runBuild(application.Name, buildpackUrls, archivePath, buildEnvironment, buildMemoryMB, 100, application.DiskMB, useBuildCache, timeout) {
+		return false
+	}
+
+	memoryMB := application.MemoryMB
+	if memoryMB == 0 {
+		memoryMB = 128
+	}
+
+	exposedPorts := []uint16{8080}
+
+	monitorConfig := app_runner.MonitorConfig{
+		Method:  app_runner.PortMonitor,
+		Port:    8080,
+		Timeout: time.Second,
+	}
+	switch application.HealthCheckType {
+	case "http":
+		monitorConfig.Method = app_runner.URLMonitor
+		monitorConfig.URI = application.HealthCheckHTTPEndpoint
+	case "process", "none":
+		monitorConfig = app_runner.MonitorConfig{Method: app_runner.NoMonitor}
+	}
+
+	environment := factory.BuildAppEnvironment([]string{}, application.Name)
+	for name, value := range application.Env {
+		environment[name] = value
+	}
+	environment["MEMORY_LIMIT"] = fmt.Sprintf("%dM", memoryMB)
+
+	appEnvironmentParams := app_runner.AppEnvironmentParams{
+		EnvironmentVariables: environment,
+		Privileged:           false,
+		User:                 "vcap",
+		Monitor:              monitorConfig,
+		Instances:            application.Instances,
+		CPUWeight:            100,
+		MemoryMB:             memoryMB,
+		DiskMB:               application.DiskMB,
+		ExposedPorts:         exposedPorts,
+		RouteOverrides:       routeOverrides,
+		NoRoutes:             application.NoRoute,
+	}
+
+	exists, err := factory.AppExaminer.AppExists(application.Name)
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error checking for app %s: %s", application.Name, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return false
+	}
+
+	if exists {
+		err = factory.dropletRunner.RelaunchDroplet(application.Name, application.Name, application.Command, []string{}, appEnvironmentParams, timeout)
+	} else {
+		err = factory.dropletRunner.LaunchDroplet(application.Name, application.Name, application.Command, []string{}, appEnvironmentParams)
+	}
+	if err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error launching app %s from droplet %s: %s", application.Name, application.Name, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return false
+	}
+
+	factory.WaitForAppCreation(application.Name, timeout, application.Instances)
+	return true
+}
+
+// routeOverrides maps the routes of a manifest to routes of the app.  A route
+// without a domain is a hostname under the system domain.  Routes with a
+// domain must be under the system domain, the only one lattice serves.
+func (factory *DropletRunnerCommandFactory) routeOverrides(routes []string) (app_runner.RouteOverrides, error) {
+	routeOverrides := app_runner.RouteOverrides{}
+	for _, route := range routes {
+		hostname := strings.SplitN(route, "/", 2)[0]
+		if strings.Contains(hostname, ".") && !strings.HasSuffix(hostname, "."+factory.Domain) {
+			return nil, fmt.Errorf("route %s is not under the system domain %s", route, factory.Domain)
+		}
+
+		routeOverrides = append(routeOverrides, app_runner.RouteOverride{HostnamePrefix: route, Port: 8080})
+	}
+
+	return routeOverrides, nil
+}
+
 func (factory *DropletRunnerCommandFactory) removeDroplet(context *cli.Context) {
 	dropletName := context.Args().First()
 	if dropletName == "" {
@@ -610,6 +1595,27 @@ func (factory *DropletRunnerCommandFactory) removeDroplet(context *cli.Context)
 	factory.UI.SayLine("Droplet removed")
 }
 
+func (factory *DropletRunnerCommandFactory) clearBuildCache(context *cli.Context) {
+	dropletName := context.Args().First()
+	if dropletName == "" {
+		factory.UI.SayIncorrectUsage("<droplet-name> is required")
+		factory.ExitHandler.Exit(exit_codes.InvalidSyntax)
+		return
+	}
+
+	if !factory.ensureBlobStoreVerified() {
+		return
+	}
+
+	if err := factory.dropletRunner.ClearBuildCache(dropletName); err != nil {
+		factory.UI.SayLine(fmt.Sprintf("Error clearing build cache of %s: %s", dropletName, err))
+		factory.ExitHandler.Exit(exit_codes.CommandFailed)
+		return
+	}
+
+	factory.UI.SayLine("Build cache cleared")
+}
+
 func (factory *DropletRunnerCommandFactory) exportDroplet(context *cli.Context) {
 	dropletName := context.Args().First()
 	if dropletName == "" {
@@ -628,15 +1634,28 @@ func (factory *DropletRunnerCommandFactory) exportDroplet(context *cli.Context)
 
 	dropletPath := dropletName + ".tgz"
 
-	dropletWriter, err := os.OpenFile(dropletPath, os.O_WRONLY|os.O_CREATE, os.FileMode(0644))
+	// the droplet is moved into place only once all of it has been read and
+	// checked, so a failed export leaves no partial file behind
+	dropletFile, err := ioutil.TempFile(filepath.Dir(dropletPath), filepath.Base(dropletPath)+".tmp")
 	if err != nil {
 		factory.UI.SayLine(fmt.Sprintf("Error exporting droplet '%s' to %s: %s", dropletName, dropletPath, err))
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
 		return
 	}
-	defer dropletWriter.Close()
+	defer os.Remove(dropletFile.Name())
 
-	_, err = io.Copy(dropletWriter, dropletReader)
+	progress := terminal.NewProgress(factory.UI)
+	_, err = io.Copy(dropletFile, blob.NewProgressReader(dropletReader, 0, -1, progress.Update))
+	progress.Done()
+	if closeErr := dropletFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(dropletFile.Name(), 0644)
+	}
+	if err == nil {
+		err = file_helpers.ReplaceFile(dropletFile.Name(), dropletPath)
+	}
 	if err != nil {
 		factory.UI.SayLine(fmt.Sprintf("Error exporting droplet '%s' to %s: %s", dropletName, dropletPath, err))
 		factory.ExitHandler.Exit(exit_codes.CommandFailed)
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -15,8 +16,10 @@ import (
 	"github.com/cloudfoundry-incubator/ltc/app_examiner/fake_app_examiner"
 	"github.com/cloudfoundry-incubator/ltc/app_runner"
 	"github.com/cloudfoundry-incubator/ltc/app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/cf_ignore"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/cf_ignore/fake_cf_ignore"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/fake_blob_store_verifier"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner/command_factory/zipper/fake_zipper"
@@ -87,6 +90,9 @@ var _ = Describe("CommandFactory", func() {
 			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, fakeCFIgnore, fakeZipper, config)
 			buildDropletCommand = commandFactory.MakeBuildDropletCommand()
 			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+			fakeDropletRunner.NewDropletVersionStub = func(dropletName string) (string, error) {
+				return dropletName + "@v1", nil
+			}
 		})
 
 		Context("when the archive path is a folder and exists", func() {
@@ -96,7 +102,7 @@ var _ = Describe("CommandFactory", func() {
 				}
 			})
 
-			It("zips up current working folder and uploads as the droplet name", func() {
+			It("zips up current working folder and uploads as a new version of the droplet", func() {
 				fakeZipper.IsZipFileReturns(false)
 				fakeZipper.ZipReturns("xyz.zip", nil)
 
@@ -108,10 +114,12 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeBlobStoreVerifier.VerifyCallCount()).To(Equal(1))
 				Expect(fakeBlobStoreVerifier.VerifyArgsForCall(0)).To(Equal(config))
 
-				Expect(outputBuffer).To(test_helpers.SayLine("Submitted build of droplet-name"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Submitted build of droplet-name@v1"))
+				Expect(fakeDropletRunner.NewDropletVersionCallCount()).To(Equal(1))
+				Expect(fakeDropletRunner.NewDropletVersionArgsForCall(0)).To(Equal("droplet-name"))
 				Expect(fakeDropletRunner.UploadBitsCallCount()).To(Equal(1))
-				dropletName, uploadPath := fakeDropletRunner.UploadBitsArgsForCall(0)
-				Expect(dropletName).To(Equal("droplet-name"))
+				dropletName, uploadPath, _ := fakeDropletRunner.UploadBitsArgsForCall(0)
+				Expect(dropletName).To(Equal("droplet-name@v1"))
 
 				Expect(uploadPath).NotTo(BeNil())
 				Expect(uploadPath).To(Equal("xyz.zip"))
@@ -121,6 +129,22 @@ var _ = Describe("CommandFactory", func() {
 				Expect(cfIgnore).To(Equal(fakeCFIgnore))
 			})
 
+			It("shows the progress of the upload", func() {
+				fakeZipper.ZipReturns("xyz.zip", nil)
+				fakeDropletRunner.UploadBitsStub = func(_, _ string, progress blob.Progress) error {
+					progress(1024, 2048)
+					progress(2048, 2048)
+					return nil
+				}
+
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "http://some.url/for/buildpack"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Uploading application bits...\n"))
+				Expect(outputBuffer).To(test_helpers.Say("1K of 2K (50%)"))
+				Expect(outputBuffer).To(test_helpers.SayLine("2K of 2K (100%)"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Uploaded."))
+			})
+
 			It("re-zips an existing .zip passed to -p and uploads as the droplet name", func() {
 				fakeZipper.IsZipFileReturns(true)
 				fakeZipper.UnzipReturns(nil)
@@ -144,10 +168,12 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeBlobStoreVerifier.VerifyCallCount()).To(Equal(1))
 				Expect(fakeBlobStoreVerifier.VerifyArgsForCall(0)).To(Equal(config))
 
-				Expect(outputBuffer).To(test_helpers.SayLine("Submitted build of droplet-name"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Submitted build of droplet-name@v1"))
+				Expect(fakeDropletRunner.NewDropletVersionCallCount()).To(Equal(1))
+				Expect(fakeDropletRunner.NewDropletVersionArgsForCall(0)).To(Equal("droplet-name"))
 				Expect(fakeDropletRunner.UploadBitsCallCount()).To(Equal(1))
-				dropletName, uploadPath := fakeDropletRunner.UploadBitsArgsForCall(0)
-				Expect(dropletName).To(Equal("droplet-name"))
+				dropletName, uploadPath, _ := fakeDropletRunner.UploadBitsArgsForCall(0)
+				Expect(dropletName).To(Equal("droplet-name@v1"))
 
 				Expect(uploadPath).NotTo(BeNil())
 				Expect(uploadPath).To(Equal("xyz.zip"))
@@ -166,7 +192,7 @@ var _ = Describe("CommandFactory", func() {
 
 				Expect(outputBuffer).To(test_helpers.Say("Submitted build of droplet-name"))
 
-				_, _, _, envVars, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+				_, _, _, envVars, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
 
 				aaaaVar, found := envVars["AAAA"]
 				Expect(found).To(BeTrue())
@@ -191,59 +217,103 @@ var _ = Describe("CommandFactory", func() {
 
 				Expect(outputBuffer).To(test_helpers.Say("Submitted build of droplet-name"))
 
-				_, _, _, _, mem, cpu, disk := fakeDropletRunner.BuildDropletArgsForCall(0)
+				_, _, _, _, mem, cpu, disk, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
 				Expect(cpu).To(Equal(75))
 				Expect(mem).To(Equal(512))
 				Expect(disk).To(Equal(800))
 			})
 
+			It("uses the build cache unless --no-cache is given", func() {
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "http://some.url/for/buildpack"})
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"--no-cache", "droplet-name", "http://some.url/for/buildpack"})
+
+				Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(2))
+				_, _, _, _, _, _, _, useBuildCache := fakeDropletRunner.BuildDropletArgsForCall(0)
+				Expect(useBuildCache).To(BeTrue())
+				_, _, _, _, _, _, _, useBuildCache = fakeDropletRunner.BuildDropletArgsForCall(1)
+				Expect(useBuildCache).To(BeFalse())
+			})
+
 			Describe("buildpack aliases", func() {
 				It("uses the correct buildpack URL for go", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "go"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/go-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/go-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for java", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "java"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/java-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/java-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for python", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "python"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/python-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/python-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for ruby", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "ruby"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/ruby-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/ruby-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for nodejs", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "nodejs"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/nodejs-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/nodejs-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for php", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "php"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/php-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/php-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for binary", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "binary"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/binary-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/binary-buildpack.git"}))
 				})
 
 				It("uses the correct buildpack URL for staticfile", func() {
 					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "staticfile"})
-					_, _, buildpackUrl, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
-					Expect(buildpackUrl).To(Equal("https://github.com/cloudfoundry/staticfile-buildpack.git"))
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/staticfile-buildpack.git"}))
+				})
+
+				It("passes several buildpacks in the given order", func() {
+					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "nodejs,http://some.url/for/buildpack,go"})
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{
+						"https://github.com/cloudfoundry/nodejs-buildpack.git",
+						"http://some.url/for/buildpack",
+						"https://github.com/cloudfoundry/go-buildpack.git",
+					}))
+				})
+
+				It("passes every known buildpack for auto-detection", func() {
+					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "auto"})
+					_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+					Expect(buildpackUrls).To(Equal([]string{
+						"https://github.com/cloudfoundry/staticfile-buildpack.git",
+						"https://github.com/cloudfoundry/java-buildpack.git",
+						"https://github.com/cloudfoundry/ruby-buildpack.git",
+						"https://github.com/cloudfoundry/nodejs-buildpack.git",
+						"https://github.com/cloudfoundry/go-buildpack.git",
+						"https://github.com/cloudfoundry/python-buildpack.git",
+						"https://github.com/cloudfoundry/php-buildpack.git",
+						"https://github.com/cloudfoundry/binary-buildpack.git",
+					}))
+				})
+
+				It("rejects a list with an invalid buildpack", func() {
+					test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "ruby,cobol"})
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Incorrect Usage: invalid buildpack cobol"))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+					Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(0))
 				})
 
 				It("rejects unknown buildpack alias or unparseable URL", func() {
@@ -257,6 +327,80 @@ var _ = Describe("CommandFactory", func() {
 			})
 		})
 
+		Context("when a local buildpack is given", func() {
+			BeforeEach(func() {
+				fakeDropletRunner.UploadBuildpackReturns("some-checksum.buildpack.zip", nil)
+			})
+
+			It("zips and uploads a buildpack directory and builds with it", func() {
+				fakeZipper.ZipStub = func(srcDir string, _ cf_ignore.CFIgnore) (string, error) {
+					return srcDir + ".zip", nil
+				}
+
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"--buildpack-path", "my-buildpack", "-p", "my-app", "droplet-name"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Uploading buildpack..."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Uploaded."))
+				Expect(outputBuffer).To(test_helpers.SayLine("Uploading application bits..."))
+
+				Expect(fakeZipper.ZipCallCount()).To(Equal(2))
+				srcDir, cfIgnore := fakeZipper.ZipArgsForCall(0)
+				Expect(srcDir).To(Equal("my-buildpack"))
+				Expect(cfIgnore).NotTo(Equal(fakeCFIgnore))
+
+				Expect(fakeDropletRunner.UploadBuildpackCallCount()).To(Equal(1))
+				buildpackPath, _ := fakeDropletRunner.UploadBuildpackArgsForCall(0)
+				Expect(buildpackPath).To(Equal("my-buildpack.zip"))
+
+				Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(1))
+				_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+				Expect(buildpackUrls).To(Equal([]string{"some-checksum.buildpack.zip"}))
+			})
+
+			It("uploads a buildpack .zip as it is", func() {
+				fakeZipper.IsZipFileStub = func(path string) bool {
+					return path == "my-buildpack.zip"
+				}
+
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"--buildpack-path", "my-buildpack.zip", "droplet-name"})
+
+				Expect(fakeZipper.ZipCallCount()).To(Equal(1))
+				Expect(fakeDropletRunner.UploadBuildpackCallCount()).To(Equal(1))
+				buildpackPath, _ := fakeDropletRunner.UploadBuildpackArgsForCall(0)
+				Expect(buildpackPath).To(Equal("my-buildpack.zip"))
+			})
+
+			It("rejects a buildpack URI given with a local buildpack", func() {
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"--buildpack-path", "my-buildpack", "droplet-name", "ruby"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Incorrect Usage: <buildpack-uri> can't be given with --buildpack-path"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+				Expect(fakeDropletRunner.UploadBuildpackCallCount()).To(BeZero())
+			})
+
+			It("prints an error when the buildpack can't be zipped", func() {
+				fakeZipper.ZipReturns("", errors.New("no such directory"))
+
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"--buildpack-path", "my-buildpack", "droplet-name"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error archiving buildpack my-buildpack: no such directory"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+				Expect(fakeDropletRunner.UploadBuildpackCallCount()).To(BeZero())
+			})
+
+			It("prints an error when the buildpack upload fails", func() {
+				fakeZipper.ZipReturns("my-buildpack.zip", nil)
+				fakeDropletRunner.UploadBuildpackReturns("", errors.New("failed"))
+
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"--buildpack-path", "my-buildpack", "droplet-name"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error uploading buildpack my-buildpack: failed"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				Expect(fakeDropletRunner.UploadBitsCallCount()).To(BeZero())
+				Expect(fakeDropletRunner.BuildDropletCallCount()).To(BeZero())
+			})
+		})
+
 		Context("when the blob store cannot be verified", func() {
 			It("prints the error and stops when verification fails", func() {
 				fakeBlobStoreVerifier.VerifyReturns(false, errors.New("failed"))
@@ -335,6 +479,17 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(0))
 			})
 
+			It("prints the error from versioning the droplet", func() {
+				fakeDropletRunner.NewDropletVersionStub = nil
+				fakeDropletRunner.NewDropletVersionReturns("", errors.New("failed"))
+
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name", "http://some.url/for/buildpack"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error versioning droplet-name: failed"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				Expect(fakeDropletRunner.UploadBitsCallCount()).To(BeZero())
+			})
+
 			It("prints the error from build droplet", func() {
 				fakeDropletRunner.BuildDropletReturns(errors.New("failed"))
 
@@ -357,13 +512,13 @@ var _ = Describe("CommandFactory", func() {
 				}
 				doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
 
-				Eventually(outputBuffer).Should(test_helpers.SayLine("Submitted build of droplet-name"))
+				Eventually(outputBuffer).Should(test_helpers.SayLine("Submitted build of droplet-name@v1"))
 
 				Eventually(fakeTailedLogsOutputter.OutputTailedLogsCallCount).Should(Equal(1))
-				Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("build-droplet-droplet-name"))
+				Expect(fakeTailedLogsOutputter.OutputTailedLogsArgsForCall(0)).To(Equal("build-droplet-droplet-name-v1"))
 
 				Eventually(fakeTaskExaminer.TaskStatusCallCount).Should(Equal(1))
-				Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("build-droplet-droplet-name"))
+				Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("build-droplet-droplet-name-v1"))
 
 				fakeClock.IncrementBySeconds(1)
 				Expect(doneChan).NotTo(BeClosed())
@@ -396,7 +551,7 @@ var _ = Describe("CommandFactory", func() {
 					}
 					doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
 
-					Eventually(outputBuffer).Should(test_helpers.SayLine("Submitted build of droppo-the-clown"))
+					Eventually(outputBuffer).Should(test_helpers.SayLine("Submitted build of droppo-the-clown@v1"))
 
 					fakeClock.IncrementBySeconds(17)
 
@@ -405,13 +560,86 @@ var _ = Describe("CommandFactory", func() {
 					Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Timed out waiting for the build to complete.")))
 					Expect(outputBuffer).To(test_helpers.SayLine("Lattice is still building your application in the background."))
 					Expect(outputBuffer).To(test_helpers.SayLine("To view logs:"))
-					Expect(outputBuffer).To(test_helpers.SayLine("ltc logs build-droplet-droppo-the-clown"))
+					Expect(outputBuffer).To(test_helpers.SayLine("ltc logs build-droplet-droppo-the-clown-v1"))
 					Expect(outputBuffer).To(test_helpers.SayLine("To view status:"))
-					Expect(outputBuffer).To(test_helpers.SayLine("ltc status build-droplet-droppo-the-clown"))
+					Expect(outputBuffer).To(test_helpers.SayLine("ltc status build-droplet-droppo-the-clown-v1"))
+					Expect(outputBuffer).To(test_helpers.SayLine("To launch it by name once built:"))
+					Expect(outputBuffer).To(test_helpers.SayLine("ltc tag-droplet droppo-the-clown@v1 latest"))
+					Expect(fakeDropletRunner.TagDropletCallCount()).To(BeZero())
 				})
 			})
 
 			Context("when the build completes", func() {
+				It("records the droplet metadata from the staging result", func() {
+					fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{
+						State:  "COMPLETED",
+						Result: `{"detected_buildpack":"Ruby"}`,
+					}, nil)
+
+					args := []string{"droppo-the-clown", "http://some.url/for/buildpack", "-e", "AAAA=1"}
+					doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
+
+					fakeClock.IncrementBySeconds(1)
+
+					Eventually(doneChan, 3).Should(BeClosed())
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Build completed"))
+					Expect(outputBuffer).To(test_helpers.SayLine("Detected buildpack: Ruby"))
+					Expect(fakeDropletRunner.RecordBuildCallCount()).To(Equal(1))
+					dropletName, buildpackURLs, environmentKeys, result := fakeDropletRunner.RecordBuildArgsForCall(0)
+					Expect(dropletName).To(Equal("droppo-the-clown@v1"))
+					Expect(buildpackURLs).To(Equal([]string{"http://some.url/for/buildpack"}))
+					Expect(environmentKeys).To(Equal([]string{"AAAA"}))
+					Expect(result).To(Equal(`{"detected_buildpack":"Ruby"}`))
+				})
+
+				It("tags the new version as latest", func() {
+					fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED"}, nil)
+
+					args := []string{"droppo-the-clown", "http://some.url/for/buildpack"}
+					doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
+
+					fakeClock.IncrementBySeconds(1)
+
+					Eventually(doneChan, 3).Should(BeClosed())
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Tagged droppo-the-clown@v1 as latest"))
+					Expect(fakeDropletRunner.TagDropletCallCount()).To(Equal(1))
+					dropletRef, tag := fakeDropletRunner.TagDropletArgsForCall(0)
+					Expect(dropletRef).To(Equal("droppo-the-clown@v1"))
+					Expect(tag).To(Equal("latest"))
+				})
+
+				It("prints an error when tagging the new version fails", func() {
+					fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED"}, nil)
+					fakeDropletRunner.TagDropletReturns(errors.New("failed"))
+
+					args := []string{"droppo-the-clown", "http://some.url/for/buildpack"}
+					doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
+
+					fakeClock.IncrementBySeconds(1)
+
+					Eventually(doneChan, 3).Should(BeClosed())
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Error tagging droppo-the-clown@v1: failed"))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				})
+
+				It("prints an error when recording the droplet metadata fails", func() {
+					fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED"}, nil)
+					fakeDropletRunner.RecordBuildReturns(errors.New("failed"))
+
+					args := []string{"droppo-the-clown", "http://some.url/for/buildpack"}
+					doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
+
+					fakeClock.IncrementBySeconds(1)
+
+					Eventually(doneChan, 3).Should(BeClosed())
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Build completed"))
+					Expect(outputBuffer).To(test_helpers.SayLine("Error recording droplet metadata: failed"))
+				})
+
 				It("alerts the user of a complete but failed build", func() {
 					fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "PENDING"}, nil)
 
@@ -443,20 +671,21 @@ var _ = Describe("CommandFactory", func() {
 					}
 					doneChan := test_helpers.AsyncExecuteCommandWithArgs(buildDropletCommand, args)
 
-					Eventually(outputBuffer).Should(test_helpers.SayLine("Submitted build of droppo-the-clown"))
+					Eventually(outputBuffer).Should(test_helpers.SayLine("Submitted build of droppo-the-clown@v1"))
 
-					Eventually(fakeTaskExaminer.TaskStatusCallCount).Should(Equal(1))
-					Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("build-droplet-droppo-the-clown"))
-
-					fakeClock.IncrementBySeconds(1)
-					Expect(fakeExitHandler.ExitCalledWith).To(BeEmpty())
-
-					fakeClock.IncrementBySeconds(1)
 					Eventually(doneChan, 3).Should(BeClosed())
 
+					Expect(fakeTaskExaminer.TaskStatusCallCount()).To(Equal(1))
+					Expect(fakeTaskExaminer.TaskStatusArgsForCall(0)).To(Equal("build-droplet-droppo-the-clown-v1"))
+
 					Expect(outputBuffer).To(test_helpers.SayLine(colors.Red("Error requesting task status: dropped the ball")))
+					Expect(outputBuffer).NotTo(test_helpers.SayLine("Build completed"))
 					Expect(outputBuffer).NotTo(test_helpers.SayLine("Timed out waiting for the build to complete."))
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 					Expect(fakeTailedLogsOutputter.StopOutputtingCallCount()).To(Equal(1))
+
+					Expect(fakeDropletRunner.RecordBuildCallCount()).To(BeZero())
+					Expect(fakeDropletRunner.TagDropletCallCount()).To(BeZero())
 				})
 			})
 		})
@@ -481,6 +710,14 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 
+			It("rejects a droplet name containing @", func() {
+				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"droplet-name@v2", "java"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Incorrect Usage: <droplet-name> cannot contain @"))
+				Expect(fakeDropletRunner.UploadBitsCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
 			It("validates cpuWeight is between 1 and 100", func() {
 				test_helpers.ExecuteCommandWithArgs(buildDropletCommand, []string{"-c", "9999", "droplet-name", "java"})
 
@@ -538,6 +775,84 @@ var _ = Describe("CommandFactory", func() {
 			Expect(outputBuffer).To(test_helpers.SayLine("drop-a\t\t12/31 14:33:52.00\t789M"))
 		})
 
+		Context("when --columns is passed", func() {
+			BeforeEach(func() {
+				fakeDropletRunner.ListDropletsReturns([]droplet_runner.Droplet{
+					{Name: "drop-a", Created: time.Date(2014, 12, 31, 8, 22, 44, 0, time.Local), Size: 789 * 1024 * 1024},
+					{Name: "drop-b", Created: time.Date(2015, 6, 15, 16, 11, 33, 0, time.Local), Size: 456 * 1024},
+				}, nil)
+			})
+
+			It("shows the droplet metadata", func() {
+				fakeDropletRunner.ListDropletMetadataReturns(map[string]droplet_runner.DropletMetadata{
+					"drop-b": {DetectedBuildpack: "Ruby", Builder: "someone@somewhere"},
+				}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(listDropletsCommand, []string{"--columns", "detected-buildpack,builder"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Droplet\t\tCreated At\t\tSize\t\tDetected Buildpack\tBuilder"))
+				Expect(outputBuffer).To(test_helpers.SayLine("drop-b\t\t06/15 16:11:33.00\t456K\t\tRuby\t\t\tsomeone@somewhere"))
+				Expect(outputBuffer).To(test_helpers.SayLine("drop-a\t\t12/31 08:22:44.00\t789M\t\t-\t\t\t-"))
+			})
+
+			It("prints an error when the metadata cannot be read", func() {
+				fakeDropletRunner.ListDropletMetadataReturns(nil, errors.New("failed"))
+
+				test_helpers.ExecuteCommandWithArgs(listDropletsCommand, []string{"--columns", "builder"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error listing droplet metadata: failed"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+
+			It("prints incorrect usage for an unknown column", func() {
+				test_helpers.ExecuteCommandWithArgs(listDropletsCommand, []string{"--columns", "builder,colour"})
+
+				Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+				Expect(outputBuffer).To(test_helpers.Say("unknown column colour"))
+				Expect(fakeDropletRunner.ListDropletsCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+
+		Context("when --checksums is passed", func() {
+			BeforeEach(func() {
+				fakeDropletRunner.ListDropletsReturns([]droplet_runner.Droplet{
+					{Name: "drop-a", Created: time.Date(2014, 12, 31, 8, 22, 44, 0, time.Local), Size: 789 * 1024 * 1024},
+					{Name: "drop-b", Created: time.Date(2015, 6, 15, 16, 11, 33, 0, time.Local), Size: 456 * 1024},
+				}, nil)
+			})
+
+			It("shows the stored checksums", func() {
+				fakeDropletRunner.DropletChecksumsReturns(map[string]string{"drop-b": "some-checksum"}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(listDropletsCommand, []string{"--checksums"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Droplet\t\tCreated At\t\tSize\t\tSHA-256"))
+				Expect(outputBuffer).To(test_helpers.SayLine("drop-b\t\t06/15 16:11:33.00\t456K\t\tsome-checksum"))
+				Expect(outputBuffer).To(test_helpers.SayLine("drop-a\t\t12/31 08:22:44.00\t789M\t\t-"))
+			})
+
+			It("shows the checksums of versioned droplets", func() {
+				fakeDropletRunner.ListDropletsReturns([]droplet_runner.Droplet{
+					{Name: "drop-a", Version: "v3", Created: time.Date(2014, 12, 31, 8, 22, 44, 0, time.Local), Size: 789 * 1024 * 1024},
+				}, nil)
+				fakeDropletRunner.DropletChecksumsReturns(map[string]string{"drop-a@v3": "some-checksum"}, nil)
+
+				test_helpers.ExecuteCommandWithArgs(listDropletsCommand, []string{"--checksums"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("drop-a@v3\t12/31 08:22:44.00\t789M\t\tsome-checksum"))
+			})
+
+			It("prints an error when the checksums cannot be read", func() {
+				fakeDropletRunner.DropletChecksumsReturns(nil, errors.New("failed"))
+
+				test_helpers.ExecuteCommandWithArgs(listDropletsCommand, []string{"--checksums"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error listing droplet checksums: failed"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+
 		Context("when the droplet runner returns errors", func() {
 			It("prints an error", func() {
 				fakeDropletRunner.ListDropletsReturns(nil, errors.New("failed"))
@@ -791,6 +1106,35 @@ var _ = Describe("CommandFactory", func() {
 				"MEMORY_LIMIT": "128M",
 			}))
 			Expect(appEnvParam.RouteOverrides).To(BeNil())
+			Expect(fakeDropletRunner.ProcessCommandCallCount()).To(BeZero())
+		})
+
+		It("launches the start command of the given process type", func() {
+			fakeDropletRunner.ProcessCommandReturns("bundle exec sidekiq", nil)
+			fakeAppExaminer.RunningAppInstancesInfoReturns(1, false, nil)
+
+			test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"--process", "worker", "--no-monitor", "droppy", "droplet-name@v2"})
+
+			Expect(fakeDropletRunner.ProcessCommandCallCount()).To(Equal(1))
+			dropletRef, processType := fakeDropletRunner.ProcessCommandArgsForCall(0)
+			Expect(dropletRef).To(Equal("droplet-name@v2"))
+			Expect(processType).To(Equal("worker"))
+
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(Equal(1))
+			_, _, startCommandParam, _, _ := fakeDropletRunner.LaunchDropletArgsForCall(0)
+			Expect(startCommandParam).To(Equal("bundle exec sidekiq"))
+		})
+
+		Context("when the droplet has no such process type", func() {
+			It("prints an error", func() {
+				fakeDropletRunner.ProcessCommandReturns("", errors.New("droplet droplet-name@v2 has no worker process type"))
+
+				test_helpers.ExecuteCommandWithArgs(launchDropletCommand, []string{"--process", "worker", "droppy", "droplet-name"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error launching app droppy from droplet droplet-name: droplet droplet-name@v2 has no worker process type"))
+				Expect(fakeDropletRunner.LaunchDropletCallCount()).To(BeZero())
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
 		})
 
 		Context("invalid syntax", func() {
@@ -817,6 +1161,22 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
 
+			It("validates that a process type isn't given with a start command", func() {
+				args := []string{
+					"--process",
+					"worker",
+					"cool-web-app",
+					"cool-web-droplet",
+					"--",
+					"start-me-up",
+				}
+				test_helpers.ExecuteCommandWithArgs(launchDropletCommand, args)
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Incorrect Usage: --process can't be used with a start command"))
+				Expect(fakeDropletRunner.LaunchDropletCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+
 			It("validates the CPU weight is in 1-100", func() {
 				args := []string{
 					"cool-app",
@@ -931,6 +1291,423 @@ var _ = Describe("CommandFactory", func() {
 		})
 	})
 
+	Describe("DropletInfoCommand", func() {
+		var dropletInfoCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, nil, fakeZipper, config)
+			dropletInfoCommand = commandFactory.MakeDropletInfoCommand()
+			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+
+			fakeDropletRunner.ResolveDropletReturns("drop-a@v2", nil)
+			fakeDropletRunner.DropletHistoryReturns([]droplet_runner.Droplet{
+				{Name: "drop-a", Version: "v2", Created: time.Date(2015, 6, 15, 16, 11, 33, 0, time.Local), Size: 456 * 1024, Tags: []string{"latest"}},
+				{Name: "drop-a", Version: "v1", Created: time.Date(2014, 12, 31, 8, 22, 44, 0, time.Local), Size: 789 * 1024},
+			}, nil)
+		})
+
+		It("shows how the droplet was built", func() {
+			fakeDropletRunner.DropletMetadataReturns(&droplet_runner.DropletMetadata{
+				BuildpackURLs:     []string{"https://github.com/cloudfoundry/ruby-buildpack.git"},
+				DetectedBuildpack: "Ruby",
+				StartCommand:      "bundle exec rackup",
+				ProcessTypes: map[string]string{
+					"worker": "bundle exec sidekiq",
+					"web":    "bundle exec rackup",
+				},
+				EnvironmentKeys: []string{"AAAA", "BBBB"},
+				SourceChecksum:  "some-checksum",
+				Builder:         "someone@somewhere",
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-a"})
+
+			Expect(fakeDropletRunner.ResolveDropletArgsForCall(0)).To(Equal("drop-a"))
+			Expect(fakeDropletRunner.DropletHistoryArgsForCall(0)).To(Equal("drop-a"))
+			Expect(fakeDropletRunner.DropletMetadataArgsForCall(0)).To(Equal("drop-a@v2"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Droplet\t\t\tdrop-a@v2"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Tags\t\t\tlatest"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Created At\t\t06/15 16:11:33.00"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Size\t\t\t456K"))
+			Expect(outputBuffer).To(test_helpers.Say("Built By"))
+			Expect(outputBuffer).To(test_helpers.Say("someone@somewhere"))
+			Expect(outputBuffer).To(test_helpers.Say("https://github.com/cloudfoundry/ruby-buildpack.git"))
+			Expect(outputBuffer).To(test_helpers.Say("Ruby"))
+			Expect(outputBuffer).To(test_helpers.Say("bundle exec rackup"))
+			Expect(outputBuffer).To(test_helpers.Say("web, worker"))
+			Expect(outputBuffer).To(test_helpers.Say("AAAA, BBBB"))
+			Expect(outputBuffer).To(test_helpers.Say("some-checksum"))
+		})
+
+		It("shows who imported an imported droplet", func() {
+			fakeDropletRunner.DropletMetadataReturns(&droplet_runner.DropletMetadata{
+				Imported:       true,
+				SourceChecksum: "some-checksum",
+				Builder:        "someone@somewhere",
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-a"})
+
+			Expect(outputBuffer).To(test_helpers.Say("Imported By"))
+			Expect(outputBuffer).To(test_helpers.Say("someone@somewhere"))
+			Expect(outputBuffer).NotTo(test_helpers.Say("Buildpack"))
+		})
+
+		It("says when no metadata was recorded", func() {
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-a"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Size\t\t456K"))
+			Expect(outputBuffer).To(test_helpers.SayLine("No metadata was recorded for this droplet."))
+		})
+
+		It("shows the version the reference resolves to", func() {
+			fakeDropletRunner.ResolveDropletReturns("drop-a@v1", nil)
+
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-a@v1"})
+
+			Expect(fakeDropletRunner.ResolveDropletArgsForCall(0)).To(Equal("drop-a@v1"))
+			Expect(fakeDropletRunner.DropletMetadataArgsForCall(0)).To(Equal("drop-a@v1"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Droplet\t\tdrop-a@v1"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Size\t\t789K"))
+		})
+
+		It("prints an error when the droplet doesn't exist", func() {
+			fakeDropletRunner.ResolveDropletReturns("", errors.New("droplet not found: drop-b"))
+
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-b"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error resolving droplet drop-b: droplet not found: drop-b"))
+			Expect(fakeDropletRunner.DropletMetadataCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error when the droplet's versions cannot be listed", func() {
+			fakeDropletRunner.DropletHistoryReturns(nil, errors.New("failed"))
+
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-a"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error listing versions of drop-a: failed"))
+			Expect(fakeDropletRunner.DropletMetadataCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error when the metadata cannot be read", func() {
+			fakeDropletRunner.DropletMetadataReturns(nil, errors.New("failed"))
+
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{"drop-a"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error reading metadata of drop-a@v2: failed"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints incorrect usage without a droplet name", func() {
+			test_helpers.ExecuteCommandWithArgs(dropletInfoCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("DropletHistoryCommand", func() {
+		var dropletHistoryCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, nil, fakeZipper, config)
+			dropletHistoryCommand = commandFactory.MakeDropletHistoryCommand()
+			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+		})
+
+		It("lists the versions of the droplet newest first", func() {
+			fakeDropletRunner.DropletHistoryReturns([]droplet_runner.Droplet{
+				{Name: "drop-a", Version: "v2", Created: time.Date(2015, 6, 15, 16, 11, 33, 0, time.Local), Size: 456 * 1024, Checksum: "0123456789abcdef", Tags: []string{"latest", "stable"}},
+				{Name: "drop-a", Version: "v1", Created: time.Date(2014, 12, 31, 8, 22, 44, 0, time.Local), Size: 789 * 1024 * 1024},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(dropletHistoryCommand, []string{"drop-a"})
+
+			Expect(fakeDropletRunner.DropletHistoryArgsForCall(0)).To(Equal("drop-a"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Version\t\tCreated At\t\tSize\t\tID\t\tTags"))
+			Expect(outputBuffer).To(test_helpers.SayLine("drop-a@v2\t06/15 16:11:33.00\t456K\t\t0123456789ab\tlatest, stable"))
+			Expect(outputBuffer).To(test_helpers.SayLine("drop-a@v1\t12/31 08:22:44.00\t789M\t\t-\t\t"))
+		})
+
+		It("prints an error when the versions cannot be listed", func() {
+			fakeDropletRunner.DropletHistoryReturns(nil, errors.New("droplet not found: drop-a"))
+
+			test_helpers.ExecuteCommandWithArgs(dropletHistoryCommand, []string{"drop-a"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error listing versions of drop-a: droplet not found: drop-a"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints incorrect usage without a droplet name", func() {
+			test_helpers.ExecuteCommandWithArgs(dropletHistoryCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeDropletRunner.DropletHistoryCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("TagDropletCommand", func() {
+		var tagDropletCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, nil, fakeZipper, config)
+			tagDropletCommand = commandFactory.MakeTagDropletCommand()
+			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+		})
+
+		It("points the tag at the droplet version", func() {
+			test_helpers.ExecuteCommandWithArgs(tagDropletCommand, []string{"drop-a@v2", "latest"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Tagged drop-a@v2 as latest"))
+			Expect(fakeDropletRunner.TagDropletCallCount()).To(Equal(1))
+			dropletRef, tag := fakeDropletRunner.TagDropletArgsForCall(0)
+			Expect(dropletRef).To(Equal("drop-a@v2"))
+			Expect(tag).To(Equal("latest"))
+		})
+
+		It("prints an error when tagging fails", func() {
+			fakeDropletRunner.TagDropletReturns(errors.New("invalid tag: v3"))
+
+			test_helpers.ExecuteCommandWithArgs(tagDropletCommand, []string{"drop-a@v2", "v3"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error tagging drop-a@v2: invalid tag: v3"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints incorrect usage without a tag", func() {
+			test_helpers.ExecuteCommandWithArgs(tagDropletCommand, []string{"drop-a@v2"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Incorrect Usage: <droplet-name>@<version> and <tag> are required"))
+			Expect(fakeDropletRunner.TagDropletCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("PushCommand", func() {
+		var (
+			pushCommand  cli.Command
+			tmpDir       string
+			manifestPath string
+		)
+
+		BeforeEach(func() {
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, fakeCFIgnore, fakeZipper, config)
+			pushCommand = commandFactory.MakePushCommand()
+
+			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+			fakeZipper.ZipReturns("xyz.zip", nil)
+			fakeDropletRunner.NewDropletVersionStub = func(dropletName string) (string, error) {
+				return dropletName + "@v1", nil
+			}
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED"}, nil)
+			fakeAppExaminer.RunningAppInstancesInfoReturns(2, false, nil)
+
+			var err error
+			tmpDir, err = ioutil.TempDir("", "push")
+			Expect(err).NotTo(HaveOccurred())
+
+			manifestPath = filepath.Join(tmpDir, "manifest.yml")
+			Expect(ioutil.WriteFile(manifestPath, []byte(`---
+applications:
+- name: web
+  path: src
+  buildpack: ruby
+  command: bundle exec rackup
+  memory: 512M
+  instances: 2
+  env:
+    RACK_ENV: ((env))
+  routes:
+  - route: web-((env))
+  health-check-type: http
+  health-check-http-endpoint: /health
+- name: worker
+  buildpack: http://some.url/for/buildpack
+  no-route: true
+  health-check-type: process
+`), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("builds and launches each app of the manifest", func() {
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"--manifest", manifestPath, "--var", "env=staging"})
+
+			Expect(fakeZipper.ZipCallCount()).To(Equal(2))
+			path, _ := fakeZipper.ZipArgsForCall(0)
+			Expect(path).To(Equal(filepath.Join(tmpDir, "src")))
+			path, _ = fakeZipper.ZipArgsForCall(1)
+			Expect(path).To(Equal(tmpDir))
+
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(2))
+			taskName, dropletName, buildpackUrls, environment, memoryMB, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+			Expect(taskName).To(Equal("build-droplet-web-v1"))
+			Expect(dropletName).To(Equal("web@v1"))
+			Expect(buildpackUrls).To(Equal([]string{"https://github.com/cloudfoundry/ruby-buildpack.git"}))
+			Expect(environment).To(Equal(map[string]string{"RACK_ENV": "staging"}))
+			Expect(memoryMB).To(Equal(512))
+			_, _, buildpackUrls, _, memoryMB, _, _, _ = fakeDropletRunner.BuildDropletArgsForCall(1)
+			Expect(buildpackUrls).To(Equal([]string{"http://some.url/for/buildpack"}))
+			Expect(memoryMB).To(Equal(1024))
+
+			Expect(fakeDropletRunner.TagDropletCallCount()).To(Equal(2))
+
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(Equal(2))
+			appName, dropletRef, startCommand, _, appEnvironmentParams := fakeDropletRunner.LaunchDropletArgsForCall(0)
+			Expect(appName).To(Equal("web"))
+			Expect(dropletRef).To(Equal("web"))
+			Expect(startCommand).To(Equal("bundle exec rackup"))
+			Expect(appEnvironmentParams.Instances).To(Equal(2))
+			Expect(appEnvironmentParams.MemoryMB).To(Equal(512))
+			Expect(appEnvironmentParams.EnvironmentVariables).To(Equal(map[string]string{
+				"PROCESS_GUID": "web",
+				"RACK_ENV":     "staging",
+				"MEMORY_LIMIT": "512M",
+			}))
+			Expect(appEnvironmentParams.RouteOverrides).To(ContainExactly(app_runner.RouteOverrides{
+				{HostnamePrefix: "web-staging", Port: 8080},
+			}))
+			Expect(appEnvironmentParams.Monitor).To(Equal(app_runner.MonitorConfig{
+				Method:  app_runner.URLMonitor,
+				Port:    8080,
+				URI:     "/health",
+				Timeout: time.Second,
+			}))
+
+			appName, _, _, _, appEnvironmentParams = fakeDropletRunner.LaunchDropletArgsForCall(1)
+			Expect(appName).To(Equal("worker"))
+			Expect(appEnvironmentParams.NoRoutes).To(BeTrue())
+			Expect(appEnvironmentParams.MemoryMB).To(Equal(128))
+			Expect(appEnvironmentParams.Monitor).To(Equal(app_runner.MonitorConfig{Method: app_runner.NoMonitor}))
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Pushing web..."))
+			Expect(outputBuffer).To(test_helpers.SayLine("Creating App: web"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Pushing worker..."))
+			Expect(outputBuffer).To(test_helpers.SayLine("Creating App: worker"))
+		})
+
+		It("replaces apps that are already running", func() {
+			fakeAppExaminer.AppExistsReturns(true, nil)
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath, "--var", "env=staging", "--timeout", "5m", "worker"})
+
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(1))
+			Expect(fakeAppExaminer.AppExistsArgsForCall(0)).To(Equal("worker"))
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(BeZero())
+			Expect(fakeDropletRunner.RelaunchDropletCallCount()).To(Equal(1))
+			appName, dropletRef, _, _, _, timeout := fakeDropletRunner.RelaunchDropletArgsForCall(0)
+			Expect(appName).To(Equal("worker"))
+			Expect(dropletRef).To(Equal("worker"))
+			Expect(timeout).To(Equal(5 * time.Minute))
+		})
+
+		It("maps routes to hostnames under the system domain", func() {
+			Expect(ioutil.WriteFile(manifestPath, []byte(`---
+applications:
+- name: web
+  routes:
+  - route: web
+  - route: web.192.168.11.11.xip.io
+  - route: api.web.192.168.11.11.xip.io/v1
+`), 0644)).To(Succeed())
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath})
+
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(Equal(1))
+			_, _, _, _, appEnvironmentParams := fakeDropletRunner.LaunchDropletArgsForCall(0)
+			Expect(appEnvironmentParams.RouteOverrides).To(Equal(app_runner.RouteOverrides{
+				{HostnamePrefix: "web", Port: 8080},
+				{HostnamePrefix: "web.192.168.11.11.xip.io", Port: 8080},
+				{HostnamePrefix: "api.web.192.168.11.11.xip.io/v1", Port: 8080},
+			}))
+		})
+
+		It("prints an error for a route outside the system domain", func() {
+			Expect(ioutil.WriteFile(manifestPath, []byte(`---
+applications:
+- name: web
+  routes:
+  - route: web.example.com
+`), 0644)).To(Succeed())
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Invalid app web: route web.example.com is not under the system domain 192.168.11.11.xip.io"))
+			Expect(fakeBlobStoreVerifier.VerifyCallCount()).To(BeZero())
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("reads variables from a vars file", func() {
+			varsPath := filepath.Join(tmpDir, "vars.yml")
+			Expect(ioutil.WriteFile(varsPath, []byte("env: production\n"), 0644)).To(Succeed())
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath, "--vars-file", varsPath, "web"})
+
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(Equal(1))
+			_, _, _, _, appEnvironmentParams := fakeDropletRunner.LaunchDropletArgsForCall(0)
+			Expect(appEnvironmentParams.EnvironmentVariables).To(HaveKeyWithValue("RACK_ENV", "production"))
+		})
+
+		It("does not launch an app whose build failed", func() {
+			fakeTaskExaminer.TaskStatusReturns(task_examiner.TaskInfo{State: "COMPLETED", Failed: true, FailureReason: "oops"}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath, "--var", "env=staging"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Build failed: oops"))
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(1))
+			Expect(fakeDropletRunner.LaunchDropletCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error when a variable has no value", func() {
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error parsing manifest " + manifestPath + ": no value for variables: env"))
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("prints an error when the manifest can't be read", func() {
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", filepath.Join(tmpDir, "missing.yml")})
+
+			Expect(outputBuffer).To(test_helpers.Say("Error reading manifest"))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.FileSystemError}))
+		})
+
+		It("prints an error for an app that is not in the manifest", func() {
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath, "--var", "env=staging", "api"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("App api is not in manifest " + manifestPath))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("detects the buildpack of an app without one", func() {
+			Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: web\n"), 0644)).To(Succeed())
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath})
+
+			Expect(fakeDropletRunner.BuildDropletCallCount()).To(Equal(1))
+			_, _, buildpackUrls, _, _, _, _, _ := fakeDropletRunner.BuildDropletArgsForCall(0)
+			Expect(buildpackUrls).To(HaveLen(8))
+		})
+
+		It("prints an error for an app with an invalid buildpack", func() {
+			Expect(ioutil.WriteFile(manifestPath, []byte("applications:\n- name: web\n  buildpacks: [ruby, cobol]\n"), 0644)).To(Succeed())
+
+			test_helpers.ExecuteCommandWithArgs(pushCommand, []string{"-f", manifestPath})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Invalid app web: invalid buildpack cobol"))
+			Expect(fakeBlobStoreVerifier.VerifyCallCount()).To(BeZero())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
 	Describe("RemoveDropletCommand", func() {
 		var removeDropletCommand cli.Command
 
@@ -968,6 +1745,44 @@ var _ = Describe("CommandFactory", func() {
 		})
 	})
 
+	Describe("ClearBuildCacheCommand", func() {
+		var clearBuildCacheCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, nil, fakeZipper, config)
+			clearBuildCacheCommand = commandFactory.MakeClearBuildCacheCommand()
+		})
+
+		It("clears the build cache of the droplet", func() {
+			test_helpers.ExecuteCommandWithArgs(clearBuildCacheCommand, []string{"droppo"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Build cache cleared"))
+			Expect(fakeDropletRunner.ClearBuildCacheCallCount()).To(Equal(1))
+			Expect(fakeDropletRunner.ClearBuildCacheArgsForCall(0)).To(Equal("droppo"))
+		})
+
+		Context("when the droplet runner returns errors", func() {
+			It("prints an error", func() {
+				fakeDropletRunner.ClearBuildCacheReturns(errors.New("failed"))
+
+				test_helpers.ExecuteCommandWithArgs(clearBuildCacheCommand, []string{"droppo"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Error clearing build cache of droppo: failed"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+			})
+		})
+
+		Context("when the required arguments are missing", func() {
+			It("prints an error", func() {
+				test_helpers.ExecuteCommandWithArgs(clearBuildCacheCommand, []string{})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("<droplet-name> is required"))
+				Expect(fakeDropletRunner.ClearBuildCacheCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+	})
+
 	Describe("ExportDropletCommand", func() {
 		var (
 			exportDropletCommand           cli.Command
@@ -1011,6 +1826,7 @@ var _ = Describe("CommandFactory", func() {
 
 			test_helpers.ExecuteCommandWithArgs(exportDropletCommand, []string{"droppo"})
 
+			Expect(outputBuffer).To(test_helpers.SayLine("3B"))
 			Expect(outputBuffer).To(test_helpers.SayLine("Droplet 'droppo' exported to droppo.tgz."))
 			Expect(fakeDropletRunner.ExportDropletCallCount()).To(Equal(1))
 			Expect(fakeDropletRunner.ExportDropletArgsForCall(0)).To(Equal("droppo"))
@@ -1018,6 +1834,42 @@ var _ = Describe("CommandFactory", func() {
 			Expect(os.Stat("droppo.tgz")).NotTo(BeNil())
 		})
 
+		It("replaces a larger file already at the destination", func() {
+			Expect(ioutil.WriteFile("droppo.tgz", []byte("some much longer stale droplet"), 0644)).To(Succeed())
+			fakeDropletRunner.ExportDropletReturns(ioutil.NopCloser(strings.NewReader("tar")), nil)
+
+			test_helpers.ExecuteCommandWithArgs(exportDropletCommand, []string{"droppo"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Droplet 'droppo' exported to droppo.tgz."))
+			Expect(ioutil.ReadFile("droppo.tgz")).To(Equal([]byte("tar")))
+		})
+
+		Context("when the droplet does not match its checksum", func() {
+			BeforeEach(func() {
+				fakeDropletRunner.ExportDropletReturns(blob.NewChecksumReader(ioutil.NopCloser(strings.NewReader("tar")), "some-checksum"), nil)
+			})
+
+			It("prints an error and leaves no partial file", func() {
+				test_helpers.ExecuteCommandWithArgs(exportDropletCommand, []string{"droppo"})
+
+				Expect(outputBuffer).To(test_helpers.Say("Error exporting droplet 'droppo' to droppo.tgz: checksum mismatch"))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+
+				files, err := ioutil.ReadDir(".")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(files).To(BeEmpty())
+			})
+
+			It("leaves an earlier export alone", func() {
+				Expect(ioutil.WriteFile("droppo.tgz", []byte("earlier droplet"), 0644)).To(Succeed())
+
+				test_helpers.ExecuteCommandWithArgs(exportDropletCommand, []string{"droppo"})
+
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				Expect(ioutil.ReadFile("droppo.tgz")).To(Equal([]byte("earlier droplet")))
+			})
+		})
+
 		Context("when the droplet runner returns errors", func() {
 			It("prints an error", func() {
 				fakeDropletRunner.ExportDropletReturns(nil, errors.New("failed"))
@@ -1047,6 +1899,9 @@ var _ = Describe("CommandFactory", func() {
 		BeforeEach(func() {
 			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, nil, fakeDropletRunner, nil, fakeZipper, config)
 			importDropletCommand = commandFactory.MakeImportDropletCommand()
+			fakeDropletRunner.NewDropletVersionStub = func(dropletName string) (string, error) {
+				return dropletName + "@v1", nil
+			}
 		})
 
 		Context("when the droplet files exist", func() {
@@ -1064,15 +1919,34 @@ var _ = Describe("CommandFactory", func() {
 				Expect(os.RemoveAll(tmpDir)).To(Succeed())
 			})
 
-			It("imports the droplet", func() {
+			It("imports the droplet as a new version tagged latest", func() {
 				test_helpers.ExecuteCommandWithArgs(importDropletCommand, []string{"droplet-name", dropletPathArg})
 
-				Expect(outputBuffer).To(test_helpers.SayLine("Imported droplet-name"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Imported droplet-name@v1"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Tagged droplet-name@v1 as latest"))
 
+				Expect(fakeDropletRunner.NewDropletVersionArgsForCall(0)).To(Equal("droplet-name"))
 				Expect(fakeDropletRunner.ImportDropletCallCount()).To(Equal(1))
-				dropletName, dropletPath := fakeDropletRunner.ImportDropletArgsForCall(0)
-				Expect(dropletName).To(Equal("droplet-name"))
+				dropletName, dropletPath, _ := fakeDropletRunner.ImportDropletArgsForCall(0)
+				Expect(dropletName).To(Equal("droplet-name@v1"))
 				Expect(dropletPath).To(Equal(dropletPathArg))
+
+				Expect(fakeDropletRunner.TagDropletCallCount()).To(Equal(1))
+				dropletRef, tag := fakeDropletRunner.TagDropletArgsForCall(0)
+				Expect(dropletRef).To(Equal("droplet-name@v1"))
+				Expect(tag).To(Equal("latest"))
+			})
+
+			It("shows the progress of the upload", func() {
+				fakeDropletRunner.ImportDropletStub = func(_, _ string, progress blob.Progress) error {
+					progress(16, 16)
+					return nil
+				}
+
+				test_helpers.ExecuteCommandWithArgs(importDropletCommand, []string{"droplet-name", dropletPathArg})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("16B of 16B (100%)"))
+				Expect(outputBuffer).To(test_helpers.SayLine("Imported droplet-name@v1"))
 			})
 
 			Context("when the droplet runner returns an error", func() {
@@ -1083,6 +1957,18 @@ var _ = Describe("CommandFactory", func() {
 
 					Expect(outputBuffer).To(test_helpers.SayLine("Error importing droplet-name: dont tread on me"))
 					Expect(fakeDropletRunner.ImportDropletCallCount()).To(Equal(1))
+					Expect(fakeDropletRunner.TagDropletCallCount()).To(BeZero())
+					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+				})
+
+				It("prints the error from versioning the droplet", func() {
+					fakeDropletRunner.NewDropletVersionStub = nil
+					fakeDropletRunner.NewDropletVersionReturns("", errors.New("failed"))
+
+					test_helpers.ExecuteCommandWithArgs(importDropletCommand, []string{"droplet-name", dropletPathArg})
+
+					Expect(outputBuffer).To(test_helpers.SayLine("Error versioning droplet-name: failed"))
+					Expect(fakeDropletRunner.ImportDropletCallCount()).To(BeZero())
 					Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
 				})
 			})
@@ -1096,6 +1982,235 @@ var _ = Describe("CommandFactory", func() {
 				Expect(fakeDropletRunner.ImportDropletCallCount()).To(Equal(0))
 				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 			})
+
+			It("rejects a droplet name containing @", func() {
+				test_helpers.ExecuteCommandWithArgs(importDropletCommand, []string{"droplet-name@v2", "droplet.tgz"})
+
+				Expect(outputBuffer).To(test_helpers.SayLine("Incorrect Usage: <droplet-name> cannot contain @"))
+				Expect(fakeDropletRunner.ImportDropletCallCount()).To(Equal(0))
+				Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+			})
+		})
+	})
+
+	Describe("MigrateDropletsCommand", func() {
+		var migrateDropletsCommand cli.Command
+
+		BeforeEach(func() {
+			config.SetTarget("s3.example.com")
+			config.SetS3BlobStore("some-access-key", "some-secret-key", "some-bucket", "some-region")
+			config.SaveProfile("s3")
+			config.SetTarget("dav.example.com")
+			config.SetBlobStore("dav.example.com", "8444", "", "")
+			config.SaveProfile("dav")
+
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, nil, fakeZipper, config)
+			migrateDropletsCommand = commandFactory.MakeMigrateDropletsCommand()
+			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+		})
+
+		It("copies droplets from the current target to the named target", func() {
+			fakeDropletRunner.MigrateDropletsStub = func(_, _ *config_package.Config, options droplet_runner.MigrateOptions) error {
+				options.Progress(droplet_runner.BlobMigration{Path: "app-droplet.tgz", Size: 2048})
+				options.Progress(droplet_runner.BlobMigration{Path: "old-droplet.tgz", Size: 1024, Skipped: true})
+				return nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{"--droplet", "app", "--droplet", "old", "s3"})
+
+			Expect(fakeBlobStoreVerifier.VerifyCallCount()).To(Equal(2))
+			Expect(fakeDropletRunner.MigrateDropletsCallCount()).To(Equal(1))
+			source, destination, options := fakeDropletRunner.MigrateDropletsArgsForCall(0)
+			Expect(source).To(Equal(config))
+			Expect(destination.ActiveBlobStore()).To(Equal(config_package.S3BlobStore))
+			Expect(destination.S3BlobStore().BucketName).To(Equal("some-bucket"))
+			Expect(options.DropletNames).To(Equal([]string{"app", "old"}))
+			Expect(options.DryRun).To(BeFalse())
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Copied app-droplet.tgz (2K)"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Skipped old-droplet.tgz (already in s3)"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Copied 1 droplet blobs to s3, skipped 1."))
+		})
+
+		It("copies droplets from the target given by --from", func() {
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{"--from", "s3", "dav"})
+
+			source, destination, _ := fakeDropletRunner.MigrateDropletsArgsForCall(0)
+			Expect(source.ActiveBlobStore()).To(Equal(config_package.S3BlobStore))
+			Expect(destination.ActiveBlobStore()).To(Equal(config_package.DAVBlobStore))
+		})
+
+		It("lists what would be copied on a dry run", func() {
+			fakeDropletRunner.MigrateDropletsStub = func(_, _ *config_package.Config, options droplet_runner.MigrateOptions) error {
+				options.Progress(droplet_runner.BlobMigration{Path: "app-droplet.tgz", Size: 2048})
+				return nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{"--dry-run", "s3"})
+
+			_, _, options := fakeDropletRunner.MigrateDropletsArgsForCall(0)
+			Expect(options.DryRun).To(BeTrue())
+			Expect(outputBuffer).To(test_helpers.SayLine("Would copy app-droplet.tgz (2K)"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Dry run: 1 droplet blobs to copy to s3, 0 already there."))
+		})
+
+		It("explains how to resume when the migration fails partway", func() {
+			fakeDropletRunner.MigrateDropletsStub = func(_, _ *config_package.Config, options droplet_runner.MigrateOptions) error {
+				options.Progress(droplet_runner.BlobMigration{Path: "app-droplet.tgz", Size: 2048})
+				return errors.New("copying other-droplet.tgz: checksum mismatch after upload")
+			}
+
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{"s3"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error migrating droplets: copying other-droplet.tgz: checksum mismatch after upload"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Run the command again to resume; copied droplet blobs will be skipped."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error when the destination droplet store cannot be verified", func() {
+			fakeBlobStoreVerifier.VerifyStub = func(verifiedConfig *config_package.Config) (bool, error) {
+				return verifiedConfig == config, nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{"s3"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error verifying droplet store of s3: unauthorized"))
+			Expect(fakeDropletRunner.MigrateDropletsCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error for an unknown target", func() {
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{"missing"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Unknown target: missing"))
+			Expect(fakeDropletRunner.MigrateDropletsCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.BadTarget}))
+		})
+
+		It("prints incorrect usage without a target", func() {
+			test_helpers.ExecuteCommandWithArgs(migrateDropletsCommand, []string{})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeDropletRunner.MigrateDropletsCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+	})
+
+	Describe("GCDropletsCommand", func() {
+		var gcDropletsCommand cli.Command
+
+		BeforeEach(func() {
+			commandFactory := droplet_runner_command_factory.NewDropletRunnerCommandFactory(appRunnerCommandFactory, fakeBlobStoreVerifier, fakeTaskExaminer, fakeDropletRunner, nil, fakeZipper, config)
+			gcDropletsCommand = commandFactory.MakeGCDropletsCommand()
+			fakeBlobStoreVerifier.VerifyReturns(true, nil)
+		})
+
+		It("removes droplet blobs and reports the space reclaimed", func() {
+			fakeDropletRunner.GCDropletsStub = func(options droplet_runner.GCOptions) error {
+				options.Progress(droplet_runner.GarbageBlob{Path: "app-bits.zip", Size: 2048, Reason: "orphaned app bits"})
+				options.Progress(droplet_runner.GarbageBlob{Path: "app-v1-droplet.tgz", Size: 1024, Reason: "older than 24h0m0s"})
+				return nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--orphaned-bits", "--unreferenced", "--older-than", "24h", "--keep", "3"})
+
+			Expect(fakeDropletRunner.GCDropletsCallCount()).To(Equal(1))
+			options := fakeDropletRunner.GCDropletsArgsForCall(0)
+			Expect(options.OrphanedBits).To(BeTrue())
+			Expect(options.Unreferenced).To(BeTrue())
+			Expect(options.OlderThan).To(Equal(24 * time.Hour))
+			Expect(options.KeepNewest).To(Equal(3))
+			Expect(options.DryRun).To(BeFalse())
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Removed app-bits.zip (2K): orphaned app bits"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Removed app-v1-droplet.tgz (1K): older than 24h0m0s"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Removed 2 droplet blobs, reclaimed 3K."))
+		})
+
+		It("keeps the app bits of droplets that are still building", func() {
+			fakeTaskExaminer.ListTasksReturns([]task_examiner.TaskInfo{
+				{TaskGuid: "build-droplet-building", State: "RUNNING"},
+				{TaskGuid: "build-droplet-web-v3", State: "PENDING"},
+				{TaskGuid: "build-droplet-built", State: "COMPLETED"},
+				{TaskGuid: "some-task", State: "RUNNING"},
+			}, nil)
+
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--orphaned-bits"})
+
+			options := fakeDropletRunner.GCDropletsArgsForCall(0)
+			Expect(options.BuildingDroplets).To(Equal([]string{"building", "web@v3"}))
+		})
+
+		It("lists what would be removed on a dry run", func() {
+			fakeDropletRunner.GCDropletsStub = func(options droplet_runner.GCOptions) error {
+				options.Progress(droplet_runner.GarbageBlob{Path: "app-bits.zip", Size: 2048, Reason: "orphaned app bits"})
+				return nil
+			}
+
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--orphaned-bits", "--dry-run"})
+
+			options := fakeDropletRunner.GCDropletsArgsForCall(0)
+			Expect(options.DryRun).To(BeTrue())
+			Expect(outputBuffer).To(test_helpers.SayLine("Would remove app-bits.zip (2K): orphaned app bits"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Dry run: 1 droplet blobs to remove, reclaiming 2K."))
+		})
+
+		It("reports what was removed when removing fails partway", func() {
+			fakeDropletRunner.GCDropletsStub = func(options droplet_runner.GCOptions) error {
+				options.Progress(droplet_runner.GarbageBlob{Path: "app-bits.zip", Size: 2048, Reason: "orphaned app bits"})
+				return errors.New("removing other-bits.zip: failed")
+			}
+
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--orphaned-bits"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error removing droplets: removing other-bits.zip: failed"))
+			Expect(outputBuffer).To(test_helpers.SayLine("Removed 1 droplet blobs, reclaimed 2K."))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error when listing the build tasks fails", func() {
+			fakeTaskExaminer.ListTasksReturns(nil, errors.New("failed"))
+
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--orphaned-bits"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error listing build tasks: failed"))
+			Expect(fakeDropletRunner.GCDropletsCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("prints an error when the droplet store cannot be verified", func() {
+			fakeBlobStoreVerifier.VerifyReturns(false, nil)
+
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--orphaned-bits"})
+
+			Expect(outputBuffer).To(test_helpers.SayLine("Error verifying droplet store: unauthorized"))
+			Expect(fakeDropletRunner.GCDropletsCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.CommandFailed}))
+		})
+
+		It("removes unused app files and buildpacks", func() {
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--unused-files", "--dry-run"})
+
+			Expect(fakeDropletRunner.GCDropletsCallCount()).To(Equal(1))
+			options := fakeDropletRunner.GCDropletsArgsForCall(0)
+			Expect(options.UnusedFiles).To(BeTrue())
+			Expect(options.OrphanedBits).To(BeFalse())
+			Expect(options.DryRun).To(BeTrue())
+		})
+
+		It("prints incorrect usage without anything to remove", func() {
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--dry-run"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeDropletRunner.GCDropletsCallCount()).To(Equal(0))
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
+		})
+
+		It("prints incorrect usage for a negative --keep", func() {
+			test_helpers.ExecuteCommandWithArgs(gcDropletsCommand, []string{"--keep", "-1"})
+
+			Expect(outputBuffer).To(test_helpers.SayIncorrectUsage())
+			Expect(fakeExitHandler.ExitCalledWith).To(Equal([]int{exit_codes.InvalidSyntax}))
 		})
 	})
 })
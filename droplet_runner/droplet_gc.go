@@ -0,0 +1,337 @@
+package droplet_runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+// recentFileAge is how long app bits, app files and buildpacks are kept after
+// they are stored, so that the build or app bits uploaded with them have
+// time to refer to them.
+const recentFileAge = time.Hour
+
+// GarbageBlob reports a droplet blob that was removed, or would be on a dry
+// run, and why.
+type GarbageBlob struct {
+	Path   string
+	Size   int64
+	Reason string
+}
+
+// GCOptions selects the blobs GCDroplets removes.  OrphanedBits selects app
+// bits left behind by failed or abandoned builds, once they are an hour old,
+// since bits are uploaded before their build is submitted.  UnusedFiles
+// selects the stored app files and uploaded buildpacks that no remaining app
+// bits or droplet refers to.  Files stored in the last hour are kept for app bits
+// still being uploaded, and buildpacks are kept while any build is running,
+// since builds record their buildpacks only once they complete.  The
+// remaining options select droplet versions, which must match all of the
+// options given; versions that are tagged or used by apps are never removed.
+type GCOptions struct {
+	OrphanedBits bool
+	UnusedFiles  bool
+	Unreferenced bool
+	OlderThan    time.Duration
+	KeepNewest   int
+
+	// BuildingDroplets are the droplet versions whose builds are still
+	// running, so their app bits aren't orphaned.  A name without a version
+	// keeps the app bits of every version of the droplet.
+	BuildingDroplets []string
+
+	DryRun   bool
+	Progress func(GarbageBlob)
+}
+
+func (dr *dropletRunner) GCDroplets(options GCOptions) error {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return err
+	}
+
+	garbage, err := dr.findGarbage(blobs, options)
+	if err != nil {
+		return err
+	}
+
+	for _, garbageBlob := range garbage {
+		if !options.DryRun {
+			if err := dr.blobStore.Delete(garbageBlob.Path); err != nil {
+				return fmt.Errorf("removing %s: %s", garbageBlob.Path, err)
+			}
+		}
+
+		if options.Progress != nil {
+			options.Progress(garbageBlob)
+		}
+	}
+
+	return nil
+}
+
+func (dr *dropletRunner) findGarbage(blobs []blob.Blob, options GCOptions) ([]GarbageBlob, error) {
+	// Builds are compared by task guid, which can't tell the app-v2 bits of
+	// a droplet stored before ltc kept versions from those of app@v2, so
+	// both are kept.
+	building := map[string]bool{}
+	for _, dropletName := range options.BuildingDroplets {
+		building[BuildTaskGuid(dropletName)] = true
+	}
+
+	collected := map[string]string{}
+	if options.Unreferenced || options.OlderThan > 0 || options.KeepNewest > 0 {
+		annotations, err := dr.appAnnotations()
+		if err != nil {
+			return nil, err
+		}
+
+		tagged, err := dr.taggedDroplets(blobs)
+		if err != nil {
+			return nil, err
+		}
+
+		collected = collectDroplets(blobs, annotations, tagged, options)
+	}
+
+	droplets := storedDroplets(blobs)
+
+	garbage := []GarbageBlob{}
+	for _, b := range blobs {
+		if dropletName, ok := bitsDropletName(b.Path); ok {
+			if name, _ := splitDropletRef(dropletName); building[BuildTaskGuid(dropletName)] || building[BuildTaskGuid(name)] {
+				continue
+			}
+
+			if reason, ok := collected[dropletName]; ok {
+				garbage = append(garbage, GarbageBlob{Path: b.Path, Size: b.Size, Reason: reason})
+			} else if options.OrphanedBits && time.Since(b.Created) >= recentFileAge {
+				garbage = append(garbage, GarbageBlob{Path: b.Path, Size: b.Size, Reason: "orphaned app bits"})
+			}
+			continue
+		}
+
+		if reason, ok := collected[blobOwner(b.Path, droplets)]; ok {
+			garbage = append(garbage, GarbageBlob{Path: b.Path, Size: b.Size, Reason: reason})
+		}
+	}
+
+	if options.UnusedFiles {
+		unused, err := dr.unusedFiles(blobs, garbage, len(options.BuildingDroplets) > 0)
+		if err != nil {
+			return nil, err
+		}
+		garbage = append(garbage, unused...)
+	}
+
+	sort.Sort(garbageByPath(garbage))
+
+	return garbage, nil
+}
+
+// unusedFiles finds the stored app files and uploaded buildpacks that none of
+// the app bits manifests or droplet metadata left after removing garbage
+// refers to.
+func (dr *dropletRunner) unusedFiles(blobs []blob.Blob, garbage []GarbageBlob, building bool) ([]GarbageBlob, error) {
+	removed := map[string]bool{}
+	for _, garbageBlob := range garbage {
+		removed[garbageBlob.Path] = true
+	}
+
+	used := map[string]bool{}
+	for _, b := range blobs {
+		if removed[b.Path] {
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(b.Path, app_bits.ManifestPath("")):
+			manifest, err := dr.readManifest(b.Path)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range manifest.Files {
+				if file.Checksum != "" {
+					used[app_bits.ResourcePath(file.Checksum)] = true
+				}
+			}
+		case strings.HasSuffix(b.Path, metadataPath("")):
+			metadata, err := dr.readMetadata(strings.TrimSuffix(b.Path, metadataPath("")))
+			if err != nil {
+				return nil, err
+			}
+			for _, buildpack := range metadata.BuildpackURLs {
+				used[buildpack] = true
+			}
+		}
+	}
+
+	// UploadBits stores the old files it reuses again, so list the blobs again
+	// to spare those a build has stored since the first listing.
+	current, err := dr.blobStore.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range current {
+		if time.Since(b.Created) < recentFileAge {
+			used[b.Path] = true
+		}
+	}
+
+	unused := []GarbageBlob{}
+	for _, b := range blobs {
+		if used[b.Path] || time.Since(b.Created) < recentFileAge {
+			continue
+		}
+
+		if strings.HasSuffix(b.Path, app_bits.ResourcePath("")) {
+			unused = append(unused, GarbageBlob{Path: b.Path, Size: b.Size, Reason: "unused app file"})
+		} else if _, ok := uploadedBuildpackChecksum(b.Path); ok && !building {
+			unused = append(unused, GarbageBlob{Path: b.Path, Size: b.Size, Reason: "unused buildpack"})
+		}
+	}
+
+	return unused, nil
+}
+
+func (dr *dropletRunner) readManifest(path string) (app_bits.Manifest, error) {
+	reader, err := dr.blobStore.Download(path)
+	if err != nil {
+		return app_bits.Manifest{}, err
+	}
+	defer reader.Close()
+
+	manifest := app_bits.Manifest{}
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return app_bits.Manifest{}, fmt.Errorf("invalid app bits manifest %s: %s", path, err)
+	}
+
+	return manifest, nil
+}
+
+func (dr *dropletRunner) appAnnotations() ([]annotation, error) {
+	apps, err := dr.appExaminer.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := []annotation{}
+	for _, app := range apps {
+		dropletAnnotation := annotation{}
+		if err := json.Unmarshal([]byte(app.Annotation), &dropletAnnotation); err != nil {
+			continue
+		}
+		annotations = append(annotations, dropletAnnotation)
+	}
+
+	return annotations, nil
+}
+
+// collectDroplets returns the reasons for removing each droplet version
+// selected by options, keyed by name@version.
+func collectDroplets(blobs []blob.Blob, annotations []annotation, tagged map[string]bool, options GCOptions) map[string]string {
+	versions := map[string][]Droplet{}
+	for _, b := range blobs {
+		if strings.HasSuffix(b.Path, "-droplet.tgz") {
+			droplet := dropletFromBlob(b)
+			prefix := droplet.Name
+			if droplet.Version == "" {
+				prefix = dropletPrefix(droplet.Name)
+			}
+			versions[prefix] = append(versions[prefix], droplet)
+		}
+	}
+
+	collected := map[string]string{}
+	for prefix, droplets := range versions {
+		sort.Sort(dropletsNewestFirst(droplets))
+
+		for index, droplet := range droplets {
+			if tagged[droplet.FullName()] || dropletInUse(droplet.FullName(), annotations) {
+				continue
+			}
+
+			reasons := []string{}
+			if options.Unreferenced {
+				reasons = append(reasons, "not used by any app")
+			}
+			if options.OlderThan > 0 {
+				if droplet.Created.IsZero() || time.Since(droplet.Created) < options.OlderThan {
+					continue
+				}
+				reasons = append(reasons, "older than "+options.OlderThan.String())
+			}
+			if options.KeepNewest > 0 {
+				if index < options.KeepNewest {
+					continue
+				}
+				reasons = append(reasons, fmt.Sprintf("not among the %d newest %s droplets", options.KeepNewest, prefix))
+			}
+
+			collected[droplet.FullName()] = strings.Join(reasons, ", ")
+		}
+	}
+
+	return collected
+}
+
+func dropletInUse(dropletName string, annotations []annotation) bool {
+	for _, a := range annotations {
+		if dropletMatchesAnnotation(dropletName, a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropletPrefix strips a trailing version, such as -v2 or -20150615, from the
+// name of a droplet stored before ltc kept versions, so that the versions of
+// a droplet are kept together.
+func dropletPrefix(dropletName string) string {
+	index := strings.LastIndex(dropletName, "-")
+	if index <= 0 || !strings.ContainsAny(dropletName[index+1:], "0123456789") {
+		return dropletName
+	}
+
+	return dropletName[:index]
+}
+
+// bitsDropletName also recognizes the zipped app bits uploaded by earlier
+// versions of ltc, which their builds may still be waiting on.
+func bitsDropletName(path string) (string, bool) {
+	path = strings.TrimSuffix(path, ".sha256")
+	for _, suffix := range []string{"-bits.json", "-bits.zip"} {
+		if strings.HasSuffix(path, suffix) {
+			return strings.TrimSuffix(path, suffix), true
+		}
+	}
+
+	return "", false
+}
+
+type dropletsNewestFirst []Droplet
+
+func (d dropletsNewestFirst) Len() int      { return len(d) }
+func (d dropletsNewestFirst) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d dropletsNewestFirst) Less(i, j int) bool {
+	if d[i].Created.Equal(d[j].Created) {
+		if d[i].Name == d[j].Name && d[i].Version != d[j].Version {
+			return versionNumber(d[i].Version) > versionNumber(d[j].Version)
+		}
+		return d[i].Name > d[j].Name
+	}
+
+	return d[i].Created.After(d[j].Created)
+}
+
+type garbageByPath []GarbageBlob
+
+func (g garbageByPath) Len() int           { return len(g) }
+func (g garbageByPath) Less(i, j int) bool { return g[i].Path < g[j].Path }
+func (g garbageByPath) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
@@ -0,0 +1,297 @@
+package droplet_runner_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/fake_blob_store"
+)
+
+var _ = Describe("DropletRunner GCDroplets", func() {
+	var (
+		fakeBlobStore   *fake_blob_store.FakeBlobStore
+		fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+		dropletRunner   droplet_runner.DropletRunner
+		garbage         []droplet_runner.GarbageBlob
+		options         droplet_runner.GCOptions
+	)
+
+	daysAgo := func(days int) time.Time {
+		return time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	}
+
+	BeforeEach(func() {
+		fakeBlobStore = &fake_blob_store.FakeBlobStore{}
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		dropletRunner = droplet_runner.New(nil, nil, nil, fakeBlobStore, fakeAppExaminer, nil)
+
+		fakeBlobStore.ListReturns([]blob.Blob{
+			{Path: "app-v1-droplet.tgz", Created: daysAgo(30), Size: 100},
+			{Path: "app-v1-droplet.tgz.sha256", Created: daysAgo(30), Size: 64},
+			{Path: "app-v2-droplet.tgz", Created: daysAgo(20), Size: 200},
+			{Path: "app-v3-droplet.tgz", Created: daysAgo(10), Size: 300},
+			{Path: "app-v4-bits.json", Created: daysAgo(1), Size: 10},
+			{Path: "app-v4-bits.json.sha256", Created: daysAgo(1), Size: 64},
+			{Path: "building-bits.zip", Created: daysAgo(0), Size: 20},
+			{Path: "b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832.resource", Created: daysAgo(50), Size: 13},
+			{Path: "other-droplet.tgz", Created: daysAgo(40), Size: 400},
+		}, nil)
+
+		fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+			{Annotation: "junk"},
+			{Annotation: `{"droplet_source": {"droplet_name": "app-v2"}}`},
+		}, nil)
+
+		garbage = nil
+		options = droplet_runner.GCOptions{
+			BuildingDroplets: []string{"building"},
+			Progress: func(garbageBlob droplet_runner.GarbageBlob) {
+				garbage = append(garbage, garbageBlob)
+			},
+		}
+	})
+
+	It("removes app bits left behind by builds that are no longer running", func() {
+		options.OrphanedBits = true
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "app-v4-bits.json", Size: 10, Reason: "orphaned app bits"},
+			{Path: "app-v4-bits.json.sha256", Size: 64, Reason: "orphaned app bits"},
+		}))
+		Expect(fakeBlobStore.DeleteCallCount()).To(Equal(2))
+		Expect(fakeBlobStore.DeleteArgsForCall(0)).To(Equal("app-v4-bits.json"))
+		Expect(fakeBlobStore.DeleteArgsForCall(1)).To(Equal("app-v4-bits.json.sha256"))
+	})
+
+	It("keeps app bits uploaded in the last hour, whose build may not be submitted yet", func() {
+		fakeBlobStore.ListReturns([]blob.Blob{
+			{Path: "web@v1-bits.json", Created: daysAgo(1), Size: 10},
+			{Path: "web@v2-bits.json", Created: time.Now().Add(-time.Minute), Size: 20},
+		}, nil)
+		options.OrphanedBits = true
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "web@v1-bits.json", Size: 10, Reason: "orphaned app bits"},
+		}))
+	})
+
+	It("keeps only the app bits of the versions still building", func() {
+		fakeBlobStore.ListReturns([]blob.Blob{
+			{Path: "web@v1-bits.json", Size: 10},
+			{Path: "web@v2-bits.json", Size: 20},
+		}, nil)
+		options.BuildingDroplets = []string{"web@v2"}
+		options.OrphanedBits = true
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "web@v1-bits.json", Size: 10, Reason: "orphaned app bits"},
+		}))
+	})
+
+	It("removes droplets not used by any app", func() {
+		options.Unreferenced = true
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "app-v1-droplet.tgz", Size: 100, Reason: "not used by any app"},
+			{Path: "app-v1-droplet.tgz.sha256", Size: 64, Reason: "not used by any app"},
+			{Path: "app-v3-droplet.tgz", Size: 300, Reason: "not used by any app"},
+			{Path: "other-droplet.tgz", Size: 400, Reason: "not used by any app"},
+		}))
+	})
+
+	It("removes unused droplets older than the given duration", func() {
+		options.OlderThan = 15 * 24 * time.Hour
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "app-v1-droplet.tgz", Size: 100, Reason: "older than 360h0m0s"},
+			{Path: "app-v1-droplet.tgz.sha256", Size: 64, Reason: "older than 360h0m0s"},
+			{Path: "other-droplet.tgz", Size: 400, Reason: "older than 360h0m0s"},
+		}))
+	})
+
+	It("keeps the newest droplets of each name", func() {
+		options.KeepNewest = 1
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "app-v1-droplet.tgz", Size: 100, Reason: "not among the 1 newest app droplets"},
+			{Path: "app-v1-droplet.tgz.sha256", Size: 64, Reason: "not among the 1 newest app droplets"},
+		}))
+	})
+
+	It("removes only the droplets matching all of the options", func() {
+		options.OrphanedBits = true
+		options.OlderThan = 35 * 24 * time.Hour
+		options.Unreferenced = true
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+			{Path: "app-v4-bits.json", Size: 10, Reason: "orphaned app bits"},
+			{Path: "app-v4-bits.json.sha256", Size: 64, Reason: "orphaned app bits"},
+			{Path: "other-droplet.tgz", Size: 400, Reason: "not used by any app, older than 840h0m0s"},
+		}))
+	})
+
+	It("doesn't remove anything on a dry run", func() {
+		options.Unreferenced = true
+		options.DryRun = true
+
+		Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+		Expect(garbage).To(HaveLen(4))
+		Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+	})
+
+	Context("when removing unused files", func() {
+		BeforeEach(func() {
+			options.UnusedFiles = true
+			options.BuildingDroplets = nil
+
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "app@v1-droplet.tgz", Size: 100},
+				{Path: "app@v1-metadata.json", Size: 10},
+				{Path: "app@v2-bits.json", Size: 10},
+				{Path: "used-by-bits.resource", Size: 1},
+				{Path: "unused.resource", Size: 2},
+				{Path: "used-by-droplet.buildpack.zip", Size: 3},
+				{Path: "unused.buildpack.zip", Size: 4},
+			}, nil)
+			fakeBlobStore.DownloadStub = func(path string) (io.ReadCloser, error) {
+				switch path {
+				case "app@v1-metadata.json":
+					return ioutil.NopCloser(strings.NewReader(`{"buildpack_urls": ["used-by-droplet.buildpack.zip"]}`)), nil
+				case "app@v2-bits.json":
+					return ioutil.NopCloser(strings.NewReader(`{"files": [{"path": "server.js", "sha256": "used-by-bits"}, {"path": "lib/"}]}`)), nil
+				}
+				return nil, errors.New("not found")
+			}
+		})
+
+		It("removes app files and buildpacks no app bits or droplet uses", func() {
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+				{Path: "unused.buildpack.zip", Size: 4, Reason: "unused buildpack"},
+				{Path: "unused.resource", Size: 2, Reason: "unused app file"},
+			}))
+			Expect(fakeBlobStore.DeleteCallCount()).To(Equal(2))
+		})
+
+		It("keeps uploaded buildpacks while a build is running", func() {
+			options.BuildingDroplets = []string{"app@v3"}
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+				{Path: "unused.resource", Size: 2, Reason: "unused app file"},
+			}))
+		})
+
+		It("keeps files stored in the last hour", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "uploading.resource", Size: 1, Created: time.Now().Add(-time.Minute)},
+				{Path: "uploaded.buildpack.zip", Size: 2, Created: time.Now().Add(-time.Minute)},
+				{Path: "unused.resource", Size: 3, Created: daysAgo(1)},
+			}, nil)
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+				{Path: "unused.resource", Size: 3, Reason: "unused app file"},
+			}))
+		})
+
+		It("keeps files a build stored again since they were listed", func() {
+			listed := []blob.Blob{
+				{Path: "reused.resource", Size: 1},
+				{Path: "unused.resource", Size: 2},
+			}
+			fakeBlobStore.ListStub = func() ([]blob.Blob, error) {
+				if fakeBlobStore.ListCallCount() == 1 {
+					return listed, nil
+				}
+				return []blob.Blob{
+					{Path: "reused.resource", Size: 1, Created: time.Now()},
+					{Path: "unused.resource", Size: 2},
+				}, nil
+			}
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(Equal([]droplet_runner.GarbageBlob{
+				{Path: "unused.resource", Size: 2, Reason: "unused app file"},
+			}))
+		})
+
+		It("frees the files of the app bits and droplets it removes", func() {
+			options.OrphanedBits = true
+			options.Unreferenced = true
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(ContainElement(droplet_runner.GarbageBlob{Path: "used-by-bits.resource", Size: 1, Reason: "unused app file"}))
+			Expect(garbage).To(ContainElement(droplet_runner.GarbageBlob{Path: "used-by-droplet.buildpack.zip", Size: 3, Reason: "unused buildpack"}))
+		})
+
+		It("doesn't remove anything on a dry run", func() {
+			options.DryRun = true
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(HaveLen(2))
+			Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+		})
+
+		It("returns an error when a manifest can't be read", func() {
+			fakeBlobStore.DownloadStub = nil
+			fakeBlobStore.DownloadReturns(nil, errors.New("some error"))
+
+			Expect(dropletRunner.GCDroplets(options)).To(HaveOccurred())
+			Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+		})
+	})
+
+	It("returns an error when listing the apps fails", func() {
+		options.Unreferenced = true
+		fakeAppExaminer.ListAppsReturns(nil, errors.New("some error"))
+
+		Expect(dropletRunner.GCDroplets(options)).To(MatchError("some error"))
+		Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+	})
+
+	It("returns an error when removing a blob fails", func() {
+		options.OrphanedBits = true
+		fakeBlobStore.DeleteReturns(errors.New("some error"))
+
+		Expect(dropletRunner.GCDroplets(options)).To(MatchError("removing app-v4-bits.json: some error"))
+		Expect(garbage).To(BeEmpty())
+	})
+
+	It("returns an error when querying the blob store fails", func() {
+		fakeBlobStore.ListReturns(nil, errors.New("some error"))
+
+		Expect(dropletRunner.GCDroplets(options)).To(MatchError("some error"))
+	})
+})
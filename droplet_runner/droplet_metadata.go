@@ -0,0 +1,233 @@
+package droplet_runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+// DropletMetadata describes how a droplet was produced.  It is stored as JSON
+// next to the droplet when it is built or imported.
+type DropletMetadata struct {
+	BuildpackURLs     []string          `json:"buildpack_urls,omitempty"`
+	DetectedBuildpack string            `json:"detected_buildpack,omitempty"`
+	StartCommand      string            `json:"start_command,omitempty"`
+	ProcessTypes      map[string]string `json:"process_types,omitempty"`
+	EnvironmentKeys   []string          `json:"environment_keys,omitempty"`
+	SourceChecksum    string            `json:"source_checksum,omitempty"`
+	Builder           string            `json:"builder,omitempty"`
+	Imported          bool              `json:"imported,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+// StagingResult is the part of the buildpack lifecycle's result file that
+// ltc uses.
+type StagingResult struct {
+	DetectedBuildpack    string            `json:"detected_buildpack"`
+	DetectedStartCommand map[string]string `json:"detected_start_command"`
+	ProcessTypes         map[string]string `json:"process_types"`
+}
+
+// Processes returns the start command of each process type the build
+// detected, from the app's Procfile or the buildpack's defaults.
+func (s StagingResult) Processes() map[string]string {
+	processes := map[string]string{}
+	for processType, command := range s.DetectedStartCommand {
+		processes[processType] = command
+	}
+	for processType, command := range s.ProcessTypes {
+		processes[processType] = command
+	}
+
+	return processes
+}
+
+// ParseStagingResult reads the result of a build task.  An empty result,
+// as left by builds that didn't write one, is an empty staging result.
+func ParseStagingResult(result string) (StagingResult, error) {
+	staging := StagingResult{}
+	if result != "" {
+		if err := json.Unmarshal([]byte(result), &staging); err != nil {
+			return StagingResult{}, fmt.Errorf("invalid staging result: %s", err)
+		}
+	}
+
+	return staging, nil
+}
+
+func metadataPath(dropletName string) string {
+	return dropletName + "-metadata.json"
+}
+
+// RecordBuild stores the metadata of a droplet once its build has completed.
+// The source checksum is taken from the app bits the build consumed, and
+// the bits' checksum is removed with it.
+func (dr *dropletRunner) RecordBuild(dropletName string, buildpackURLs []string, environmentKeys []string, result string) error {
+	bitsPath := app_bits.ManifestPath(dropletName)
+
+	sourceChecksum, err := dr.checksum(bitsPath)
+	if err != nil {
+		return err
+	}
+	if sourceChecksum != "" {
+		if err := dr.blobStore.Delete(blob.ChecksumPath(bitsPath)); err != nil {
+			return err
+		}
+	}
+
+	staging, err := ParseStagingResult(result)
+	if err != nil {
+		return err
+	}
+
+	keys := append([]string{}, environmentKeys...)
+	sort.Strings(keys)
+
+	processes := staging.Processes()
+
+	metadata := DropletMetadata{
+		BuildpackURLs:     buildpackURLs,
+		DetectedBuildpack: staging.DetectedBuildpack,
+		StartCommand:      processes[DefaultProcessType],
+		EnvironmentKeys:   keys,
+		SourceChecksum:    sourceChecksum,
+		Builder:           dr.builder(),
+		CreatedAt:         time.Now(),
+	}
+	if len(processes) > 0 {
+		metadata.ProcessTypes = processes
+	}
+
+	return dr.saveMetadata(dropletName, metadata)
+}
+
+// DropletMetadata returns the metadata stored with the droplet, or nil for
+// droplets stored before ltc recorded metadata.
+func (dr *dropletRunner) DropletMetadata(dropletName string) (*DropletMetadata, error) {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range blobs {
+		if b.Path == metadataPath(dropletName) {
+			return dr.readMetadata(dropletName)
+		}
+	}
+
+	return nil, nil
+}
+
+// ProcessCommand returns the start command the build detected for the
+// process type.  Droplets without recorded process types only know their web
+// process; when even its command is unknown it is left empty, so the
+// launcher reads it from the staging info inside the droplet.
+func (dr *dropletRunner) ProcessCommand(dropletRef, processType string) (string, error) {
+	dropletName, err := dr.ResolveDroplet(dropletRef)
+	if err != nil {
+		return "", err
+	}
+
+	metadata, err := dr.DropletMetadata(dropletName)
+	if err != nil {
+		return "", err
+	}
+
+	if metadata != nil {
+		if command, ok := metadata.ProcessTypes[processType]; ok {
+			return command, nil
+		}
+		if len(metadata.ProcessTypes) > 0 {
+			return "", fmt.Errorf("droplet %s has no %s process type", dropletName, processType)
+		}
+		if processType == DefaultProcessType && metadata.StartCommand != "" {
+			return metadata.StartCommand, nil
+		}
+	}
+
+	if processType != DefaultProcessType {
+		return "", fmt.Errorf("droplet %s has no %s process type", dropletName, processType)
+	}
+
+	return "", nil
+}
+
+func (dr *dropletRunner) ListDropletMetadata() (map[string]DropletMetadata, error) {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]bool{}
+	for _, b := range blobs {
+		paths[b.Path] = true
+	}
+
+	allMetadata := map[string]DropletMetadata{}
+	for _, b := range blobs {
+		if !strings.HasSuffix(b.Path, "-droplet.tgz") {
+			continue
+		}
+
+		dropletName := strings.TrimSuffix(b.Path, "-droplet.tgz")
+		if paths[metadataPath(dropletName)] {
+			metadata, err := dr.readMetadata(dropletName)
+			if err != nil {
+				return nil, err
+			}
+			allMetadata[dropletName] = *metadata
+		}
+	}
+
+	return allMetadata, nil
+}
+
+func (dr *dropletRunner) saveMetadata(dropletName string, metadata DropletMetadata) error {
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	return dr.blobStore.Upload(metadataPath(dropletName), strings.NewReader(string(metadataBytes)), nil)
+}
+
+func (dr *dropletRunner) readMetadata(dropletName string) (*DropletMetadata, error) {
+	reader, err := dr.blobStore.Download(metadataPath(dropletName))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	metadata := &DropletMetadata{}
+	if err := json.NewDecoder(reader).Decode(metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata for droplet %s: %s", dropletName, err)
+	}
+
+	return metadata, nil
+}
+
+// builder identifies the local user and host, and the Lattice user when
+// logged in to the target.
+func (dr *dropletRunner) builder() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+
+	builder := user
+	if hostname, err := os.Hostname(); err == nil {
+		builder += "@" + hostname
+	}
+
+	if username := dr.config.Username(); username != "" {
+		builder += " (" + username + ")"
+	}
+
+	return builder
+}
@@ -0,0 +1,218 @@
+package droplet_runner_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/fake_blob_store"
+
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+var _ = Describe("DropletRunner metadata", func() {
+	var (
+		fakeBlobStore *fake_blob_store.FakeBlobStore
+		dropletRunner droplet_runner.DropletRunner
+		blobContents  map[string]string
+	)
+
+	BeforeEach(func() {
+		config := config_package.New(persister.NewMemPersister())
+		config.SetLogin("some-user", "some-password")
+
+		fakeBlobStore = &fake_blob_store.FakeBlobStore{}
+		dropletRunner = droplet_runner.New(nil, nil, config, fakeBlobStore, nil, nil)
+
+		blobContents = map[string]string{}
+		fakeBlobStore.ListStub = func() ([]blob.Blob, error) {
+			blobs := []blob.Blob{}
+			for path := range blobContents {
+				blobs = append(blobs, blob.Blob{Path: path})
+			}
+			return blobs, nil
+		}
+		fakeBlobStore.DownloadStub = func(path string) (io.ReadCloser, error) {
+			contents, ok := blobContents[path]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return ioutil.NopCloser(strings.NewReader(contents)), nil
+		}
+		fakeBlobStore.UploadStub = func(path string, contents io.ReadSeeker, _ blob.Progress) error {
+			contentBytes, err := ioutil.ReadAll(contents)
+			blobContents[path] = string(contentBytes)
+			return err
+		}
+	})
+
+	Describe("RecordBuild", func() {
+		BeforeEach(func() {
+			blobContents["drippy-bits.json.sha256"] = "some-source-checksum"
+		})
+
+		It("stores the droplet metadata next to the droplet", func() {
+			result := `{
+				"buildpack_key": "some-key",
+				"detected_buildpack": "Ruby",
+				"execution_metadata": "{}",
+				"detected_start_command": {"web": "bundle exec rackup"}
+			}`
+
+			Expect(dropletRunner.RecordBuild("drippy", []string{"https://buildpack.example.com"}, []string{"ZZZZ", "AAAA"}, result)).To(Succeed())
+
+			metadata := droplet_runner.DropletMetadata{}
+			Expect(json.Unmarshal([]byte(blobContents["drippy-metadata.json"]), &metadata)).To(Succeed())
+			Expect(metadata.BuildpackURLs).To(Equal([]string{"https://buildpack.example.com"}))
+			Expect(metadata.DetectedBuildpack).To(Equal("Ruby"))
+			Expect(metadata.StartCommand).To(Equal("bundle exec rackup"))
+			Expect(metadata.ProcessTypes).To(Equal(map[string]string{"web": "bundle exec rackup"}))
+			Expect(metadata.EnvironmentKeys).To(Equal([]string{"AAAA", "ZZZZ"}))
+			Expect(metadata.SourceChecksum).To(Equal("some-source-checksum"))
+			Expect(metadata.Builder).To(HaveSuffix(" (some-user)"))
+			Expect(metadata.Imported).To(BeFalse())
+			Expect(metadata.CreatedAt.IsZero()).To(BeFalse())
+		})
+
+		It("stores every buildpack the build was given", func() {
+			buildpackURLs := []string{"https://buildpack-a.example.com", "https://buildpack-b.example.com"}
+			Expect(dropletRunner.RecordBuild("drippy", buildpackURLs, nil, `{"detected_buildpack": "Go"}`)).To(Succeed())
+
+			metadata := droplet_runner.DropletMetadata{}
+			Expect(json.Unmarshal([]byte(blobContents["drippy-metadata.json"]), &metadata)).To(Succeed())
+			Expect(metadata.BuildpackURLs).To(Equal(buildpackURLs))
+			Expect(metadata.DetectedBuildpack).To(Equal("Go"))
+		})
+
+		It("stores the process types of the app's Procfile", func() {
+			result := `{
+				"detected_buildpack": "Ruby",
+				"detected_start_command": {"web": "bundle exec rackup"},
+				"process_types": {"web": "bundle exec puma", "worker": "bundle exec sidekiq"}
+			}`
+
+			Expect(dropletRunner.RecordBuild("drippy", []string{"https://buildpack.example.com"}, nil, result)).To(Succeed())
+
+			metadata := droplet_runner.DropletMetadata{}
+			Expect(json.Unmarshal([]byte(blobContents["drippy-metadata.json"]), &metadata)).To(Succeed())
+			Expect(metadata.StartCommand).To(Equal("bundle exec puma"))
+			Expect(metadata.ProcessTypes).To(Equal(map[string]string{
+				"web":    "bundle exec puma",
+				"worker": "bundle exec sidekiq",
+			}))
+		})
+
+		It("removes the checksum of the consumed app bits", func() {
+			Expect(dropletRunner.RecordBuild("drippy", []string{"https://buildpack.example.com"}, nil, "")).To(Succeed())
+
+			Expect(fakeBlobStore.DeleteCallCount()).To(Equal(1))
+			Expect(fakeBlobStore.DeleteArgsForCall(0)).To(Equal("drippy-bits.json.sha256"))
+		})
+
+		It("returns an error for an invalid staging result", func() {
+			err := dropletRunner.RecordBuild("drippy", []string{"https://buildpack.example.com"}, nil, "{")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid staging result: "))
+		})
+
+		It("returns an error when storing the metadata fails", func() {
+			fakeBlobStore.UploadStub = nil
+			fakeBlobStore.UploadReturns(errors.New("some error"))
+
+			Expect(dropletRunner.RecordBuild("drippy", []string{"https://buildpack.example.com"}, nil, "")).To(MatchError("some error"))
+		})
+	})
+
+	Describe("DropletMetadata", func() {
+		It("returns the metadata stored with the droplet", func() {
+			blobContents["drippy-droplet.tgz"] = "droplet"
+			blobContents["drippy-metadata.json"] = `{"buildpack_urls": ["https://buildpack.example.com"], "builder": "someone"}`
+
+			Expect(dropletRunner.DropletMetadata("drippy")).To(Equal(&droplet_runner.DropletMetadata{
+				BuildpackURLs: []string{"https://buildpack.example.com"},
+				Builder:       "someone",
+			}))
+		})
+
+		It("returns nil when no metadata was recorded", func() {
+			blobContents["drippy-droplet.tgz"] = "droplet"
+
+			Expect(dropletRunner.DropletMetadata("drippy")).To(BeNil())
+		})
+
+		It("returns an error for invalid metadata", func() {
+			blobContents["drippy-metadata.json"] = "{"
+
+			_, err := dropletRunner.DropletMetadata("drippy")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid metadata for droplet drippy: "))
+		})
+	})
+
+	Describe("ProcessCommand", func() {
+		BeforeEach(func() {
+			blobContents["drippy@v1-droplet.tgz"] = "droplet"
+			blobContents["drippy@v2-droplet.tgz"] = "droplet"
+		})
+
+		It("returns the command of the process type in the resolved droplet version", func() {
+			blobContents["drippy@v2-metadata.json"] = `{"process_types": {"web": "bundle exec rackup", "worker": "bundle exec sidekiq"}}`
+
+			Expect(dropletRunner.ProcessCommand("drippy", "worker")).To(Equal("bundle exec sidekiq"))
+			Expect(dropletRunner.ProcessCommand("drippy", "web")).To(Equal("bundle exec rackup"))
+		})
+
+		It("returns an error for a process type the build didn't detect", func() {
+			blobContents["drippy@v2-metadata.json"] = `{"process_types": {"web": "bundle exec rackup"}}`
+
+			_, err := dropletRunner.ProcessCommand("drippy", "worker")
+			Expect(err).To(MatchError("droplet drippy@v2 has no worker process type"))
+		})
+
+		It("falls back to the start command of droplets without process types", func() {
+			blobContents["drippy@v2-metadata.json"] = `{"start_command": "bundle exec rackup"}`
+
+			Expect(dropletRunner.ProcessCommand("drippy", "web")).To(Equal("bundle exec rackup"))
+		})
+
+		Context("when the droplet has no metadata", func() {
+			It("leaves the web process to the staging info in the droplet", func() {
+				Expect(dropletRunner.ProcessCommand("drippy", "web")).To(BeEmpty())
+			})
+
+			It("returns an error for other process types", func() {
+				_, err := dropletRunner.ProcessCommand("drippy@v1", "worker")
+				Expect(err).To(MatchError("droplet drippy@v1 has no worker process type"))
+			})
+		})
+	})
+
+	Describe("ListDropletMetadata", func() {
+		It("returns the metadata of every droplet that has some", func() {
+			blobContents["drippy-droplet.tgz"] = "droplet"
+			blobContents["drippy-metadata.json"] = `{"detected_buildpack": "Ruby"}`
+			blobContents["old-droplet.tgz"] = "droplet"
+			blobContents["orphan-metadata.json"] = `{"detected_buildpack": "Go"}`
+
+			Expect(dropletRunner.ListDropletMetadata()).To(Equal(map[string]droplet_runner.DropletMetadata{
+				"drippy": {DetectedBuildpack: "Ruby"},
+			}))
+		})
+
+		It("returns an error when querying the blob store fails", func() {
+			fakeBlobStore.ListStub = nil
+			fakeBlobStore.ListReturns(nil, errors.New("some error"))
+
+			_, err := dropletRunner.ListDropletMetadata()
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+})
@@ -0,0 +1,253 @@
+package droplet_runner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+// BlobMigration reports a droplet blob that was copied, or skipped because
+// the destination already holds the same contents.
+type BlobMigration struct {
+	Path    string
+	Size    int64
+	Skipped bool
+}
+
+type MigrateOptions struct {
+	DropletNames []string
+	DryRun       bool
+	Progress     func(BlobMigration)
+}
+
+// DropletMigrator copies droplets between blob stores.  Blobs the destination
+// already holds with the same checksum are skipped, so an interrupted
+// migration resumes where it left off when run again.
+type DropletMigrator struct {
+	Source      BlobStore
+	Destination BlobStore
+}
+
+func (m *DropletMigrator) Migrate(options MigrateOptions) error {
+	sourceBlobs, err := m.Source.List()
+	if err != nil {
+		return fmt.Errorf("listing source droplet store: %s", err)
+	}
+
+	destinationBlobs, err := m.Destination.List()
+	if err != nil {
+		return fmt.Errorf("listing destination droplet store: %s", err)
+	}
+
+	sourcePaths := map[string]bool{}
+	for _, sourceBlob := range sourceBlobs {
+		sourcePaths[sourceBlob.Path] = true
+	}
+
+	migratedSizes := map[string]int64{}
+	for _, destinationBlob := range destinationBlobs {
+		migratedSizes[destinationBlob.Path] = destinationBlob.Size
+	}
+
+	dropletBlobs, err := selectDropletBlobs(sourceBlobs, options.DropletNames)
+	if err != nil {
+		return err
+	}
+
+	for _, dropletBlob := range dropletBlobs {
+		migration := BlobMigration{Path: dropletBlob.Path, Size: dropletBlob.Size}
+
+		if size, ok := migratedSizes[dropletBlob.Path]; ok && size == dropletBlob.Size {
+			migrated, err := m.alreadyMigrated(dropletBlob, sourcePaths[blob.ChecksumPath(dropletBlob.Path)])
+			if err != nil {
+				return fmt.Errorf("checking %s: %s", dropletBlob.Path, err)
+			}
+			migration.Skipped = migrated
+		}
+
+		if !migration.Skipped && !options.DryRun {
+			if err := m.copyBlob(dropletBlob); err != nil {
+				return fmt.Errorf("copying %s: %s", dropletBlob.Path, err)
+			}
+		}
+
+		if options.Progress != nil {
+			options.Progress(migration)
+		}
+	}
+
+	return nil
+}
+
+// alreadyMigrated reports whether the destination blob has the same contents
+// as the source blob.  The source's checksum is read from its .sha256
+// sidecar when it has one, so that only the destination blob is downloaded.
+func (m *DropletMigrator) alreadyMigrated(dropletBlob blob.Blob, hasChecksum bool) (bool, error) {
+	var sourceChecksum string
+	var err error
+	if hasChecksum {
+		sourceChecksum, err = readChecksum(m.Source, dropletBlob.Path)
+	} else {
+		sourceChecksum, err = hashBlob(m.Source, dropletBlob.Path)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	destinationChecksum, err := hashBlob(m.Destination, dropletBlob.Path)
+	if err != nil {
+		return false, err
+	}
+
+	return destinationChecksum == sourceChecksum, nil
+}
+
+func hashBlob(store BlobStore, path string) (string, error) {
+	reader, err := store.Download(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (m *DropletMigrator) copyBlob(dropletBlob blob.Blob) error {
+	reader, err := m.Source.Download(dropletBlob.Path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tmpFile, err := ioutil.TempFile("", "ltc-migrate-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	sourceChecksum := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, sourceChecksum), reader)
+	if err != nil {
+		return err
+	}
+	if size != dropletBlob.Size {
+		return fmt.Errorf("downloaded %d bytes, expected %d", size, dropletBlob.Size)
+	}
+
+	if _, err := tmpFile.Seek(0, 0); err != nil {
+		return err
+	}
+
+	if err := m.Destination.Upload(dropletBlob.Path, tmpFile, nil); err != nil {
+		return err
+	}
+
+	return m.verifyBlob(dropletBlob, sourceChecksum.Sum(nil))
+}
+
+func (m *DropletMigrator) verifyBlob(dropletBlob blob.Blob, checksum []byte) error {
+	reader, err := m.Destination.Download(dropletBlob.Path)
+	if err != nil {
+		return fmt.Errorf("verifying upload: %s", err)
+	}
+	defer reader.Close()
+
+	destinationChecksum := sha256.New()
+	size, err := io.Copy(destinationChecksum, reader)
+	if err != nil {
+		return fmt.Errorf("verifying upload: %s", err)
+	}
+	if size != dropletBlob.Size {
+		return fmt.Errorf("uploaded %d bytes, expected %d", size, dropletBlob.Size)
+	}
+	if !bytes.Equal(destinationChecksum.Sum(nil), checksum) {
+		return fmt.Errorf("checksum mismatch after upload")
+	}
+
+	return nil
+}
+
+// selectDropletBlobs returns the blobs of the named droplets, or of every
+// droplet when no names are given.  A name selects every version of the
+// droplet, along with its tags, and a name@version selects that version
+// alone.  A blob belongs to the droplet with the longest name it starts
+// with, so app-v2's blobs aren't taken for app's.
+func selectDropletBlobs(blobs []blob.Blob, dropletNames []string) ([]blob.Blob, error) {
+	droplets := storedDroplets(blobs)
+
+	selected := droplets
+	if len(dropletNames) > 0 {
+		selected = map[string]bool{}
+		for _, dropletName := range dropletNames {
+			found := false
+			for storedName := range droplets {
+				if name, _ := splitDropletRef(storedName); storedName == dropletName || name == dropletName {
+					selected[storedName] = true
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("droplet not found: %s", dropletName)
+			}
+		}
+	}
+
+	selectedNames := map[string]bool{}
+	for dropletName := range selected {
+		name, _ := splitDropletRef(dropletName)
+		selectedNames[name] = true
+	}
+
+	dropletBlobs := []blob.Blob{}
+	for _, b := range blobs {
+		if selected[blobOwner(b.Path, droplets)] || selectedNames[tagOwner(b.Path)] {
+			dropletBlobs = append(dropletBlobs, b)
+		}
+	}
+
+	sort.Sort(blobsByPath(dropletBlobs))
+
+	return dropletBlobs, nil
+}
+
+func storedDroplets(blobs []blob.Blob) map[string]bool {
+	droplets := map[string]bool{}
+	for _, b := range blobs {
+		if strings.HasSuffix(b.Path, "-droplet.tgz") {
+			droplets[strings.TrimSuffix(b.Path, "-droplet.tgz")] = true
+		}
+	}
+
+	return droplets
+}
+
+func blobOwner(path string, droplets map[string]bool) string {
+	owner := ""
+	for dropletName := range droplets {
+		if strings.HasPrefix(path, dropletName+"-") && len(dropletName) > len(owner) {
+			owner = dropletName
+		}
+	}
+
+	return owner
+}
+
+type blobsByPath []blob.Blob
+
+func (b blobsByPath) Len() int           { return len(b) }
+func (b blobsByPath) Less(i, j int) bool { return b[i].Path < b[j].Path }
+func (b blobsByPath) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,272 @@
+package droplet_runner_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/fake_blob_store"
+
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+var _ = Describe("DropletMigrator", func() {
+	var (
+		source, destination *fake_blob_store.FakeBlobStore
+		sourceContents      map[string]string
+		uploadedContents    map[string]string
+		migrations          []droplet_runner.BlobMigration
+		migrator            *droplet_runner.DropletMigrator
+		options             droplet_runner.MigrateOptions
+	)
+
+	BeforeEach(func() {
+		source = &fake_blob_store.FakeBlobStore{}
+		destination = &fake_blob_store.FakeBlobStore{}
+
+		sourceContents = map[string]string{
+			"app-droplet.tgz":    "app droplet",
+			"app-v2-droplet.tgz": "app v2 droplet",
+			"other-bits.zip":     "bits",
+		}
+		source.ListReturns([]blob.Blob{
+			{Path: "app-v2-droplet.tgz", Size: 14},
+			{Path: "app-droplet.tgz", Size: 11},
+			{Path: "other-bits.zip", Size: 4},
+		}, nil)
+		source.DownloadStub = func(path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(sourceContents[path])), nil
+		}
+
+		uploadedContents = map[string]string{}
+		destination.UploadStub = func(path string, contents io.ReadSeeker, _ blob.Progress) error {
+			contentBytes, err := ioutil.ReadAll(contents)
+			Expect(err).NotTo(HaveOccurred())
+			uploadedContents[path] = string(contentBytes)
+			return nil
+		}
+		destination.DownloadStub = func(path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(uploadedContents[path])), nil
+		}
+
+		migrations = []droplet_runner.BlobMigration{}
+		options = droplet_runner.MigrateOptions{
+			Progress: func(migration droplet_runner.BlobMigration) {
+				migrations = append(migrations, migration)
+			},
+		}
+
+		migrator = &droplet_runner.DropletMigrator{Source: source, Destination: destination}
+	})
+
+	It("copies every droplet to the destination", func() {
+		Expect(migrator.Migrate(options)).To(Succeed())
+
+		Expect(uploadedContents).To(Equal(map[string]string{
+			"app-droplet.tgz":    "app droplet",
+			"app-v2-droplet.tgz": "app v2 droplet",
+		}))
+		Expect(migrations).To(Equal([]droplet_runner.BlobMigration{
+			{Path: "app-droplet.tgz", Size: 11},
+			{Path: "app-v2-droplet.tgz", Size: 14},
+		}))
+	})
+
+	It("copies only the named droplets", func() {
+		options.DropletNames = []string{"app"}
+
+		Expect(migrator.Migrate(options)).To(Succeed())
+
+		Expect(uploadedContents).To(Equal(map[string]string{"app-droplet.tgz": "app droplet"}))
+	})
+
+	It("copies every version of a named droplet along with its tags", func() {
+		sourceContents["web@v1-droplet.tgz"] = "web v1"
+		sourceContents["web@v2-droplet.tgz"] = "web v2"
+		sourceContents["web@latest.tag"] = "v2"
+		sourceContents["web@stable.tag"] = "v1"
+		source.ListReturns([]blob.Blob{
+			{Path: "app-droplet.tgz", Size: 11},
+			{Path: "web@latest.tag", Size: 2},
+			{Path: "web@stable.tag", Size: 2},
+			{Path: "web@v1-droplet.tgz", Size: 6},
+			{Path: "web@v2-droplet.tgz", Size: 6},
+		}, nil)
+
+		options.DropletNames = []string{"web"}
+		Expect(migrator.Migrate(options)).To(Succeed())
+
+		Expect(uploadedContents).To(Equal(map[string]string{
+			"web@latest.tag":     "v2",
+			"web@stable.tag":     "v1",
+			"web@v1-droplet.tgz": "web v1",
+			"web@v2-droplet.tgz": "web v2",
+		}))
+	})
+
+	It("returns an error for an unknown droplet", func() {
+		options.DropletNames = []string{"missing"}
+
+		Expect(migrator.Migrate(options)).To(MatchError("droplet not found: missing"))
+		Expect(destination.UploadCallCount()).To(Equal(0))
+	})
+
+	Context("when blobs are already in the destination", func() {
+		BeforeEach(func() {
+			uploadedContents["app-droplet.tgz"] = "app droplet"
+			uploadedContents["app-v2-droplet.tgz"] = "app"
+			destination.ListReturns([]blob.Blob{
+				{Path: "app-droplet.tgz", Size: 11},
+				{Path: "app-v2-droplet.tgz", Size: 3},
+			}, nil)
+		})
+
+		It("skips blobs with the same contents", func() {
+			Expect(migrator.Migrate(options)).To(Succeed())
+
+			Expect(destination.UploadCallCount()).To(Equal(1))
+			path, _, _ := destination.UploadArgsForCall(0)
+			Expect(path).To(Equal("app-v2-droplet.tgz"))
+			Expect(uploadedContents["app-v2-droplet.tgz"]).To(Equal("app v2 droplet"))
+			Expect(migrations).To(Equal([]droplet_runner.BlobMigration{
+				{Path: "app-droplet.tgz", Size: 11, Skipped: true},
+				{Path: "app-v2-droplet.tgz", Size: 14},
+			}))
+		})
+
+		It("copies blobs of the same size with different contents", func() {
+			uploadedContents["app-droplet.tgz"] = "old droplet"
+
+			Expect(migrator.Migrate(options)).To(Succeed())
+
+			Expect(uploadedContents["app-droplet.tgz"]).To(Equal("app droplet"))
+			Expect(migrations).To(ContainElement(droplet_runner.BlobMigration{Path: "app-droplet.tgz", Size: 11}))
+		})
+
+		Context("when the source blob has a checksum", func() {
+			BeforeEach(func() {
+				hash := sha256.Sum256([]byte("app droplet"))
+				sourceContents["app-droplet.tgz.sha256"] = hex.EncodeToString(hash[:])
+				source.ListReturns([]blob.Blob{
+					{Path: "app-droplet.tgz", Size: 11},
+					{Path: "app-droplet.tgz.sha256", Size: 64},
+				}, nil)
+				uploadedContents["app-droplet.tgz.sha256"] = sourceContents["app-droplet.tgz.sha256"]
+				destination.ListReturns([]blob.Blob{
+					{Path: "app-droplet.tgz", Size: 11},
+					{Path: "app-droplet.tgz.sha256", Size: 64},
+				}, nil)
+			})
+
+			It("compares the destination blob against the checksum", func() {
+				Expect(migrator.Migrate(options)).To(Succeed())
+
+				for i := 0; i < source.DownloadCallCount(); i++ {
+					Expect(source.DownloadArgsForCall(i)).NotTo(Equal("app-droplet.tgz"))
+				}
+				Expect(destination.UploadCallCount()).To(Equal(0))
+				Expect(migrations).To(Equal([]droplet_runner.BlobMigration{
+					{Path: "app-droplet.tgz", Size: 11, Skipped: true},
+					{Path: "app-droplet.tgz.sha256", Size: 64, Skipped: true},
+				}))
+			})
+
+			It("copies the blob when it does not match the checksum", func() {
+				uploadedContents["app-droplet.tgz"] = "old droplet"
+
+				Expect(migrator.Migrate(options)).To(Succeed())
+
+				Expect(destination.UploadCallCount()).To(Equal(1))
+				Expect(uploadedContents["app-droplet.tgz"]).To(Equal("app droplet"))
+			})
+		})
+
+		It("returns an error when the destination blob cannot be checked", func() {
+			destination.DownloadStub = nil
+			destination.DownloadReturns(nil, errors.New("some error"))
+
+			Expect(migrator.Migrate(options)).To(MatchError("checking app-droplet.tgz: some error"))
+			Expect(destination.UploadCallCount()).To(Equal(0))
+		})
+	})
+
+	It("reports the blobs without copying them on a dry run", func() {
+		options.DryRun = true
+
+		Expect(migrator.Migrate(options)).To(Succeed())
+
+		Expect(source.DownloadCallCount()).To(Equal(0))
+		Expect(destination.UploadCallCount()).To(Equal(0))
+		Expect(migrations).To(HaveLen(2))
+	})
+
+	It("returns an error when the source blob is truncated", func() {
+		sourceContents["app-droplet.tgz"] = "app"
+
+		Expect(migrator.Migrate(options)).To(MatchError("copying app-droplet.tgz: downloaded 3 bytes, expected 11"))
+		Expect(destination.UploadCallCount()).To(Equal(0))
+	})
+
+	It("returns an error when the uploaded blob does not match", func() {
+		destination.DownloadStub = func(path string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader("app-droplet")), nil
+		}
+
+		Expect(migrator.Migrate(options)).To(MatchError("copying app-droplet.tgz: checksum mismatch after upload"))
+	})
+
+	It("returns an error when the upload fails", func() {
+		destination.UploadStub = nil
+		destination.UploadReturns(errors.New("some error"))
+
+		Expect(migrator.Migrate(options)).To(MatchError("copying app-droplet.tgz: some error"))
+		Expect(migrations).To(BeEmpty())
+	})
+
+	It("returns an error when a droplet store cannot be listed", func() {
+		destination.ListReturns(nil, errors.New("some error"))
+
+		Expect(migrator.Migrate(options)).To(MatchError("listing destination droplet store: some error"))
+	})
+})
+
+var _ = Describe("DropletRunner MigrateDroplets", func() {
+	var sourceDir, destinationDir string
+
+	BeforeEach(func() {
+		var err error
+		sourceDir, err = ioutil.TempDir("", "source")
+		Expect(err).NotTo(HaveOccurred())
+		destinationDir, err = ioutil.TempDir("", "destination")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(ioutil.WriteFile(filepath.Join(sourceDir, "app-droplet.tgz"), []byte("some droplet"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(sourceDir)).To(Succeed())
+		Expect(os.RemoveAll(destinationDir)).To(Succeed())
+	})
+
+	It("copies droplets between the blob stores of the given configs", func() {
+		sourceConfig := config_package.New(nil)
+		sourceConfig.SetLocalBlobStore(sourceDir, "some-host", "8445", "some-user", "some-password")
+		destinationConfig := config_package.New(nil)
+		destinationConfig.SetLocalBlobStore(destinationDir, "some-host", "8445", "some-user", "some-password")
+
+		dropletRunner := droplet_runner.New(nil, nil, sourceConfig, nil, nil, nil)
+		Expect(dropletRunner.MigrateDroplets(sourceConfig, destinationConfig, droplet_runner.MigrateOptions{})).To(Succeed())
+
+		Expect(ioutil.ReadFile(filepath.Join(destinationDir, "app-droplet.tgz"))).To(Equal([]byte("some droplet")))
+	})
+})
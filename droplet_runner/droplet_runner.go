@@ -1,11 +1,17 @@
 package droplet_runner
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +20,7 @@ import (
 	"github.com/cloudfoundry-incubator/ltc/app_examiner"
 	"github.com/cloudfoundry-incubator/ltc/app_runner"
 	"github.com/cloudfoundry-incubator/ltc/blob_store"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	"github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/task_runner"
@@ -22,23 +29,42 @@ import (
 const (
 	DropletStack  = "cflinuxfs2"
 	DropletRootFS = "preloaded:" + DropletStack
+
+	DefaultProcessType = "web"
 )
 
 //go:generate counterfeiter -o fake_droplet_runner/fake_droplet_runner.go . DropletRunner
 type DropletRunner interface {
-	UploadBits(dropletName, uploadPath string) error
-	BuildDroplet(taskName, dropletName, buildpackUrl string, environment map[string]string, memoryMB, cpuWeight, diskMB int) error
-	LaunchDroplet(appName, dropletName, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams) error
+	UploadBits(dropletName, uploadPath string, progress blob.Progress) error
+	UploadBuildpack(buildpackPath string, progress blob.Progress) (string, error)
+	BuildDroplet(taskName, dropletName string, buildpackUrls []string, environment map[string]string, memoryMB, cpuWeight, diskMB int, useBuildCache bool) error
+	LaunchDroplet(appName, dropletRef, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams) error
+	RelaunchDroplet(appName, dropletRef, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams, timeout time.Duration) error
 	ListDroplets() ([]Droplet, error)
-	RemoveDroplet(dropletName string) error
-	ExportDroplet(dropletName string) (io.ReadCloser, error)
-	ImportDroplet(dropletName, dropletPath string) error
+	RemoveDroplet(dropletRef string) error
+	ExportDroplet(dropletRef string) (io.ReadCloser, error)
+	ImportDroplet(dropletName, dropletPath string, progress blob.Progress) error
+	DropletChecksums() (map[string]string, error)
+	MigrateDroplets(source, destination *config.Config, options MigrateOptions) error
+	GCDroplets(options GCOptions) error
+	RecordBuild(dropletName string, buildpackURLs []string, environmentKeys []string, result string) error
+	DropletMetadata(dropletName string) (*DropletMetadata, error)
+	ProcessCommand(dropletRef, processType string) (string, error)
+	ListDropletMetadata() (map[string]DropletMetadata, error)
+	NewDropletVersion(dropletName string) (string, error)
+	ResolveDroplet(dropletRef string) (string, error)
+	TagDroplet(dropletRef, tag string) error
+	DropletHistory(dropletName string) ([]Droplet, error)
+	ClearBuildCache(dropletName string) error
 }
 
 type Droplet struct {
-	Name    string
-	Created time.Time
-	Size    int64
+	Name     string
+	Version  string
+	Created  time.Time
+	Size     int64
+	Checksum string
+	Tags     []string
 }
 
 type dropletRunner struct {
@@ -54,7 +80,8 @@ type dropletRunner struct {
 type BlobStore interface {
 	List() ([]blob.Blob, error)
 	Delete(path string) error
-	Upload(path string, contents io.ReadSeeker) error
+	Upload(path string, contents io.ReadSeeker, progress blob.Progress) error
+	Create(path string, contents io.ReadSeeker) error
 	Download(path string) (io.ReadCloser, error)
 
 	blob_store.DropletStore
@@ -88,65 +115,262 @@ func New(appRunner app_runner.AppRunner, taskRunner task_runner.TaskRunner, conf
 	}
 }
 
+// ListDroplets returns the version of each droplet tagged latest, or its
+// newest version when none is.
 func (dr *dropletRunner) ListDroplets() ([]Droplet, error) {
 	blobs, err := dr.blobStore.List()
 	if err != nil {
 		return nil, err
 	}
 
+	paths := map[string]bool{}
+	for _, b := range blobs {
+		paths[b.Path] = true
+	}
+
+	dropletNames := []string{}
+	versions := map[string][]Droplet{}
+	for _, b := range blobs {
+		if strings.HasSuffix(b.Path, "-droplet.tgz") {
+			droplet := dropletFromBlob(b)
+			if _, ok := versions[droplet.Name]; !ok {
+				dropletNames = append(dropletNames, droplet.Name)
+			}
+			versions[droplet.Name] = append(versions[droplet.Name], droplet)
+		}
+	}
+
 	droplets := []Droplet{}
-	for _, blob := range blobs {
-		if strings.HasSuffix(blob.Path, "-droplet.tgz") {
-			dropletName := strings.Replace(blob.Path, "-droplet.tgz", "", 1)
-			droplets = append(droplets, Droplet{Name: dropletName, Size: blob.Size, Created: blob.Created})
+	for _, dropletName := range dropletNames {
+		tags, err := dr.readTags(dropletName, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Sort(dropletsByVersion(versions[dropletName]))
+		current := versions[dropletName][0]
+		for _, droplet := range versions[dropletName] {
+			if droplet.Version != "" && droplet.Version == tags[LatestTag] {
+				current = droplet
+			}
+		}
+
+		for tag, version := range tags {
+			if version == current.Version && current.Version != "" {
+				current.Tags = append(current.Tags, tag)
+			}
 		}
+		sort.Strings(current.Tags)
+
+		droplets = append(droplets, current)
 	}
 
 	return droplets, nil
 }
 
-func (dr *dropletRunner) UploadBits(dropletName, uploadPath string) error {
-	uploadFile, err := os.Open(uploadPath)
+// UploadBits uploads only the files of the zip at uploadPath that aren't
+// already stored as resources, then the manifest cells rebuild the app from.
+// Resources stored longer ago than GCDroplets spares unused files for are
+// uploaded again, so that they aren't removed before the manifest refers to
+// them.
+func (dr *dropletRunner) UploadBits(dropletName, uploadPath string, progress blob.Progress) error {
+	archive, err := zip.OpenReader(uploadPath)
 	if err != nil {
 		return err
 	}
-	defer uploadFile.Close()
+	defer archive.Close()
+
+	manifest, err := app_bits.ReadZip(archive.File)
+	if err != nil {
+		return err
+	}
+
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return err
+	}
+
+	stored := map[string]bool{}
+	for _, b := range blobs {
+		stored[b.Path] = time.Since(b.Created) < recentFileAge
+	}
 
-	return dr.blobStore.Upload(dropletName+"-bits.zip", uploadFile)
+	missing := []int{}
+	var total int64
+	for index, file := range manifest.Files {
+		resourcePath := app_bits.ResourcePath(file.Checksum)
+		if file.Checksum == "" || stored[resourcePath] {
+			continue
+		}
+
+		stored[resourcePath] = true
+		missing = append(missing, index)
+		total += file.Size
+	}
+
+	var uploaded int64
+	for _, index := range missing {
+		file := manifest.Files[index]
+		if err := dr.uploadResource(file.Checksum, archive.File[index], offsetProgress(progress, uploaded, total)); err != nil {
+			return fmt.Errorf("%s: %s", file.Path, err)
+		}
+		uploaded += file.Size
+	}
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	_, err = dr.uploadWithChecksum(app_bits.ManifestPath(dropletName), bytes.NewReader(manifestJSON), nil)
+	return err
 }
 
-func (dr *dropletRunner) BuildDroplet(taskName, dropletName, buildpackUrl string, environment map[string]string, memoryMB, cpuWeight, diskMB int) error {
-	builderConfig := buildpack_app_lifecycle.NewLifecycleBuilderConfig([]string{buildpackUrl}, true, false)
-
-	action := models.WrapAction(&models.SerialAction{
-		Actions: []*models.Action{
-			models.WrapAction(&models.DownloadAction{
-				From: "http://file-server.service.cf.internal:8080/v1/static/cell-helpers/cell-helpers.tgz",
-				To:   "/tmp",
-				User: "vcap",
-			}),
-			models.WrapAction(&models.DownloadAction{
-				From: "http://file-server.service.cf.internal:8080/v1/static/buildpack_app_lifecycle/buildpack_app_lifecycle.tgz",
-				To:   "/tmp",
-				User: "vcap",
-			}),
-			dr.blobStore.DownloadAppBitsAction(dropletName),
-			dr.blobStore.DeleteAppBitsAction(dropletName),
-			models.WrapAction(&models.RunAction{
-				Path: "/bin/chmod",
-				Dir:  "/tmp/app",
-				Args: []string{"-R", "a+X", "."},
-				User: "vcap",
-			}),
-			models.WrapAction(&models.RunAction{
-				Path: "/tmp/builder",
-				Dir:  "/",
-				Args: builderConfig.Args(),
-				User: "vcap",
-			}),
-			dr.blobStore.UploadDropletAction(dropletName),
-		},
-	})
+// uploadResource stages the file from the zip on disk, since uploads must be
+// able to seek back to the start when they're retried.
+func (dr *dropletRunner) uploadResource(checksum string, zipFile *zip.File, progress blob.Progress) error {
+	reader, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tempFile, err := ioutil.TempFile("", "resource")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	if _, err := io.Copy(tempFile, reader); err != nil {
+		return err
+	}
+	if _, err := tempFile.Seek(0, 0); err != nil {
+		return err
+	}
+
+	return dr.blobStore.Upload(app_bits.ResourcePath(checksum), tempFile, progress)
+}
+
+// offsetProgress reports a single upload's progress as part of a larger one.
+func offsetProgress(progress blob.Progress, offset, total int64) blob.Progress {
+	if progress == nil {
+		return nil
+	}
+
+	return func(transferred, _ int64) {
+		progress(offset+transferred, total)
+	}
+}
+
+// UploadBuildpack stores the zipped buildpack at buildpackPath for builds on
+// cells that can't fetch buildpacks themselves, and returns the name to
+// build with.  The name is derived from the zip's checksum, and a buildpack
+// that is already stored isn't uploaded again.
+func (dr *dropletRunner) UploadBuildpack(buildpackPath string, progress blob.Progress) (string, error) {
+	buildpackFile, err := os.Open(buildpackPath)
+	if err != nil {
+		return "", err
+	}
+	defer buildpackFile.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, buildpackFile); err != nil {
+		return "", err
+	}
+	buildpackName := blob.BuildpackPath(hex.EncodeToString(hash.Sum(nil)))
+
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return "", err
+	}
+
+	for _, b := range blobs {
+		if b.Path == buildpackName {
+			return buildpackName, nil
+		}
+	}
+
+	if _, err := buildpackFile.Seek(0, 0); err != nil {
+		return "", err
+	}
+
+	return buildpackName, dr.blobStore.Upload(buildpackName, buildpackFile, progress)
+}
+
+// uploadedBuildpackChecksum returns the checksum of a buildpack named by
+// UploadBuildpack.
+func uploadedBuildpackChecksum(buildpack string) (string, bool) {
+	checksum := strings.TrimSuffix(buildpack, blob.BuildpackPath(""))
+	if checksum == buildpack || strings.Contains(checksum, "/") {
+		return "", false
+	}
+
+	return checksum, true
+}
+
+// BuildDroplet submits a task that builds the droplet with the first of the
+// buildpacks whose detect script accepts the app.  Detection is skipped when
+// only one buildpack is given.  The builder's artifacts cache is kept per
+// droplet name between builds; without useBuildCache the build starts from
+// an empty cache, and the cache it leaves replaces the stored one.
+// Buildpacks stored by UploadBuildpack are unzipped where the builder looks
+// for them instead of fetching them.
+func (dr *dropletRunner) BuildDroplet(taskName, dropletName string, buildpackUrls []string, environment map[string]string, memoryMB, cpuWeight, diskMB int, useBuildCache bool) error {
+	if len(buildpackUrls) == 0 {
+		return errors.New("no buildpacks given")
+	}
+
+	bitsChecksum, err := dr.checksum(app_bits.ManifestPath(dropletName))
+	if err != nil {
+		return err
+	}
+
+	skipDetect := len(buildpackUrls) == 1
+	builderConfig := buildpack_app_lifecycle.NewLifecycleBuilderConfig(buildpackUrls, skipDetect, false)
+
+	cacheName, _ := splitDropletRef(dropletName)
+
+	actions := []*models.Action{
+		models.WrapAction(&models.DownloadAction{
+			From: "http://file-server.service.cf.internal:8080/v1/static/cell-helpers/cell-helpers.tgz",
+			To:   "/tmp",
+			User: "vcap",
+		}),
+		models.WrapAction(&models.DownloadAction{
+			From: "http://file-server.service.cf.internal:8080/v1/static/buildpack_app_lifecycle/buildpack_app_lifecycle.tgz",
+			To:   "/tmp",
+			User: "vcap",
+		}),
+		dr.blobStore.DownloadAppBitsAction(dropletName, bitsChecksum),
+		dr.blobStore.DeleteAppBitsAction(dropletName),
+	}
+	if useBuildCache {
+		actions = append(actions, dr.blobStore.DownloadBuildCacheAction(cacheName))
+	}
+	for _, buildpack := range buildpackUrls {
+		if checksum, ok := uploadedBuildpackChecksum(buildpack); ok {
+			actions = append(actions, dr.blobStore.DownloadBuildpackAction(checksum, builderConfig.BuildpackPath(buildpack)))
+		}
+	}
+	actions = append(actions,
+		models.WrapAction(&models.RunAction{
+			Path: "/bin/chmod",
+			Dir:  "/tmp/app",
+			Args: []string{"-R", "a+X", "."},
+			User: "vcap",
+		}),
+		models.WrapAction(&models.RunAction{
+			Path: "/tmp/builder",
+			Dir:  "/",
+			Args: builderConfig.Args(),
+			User: "vcap",
+		}),
+		dr.blobStore.UploadDropletAction(dropletName),
+		dr.blobStore.UploadBuildCacheAction(cacheName),
+	)
+
+	action := models.WrapAction(&models.SerialAction{Actions: actions})
 
 	environment["CF_STACK"] = DropletStack
 	environment["MEMORY_LIMIT"] = fmt.Sprintf("%dM", memoryMB)
@@ -171,17 +395,59 @@ func (dr *dropletRunner) BuildDroplet(taskName, dropletName, buildpackUrl string
 		cpuWeight,
 		diskMB,
 	)
+	createTaskParams.SetResultFile(builderConfig.OutputMetadata())
 
 	return dr.taskRunner.CreateTask(createTaskParams)
 }
 
-func (dr *dropletRunner) LaunchDroplet(appName, dropletName string, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams) error {
+// LaunchDroplet runs the droplet as an app.  Without a start command the app
+// runs the droplet's web process.
+func (dr *dropletRunner) LaunchDroplet(appName, dropletRef string, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams) error {
+	appParams, err := dr.createAppParams(appName, dropletRef, startCommand, startArgs, appEnvironmentParams)
+	if err != nil {
+		return err
+	}
+
+	return dr.appRunner.CreateApp(appParams)
+}
+
+// RelaunchDroplet replaces a running app with one launched from the droplet.
+// The app keeps running until the new app's instances are running, which
+// they must be within timeout.
+func (dr *dropletRunner) RelaunchDroplet(appName, dropletRef string, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams, timeout time.Duration) error {
+	appParams, err := dr.createAppParams(appName, dropletRef, startCommand, startArgs, appEnvironmentParams)
+	if err != nil {
+		return err
+	}
+	appParams.Timeout = timeout
+
+	return dr.appRunner.ReplaceApp(appParams)
+}
+
+func (dr *dropletRunner) createAppParams(appName, dropletRef string, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams) (app_runner.CreateAppParams, error) {
+	dropletName, err := dr.ResolveDroplet(dropletRef)
+	if err != nil {
+		return app_runner.CreateAppParams{}, err
+	}
+
+	if startCommand == "" {
+		startCommand, err = dr.ProcessCommand(dropletName, DefaultProcessType)
+		if err != nil {
+			return app_runner.CreateAppParams{}, err
+		}
+	}
+
+	dropletChecksum, err := dr.checksum(dropletName + "-droplet.tgz")
+	if err != nil {
+		return app_runner.CreateAppParams{}, err
+	}
+
 	dropletAnnotation := annotation{}
 	dropletAnnotation.DropletSource.DropletName = dropletName
 
 	annotationBytes, err := json.Marshal(dropletAnnotation)
 	if err != nil {
-		return err
+		return app_runner.CreateAppParams{}, err
 	}
 
 	if appEnvironmentParams.EnvironmentVariables == nil {
@@ -194,7 +460,7 @@ func (dr *dropletRunner) LaunchDroplet(appName, dropletName string, startCommand
 
 	proxyConf, err := dr.proxyConfReader.ProxyConf()
 	if err != nil {
-		return err
+		return app_runner.CreateAppParams{}, err
 	}
 	appEnvironmentParams.EnvironmentVariables["http_proxy"] = proxyConf.HTTPProxy
 	appEnvironmentParams.EnvironmentVariables["https_proxy"] = proxyConf.HTTPSProxy
@@ -222,19 +488,34 @@ func (dr *dropletRunner) LaunchDroplet(appName, dropletName string, startCommand
 					To:   "/tmp",
 					User: "vcap",
 				}),
-				dr.blobStore.DownloadDropletAction(dropletName),
+				dr.blobStore.DownloadDropletAction(dropletName, dropletChecksum),
 			},
 		}),
 	}
 
-	return dr.appRunner.CreateApp(appParams)
+	return appParams, nil
 }
 
+// dropletMatchesAnnotation reports whether the app was launched from the
+// droplet, or from any version of it when dropletName has no version.
 func dropletMatchesAnnotation(dropletName string, a annotation) bool {
-	return a.DropletSource.DropletName == dropletName
+	launchedFrom := a.DropletSource.DropletName
+	return launchedFrom == dropletName || strings.HasPrefix(launchedFrom, dropletName+"@")
 }
 
-func (dr *dropletRunner) RemoveDroplet(dropletName string) error {
+// RemoveDroplet removes a single version of the droplet, or every version
+// and tag of it when dropletRef has no version.
+func (dr *dropletRunner) RemoveDroplet(dropletRef string) error {
+	dropletName, version := splitDropletRef(dropletRef)
+	prefixes := []string{dropletName + "-", dropletName + "@"}
+	if version != "" {
+		var err error
+		if dropletName, err = dr.ResolveDroplet(dropletRef); err != nil {
+			return err
+		}
+		prefixes = []string{dropletName + "-"}
+	}
+
 	apps, err := dr.appExaminer.ListApps()
 	if err != nil {
 		return err
@@ -255,13 +536,25 @@ func (dr *dropletRunner) RemoveDroplet(dropletName string) error {
 		return err
 	}
 
+	if version != "" {
+		tagged, err := dr.taggedDroplets(blobs)
+		if err != nil {
+			return err
+		}
+		if tagged[dropletName] {
+			return fmt.Errorf("droplet %s is tagged; tag another version first", dropletName)
+		}
+	}
+
 	found := false
 	for _, blob := range blobs {
-		if strings.HasPrefix(blob.Path, dropletName+"-") {
-			if err := dr.blobStore.Delete(blob.Path); err != nil {
-				return err
-			} else {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(blob.Path, prefix) {
+				if err := dr.blobStore.Delete(blob.Path); err != nil {
+					return err
+				}
 				found = true
+				break
 			}
 		}
 	}
@@ -273,25 +566,168 @@ func (dr *dropletRunner) RemoveDroplet(dropletName string) error {
 	return nil
 }
 
-func (dr *dropletRunner) ExportDroplet(dropletName string) (io.ReadCloser, error) {
-	dropletReader, err := dr.blobStore.Download(dropletName + "-droplet.tgz")
+// ClearBuildCache removes the build artifacts cache kept for the droplet, so
+// that its next build starts from scratch.
+func (dr *dropletRunner) ClearBuildCache(dropletName string) error {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		if blob.Path == dropletName+"-cache.tgz" {
+			return dr.blobStore.Delete(blob.Path)
+		}
+	}
+
+	return fmt.Errorf("droplet %s has no build cache", dropletName)
+}
+
+func (dr *dropletRunner) ExportDroplet(dropletRef string) (io.ReadCloser, error) {
+	dropletName, err := dr.ResolveDroplet(dropletRef)
+	if err != nil {
+		return nil, err
+	}
+
+	dropletPath := dropletName + "-droplet.tgz"
+
+	checksum, err := dr.checksum(dropletPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dropletReader, err := dr.blobStore.Download(dropletPath)
 	if err != nil {
 		return nil, fmt.Errorf("droplet not found: %s", err)
 	}
 
-	return dropletReader, err
+	if checksum == "" {
+		return dropletReader, nil
+	}
+
+	return blob.NewChecksumReader(dropletReader, checksum), nil
 }
 
-func (dr *dropletRunner) ImportDroplet(dropletName, dropletPath string) error {
+func (dr *dropletRunner) ImportDroplet(dropletName, dropletPath string, progress blob.Progress) error {
 	dropletFile, err := os.Open(dropletPath)
 	if err != nil {
 		return err
 	}
 	defer dropletFile.Close()
 
-	if err := dr.blobStore.Upload(dropletName+"-droplet.tgz", dropletFile); err != nil {
+	checksum, err := dr.uploadWithChecksum(dropletName+"-droplet.tgz", dropletFile, progress)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	// cells and exports check the droplet against the stored checksum, so
+	// make sure it is the one computed for this upload
+	storedChecksum, err := dr.readChecksum(dropletName + "-droplet.tgz")
+	if err != nil {
+		return fmt.Errorf("verifying upload: %s", err)
+	}
+	if storedChecksum != checksum {
+		return fmt.Errorf("verifying upload: checksum mismatch: expected %s, got %s", checksum, storedChecksum)
+	}
+
+	return dr.saveMetadata(dropletName, DropletMetadata{
+		SourceChecksum: checksum,
+		Builder:        dr.builder(),
+		Imported:       true,
+		CreatedAt:      time.Now(),
+	})
+}
+
+func (dr *dropletRunner) DropletChecksums() (map[string]string, error) {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]bool{}
+	for _, b := range blobs {
+		paths[b.Path] = true
+	}
+
+	checksums := map[string]string{}
+	for _, b := range blobs {
+		if strings.HasSuffix(b.Path, "-droplet.tgz") && paths[blob.ChecksumPath(b.Path)] {
+			checksum, err := dr.readChecksum(b.Path)
+			if err != nil {
+				return nil, err
+			}
+			checksums[strings.TrimSuffix(b.Path, "-droplet.tgz")] = checksum
+		}
+	}
+
+	return checksums, nil
+}
+
+// uploadWithChecksum stores the SHA-256 checksum of contents alongside the
+// blob for exports and cells to verify the blob against.
+func (dr *dropletRunner) uploadWithChecksum(path string, contents io.ReadSeeker, progress blob.Progress) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, contents); err != nil {
+		return "", err
+	}
+	if _, err := contents.Seek(0, 0); err != nil {
+		return "", err
+	}
+
+	if err := dr.blobStore.Upload(path, contents, progress); err != nil {
+		return "", err
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := dr.blobStore.Upload(blob.ChecksumPath(path), strings.NewReader(checksum), nil); err != nil {
+		return "", err
+	}
+
+	return checksum, nil
+}
+
+// checksum returns the checksum stored alongside the blob at path, or "" for
+// blobs stored before ltc recorded checksums.
+func (dr *dropletRunner) checksum(path string) (string, error) {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return "", err
+	}
+
+	for _, b := range blobs {
+		if b.Path == blob.ChecksumPath(path) {
+			return dr.readChecksum(path)
+		}
+	}
+
+	return "", nil
+}
+
+func (dr *dropletRunner) readChecksum(path string) (string, error) {
+	return readChecksum(dr.blobStore, path)
+}
+
+// readChecksum reads the checksum stored alongside the blob at path.
+func readChecksum(store BlobStore, path string) (string, error) {
+	reader, err := store.Download(blob.ChecksumPath(path))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	checksum, err := ioutil.ReadAll(io.LimitReader(reader, sha256.Size*2))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(checksum)), nil
+}
+
+func (dr *dropletRunner) MigrateDroplets(source, destination *config.Config, options MigrateOptions) error {
+	migrator := &DropletMigrator{
+		Source:      blob_store.New(source),
+		Destination: blob_store.New(destination),
+	}
+
+	return migrator.Migrate(options)
 }
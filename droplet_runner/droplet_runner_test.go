@@ -1,6 +1,11 @@
 package droplet_runner_test
 
 import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -19,6 +24,7 @@ import (
 	"github.com/cloudfoundry-incubator/ltc/app_examiner/fake_app_examiner"
 	"github.com/cloudfoundry-incubator/ltc/app_runner"
 	"github.com/cloudfoundry-incubator/ltc/app_runner/fake_app_runner"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/app_bits"
 	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
 	"github.com/cloudfoundry-incubator/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
@@ -41,6 +47,8 @@ var _ = Describe("DropletRunner", func() {
 		dropletRunner       droplet_runner.DropletRunner
 	)
 
+	const someContentsChecksum = "b9e6fc6474139fd230ff8a7a9699484c015cb585e1537efad21ae5edf7f79832"
+
 	BeforeEach(func() {
 		fakeAppRunner = &fake_app_runner.FakeAppRunner{}
 		fakeTaskRunner = &fake_task_runner.FakeTaskRunner{}
@@ -74,42 +82,179 @@ var _ = Describe("DropletRunner", func() {
 	})
 
 	Describe("UploadBits", func() {
-		Context("when the archive path is a file and exists", func() {
-			var tmpFile *os.File
+		var (
+			zipPath         string
+			uploadedBlobs   map[string]string
+			progressUpdates []int64
+		)
 
-			BeforeEach(func() {
-				tmpDir := os.TempDir()
-				var err error
-				tmpFile, err = ioutil.TempFile(tmpDir, "tmp_file")
+		BeforeEach(func() {
+			zipFile, err := ioutil.TempFile("", "bits")
+			Expect(err).NotTo(HaveOccurred())
+			defer zipFile.Close()
+			zipPath = zipFile.Name()
+
+			zipWriter := zip.NewWriter(zipFile)
+			for _, entry := range []struct{ name, contents string }{
+				{"app/", ""},
+				{"app/server.js", "some contents"},
+				{"app/copy.js", "some contents"},
+				{"package.json", "{}"},
+			} {
+				writer, err := zipWriter.Create(entry.name)
 				Expect(err).NotTo(HaveOccurred())
+				_, err = writer.Write([]byte(entry.contents))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(zipWriter.Close()).To(Succeed())
 
-				Expect(ioutil.WriteFile(tmpFile.Name(), []byte("some contents"), 0600)).To(Succeed())
-			})
+			uploadedBlobs = map[string]string{}
+			fakeBlobStore.UploadStub = func(path string, contents io.ReadSeeker, progress blob.Progress) error {
+				contentBytes, err := ioutil.ReadAll(contents)
+				Expect(err).NotTo(HaveOccurred())
+				uploadedBlobs[path] = string(contentBytes)
+				if progress != nil {
+					progress(int64(len(contentBytes)), int64(len(contentBytes)))
+				}
+				return nil
+			}
 
-			AfterEach(func() {
-				tmpFile.Close()
-				Expect(os.Remove(tmpFile.Name())).To(Succeed())
-			})
+			progressUpdates = []int64{}
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(zipPath)).To(Succeed())
+		})
 
-			It("uploads the file to the bucket", func() {
-				Expect(dropletRunner.UploadBits("droplet-name", tmpFile.Name())).To(Succeed())
+		It("uploads each distinct file as a resource named by its checksum", func() {
+			Expect(dropletRunner.UploadBits("droplet-name", zipPath, func(transferred, total int64) {
+				Expect(total).To(Equal(int64(15)))
+				progressUpdates = append(progressUpdates, transferred)
+			})).To(Succeed())
 
-				Expect(fakeBlobStore.UploadCallCount()).To(Equal(1))
-				path, _ := fakeBlobStore.UploadArgsForCall(0)
-				Expect(path).To(Equal("droplet-name-bits.zip"))
-			})
+			Expect(uploadedBlobs).To(HaveKeyWithValue(someContentsChecksum+".resource", "some contents"))
+			Expect(uploadedBlobs).To(HaveKeyWithValue("44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a.resource", "{}"))
+			Expect(progressUpdates).To(Equal([]int64{13, 15}))
+		})
 
-			It("returns an error when we fail to open the droplet bits", func() {
-				err := dropletRunner.UploadBits("droplet-name", "some non-existent file")
-				Expect(reflect.TypeOf(err).String()).To(Equal("*os.PathError"))
-			})
+		It("skips files already stored as resources", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{{Path: someContentsChecksum + ".resource", Created: time.Now()}}, nil)
 
-			It("returns an error when the upload fails", func() {
-				fakeBlobStore.UploadReturns(errors.New("some error"))
+			Expect(dropletRunner.UploadBits("droplet-name", zipPath, nil)).To(Succeed())
 
-				err := dropletRunner.UploadBits("droplet-name", tmpFile.Name())
-				Expect(err).To(MatchError("some error"))
-			})
+			Expect(uploadedBlobs).NotTo(HaveKey(someContentsChecksum + ".resource"))
+			Expect(uploadedBlobs).To(HaveLen(3))
+		})
+
+		It("uploads files stored over an hour ago again, so removing unused files spares them", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{{Path: someContentsChecksum + ".resource", Created: time.Now().Add(-2 * time.Hour)}}, nil)
+
+			Expect(dropletRunner.UploadBits("droplet-name", zipPath, nil)).To(Succeed())
+
+			Expect(uploadedBlobs).To(HaveKeyWithValue(someContentsChecksum+".resource", "some contents"))
+		})
+
+		It("uploads the manifest of the app's files with its checksum", func() {
+			Expect(dropletRunner.UploadBits("droplet-name", zipPath, nil)).To(Succeed())
+
+			manifest := app_bits.Manifest{}
+			Expect(json.Unmarshal([]byte(uploadedBlobs["droplet-name-bits.json"]), &manifest)).To(Succeed())
+			Expect(manifest.Files).To(HaveLen(4))
+			Expect(manifest.Files[0].Path).To(Equal("app"))
+			Expect(manifest.Files[0].Mode.IsDir()).To(BeTrue())
+			Expect(manifest.Files[2]).To(Equal(app_bits.File{
+				Path:     "app/copy.js",
+				Mode:     manifest.Files[2].Mode,
+				Size:     13,
+				Checksum: someContentsChecksum,
+			}))
+
+			checksum := sha256.Sum256([]byte(uploadedBlobs["droplet-name-bits.json"]))
+			Expect(uploadedBlobs).To(HaveKeyWithValue("droplet-name-bits.json.sha256", hex.EncodeToString(checksum[:])))
+		})
+
+		It("returns an error when we fail to open the droplet bits", func() {
+			err := dropletRunner.UploadBits("droplet-name", "some non-existent file", nil)
+			Expect(reflect.TypeOf(err).String()).To(Equal("*os.PathError"))
+		})
+
+		It("returns an error when listing the stored resources fails", func() {
+			fakeBlobStore.ListReturns(nil, errors.New("some error"))
+
+			err := dropletRunner.UploadBits("droplet-name", zipPath, nil)
+			Expect(err).To(MatchError("some error"))
+			Expect(fakeBlobStore.UploadCallCount()).To(BeZero())
+		})
+
+		It("returns an error when the upload fails", func() {
+			fakeBlobStore.UploadStub = nil
+			fakeBlobStore.UploadReturns(errors.New("some error"))
+
+			err := dropletRunner.UploadBits("droplet-name", zipPath, nil)
+			Expect(err).To(MatchError("app/server.js: some error"))
+		})
+	})
+
+	Describe("UploadBuildpack", func() {
+		var (
+			buildpackPath, buildpackName string
+			uploadedBuildpack            string
+		)
+
+		BeforeEach(func() {
+			buildpackFile, err := ioutil.TempFile("", "buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			defer buildpackFile.Close()
+			buildpackPath = buildpackFile.Name()
+
+			_, err = buildpackFile.WriteString("some buildpack")
+			Expect(err).NotTo(HaveOccurred())
+
+			checksum := sha256.Sum256([]byte("some buildpack"))
+			buildpackName = hex.EncodeToString(checksum[:]) + ".buildpack.zip"
+
+			uploadedBuildpack = ""
+			fakeBlobStore.UploadStub = func(path string, contents io.ReadSeeker, progress blob.Progress) error {
+				contentBytes, err := ioutil.ReadAll(contents)
+				Expect(err).NotTo(HaveOccurred())
+				uploadedBuildpack = string(contentBytes)
+				return nil
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(buildpackPath)).To(Succeed())
+		})
+
+		It("uploads the buildpack under its checksum", func() {
+			Expect(dropletRunner.UploadBuildpack(buildpackPath, nil)).To(Equal(buildpackName))
+
+			Expect(fakeBlobStore.UploadCallCount()).To(Equal(1))
+			path, _, _ := fakeBlobStore.UploadArgsForCall(0)
+			Expect(path).To(Equal(buildpackName))
+			Expect(uploadedBuildpack).To(Equal("some buildpack"))
+		})
+
+		It("doesn't upload a buildpack that is already stored", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{{Path: buildpackName}}, nil)
+
+			Expect(dropletRunner.UploadBuildpack(buildpackPath, nil)).To(Equal(buildpackName))
+
+			Expect(fakeBlobStore.UploadCallCount()).To(BeZero())
+		})
+
+		It("returns an error when the buildpack can't be read", func() {
+			_, err := dropletRunner.UploadBuildpack(filepath.Join(buildpackPath, "missing"), nil)
+			Expect(err).To(HaveOccurred())
+
+			Expect(fakeBlobStore.UploadCallCount()).To(BeZero())
+		})
+
+		It("returns an error when the upload fails", func() {
+			fakeBlobStore.UploadReturns(errors.New("no space"))
+
+			_, err := dropletRunner.UploadBuildpack(buildpackPath, nil)
+			Expect(err).To(MatchError("no space"))
 		})
 	})
 
@@ -125,16 +270,17 @@ var _ = Describe("DropletRunner", func() {
 				config.BlobStore().Port,
 				"/blobs/droplet-name")
 
-			fakeBlobStore.DownloadAppBitsActionReturns(models.WrapAction(&models.DownloadAction{
-				From: blobURL + "-bits.zip",
-				To:   "/tmp/app",
+			fakeBlobStore.DownloadAppBitsActionReturns(models.WrapAction(&models.RunAction{
+				Path: "/tmp/davtool",
+				Dir:  "/",
+				Args: []string{"get-app", blobURL + "-bits.json", "/tmp/app"},
 				User: "vcap",
 			}))
 
 			fakeBlobStore.DeleteAppBitsActionReturns(models.WrapAction(&models.RunAction{
 				Path: "/tmp/davtool",
 				Dir:  "/",
-				Args: []string{"delete", blobURL + "-bits.zip"},
+				Args: []string{"delete", blobURL + "-bits.json"},
 				User: "vcap",
 			}))
 
@@ -144,7 +290,22 @@ var _ = Describe("DropletRunner", func() {
 				Args: []string{"put", blobURL + "-droplet.tgz", "/tmp/droplet"},
 				User: "vcap",
 			}))
-			err := dropletRunner.BuildDroplet("task-name", "droplet-name", "buildpack", map[string]string{}, 128, 100, 800)
+
+			fakeBlobStore.DownloadBuildCacheActionReturns(models.WrapAction(&models.RunAction{
+				Path: "/tmp/davtool",
+				Dir:  "/",
+				Args: []string{"get", blobURL + "-cache.tgz", "/tmp/cache.tgz"},
+				User: "vcap",
+			}))
+
+			fakeBlobStore.UploadBuildCacheActionReturns(models.WrapAction(&models.RunAction{
+				Path: "/tmp/davtool",
+				Dir:  "/",
+				Args: []string{"put", blobURL + "-cache.tgz", "/tmp/output-cache"},
+				User: "vcap",
+			}))
+
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, map[string]string{}, 128, 100, 800, true)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(1))
@@ -164,15 +325,22 @@ var _ = Describe("DropletRunner", func() {
 						To:   "/tmp",
 						User: "vcap",
 					}),
-					models.WrapAction(&models.DownloadAction{
-						From: blobURL + "-bits.zip",
-						To:   "/tmp/app",
+					models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"get-app", blobURL + "-bits.json", "/tmp/app"},
 						User: "vcap",
 					}),
 					models.WrapAction(&models.RunAction{
 						Path: "/tmp/davtool",
 						Dir:  "/",
-						Args: []string{"delete", blobURL + "-bits.zip"},
+						Args: []string{"delete", blobURL + "-bits.json"},
+						User: "vcap",
+					}),
+					models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"get", blobURL + "-cache.tgz", "/tmp/cache.tgz"},
 						User: "vcap",
 					}),
 					models.WrapAction(&models.RunAction{
@@ -203,6 +371,12 @@ var _ = Describe("DropletRunner", func() {
 						Args: []string{"put", blobURL + "-droplet.tgz", "/tmp/droplet"},
 						User: "vcap",
 					}),
+					models.WrapAction(&models.RunAction{
+						Path: "/tmp/davtool",
+						Dir:  "/",
+						Args: []string{"put", blobURL + "-cache.tgz", "/tmp/output-cache"},
+						User: "vcap",
+					}),
 				},
 			})
 			Expect(receptorRequest.Action).To(Equal(expectedActions))
@@ -223,6 +397,7 @@ var _ = Describe("DropletRunner", func() {
 			Expect(receptorRequest.EgressRules).ToNot(BeNil())
 			Expect(receptorRequest.EgressRules).To(BeEmpty())
 			Expect(receptorRequest.MemoryMB).To(Equal(128))
+			Expect(receptorRequest.ResultFile).To(Equal("/tmp/result.json"))
 		})
 
 		It("passes through user environment variables", func() {
@@ -234,7 +409,7 @@ var _ = Describe("DropletRunner", func() {
 				"OTHER_VAR": "same",
 			}
 
-			err := dropletRunner.BuildDroplet("task-name", "droplet-name", "buildpack", env, 128, 100, 800)
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, env, 128, 100, 800, true)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(1))
@@ -265,7 +440,7 @@ var _ = Describe("DropletRunner", func() {
 				NoProxy:    "no-proxy",
 			}, nil)
 
-			err := dropletRunner.BuildDroplet("task-name", "droplet-name", "buildpack", env, 128, 100, 800)
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, env, 128, 100, 800, true)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(1))
@@ -286,7 +461,7 @@ var _ = Describe("DropletRunner", func() {
 			config.SetBlobStore("blob-host", "7474", "dav-user", "dav-pass")
 			Expect(config.Save()).To(Succeed())
 
-			err := dropletRunner.BuildDroplet("task-name", "droplet-name", "buildpack", map[string]string{}, 1, 2, 3)
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, map[string]string{}, 1, 2, 3, true)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(1))
@@ -297,10 +472,85 @@ var _ = Describe("DropletRunner", func() {
 			Expect(receptorRequest.DiskMB).To(Equal(3))
 		})
 
+		It("lets the builder detect among several buildpacks in order", func() {
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack-a", "buildpack-b"}, map[string]string{}, 0, 0, 0, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(1))
+			receptorRequest := fakeTaskRunner.CreateTaskArgsForCall(0).GetReceptorRequest()
+			builderAction := receptorRequest.Action.SerialAction.Actions[6].RunAction
+			Expect(builderAction.Path).To(Equal("/tmp/builder"))
+			Expect(builderAction.Args).To(ContainElement("-buildpackOrder=buildpack-a,buildpack-b"))
+			Expect(builderAction.Args).To(ContainElement("-skipDetect=false"))
+		})
+
+		It("unzips uploaded buildpacks where the builder looks for them", func() {
+			buildpackAction := models.WrapAction(&models.RunAction{Path: "/usr/bin/unzip"})
+			fakeBlobStore.DownloadBuildpackActionReturns(buildpackAction)
+
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"some-checksum.buildpack.zip", "https://buildpack.example.com/some.buildpack.zip"}, map[string]string{}, 0, 0, 0, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadBuildpackActionCallCount()).To(Equal(1))
+			checksum, destDir := fakeBlobStore.DownloadBuildpackActionArgsForCall(0)
+			Expect(checksum).To(Equal("some-checksum"))
+			Expect(destDir).To(Equal(fmt.Sprintf("/tmp/buildpacks/%x", md5.Sum([]byte("some-checksum.buildpack.zip")))))
+
+			receptorRequest := fakeTaskRunner.CreateTaskArgsForCall(0).GetReceptorRequest()
+			Expect(receptorRequest.Action.SerialAction.Actions[5]).To(Equal(buildpackAction))
+			builderAction := receptorRequest.Action.SerialAction.Actions[7].RunAction
+			Expect(builderAction.Path).To(Equal("/tmp/builder"))
+			Expect(builderAction.Args).To(ContainElement("-buildpackOrder=some-checksum.buildpack.zip,https://buildpack.example.com/some.buildpack.zip"))
+		})
+
+		It("keeps the build cache under the name of the droplet", func() {
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name@v3", []string{"buildpack"}, map[string]string{}, 0, 0, 0, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadBuildCacheActionCallCount()).To(Equal(1))
+			Expect(fakeBlobStore.DownloadBuildCacheActionArgsForCall(0)).To(Equal("droplet-name"))
+			Expect(fakeBlobStore.UploadBuildCacheActionCallCount()).To(Equal(1))
+			Expect(fakeBlobStore.UploadBuildCacheActionArgsForCall(0)).To(Equal("droplet-name"))
+		})
+
+		It("starts from an empty cache but still stores the new one without the build cache", func() {
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, map[string]string{}, 0, 0, 0, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadBuildCacheActionCallCount()).To(BeZero())
+			Expect(fakeBlobStore.UploadBuildCacheActionCallCount()).To(Equal(1))
+
+			receptorRequest := fakeTaskRunner.CreateTaskArgsForCall(0).GetReceptorRequest()
+			Expect(receptorRequest.Action.SerialAction.Actions).To(HaveLen(8))
+		})
+
+		It("returns an error when no buildpacks are given", func() {
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{}, map[string]string{}, 0, 0, 0, true)
+			Expect(err).To(MatchError("no buildpacks given"))
+
+			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(0))
+		})
+
+		It("passes the checksum of the app bits to the download action", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "droplet-name-bits.json"},
+				{Path: "droplet-name-bits.json.sha256"},
+			}, nil)
+			fakeBlobStore.DownloadReturns(ioutil.NopCloser(strings.NewReader(someContentsChecksum+"\n")), nil)
+
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, map[string]string{}, 0, 0, 0, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadArgsForCall(0)).To(Equal("droplet-name-bits.json.sha256"))
+			dropletName, checksum := fakeBlobStore.DownloadAppBitsActionArgsForCall(0)
+			Expect(dropletName).To(Equal("droplet-name"))
+			Expect(checksum).To(Equal(someContentsChecksum))
+		})
+
 		It("returns an error when ProxyConfReader fails", func() {
 			fakeProxyConfReader.ProxyConfReturns(droplet_runner.ProxyConf{}, errors.New("can't proxy"))
 
-			err := dropletRunner.BuildDroplet("task-name", "droplet-name", "buildpack", map[string]string{}, 0, 0, 0)
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, map[string]string{}, 0, 0, 0, true)
 			Expect(err).To(MatchError("can't proxy"))
 
 			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(0))
@@ -309,7 +559,7 @@ var _ = Describe("DropletRunner", func() {
 		It("returns an error when create task fails", func() {
 			fakeTaskRunner.CreateTaskReturns(errors.New("creating task failed"))
 
-			err := dropletRunner.BuildDroplet("task-name", "droplet-name", "buildpack", map[string]string{}, 0, 0, 0)
+			err := dropletRunner.BuildDroplet("task-name", "droplet-name", []string{"buildpack"}, map[string]string{}, 0, 0, 0, true)
 			Expect(err).To(MatchError("creating task failed"))
 
 			Expect(fakeTaskRunner.CreateTaskCallCount()).To(Equal(1))
@@ -369,6 +619,22 @@ var _ = Describe("DropletRunner", func() {
 			})))
 		})
 
+		It("launches the web process detected by the build without a start command", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "droplet-name-droplet.tgz"},
+				{Path: "droplet-name-metadata.json"},
+			}, nil)
+			fakeBlobStore.DownloadReturns(ioutil.NopCloser(strings.NewReader(`{"process_types": {"web": "bundle exec rackup"}}`)), nil)
+
+			err := dropletRunner.LaunchDroplet("app-name", "droplet-name", "", []string{}, app_runner.AppEnvironmentParams{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadArgsForCall(0)).To(Equal("droplet-name-metadata.json"))
+			Expect(fakeAppRunner.CreateAppCallCount()).To(Equal(1))
+			createAppParams := fakeAppRunner.CreateAppArgsForCall(0)
+			Expect(createAppParams.AppArgs).To(Equal([]string{"/home/vcap/app", "bundle exec rackup", "{}"}))
+		})
+
 		It("launches the droplet lrp task with proxy environment variables", func() {
 			fakeBlobStore.DownloadReturns(ioutil.NopCloser(strings.NewReader("{}")), nil)
 			fakeBlobStore.DownloadDropletActionReturns(models.WrapAction(&models.DownloadAction{}))
@@ -404,6 +670,22 @@ var _ = Describe("DropletRunner", func() {
 			Expect(createAppParams.AppArgs).To(Equal([]string{"/home/vcap/app", "start-r-up -yeah!", "{}"}))
 		})
 
+		It("passes the checksum of the droplet to the download action", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "droplet-name-droplet.tgz"},
+				{Path: "droplet-name-droplet.tgz.sha256"},
+			}, nil)
+			fakeBlobStore.DownloadReturns(ioutil.NopCloser(strings.NewReader(someContentsChecksum)), nil)
+
+			err := dropletRunner.LaunchDroplet("app-name", "droplet-name", "", []string{}, app_runner.AppEnvironmentParams{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeBlobStore.DownloadArgsForCall(0)).To(Equal("droplet-name-droplet.tgz.sha256"))
+			dropletName, checksum := fakeBlobStore.DownloadDropletActionArgsForCall(0)
+			Expect(dropletName).To(Equal("droplet-name"))
+			Expect(checksum).To(Equal(someContentsChecksum))
+		})
+
 		It("returns an error when proxyConf reader fails", func() {
 			fakeBlobStore.DownloadReturns(ioutil.NopCloser(strings.NewReader("{}")), nil)
 			fakeBlobStore.DownloadDropletActionReturns(models.WrapAction(&models.DownloadAction{}))
@@ -422,6 +704,40 @@ var _ = Describe("DropletRunner", func() {
 		})
 	})
 
+	Describe("RelaunchDroplet", func() {
+		It("replaces the app with one launched from the droplet", func() {
+			err := dropletRunner.RelaunchDroplet("app-name", "droplet-name", "start-r-up", []string{"-yeah!"}, app_runner.AppEnvironmentParams{}, 5*time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeAppRunner.RemoveAppCallCount()).To(BeZero())
+			Expect(fakeAppRunner.CreateAppCallCount()).To(BeZero())
+
+			Expect(fakeAppRunner.ReplaceAppCallCount()).To(Equal(1))
+			replaceAppParams := fakeAppRunner.ReplaceAppArgsForCall(0)
+			Expect(replaceAppParams.Name).To(Equal("app-name"))
+			Expect(replaceAppParams.AppArgs).To(Equal([]string{"/home/vcap/app", "start-r-up -yeah!", "{}"}))
+			Expect(replaceAppParams.Annotation).To(MatchJSON(`{"droplet_source": {"droplet_name": "droplet-name"}}`))
+			Expect(replaceAppParams.Timeout).To(Equal(5 * time.Minute))
+		})
+
+		It("leaves the app running when the droplet can't be launched", func() {
+			fakeProxyConfReader.ProxyConfReturns(droplet_runner.ProxyConf{}, errors.New("bad proxy"))
+
+			err := dropletRunner.RelaunchDroplet("app-name", "droplet-name", "start-r-up", []string{}, app_runner.AppEnvironmentParams{}, time.Minute)
+			Expect(err).To(MatchError("bad proxy"))
+
+			Expect(fakeAppRunner.ReplaceAppCallCount()).To(BeZero())
+			Expect(fakeAppRunner.RemoveAppCallCount()).To(BeZero())
+		})
+
+		It("returns errors from replacing the app", func() {
+			fakeAppRunner.ReplaceAppReturns(errors.New("nope"))
+
+			err := dropletRunner.RelaunchDroplet("app-name", "droplet-name", "start-r-up", []string{}, app_runner.AppEnvironmentParams{}, time.Minute)
+			Expect(err).To(MatchError("nope"))
+		})
+	})
+
 	Describe("RemoveDroplet", func() {
 		It("recursively removes a droplets from the blob store", func() {
 			config.SetBlobStore("blob-host", "7474", "dav-user", "dav-pass")
@@ -501,6 +817,58 @@ var _ = Describe("DropletRunner", func() {
 		})
 	})
 
+	Describe("ClearBuildCache", func() {
+		It("removes the build cache of the droplet", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "droplet-name@v1-droplet.tgz"},
+				{Path: "droplet-name-cache.tgz"},
+			}, nil)
+
+			Expect(dropletRunner.ClearBuildCache("droplet-name")).To(Succeed())
+
+			Expect(fakeBlobStore.DeleteCallCount()).To(Equal(1))
+			Expect(fakeBlobStore.DeleteArgsForCall(0)).To(Equal("droplet-name-cache.tgz"))
+		})
+
+		It("returns an error when the droplet has no build cache", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{{Path: "droplet-name@v1-droplet.tgz"}}, nil)
+
+			Expect(dropletRunner.ClearBuildCache("droplet-name")).To(MatchError("droplet droplet-name has no build cache"))
+			Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+		})
+
+		It("returns an error when listing the blob store fails", func() {
+			fakeBlobStore.ListReturns(nil, errors.New("some error"))
+
+			Expect(dropletRunner.ClearBuildCache("droplet-name")).To(MatchError("some error"))
+		})
+	})
+
+	Describe("DropletChecksums", func() {
+		It("returns the checksums recorded for droplets", func() {
+			fakeBlobStore.ListReturns([]blob.Blob{
+				{Path: "drippy-bits.zip"},
+				{Path: "drippy-bits.zip.sha256"},
+				{Path: "drippy-droplet.tgz"},
+				{Path: "drippy-droplet.tgz.sha256"},
+				{Path: "old-droplet.tgz"},
+			}, nil)
+			fakeBlobStore.DownloadReturns(ioutil.NopCloser(strings.NewReader(someContentsChecksum)), nil)
+
+			Expect(dropletRunner.DropletChecksums()).To(Equal(map[string]string{"drippy": someContentsChecksum}))
+
+			Expect(fakeBlobStore.DownloadCallCount()).To(Equal(1))
+			Expect(fakeBlobStore.DownloadArgsForCall(0)).To(Equal("drippy-droplet.tgz.sha256"))
+		})
+
+		It("returns an error when querying the blob store fails", func() {
+			fakeBlobStore.ListReturns(nil, errors.New("some error"))
+
+			_, err := dropletRunner.DropletChecksums()
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+
 	Describe("ExportDroplet", func() {
 		BeforeEach(func() {
 			fakeDropletReader := ioutil.NopCloser(strings.NewReader("some droplet reader"))
@@ -530,6 +898,49 @@ var _ = Describe("DropletRunner", func() {
 				Expect(err).To(MatchError("droplet not found: some missing droplet error"))
 			})
 		})
+
+		Context("when a checksum was recorded for the droplet", func() {
+			var checksum string
+
+			BeforeEach(func() {
+				fakeBlobStore.ListReturns([]blob.Blob{
+					{Path: "drippy-droplet.tgz"},
+					{Path: "drippy-droplet.tgz.sha256"},
+				}, nil)
+
+				fakeBlobStore.DownloadStub = func(path string) (io.ReadCloser, error) {
+					switch path {
+					case "drippy-droplet.tgz":
+						return ioutil.NopCloser(strings.NewReader("some contents")), nil
+					case "drippy-droplet.tgz.sha256":
+						return ioutil.NopCloser(strings.NewReader(checksum)), nil
+					default:
+						return nil, errors.New("fake GetReader called with invalid arguments")
+					}
+				}
+			})
+
+			It("verifies the droplet against it", func() {
+				checksum = someContentsChecksum
+
+				dropletReader, err := dropletRunner.ExportDroplet("drippy")
+				Expect(err).NotTo(HaveOccurred())
+				defer dropletReader.Close()
+
+				Expect(ioutil.ReadAll(dropletReader)).To(BeEquivalentTo("some contents"))
+			})
+
+			It("returns an error reading a corrupted droplet", func() {
+				checksum = "bad-checksum"
+
+				dropletReader, err := dropletRunner.ExportDroplet("drippy")
+				Expect(err).NotTo(HaveOccurred())
+				defer dropletReader.Close()
+
+				_, err = ioutil.ReadAll(dropletReader)
+				Expect(err).To(MatchError("checksum mismatch: expected bad-checksum, got " + someContentsChecksum))
+			})
+		})
 	})
 
 	Describe("ImportDroplet", func() {
@@ -549,21 +960,64 @@ var _ = Describe("DropletRunner", func() {
 		})
 
 		Context("when the droplet files exist", func() {
+			var dropletChecksum string
+
+			BeforeEach(func() {
+				checksum := sha256.Sum256([]byte("droplet contents"))
+				dropletChecksum = hex.EncodeToString(checksum[:])
+
+				fakeBlobStore.DownloadStub = func(path string) (io.ReadCloser, error) {
+					return ioutil.NopCloser(strings.NewReader(dropletChecksum)), nil
+				}
+			})
+
 			It("uploads the droplet files to the blob store", func() {
-				err := dropletRunner.ImportDroplet("drippy", dropletPathArg)
+				err := dropletRunner.ImportDroplet("drippy", dropletPathArg, nil)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(fakeBlobStore.UploadCallCount()).To(Equal(1))
+				Expect(fakeBlobStore.UploadCallCount()).To(Equal(3))
 
-				path, _ := fakeBlobStore.UploadArgsForCall(0)
+				path, _, _ := fakeBlobStore.UploadArgsForCall(0)
 				Expect(path).To(Equal("drippy-droplet.tgz"))
+
+				path, _, _ = fakeBlobStore.UploadArgsForCall(1)
+				Expect(path).To(Equal("drippy-droplet.tgz.sha256"))
+			})
+
+			It("records that the droplet was imported", func() {
+				Expect(dropletRunner.ImportDroplet("drippy", dropletPathArg, nil)).To(Succeed())
+
+				path, contents, _ := fakeBlobStore.UploadArgsForCall(2)
+				Expect(path).To(Equal("drippy-metadata.json"))
+
+				metadata := droplet_runner.DropletMetadata{}
+				Expect(json.NewDecoder(contents).Decode(&metadata)).To(Succeed())
+				Expect(metadata.Imported).To(BeTrue())
+				Expect(metadata.SourceChecksum).To(HaveLen(64))
+				Expect(metadata.Builder).NotTo(BeEmpty())
+			})
+
+			It("checks the stored checksum without downloading the droplet again", func() {
+				Expect(dropletRunner.ImportDroplet("drippy", dropletPathArg, nil)).To(Succeed())
+
+				Expect(fakeBlobStore.DownloadCallCount()).To(Equal(1))
+				Expect(fakeBlobStore.DownloadArgsForCall(0)).To(Equal("drippy-droplet.tgz.sha256"))
+			})
+
+			It("returns an error when the stored checksum does not match", func() {
+				fakeBlobStore.DownloadStub = func(path string) (io.ReadCloser, error) {
+					return ioutil.NopCloser(strings.NewReader("some-other-checksum")), nil
+				}
+
+				err := dropletRunner.ImportDroplet("drippy", dropletPathArg, nil)
+				Expect(err).To(MatchError(fmt.Sprintf("verifying upload: checksum mismatch: expected %s, got some-other-checksum", dropletChecksum)))
 			})
 
 			Context("when the blob bucket returns error(s)", func() {
 				It("returns an error uploading the droplet file", func() {
 					fakeBlobStore.UploadReturns(errors.New("some error"))
 
-					err := dropletRunner.ImportDroplet("drippy", dropletPathArg)
+					err := dropletRunner.ImportDroplet("drippy", dropletPathArg, nil)
 					Expect(err).To(MatchError("some error"))
 				})
 			})
@@ -571,7 +1025,7 @@ var _ = Describe("DropletRunner", func() {
 
 		Context("when the droplet files do not exist", func() {
 			It("returns an error opening the droplet file", func() {
-				err := dropletRunner.ImportDroplet("drippy", "some/missing/droplet/path")
+				err := dropletRunner.ImportDroplet("drippy", "some/missing/droplet/path", nil)
 				Expect(reflect.TypeOf(err).String()).To(Equal("*os.PathError"))
 			})
 		})
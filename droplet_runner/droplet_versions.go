@@ -0,0 +1,370 @@
+package droplet_runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+)
+
+// LatestTag is moved to each droplet version as it is built or imported.
+const LatestTag = "latest"
+
+// reserveAttempts is how many of the following versions NewDropletVersion
+// tries when other builds reserve them first.
+const reserveAttempts = 10
+
+const buildTaskPrefix = "build-droplet-"
+
+var (
+	versionPattern = regexp.MustCompile(`^v[0-9]+$`)
+	tagPattern     = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	hashPattern    = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+)
+
+// FullName refers to this version of the droplet, as name@version.  Droplets
+// stored before ltc kept versions are referred to by name alone.
+func (d Droplet) FullName() string {
+	if d.Version == "" {
+		return d.Name
+	}
+	return d.Name + "@" + d.Version
+}
+
+// splitDropletRef splits a name@version reference, where the version may
+// also be a tag or the prefix of the droplet's checksum.
+func splitDropletRef(dropletRef string) (name, version string) {
+	if index := strings.Index(dropletRef, "@"); index >= 0 {
+		return dropletRef[:index], dropletRef[index+1:]
+	}
+	return dropletRef, ""
+}
+
+// tagPath stores the version a single tag points at.  Each tag is a blob of
+// its own, so moving one tag never overwrites a concurrent move of another.
+func tagPath(dropletName, tag string) string {
+	return dropletName + "@" + tag + ".tag"
+}
+
+// tagOwner returns the name of the droplet the tag blob at path belongs to,
+// or "" if the path isn't a tag blob.
+func tagOwner(path string) string {
+	if !strings.HasSuffix(path, ".tag") {
+		return ""
+	}
+
+	name, tag := splitDropletRef(strings.TrimSuffix(path, ".tag"))
+	if !tagPattern.MatchString(tag) || versionPattern.MatchString(tag) {
+		return ""
+	}
+	return name
+}
+
+// BuildTaskGuid names the task that builds the droplet version.  Task guids
+// cannot contain @, so the version follows the name after a dash.
+func BuildTaskGuid(dropletVersion string) string {
+	return buildTaskPrefix + strings.Replace(dropletVersion, "@", "-", 1)
+}
+
+// BuildTaskDroplet returns the name@version the build task builds, or only
+// the name for tasks submitted before ltc kept versions.
+func BuildTaskDroplet(taskGuid string) (string, bool) {
+	if !strings.HasPrefix(taskGuid, buildTaskPrefix) {
+		return "", false
+	}
+
+	dropletName := strings.TrimPrefix(taskGuid, buildTaskPrefix)
+	if index := strings.LastIndex(dropletName, "-"); index > 0 && versionPattern.MatchString(dropletName[index+1:]) {
+		return dropletName[:index] + "@" + dropletName[index+1:], true
+	}
+	return dropletName, true
+}
+
+// reservationPath marks a droplet version as taken, whether or not its build
+// succeeded.  It is removed along with the droplet version.
+func reservationPath(dropletVersion string) string {
+	return dropletVersion + "-reserved"
+}
+
+func versionNumber(version string) int {
+	if !versionPattern.MatchString(version) {
+		return 0
+	}
+
+	number, _ := strconv.Atoi(version[1:])
+	return number
+}
+
+func dropletFromBlob(b blob.Blob) Droplet {
+	name, version := splitDropletRef(strings.TrimSuffix(b.Path, "-droplet.tgz"))
+	return Droplet{Name: name, Version: version, Created: b.Created, Size: b.Size}
+}
+
+// NewDropletVersion reserves the next version of the droplet and returns the
+// name@version to build or import it as.  The version is reserved by
+// creating a marker blob, which the blob store refuses to create twice, so
+// concurrent builds never get the same version.
+func (dr *dropletRunner) NewDropletVersion(dropletName string) (string, error) {
+	if strings.Contains(dropletName, "@") {
+		return "", errors.New("droplet names cannot contain @")
+	}
+
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return "", err
+	}
+
+	latest := 0
+	for _, b := range blobs {
+		if !strings.HasPrefix(b.Path, dropletName+"@") {
+			continue
+		}
+
+		version := strings.SplitN(strings.TrimPrefix(b.Path, dropletName+"@"), "-", 2)[0]
+		if number := versionNumber(version); number > latest {
+			latest = number
+		}
+	}
+
+	for attempt := 1; attempt <= reserveAttempts; attempt++ {
+		dropletVersion := fmt.Sprintf("%s@v%d", dropletName, latest+attempt)
+
+		err := dr.blobStore.Create(reservationPath(dropletVersion), strings.NewReader(""))
+		if err == nil {
+			return dropletVersion, nil
+		} else if err != blob.ErrExists {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("could not reserve a new version of droplet %s", dropletName)
+}
+
+// ResolveDroplet returns the name@version of the droplet the reference
+// refers to.  A name alone refers to the version tagged latest.
+func (dr *dropletRunner) ResolveDroplet(dropletRef string) (string, error) {
+	name, version := splitDropletRef(dropletRef)
+
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return "", err
+	}
+
+	paths := map[string]bool{}
+	for _, b := range blobs {
+		paths[b.Path] = true
+	}
+
+	tags, err := dr.readTags(name, paths)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" {
+		if tagged, ok := tags[LatestTag]; ok {
+			return name + "@" + tagged, nil
+		}
+
+		newest := ""
+		for _, b := range blobs {
+			if strings.HasSuffix(b.Path, "-droplet.tgz") {
+				droplet := dropletFromBlob(b)
+				if droplet.Name == name && versionNumber(droplet.Version) > versionNumber(newest) {
+					newest = droplet.Version
+				}
+			}
+		}
+		if newest != "" {
+			return name + "@" + newest, nil
+		}
+
+		return name, nil
+	}
+
+	if tagged, ok := tags[version]; ok {
+		version = tagged
+	}
+	if paths[name+"@"+version+"-droplet.tgz"] {
+		return name + "@" + version, nil
+	}
+
+	if hashPattern.MatchString(version) {
+		matches := []string{}
+		for _, b := range blobs {
+			if !strings.HasSuffix(b.Path, "-droplet.tgz") || !paths[blob.ChecksumPath(b.Path)] {
+				continue
+			}
+
+			droplet := dropletFromBlob(b)
+			if droplet.Name != name {
+				continue
+			}
+
+			checksum, err := dr.readChecksum(b.Path)
+			if err != nil {
+				return "", err
+			}
+			if strings.HasPrefix(checksum, version) {
+				matches = append(matches, droplet.FullName())
+			}
+		}
+
+		if len(matches) > 1 {
+			return "", fmt.Errorf("ambiguous droplet version: %s", dropletRef)
+		}
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("droplet not found: %s", dropletRef)
+}
+
+// TagDroplet points the tag at the referenced droplet version, moving it
+// from any version it pointed at before.
+func (dr *dropletRunner) TagDroplet(dropletRef, tag string) error {
+	if !tagPattern.MatchString(tag) || versionPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag: %s", tag)
+	}
+
+	resolved, err := dr.ResolveDroplet(dropletRef)
+	if err != nil {
+		return err
+	}
+
+	name, version := splitDropletRef(resolved)
+	if version == "" {
+		return fmt.Errorf("droplet %s has no versions to tag", name)
+	}
+
+	return dr.blobStore.Upload(tagPath(name, tag), strings.NewReader(version), nil)
+}
+
+// DropletHistory returns every stored version of the droplet, newest first.
+func (dr *dropletRunner) DropletHistory(dropletName string) ([]Droplet, error) {
+	blobs, err := dr.blobStore.List()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]bool{}
+	for _, b := range blobs {
+		paths[b.Path] = true
+	}
+
+	tags, err := dr.readTags(dropletName, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	droplets := []Droplet{}
+	for _, b := range blobs {
+		if !strings.HasSuffix(b.Path, "-droplet.tgz") {
+			continue
+		}
+
+		droplet := dropletFromBlob(b)
+		if droplet.Name != dropletName {
+			continue
+		}
+
+		if paths[blob.ChecksumPath(b.Path)] {
+			if droplet.Checksum, err = dr.readChecksum(b.Path); err != nil {
+				return nil, err
+			}
+		}
+
+		for tag, version := range tags {
+			if version == droplet.Version && droplet.Version != "" {
+				droplet.Tags = append(droplet.Tags, tag)
+			}
+		}
+		sort.Strings(droplet.Tags)
+
+		droplets = append(droplets, droplet)
+	}
+
+	if len(droplets) == 0 {
+		return nil, fmt.Errorf("droplet not found: %s", dropletName)
+	}
+
+	sort.Sort(dropletsByVersion(droplets))
+
+	return droplets, nil
+}
+
+func (dr *dropletRunner) readTags(dropletName string, paths map[string]bool) (map[string]string, error) {
+	tags := map[string]string{}
+	for path := range paths {
+		if tagOwner(path) != dropletName {
+			continue
+		}
+
+		version, err := dr.readTag(path)
+		if err != nil {
+			return nil, err
+		}
+
+		_, tag := splitDropletRef(strings.TrimSuffix(path, ".tag"))
+		tags[tag] = version
+	}
+
+	return tags, nil
+}
+
+func (dr *dropletRunner) readTag(path string) (string, error) {
+	reader, err := dr.blobStore.Download(path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	version, err := ioutil.ReadAll(io.LimitReader(reader, 64))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(version)), nil
+}
+
+// taggedDroplets returns the name@version of every tagged droplet version.
+func (dr *dropletRunner) taggedDroplets(blobs []blob.Blob) (map[string]bool, error) {
+	paths := map[string]bool{}
+	for _, b := range blobs {
+		paths[b.Path] = true
+	}
+
+	tagged := map[string]bool{}
+	dropletNames := map[string]bool{}
+	for _, b := range blobs {
+		dropletName := tagOwner(b.Path)
+		if dropletName == "" || dropletNames[dropletName] {
+			continue
+		}
+		dropletNames[dropletName] = true
+
+		tags, err := dr.readTags(dropletName, paths)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range tags {
+			tagged[dropletName+"@"+version] = true
+		}
+	}
+
+	return tagged, nil
+}
+
+type dropletsByVersion []Droplet
+
+func (d dropletsByVersion) Len() int      { return len(d) }
+func (d dropletsByVersion) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d dropletsByVersion) Less(i, j int) bool {
+	return versionNumber(d[i].Version) > versionNumber(d[j].Version)
+}
@@ -0,0 +1,312 @@
+package droplet_runner_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/app_examiner"
+	"github.com/cloudfoundry-incubator/ltc/app_examiner/fake_app_examiner"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
+	"github.com/cloudfoundry-incubator/ltc/droplet_runner/fake_blob_store"
+)
+
+var _ = Describe("DropletRunner versions", func() {
+	var (
+		fakeBlobStore   *fake_blob_store.FakeBlobStore
+		fakeAppExaminer *fake_app_examiner.FakeAppExaminer
+		dropletRunner   droplet_runner.DropletRunner
+		blobContents    map[string]string
+	)
+
+	BeforeEach(func() {
+		fakeBlobStore = &fake_blob_store.FakeBlobStore{}
+		fakeAppExaminer = &fake_app_examiner.FakeAppExaminer{}
+		dropletRunner = droplet_runner.New(nil, nil, nil, fakeBlobStore, fakeAppExaminer, nil)
+
+		blobContents = map[string]string{
+			"drippy@v1-droplet.tgz":        "droplet",
+			"drippy@v1-droplet.tgz.sha256": "aaaa1111bbbb2222",
+			"drippy@v1-metadata.json":      "{}",
+			"drippy@v2-droplet.tgz":        "droplet",
+			"drippy@v2-droplet.tgz.sha256": "aaaa1111cccc3333",
+			"drippy@v10-droplet.tgz":       "droplet",
+			"drippy@latest.tag":            "v2",
+			"drippy@stable.tag":            "v1",
+			"old-droplet.tgz":              "droplet",
+		}
+		fakeBlobStore.ListStub = func() ([]blob.Blob, error) {
+			paths := []string{}
+			for path := range blobContents {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			blobs := []blob.Blob{}
+			for _, path := range paths {
+				blobs = append(blobs, blob.Blob{Path: path, Size: int64(len(blobContents[path]))})
+			}
+			return blobs, nil
+		}
+		fakeBlobStore.DownloadStub = func(path string) (io.ReadCloser, error) {
+			contents, ok := blobContents[path]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return ioutil.NopCloser(strings.NewReader(contents)), nil
+		}
+		fakeBlobStore.UploadStub = func(path string, contents io.ReadSeeker, _ blob.Progress) error {
+			contentBytes, err := ioutil.ReadAll(contents)
+			blobContents[path] = string(contentBytes)
+			return err
+		}
+		fakeBlobStore.CreateStub = func(path string, contents io.ReadSeeker) error {
+			if _, ok := blobContents[path]; ok {
+				return blob.ErrExists
+			}
+			contentBytes, err := ioutil.ReadAll(contents)
+			blobContents[path] = string(contentBytes)
+			return err
+		}
+		fakeBlobStore.DeleteStub = func(path string) error {
+			delete(blobContents, path)
+			return nil
+		}
+	})
+
+	Describe("NewDropletVersion", func() {
+		It("returns the version after the newest stored version", func() {
+			Expect(dropletRunner.NewDropletVersion("drippy")).To(Equal("drippy@v11"))
+		})
+
+		It("reserves the version", func() {
+			Expect(dropletRunner.NewDropletVersion("drippy")).To(Equal("drippy@v11"))
+
+			Expect(blobContents).To(HaveKey("drippy@v11-reserved"))
+			Expect(dropletRunner.NewDropletVersion("drippy")).To(Equal("drippy@v12"))
+		})
+
+		It("moves on to the following version when another build reserves it first", func() {
+			fakeBlobStore.ListStub = func() ([]blob.Blob, error) {
+				return []blob.Blob{{Path: "drippy@v10-droplet.tgz"}}, nil
+			}
+
+			Expect(dropletRunner.NewDropletVersion("drippy")).To(Equal("drippy@v11"))
+			Expect(dropletRunner.NewDropletVersion("drippy")).To(Equal("drippy@v12"))
+			Expect(fakeBlobStore.CreateCallCount()).To(Equal(3))
+		})
+
+		It("returns an error when the version cannot be reserved", func() {
+			fakeBlobStore.CreateStub = nil
+			fakeBlobStore.CreateReturns(errors.New("some error"))
+
+			_, err := dropletRunner.NewDropletVersion("drippy")
+			Expect(err).To(MatchError("some error"))
+		})
+
+		It("gives up when every following version is taken", func() {
+			fakeBlobStore.CreateStub = nil
+			fakeBlobStore.CreateReturns(blob.ErrExists)
+
+			_, err := dropletRunner.NewDropletVersion("drippy")
+			Expect(err).To(MatchError("could not reserve a new version of droplet drippy"))
+			Expect(fakeBlobStore.CreateCallCount()).To(Equal(10))
+		})
+
+		It("starts at v1 for a droplet without versions", func() {
+			Expect(dropletRunner.NewDropletVersion("old")).To(Equal("old@v1"))
+		})
+
+		It("returns an error for a name containing @", func() {
+			_, err := dropletRunner.NewDropletVersion("drippy@v2")
+			Expect(err).To(MatchError("droplet names cannot contain @"))
+		})
+	})
+
+	Describe("BuildTaskGuid", func() {
+		It("names the build task after the droplet version", func() {
+			Expect(droplet_runner.BuildTaskGuid("my-app@v12")).To(Equal("build-droplet-my-app-v12"))
+		})
+
+		It("recovers the droplet version from the build task guid", func() {
+			dropletName, ok := droplet_runner.BuildTaskDroplet("build-droplet-my-app-v12")
+			Expect(ok).To(BeTrue())
+			Expect(dropletName).To(Equal("my-app@v12"))
+
+			dropletName, ok = droplet_runner.BuildTaskDroplet("build-droplet-my-app")
+			Expect(ok).To(BeTrue())
+			Expect(dropletName).To(Equal("my-app"))
+
+			_, ok = droplet_runner.BuildTaskDroplet("my-app-v12")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("ResolveDroplet", func() {
+		It("resolves a name to the version tagged latest", func() {
+			Expect(dropletRunner.ResolveDroplet("drippy")).To(Equal("drippy@v2"))
+		})
+
+		It("resolves a name to the newest version when no version is tagged latest", func() {
+			delete(blobContents, "drippy@latest.tag")
+
+			Expect(dropletRunner.ResolveDroplet("drippy")).To(Equal("drippy@v10"))
+		})
+
+		It("resolves a name without versions to itself", func() {
+			Expect(dropletRunner.ResolveDroplet("old")).To(Equal("old"))
+		})
+
+		It("resolves versions and tags", func() {
+			Expect(dropletRunner.ResolveDroplet("drippy@v10")).To(Equal("drippy@v10"))
+			Expect(dropletRunner.ResolveDroplet("drippy@stable")).To(Equal("drippy@v1"))
+		})
+
+		It("resolves a prefix of the droplet's checksum", func() {
+			_, err := dropletRunner.ResolveDroplet("drippy@aaaa111")
+			Expect(err).To(MatchError("ambiguous droplet version: drippy@aaaa111"))
+
+			Expect(dropletRunner.ResolveDroplet("drippy@aaaa1111c")).To(Equal("drippy@v2"))
+		})
+
+		It("returns an error for an unknown version", func() {
+			_, err := dropletRunner.ResolveDroplet("drippy@v3")
+			Expect(err).To(MatchError("droplet not found: drippy@v3"))
+		})
+	})
+
+	Describe("TagDroplet", func() {
+		It("moves the tag to the droplet version", func() {
+			Expect(dropletRunner.TagDroplet("drippy@v1", "latest")).To(Succeed())
+
+			Expect(blobContents).To(HaveKeyWithValue("drippy@latest.tag", "v1"))
+			Expect(dropletRunner.ResolveDroplet("drippy")).To(Equal("drippy@v1"))
+		})
+
+		It("stores each tag in a blob of its own", func() {
+			Expect(dropletRunner.TagDroplet("drippy@v10", "beta")).To(Succeed())
+
+			Expect(fakeBlobStore.UploadCallCount()).To(Equal(1))
+			path, _, _ := fakeBlobStore.UploadArgsForCall(0)
+			Expect(path).To(Equal("drippy@beta.tag"))
+			Expect(blobContents).To(HaveKeyWithValue("drippy@latest.tag", "v2"))
+
+			history, err := dropletRunner.DropletHistory("drippy")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(history[0].Tags).To(Equal([]string{"beta"}))
+			Expect(history[1].Tags).To(Equal([]string{"latest"}))
+			Expect(history[2].Tags).To(Equal([]string{"stable"}))
+		})
+
+		It("returns an error for a tag that looks like a version", func() {
+			Expect(dropletRunner.TagDroplet("drippy@v1", "v2")).To(MatchError("invalid tag: v2"))
+			Expect(fakeBlobStore.UploadCallCount()).To(BeZero())
+		})
+
+		It("returns an error for a droplet without versions", func() {
+			Expect(dropletRunner.TagDroplet("old", "stable")).To(MatchError("droplet old has no versions to tag"))
+		})
+	})
+
+	Describe("DropletHistory", func() {
+		It("returns every version of the droplet newest first", func() {
+			Expect(dropletRunner.DropletHistory("drippy")).To(Equal([]droplet_runner.Droplet{
+				{Name: "drippy", Version: "v10", Size: 7},
+				{Name: "drippy", Version: "v2", Size: 7, Checksum: "aaaa1111cccc3333", Tags: []string{"latest"}},
+				{Name: "drippy", Version: "v1", Size: 7, Checksum: "aaaa1111bbbb2222", Tags: []string{"stable"}},
+			}))
+		})
+
+		It("returns an error for an unknown droplet", func() {
+			_, err := dropletRunner.DropletHistory("droopy")
+			Expect(err).To(MatchError("droplet not found: droopy"))
+		})
+	})
+
+	Describe("ListDroplets", func() {
+		It("lists the version tagged latest of each droplet", func() {
+			Expect(dropletRunner.ListDroplets()).To(Equal([]droplet_runner.Droplet{
+				{Name: "drippy", Version: "v2", Size: 7, Tags: []string{"latest"}},
+				{Name: "old", Size: 7},
+			}))
+		})
+	})
+
+	Describe("RemoveDroplet", func() {
+		BeforeEach(func() {
+			fakeAppExaminer.ListAppsReturns([]app_examiner.AppInfo{
+				{ProcessGuid: "dripapp", Annotation: `{"droplet_source": {"droplet_name": "drippy@v10"}}`},
+			}, nil)
+		})
+
+		It("removes a single untagged version", func() {
+			fakeAppExaminer.ListAppsReturns(nil, nil)
+
+			Expect(dropletRunner.RemoveDroplet("drippy@v10")).To(Succeed())
+
+			Expect(fakeBlobStore.DeleteCallCount()).To(Equal(1))
+			Expect(fakeBlobStore.DeleteArgsForCall(0)).To(Equal("drippy@v10-droplet.tgz"))
+		})
+
+		It("returns an error removing a tagged version", func() {
+			Expect(dropletRunner.RemoveDroplet("drippy@v1")).To(MatchError("droplet drippy@v1 is tagged; tag another version first"))
+			Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+		})
+
+		It("returns an error removing a version an app was launched from", func() {
+			Expect(dropletRunner.RemoveDroplet("drippy@v10")).To(MatchError("app dripapp was launched from droplet"))
+			Expect(dropletRunner.RemoveDroplet("drippy")).To(MatchError("app dripapp was launched from droplet"))
+			Expect(fakeBlobStore.DeleteCallCount()).To(BeZero())
+		})
+	})
+
+	Describe("GCDroplets", func() {
+		It("keeps tagged versions", func() {
+			garbage := []string{}
+			options := droplet_runner.GCOptions{
+				KeepNewest: 1,
+				DryRun:     true,
+				Progress: func(garbageBlob droplet_runner.GarbageBlob) {
+					garbage = append(garbage, garbageBlob.Path)
+				},
+			}
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(BeEmpty())
+		})
+
+		It("removes untagged versions beyond the retention count", func() {
+			fakeBlobStore.ListStub = func() ([]blob.Blob, error) {
+				return []blob.Blob{
+					{Path: "drippy@latest.tag"},
+					{Path: "drippy@stable.tag"},
+					{Path: "drippy@v1-droplet.tgz", Created: time.Unix(1000, 0)},
+					{Path: "drippy@v2-droplet.tgz", Created: time.Unix(2000, 0)},
+					{Path: "drippy@v3-droplet.tgz", Created: time.Unix(3000, 0)},
+					{Path: "drippy@v3-metadata.json", Created: time.Unix(3000, 0)},
+					{Path: "drippy@v4-droplet.tgz", Created: time.Unix(4000, 0)},
+				}, nil
+			}
+
+			garbage := []string{}
+			options := droplet_runner.GCOptions{
+				KeepNewest: 1,
+				Progress: func(garbageBlob droplet_runner.GarbageBlob) {
+					garbage = append(garbage, garbageBlob.Path)
+				},
+			}
+
+			Expect(dropletRunner.GCDroplets(options)).To(Succeed())
+
+			Expect(garbage).To(Equal([]string{"drippy@v3-droplet.tgz", "drippy@v3-metadata.json"}))
+		})
+	})
+})
@@ -26,15 +26,25 @@ type FakeBlobStore struct {
 	deleteReturns struct {
 		result1 error
 	}
-	UploadStub        func(path string, contents io.ReadSeeker) error
+	UploadStub        func(path string, contents io.ReadSeeker, progress blob.Progress) error
 	uploadMutex       sync.RWMutex
 	uploadArgsForCall []struct {
 		path     string
 		contents io.ReadSeeker
+		progress blob.Progress
 	}
 	uploadReturns struct {
 		result1 error
 	}
+	CreateStub        func(path string, contents io.ReadSeeker) error
+	createMutex       sync.RWMutex
+	createArgsForCall []struct {
+		path     string
+		contents io.ReadSeeker
+	}
+	createReturns struct {
+		result1 error
+	}
 	DownloadStub        func(path string) (io.ReadCloser, error)
 	downloadMutex       sync.RWMutex
 	downloadArgsForCall []struct {
@@ -44,10 +54,11 @@ type FakeBlobStore struct {
 		result1 io.ReadCloser
 		result2 error
 	}
-	DownloadAppBitsActionStub        func(dropletName string) *models.Action
+	DownloadAppBitsActionStub        func(dropletName, checksum string) *models.Action
 	downloadAppBitsActionMutex       sync.RWMutex
 	downloadAppBitsActionArgsForCall []struct {
 		dropletName string
+		checksum    string
 	}
 	downloadAppBitsActionReturns struct {
 		result1 *models.Action
@@ -76,14 +87,40 @@ type FakeBlobStore struct {
 	uploadDropletMetadataActionReturns struct {
 		result1 *models.Action
 	}
-	DownloadDropletActionStub        func(dropletName string) *models.Action
+	DownloadDropletActionStub        func(dropletName, checksum string) *models.Action
 	downloadDropletActionMutex       sync.RWMutex
 	downloadDropletActionArgsForCall []struct {
 		dropletName string
+		checksum    string
 	}
 	downloadDropletActionReturns struct {
 		result1 *models.Action
 	}
+	DownloadBuildCacheActionStub        func(dropletName string) *models.Action
+	downloadBuildCacheActionMutex       sync.RWMutex
+	downloadBuildCacheActionArgsForCall []struct {
+		dropletName string
+	}
+	downloadBuildCacheActionReturns struct {
+		result1 *models.Action
+	}
+	UploadBuildCacheActionStub        func(dropletName string) *models.Action
+	uploadBuildCacheActionMutex       sync.RWMutex
+	uploadBuildCacheActionArgsForCall []struct {
+		dropletName string
+	}
+	uploadBuildCacheActionReturns struct {
+		result1 *models.Action
+	}
+	DownloadBuildpackActionStub        func(checksum, destDir string) *models.Action
+	downloadBuildpackActionMutex       sync.RWMutex
+	downloadBuildpackActionArgsForCall []struct {
+		checksum string
+		destDir  string
+	}
+	downloadBuildpackActionReturns struct {
+		result1 *models.Action
+	}
 }
 
 func (fake *FakeBlobStore) List() ([]blob.Blob, error) {
@@ -143,15 +180,16 @@ func (fake *FakeBlobStore) DeleteReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeBlobStore) Upload(path string, contents io.ReadSeeker) error {
+func (fake *FakeBlobStore) Upload(path string, contents io.ReadSeeker, progress blob.Progress) error {
 	fake.uploadMutex.Lock()
 	fake.uploadArgsForCall = append(fake.uploadArgsForCall, struct {
 		path     string
 		contents io.ReadSeeker
-	}{path, contents})
+		progress blob.Progress
+	}{path, contents, progress})
 	fake.uploadMutex.Unlock()
 	if fake.UploadStub != nil {
-		return fake.UploadStub(path, contents)
+		return fake.UploadStub(path, contents, progress)
 	} else {
 		return fake.uploadReturns.result1
 	}
@@ -163,10 +201,10 @@ func (fake *FakeBlobStore) UploadCallCount() int {
 	return len(fake.uploadArgsForCall)
 }
 
-func (fake *FakeBlobStore) UploadArgsForCall(i int) (string, io.ReadSeeker) {
+func (fake *FakeBlobStore) UploadArgsForCall(i int) (string, io.ReadSeeker, blob.Progress) {
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
-	return fake.uploadArgsForCall[i].path, fake.uploadArgsForCall[i].contents
+	return fake.uploadArgsForCall[i].path, fake.uploadArgsForCall[i].contents, fake.uploadArgsForCall[i].progress
 }
 
 func (fake *FakeBlobStore) UploadReturns(result1 error) {
@@ -176,6 +214,39 @@ func (fake *FakeBlobStore) UploadReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBlobStore) Create(path string, contents io.ReadSeeker) error {
+	fake.createMutex.Lock()
+	fake.createArgsForCall = append(fake.createArgsForCall, struct {
+		path     string
+		contents io.ReadSeeker
+	}{path, contents})
+	fake.createMutex.Unlock()
+	if fake.CreateStub != nil {
+		return fake.CreateStub(path, contents)
+	} else {
+		return fake.createReturns.result1
+	}
+}
+
+func (fake *FakeBlobStore) CreateCallCount() int {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return len(fake.createArgsForCall)
+}
+
+func (fake *FakeBlobStore) CreateArgsForCall(i int) (string, io.ReadSeeker) {
+	fake.createMutex.RLock()
+	defer fake.createMutex.RUnlock()
+	return fake.createArgsForCall[i].path, fake.createArgsForCall[i].contents
+}
+
+func (fake *FakeBlobStore) CreateReturns(result1 error) {
+	fake.CreateStub = nil
+	fake.createReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBlobStore) Download(path string) (io.ReadCloser, error) {
 	fake.downloadMutex.Lock()
 	fake.downloadArgsForCall = append(fake.downloadArgsForCall, struct {
@@ -209,14 +280,15 @@ func (fake *FakeBlobStore) DownloadReturns(result1 io.ReadCloser, result2 error)
 	}{result1, result2}
 }
 
-func (fake *FakeBlobStore) DownloadAppBitsAction(dropletName string) *models.Action {
+func (fake *FakeBlobStore) DownloadAppBitsAction(dropletName string, checksum string) *models.Action {
 	fake.downloadAppBitsActionMutex.Lock()
 	fake.downloadAppBitsActionArgsForCall = append(fake.downloadAppBitsActionArgsForCall, struct {
 		dropletName string
-	}{dropletName})
+		checksum    string
+	}{dropletName, checksum})
 	fake.downloadAppBitsActionMutex.Unlock()
 	if fake.DownloadAppBitsActionStub != nil {
-		return fake.DownloadAppBitsActionStub(dropletName)
+		return fake.DownloadAppBitsActionStub(dropletName, checksum)
 	} else {
 		return fake.downloadAppBitsActionReturns.result1
 	}
@@ -228,10 +300,10 @@ func (fake *FakeBlobStore) DownloadAppBitsActionCallCount() int {
 	return len(fake.downloadAppBitsActionArgsForCall)
 }
 
-func (fake *FakeBlobStore) DownloadAppBitsActionArgsForCall(i int) string {
+func (fake *FakeBlobStore) DownloadAppBitsActionArgsForCall(i int) (string, string) {
 	fake.downloadAppBitsActionMutex.RLock()
 	defer fake.downloadAppBitsActionMutex.RUnlock()
-	return fake.downloadAppBitsActionArgsForCall[i].dropletName
+	return fake.downloadAppBitsActionArgsForCall[i].dropletName, fake.downloadAppBitsActionArgsForCall[i].checksum
 }
 
 func (fake *FakeBlobStore) DownloadAppBitsActionReturns(result1 *models.Action) {
@@ -337,14 +409,15 @@ func (fake *FakeBlobStore) UploadDropletMetadataActionReturns(result1 *models.Ac
 	}{result1}
 }
 
-func (fake *FakeBlobStore) DownloadDropletAction(dropletName string) *models.Action {
+func (fake *FakeBlobStore) DownloadDropletAction(dropletName string, checksum string) *models.Action {
 	fake.downloadDropletActionMutex.Lock()
 	fake.downloadDropletActionArgsForCall = append(fake.downloadDropletActionArgsForCall, struct {
 		dropletName string
-	}{dropletName})
+		checksum    string
+	}{dropletName, checksum})
 	fake.downloadDropletActionMutex.Unlock()
 	if fake.DownloadDropletActionStub != nil {
-		return fake.DownloadDropletActionStub(dropletName)
+		return fake.DownloadDropletActionStub(dropletName, checksum)
 	} else {
 		return fake.downloadDropletActionReturns.result1
 	}
@@ -356,10 +429,10 @@ func (fake *FakeBlobStore) DownloadDropletActionCallCount() int {
 	return len(fake.downloadDropletActionArgsForCall)
 }
 
-func (fake *FakeBlobStore) DownloadDropletActionArgsForCall(i int) string {
+func (fake *FakeBlobStore) DownloadDropletActionArgsForCall(i int) (string, string) {
 	fake.downloadDropletActionMutex.RLock()
 	defer fake.downloadDropletActionMutex.RUnlock()
-	return fake.downloadDropletActionArgsForCall[i].dropletName
+	return fake.downloadDropletActionArgsForCall[i].dropletName, fake.downloadDropletActionArgsForCall[i].checksum
 }
 
 func (fake *FakeBlobStore) DownloadDropletActionReturns(result1 *models.Action) {
@@ -369,4 +442,101 @@ func (fake *FakeBlobStore) DownloadDropletActionReturns(result1 *models.Action)
 	}{result1}
 }
 
+func (fake *FakeBlobStore) DownloadBuildCacheAction(dropletName string) *models.Action {
+	fake.downloadBuildCacheActionMutex.Lock()
+	fake.downloadBuildCacheActionArgsForCall = append(fake.downloadBuildCacheActionArgsForCall, struct {
+		dropletName string
+	}{dropletName})
+	fake.downloadBuildCacheActionMutex.Unlock()
+	if fake.DownloadBuildCacheActionStub != nil {
+		return fake.DownloadBuildCacheActionStub(dropletName)
+	} else {
+		return fake.downloadBuildCacheActionReturns.result1
+	}
+}
+
+func (fake *FakeBlobStore) DownloadBuildCacheActionCallCount() int {
+	fake.downloadBuildCacheActionMutex.RLock()
+	defer fake.downloadBuildCacheActionMutex.RUnlock()
+	return len(fake.downloadBuildCacheActionArgsForCall)
+}
+
+func (fake *FakeBlobStore) DownloadBuildCacheActionArgsForCall(i int) string {
+	fake.downloadBuildCacheActionMutex.RLock()
+	defer fake.downloadBuildCacheActionMutex.RUnlock()
+	return fake.downloadBuildCacheActionArgsForCall[i].dropletName
+}
+
+func (fake *FakeBlobStore) DownloadBuildCacheActionReturns(result1 *models.Action) {
+	fake.DownloadBuildCacheActionStub = nil
+	fake.downloadBuildCacheActionReturns = struct {
+		result1 *models.Action
+	}{result1}
+}
+
+func (fake *FakeBlobStore) UploadBuildCacheAction(dropletName string) *models.Action {
+	fake.uploadBuildCacheActionMutex.Lock()
+	fake.uploadBuildCacheActionArgsForCall = append(fake.uploadBuildCacheActionArgsForCall, struct {
+		dropletName string
+	}{dropletName})
+	fake.uploadBuildCacheActionMutex.Unlock()
+	if fake.UploadBuildCacheActionStub != nil {
+		return fake.UploadBuildCacheActionStub(dropletName)
+	} else {
+		return fake.uploadBuildCacheActionReturns.result1
+	}
+}
+
+func (fake *FakeBlobStore) UploadBuildCacheActionCallCount() int {
+	fake.uploadBuildCacheActionMutex.RLock()
+	defer fake.uploadBuildCacheActionMutex.RUnlock()
+	return len(fake.uploadBuildCacheActionArgsForCall)
+}
+
+func (fake *FakeBlobStore) UploadBuildCacheActionArgsForCall(i int) string {
+	fake.uploadBuildCacheActionMutex.RLock()
+	defer fake.uploadBuildCacheActionMutex.RUnlock()
+	return fake.uploadBuildCacheActionArgsForCall[i].dropletName
+}
+
+func (fake *FakeBlobStore) UploadBuildCacheActionReturns(result1 *models.Action) {
+	fake.UploadBuildCacheActionStub = nil
+	fake.uploadBuildCacheActionReturns = struct {
+		result1 *models.Action
+	}{result1}
+}
+
+func (fake *FakeBlobStore) DownloadBuildpackAction(checksum, destDir string) *models.Action {
+	fake.downloadBuildpackActionMutex.Lock()
+	fake.downloadBuildpackActionArgsForCall = append(fake.downloadBuildpackActionArgsForCall, struct {
+		checksum string
+		destDir  string
+	}{checksum, destDir})
+	fake.downloadBuildpackActionMutex.Unlock()
+	if fake.DownloadBuildpackActionStub != nil {
+		return fake.DownloadBuildpackActionStub(checksum, destDir)
+	} else {
+		return fake.downloadBuildpackActionReturns.result1
+	}
+}
+
+func (fake *FakeBlobStore) DownloadBuildpackActionCallCount() int {
+	fake.downloadBuildpackActionMutex.RLock()
+	defer fake.downloadBuildpackActionMutex.RUnlock()
+	return len(fake.downloadBuildpackActionArgsForCall)
+}
+
+func (fake *FakeBlobStore) DownloadBuildpackActionArgsForCall(i int) (string, string) {
+	fake.downloadBuildpackActionMutex.RLock()
+	defer fake.downloadBuildpackActionMutex.RUnlock()
+	return fake.downloadBuildpackActionArgsForCall[i].checksum, fake.downloadBuildpackActionArgsForCall[i].destDir
+}
+
+func (fake *FakeBlobStore) DownloadBuildpackActionReturns(result1 *models.Action) {
+	fake.DownloadBuildpackActionStub = nil
+	fake.downloadBuildpackActionReturns = struct {
+		result1 *models.Action
+	}{result1}
+}
+
 var _ droplet_runner.BlobStore = new(FakeBlobStore)
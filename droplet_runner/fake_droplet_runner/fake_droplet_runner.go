@@ -4,31 +4,46 @@ package fake_droplet_runner
 import (
 	"io"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/ltc/app_runner"
+	"github.com/cloudfoundry-incubator/ltc/blob_store/blob"
+	"github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/droplet_runner"
 )
 
 type FakeDropletRunner struct {
-	UploadBitsStub        func(dropletName, uploadPath string) error
+	UploadBitsStub        func(dropletName, uploadPath string, progress blob.Progress) error
 	uploadBitsMutex       sync.RWMutex
 	uploadBitsArgsForCall []struct {
 		dropletName string
 		uploadPath  string
+		progress    blob.Progress
 	}
 	uploadBitsReturns struct {
 		result1 error
 	}
-	BuildDropletStub        func(taskName, dropletName, buildpackUrl string, environment map[string]string, memoryMB, cpuWeight, diskMB int) error
+	UploadBuildpackStub        func(buildpackPath string, progress blob.Progress) (string, error)
+	uploadBuildpackMutex       sync.RWMutex
+	uploadBuildpackArgsForCall []struct {
+		buildpackPath string
+		progress      blob.Progress
+	}
+	uploadBuildpackReturns struct {
+		result1 string
+		result2 error
+	}
+	BuildDropletStub        func(taskName, dropletName string, buildpackUrls []string, environment map[string]string, memoryMB, cpuWeight, diskMB int, useBuildCache bool) error
 	buildDropletMutex       sync.RWMutex
 	buildDropletArgsForCall []struct {
-		taskName     string
-		dropletName  string
-		buildpackUrl string
-		environment  map[string]string
-		memoryMB     int
-		cpuWeight    int
-		diskMB       int
+		taskName      string
+		dropletName   string
+		buildpackUrls []string
+		environment   map[string]string
+		memoryMB      int
+		cpuWeight     int
+		diskMB        int
+		useBuildCache bool
 	}
 	buildDropletReturns struct {
 		result1 error
@@ -45,6 +60,19 @@ type FakeDropletRunner struct {
 	launchDropletReturns struct {
 		result1 error
 	}
+	RelaunchDropletStub        func(appName, dropletRef, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams, timeout time.Duration) error
+	relaunchDropletMutex       sync.RWMutex
+	relaunchDropletArgsForCall []struct {
+		appName              string
+		dropletRef           string
+		startCommand         string
+		startArgs            []string
+		appEnvironmentParams app_runner.AppEnvironmentParams
+		timeout              time.Duration
+	}
+	relaunchDropletReturns struct {
+		result1 error
+	}
 	ListDropletsStub        func() ([]droplet_runner.Droplet, error)
 	listDropletsMutex       sync.RWMutex
 	listDropletsArgsForCall []struct{}
@@ -69,26 +97,134 @@ type FakeDropletRunner struct {
 		result1 io.ReadCloser
 		result2 error
 	}
-	ImportDropletStub        func(dropletName, dropletPath string) error
+	ImportDropletStub        func(dropletName, dropletPath string, progress blob.Progress) error
 	importDropletMutex       sync.RWMutex
 	importDropletArgsForCall []struct {
 		dropletName string
 		dropletPath string
+		progress    blob.Progress
 	}
 	importDropletReturns struct {
 		result1 error
 	}
+	DropletChecksumsStub        func() (map[string]string, error)
+	dropletChecksumsMutex       sync.RWMutex
+	dropletChecksumsArgsForCall []struct{}
+	dropletChecksumsReturns     struct {
+		result1 map[string]string
+		result2 error
+	}
+	MigrateDropletsStub        func(source, destination *config.Config, options droplet_runner.MigrateOptions) error
+	migrateDropletsMutex       sync.RWMutex
+	migrateDropletsArgsForCall []struct {
+		source      *config.Config
+		destination *config.Config
+		options     droplet_runner.MigrateOptions
+	}
+	migrateDropletsReturns struct {
+		result1 error
+	}
+	GCDropletsStub        func(options droplet_runner.GCOptions) error
+	gCDropletsMutex       sync.RWMutex
+	gCDropletsArgsForCall []struct {
+		options droplet_runner.GCOptions
+	}
+	gCDropletsReturns struct {
+		result1 error
+	}
+	RecordBuildStub        func(dropletName string, buildpackURLs []string, environmentKeys []string, result string) error
+	recordBuildMutex       sync.RWMutex
+	recordBuildArgsForCall []struct {
+		dropletName     string
+		buildpackURLs   []string
+		environmentKeys []string
+		result          string
+	}
+	recordBuildReturns struct {
+		result1 error
+	}
+	DropletMetadataStub        func(dropletName string) (*droplet_runner.DropletMetadata, error)
+	dropletMetadataMutex       sync.RWMutex
+	dropletMetadataArgsForCall []struct {
+		dropletName string
+	}
+	dropletMetadataReturns struct {
+		result1 *droplet_runner.DropletMetadata
+		result2 error
+	}
+	ProcessCommandStub        func(dropletRef, processType string) (string, error)
+	processCommandMutex       sync.RWMutex
+	processCommandArgsForCall []struct {
+		dropletRef  string
+		processType string
+	}
+	processCommandReturns struct {
+		result1 string
+		result2 error
+	}
+	ListDropletMetadataStub        func() (map[string]droplet_runner.DropletMetadata, error)
+	listDropletMetadataMutex       sync.RWMutex
+	listDropletMetadataArgsForCall []struct{}
+	listDropletMetadataReturns     struct {
+		result1 map[string]droplet_runner.DropletMetadata
+		result2 error
+	}
+	NewDropletVersionStub        func(dropletName string) (string, error)
+	newDropletVersionMutex       sync.RWMutex
+	newDropletVersionArgsForCall []struct {
+		dropletName string
+	}
+	newDropletVersionReturns struct {
+		result1 string
+		result2 error
+	}
+	ResolveDropletStub        func(dropletRef string) (string, error)
+	resolveDropletMutex       sync.RWMutex
+	resolveDropletArgsForCall []struct {
+		dropletRef string
+	}
+	resolveDropletReturns struct {
+		result1 string
+		result2 error
+	}
+	TagDropletStub        func(dropletRef, tag string) error
+	tagDropletMutex       sync.RWMutex
+	tagDropletArgsForCall []struct {
+		dropletRef string
+		tag        string
+	}
+	tagDropletReturns struct {
+		result1 error
+	}
+	DropletHistoryStub        func(dropletName string) ([]droplet_runner.Droplet, error)
+	dropletHistoryMutex       sync.RWMutex
+	dropletHistoryArgsForCall []struct {
+		dropletName string
+	}
+	dropletHistoryReturns struct {
+		result1 []droplet_runner.Droplet
+		result2 error
+	}
+	ClearBuildCacheStub        func(dropletName string) error
+	clearBuildCacheMutex       sync.RWMutex
+	clearBuildCacheArgsForCall []struct {
+		dropletName string
+	}
+	clearBuildCacheReturns struct {
+		result1 error
+	}
 }
 
-func (fake *FakeDropletRunner) UploadBits(dropletName string, uploadPath string) error {
+func (fake *FakeDropletRunner) UploadBits(dropletName string, uploadPath string, progress blob.Progress) error {
 	fake.uploadBitsMutex.Lock()
 	fake.uploadBitsArgsForCall = append(fake.uploadBitsArgsForCall, struct {
 		dropletName string
 		uploadPath  string
-	}{dropletName, uploadPath})
+		progress    blob.Progress
+	}{dropletName, uploadPath, progress})
 	fake.uploadBitsMutex.Unlock()
 	if fake.UploadBitsStub != nil {
-		return fake.UploadBitsStub(dropletName, uploadPath)
+		return fake.UploadBitsStub(dropletName, uploadPath, progress)
 	} else {
 		return fake.uploadBitsReturns.result1
 	}
@@ -100,10 +236,10 @@ func (fake *FakeDropletRunner) UploadBitsCallCount() int {
 	return len(fake.uploadBitsArgsForCall)
 }
 
-func (fake *FakeDropletRunner) UploadBitsArgsForCall(i int) (string, string) {
+func (fake *FakeDropletRunner) UploadBitsArgsForCall(i int) (string, string, blob.Progress) {
 	fake.uploadBitsMutex.RLock()
 	defer fake.uploadBitsMutex.RUnlock()
-	return fake.uploadBitsArgsForCall[i].dropletName, fake.uploadBitsArgsForCall[i].uploadPath
+	return fake.uploadBitsArgsForCall[i].dropletName, fake.uploadBitsArgsForCall[i].uploadPath, fake.uploadBitsArgsForCall[i].progress
 }
 
 func (fake *FakeDropletRunner) UploadBitsReturns(result1 error) {
@@ -113,20 +249,55 @@ func (fake *FakeDropletRunner) UploadBitsReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeDropletRunner) BuildDroplet(taskName string, dropletName string, buildpackUrl string, environment map[string]string, memoryMB int, cpuWeight int, diskMB int) error {
+func (fake *FakeDropletRunner) UploadBuildpack(buildpackPath string, progress blob.Progress) (string, error) {
+	fake.uploadBuildpackMutex.Lock()
+	fake.uploadBuildpackArgsForCall = append(fake.uploadBuildpackArgsForCall, struct {
+		buildpackPath string
+		progress      blob.Progress
+	}{buildpackPath, progress})
+	fake.uploadBuildpackMutex.Unlock()
+	if fake.UploadBuildpackStub != nil {
+		return fake.UploadBuildpackStub(buildpackPath, progress)
+	} else {
+		return fake.uploadBuildpackReturns.result1, fake.uploadBuildpackReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) UploadBuildpackCallCount() int {
+	fake.uploadBuildpackMutex.RLock()
+	defer fake.uploadBuildpackMutex.RUnlock()
+	return len(fake.uploadBuildpackArgsForCall)
+}
+
+func (fake *FakeDropletRunner) UploadBuildpackArgsForCall(i int) (string, blob.Progress) {
+	fake.uploadBuildpackMutex.RLock()
+	defer fake.uploadBuildpackMutex.RUnlock()
+	return fake.uploadBuildpackArgsForCall[i].buildpackPath, fake.uploadBuildpackArgsForCall[i].progress
+}
+
+func (fake *FakeDropletRunner) UploadBuildpackReturns(result1 string, result2 error) {
+	fake.UploadBuildpackStub = nil
+	fake.uploadBuildpackReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) BuildDroplet(taskName string, dropletName string, buildpackUrls []string, environment map[string]string, memoryMB int, cpuWeight int, diskMB int, useBuildCache bool) error {
 	fake.buildDropletMutex.Lock()
 	fake.buildDropletArgsForCall = append(fake.buildDropletArgsForCall, struct {
-		taskName     string
-		dropletName  string
-		buildpackUrl string
-		environment  map[string]string
-		memoryMB     int
-		cpuWeight    int
-		diskMB       int
-	}{taskName, dropletName, buildpackUrl, environment, memoryMB, cpuWeight, diskMB})
+		taskName      string
+		dropletName   string
+		buildpackUrls []string
+		environment   map[string]string
+		memoryMB      int
+		cpuWeight     int
+		diskMB        int
+		useBuildCache bool
+	}{taskName, dropletName, buildpackUrls, environment, memoryMB, cpuWeight, diskMB, useBuildCache})
 	fake.buildDropletMutex.Unlock()
 	if fake.BuildDropletStub != nil {
-		return fake.BuildDropletStub(taskName, dropletName, buildpackUrl, environment, memoryMB, cpuWeight, diskMB)
+		return fake.BuildDropletStub(taskName, dropletName, buildpackUrls, environment, memoryMB, cpuWeight, diskMB, useBuildCache)
 	} else {
 		return fake.buildDropletReturns.result1
 	}
@@ -138,10 +309,10 @@ func (fake *FakeDropletRunner) BuildDropletCallCount() int {
 	return len(fake.buildDropletArgsForCall)
 }
 
-func (fake *FakeDropletRunner) BuildDropletArgsForCall(i int) (string, string, string, map[string]string, int, int, int) {
+func (fake *FakeDropletRunner) BuildDropletArgsForCall(i int) (string, string, []string, map[string]string, int, int, int, bool) {
 	fake.buildDropletMutex.RLock()
 	defer fake.buildDropletMutex.RUnlock()
-	return fake.buildDropletArgsForCall[i].taskName, fake.buildDropletArgsForCall[i].dropletName, fake.buildDropletArgsForCall[i].buildpackUrl, fake.buildDropletArgsForCall[i].environment, fake.buildDropletArgsForCall[i].memoryMB, fake.buildDropletArgsForCall[i].cpuWeight, fake.buildDropletArgsForCall[i].diskMB
+	return fake.buildDropletArgsForCall[i].taskName, fake.buildDropletArgsForCall[i].dropletName, fake.buildDropletArgsForCall[i].buildpackUrls, fake.buildDropletArgsForCall[i].environment, fake.buildDropletArgsForCall[i].memoryMB, fake.buildDropletArgsForCall[i].cpuWeight, fake.buildDropletArgsForCall[i].diskMB, fake.buildDropletArgsForCall[i].useBuildCache
 }
 
 func (fake *FakeDropletRunner) BuildDropletReturns(result1 error) {
@@ -187,6 +358,43 @@ func (fake *FakeDropletRunner) LaunchDropletReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDropletRunner) RelaunchDroplet(appName string, dropletRef string, startCommand string, startArgs []string, appEnvironmentParams app_runner.AppEnvironmentParams, timeout time.Duration) error {
+	fake.relaunchDropletMutex.Lock()
+	fake.relaunchDropletArgsForCall = append(fake.relaunchDropletArgsForCall, struct {
+		appName              string
+		dropletRef           string
+		startCommand         string
+		startArgs            []string
+		appEnvironmentParams app_runner.AppEnvironmentParams
+		timeout              time.Duration
+	}{appName, dropletRef, startCommand, startArgs, appEnvironmentParams, timeout})
+	fake.relaunchDropletMutex.Unlock()
+	if fake.RelaunchDropletStub != nil {
+		return fake.RelaunchDropletStub(appName, dropletRef, startCommand, startArgs, appEnvironmentParams, timeout)
+	} else {
+		return fake.relaunchDropletReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) RelaunchDropletCallCount() int {
+	fake.relaunchDropletMutex.RLock()
+	defer fake.relaunchDropletMutex.RUnlock()
+	return len(fake.relaunchDropletArgsForCall)
+}
+
+func (fake *FakeDropletRunner) RelaunchDropletArgsForCall(i int) (string, string, string, []string, app_runner.AppEnvironmentParams, time.Duration) {
+	fake.relaunchDropletMutex.RLock()
+	defer fake.relaunchDropletMutex.RUnlock()
+	return fake.relaunchDropletArgsForCall[i].appName, fake.relaunchDropletArgsForCall[i].dropletRef, fake.relaunchDropletArgsForCall[i].startCommand, fake.relaunchDropletArgsForCall[i].startArgs, fake.relaunchDropletArgsForCall[i].appEnvironmentParams, fake.relaunchDropletArgsForCall[i].timeout
+}
+
+func (fake *FakeDropletRunner) RelaunchDropletReturns(result1 error) {
+	fake.RelaunchDropletStub = nil
+	fake.relaunchDropletReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeDropletRunner) ListDroplets() ([]droplet_runner.Droplet, error) {
 	fake.listDropletsMutex.Lock()
 	fake.listDropletsArgsForCall = append(fake.listDropletsArgsForCall, struct{}{})
@@ -277,15 +485,16 @@ func (fake *FakeDropletRunner) ExportDropletReturns(result1 io.ReadCloser, resul
 	}{result1, result2}
 }
 
-func (fake *FakeDropletRunner) ImportDroplet(dropletName string, dropletPath string) error {
+func (fake *FakeDropletRunner) ImportDroplet(dropletName string, dropletPath string, progress blob.Progress) error {
 	fake.importDropletMutex.Lock()
 	fake.importDropletArgsForCall = append(fake.importDropletArgsForCall, struct {
 		dropletName string
 		dropletPath string
-	}{dropletName, dropletPath})
+		progress    blob.Progress
+	}{dropletName, dropletPath, progress})
 	fake.importDropletMutex.Unlock()
 	if fake.ImportDropletStub != nil {
-		return fake.ImportDropletStub(dropletName, dropletPath)
+		return fake.ImportDropletStub(dropletName, dropletPath, progress)
 	} else {
 		return fake.importDropletReturns.result1
 	}
@@ -297,10 +506,10 @@ func (fake *FakeDropletRunner) ImportDropletCallCount() int {
 	return len(fake.importDropletArgsForCall)
 }
 
-func (fake *FakeDropletRunner) ImportDropletArgsForCall(i int) (string, string) {
+func (fake *FakeDropletRunner) ImportDropletArgsForCall(i int) (string, string, blob.Progress) {
 	fake.importDropletMutex.RLock()
 	defer fake.importDropletMutex.RUnlock()
-	return fake.importDropletArgsForCall[i].dropletName, fake.importDropletArgsForCall[i].dropletPath
+	return fake.importDropletArgsForCall[i].dropletName, fake.importDropletArgsForCall[i].dropletPath, fake.importDropletArgsForCall[i].progress
 }
 
 func (fake *FakeDropletRunner) ImportDropletReturns(result1 error) {
@@ -310,4 +519,386 @@ func (fake *FakeDropletRunner) ImportDropletReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDropletRunner) DropletChecksums() (map[string]string, error) {
+	fake.dropletChecksumsMutex.Lock()
+	fake.dropletChecksumsArgsForCall = append(fake.dropletChecksumsArgsForCall, struct{}{})
+	fake.dropletChecksumsMutex.Unlock()
+	if fake.DropletChecksumsStub != nil {
+		return fake.DropletChecksumsStub()
+	} else {
+		return fake.dropletChecksumsReturns.result1, fake.dropletChecksumsReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) DropletChecksumsCallCount() int {
+	fake.dropletChecksumsMutex.RLock()
+	defer fake.dropletChecksumsMutex.RUnlock()
+	return len(fake.dropletChecksumsArgsForCall)
+}
+
+func (fake *FakeDropletRunner) DropletChecksumsReturns(result1 map[string]string, result2 error) {
+	fake.DropletChecksumsStub = nil
+	fake.dropletChecksumsReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) MigrateDroplets(source *config.Config, destination *config.Config, options droplet_runner.MigrateOptions) error {
+	fake.migrateDropletsMutex.Lock()
+	fake.migrateDropletsArgsForCall = append(fake.migrateDropletsArgsForCall, struct {
+		source      *config.Config
+		destination *config.Config
+		options     droplet_runner.MigrateOptions
+	}{source, destination, options})
+	fake.migrateDropletsMutex.Unlock()
+	if fake.MigrateDropletsStub != nil {
+		return fake.MigrateDropletsStub(source, destination, options)
+	} else {
+		return fake.migrateDropletsReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) MigrateDropletsCallCount() int {
+	fake.migrateDropletsMutex.RLock()
+	defer fake.migrateDropletsMutex.RUnlock()
+	return len(fake.migrateDropletsArgsForCall)
+}
+
+func (fake *FakeDropletRunner) MigrateDropletsArgsForCall(i int) (*config.Config, *config.Config, droplet_runner.MigrateOptions) {
+	fake.migrateDropletsMutex.RLock()
+	defer fake.migrateDropletsMutex.RUnlock()
+	return fake.migrateDropletsArgsForCall[i].source, fake.migrateDropletsArgsForCall[i].destination, fake.migrateDropletsArgsForCall[i].options
+}
+
+func (fake *FakeDropletRunner) MigrateDropletsReturns(result1 error) {
+	fake.MigrateDropletsStub = nil
+	fake.migrateDropletsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) GCDroplets(options droplet_runner.GCOptions) error {
+	fake.gCDropletsMutex.Lock()
+	fake.gCDropletsArgsForCall = append(fake.gCDropletsArgsForCall, struct {
+		options droplet_runner.GCOptions
+	}{options})
+	fake.gCDropletsMutex.Unlock()
+	if fake.GCDropletsStub != nil {
+		return fake.GCDropletsStub(options)
+	} else {
+		return fake.gCDropletsReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) GCDropletsCallCount() int {
+	fake.gCDropletsMutex.RLock()
+	defer fake.gCDropletsMutex.RUnlock()
+	return len(fake.gCDropletsArgsForCall)
+}
+
+func (fake *FakeDropletRunner) GCDropletsArgsForCall(i int) droplet_runner.GCOptions {
+	fake.gCDropletsMutex.RLock()
+	defer fake.gCDropletsMutex.RUnlock()
+	return fake.gCDropletsArgsForCall[i].options
+}
+
+func (fake *FakeDropletRunner) GCDropletsReturns(result1 error) {
+	fake.GCDropletsStub = nil
+	fake.gCDropletsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) RecordBuild(dropletName string, buildpackURLs []string, environmentKeys []string, result string) error {
+	fake.recordBuildMutex.Lock()
+	fake.recordBuildArgsForCall = append(fake.recordBuildArgsForCall, struct {
+		dropletName     string
+		buildpackURLs   []string
+		environmentKeys []string
+		result          string
+	}{dropletName, buildpackURLs, environmentKeys, result})
+	fake.recordBuildMutex.Unlock()
+	if fake.RecordBuildStub != nil {
+		return fake.RecordBuildStub(dropletName, buildpackURLs, environmentKeys, result)
+	} else {
+		return fake.recordBuildReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) RecordBuildCallCount() int {
+	fake.recordBuildMutex.RLock()
+	defer fake.recordBuildMutex.RUnlock()
+	return len(fake.recordBuildArgsForCall)
+}
+
+func (fake *FakeDropletRunner) RecordBuildArgsForCall(i int) (string, []string, []string, string) {
+	fake.recordBuildMutex.RLock()
+	defer fake.recordBuildMutex.RUnlock()
+	return fake.recordBuildArgsForCall[i].dropletName, fake.recordBuildArgsForCall[i].buildpackURLs, fake.recordBuildArgsForCall[i].environmentKeys, fake.recordBuildArgsForCall[i].result
+}
+
+func (fake *FakeDropletRunner) RecordBuildReturns(result1 error) {
+	fake.RecordBuildStub = nil
+	fake.recordBuildReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) DropletMetadata(dropletName string) (*droplet_runner.DropletMetadata, error) {
+	fake.dropletMetadataMutex.Lock()
+	fake.dropletMetadataArgsForCall = append(fake.dropletMetadataArgsForCall, struct {
+		dropletName string
+	}{dropletName})
+	fake.dropletMetadataMutex.Unlock()
+	if fake.DropletMetadataStub != nil {
+		return fake.DropletMetadataStub(dropletName)
+	} else {
+		return fake.dropletMetadataReturns.result1, fake.dropletMetadataReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) DropletMetadataCallCount() int {
+	fake.dropletMetadataMutex.RLock()
+	defer fake.dropletMetadataMutex.RUnlock()
+	return len(fake.dropletMetadataArgsForCall)
+}
+
+func (fake *FakeDropletRunner) DropletMetadataArgsForCall(i int) string {
+	fake.dropletMetadataMutex.RLock()
+	defer fake.dropletMetadataMutex.RUnlock()
+	return fake.dropletMetadataArgsForCall[i].dropletName
+}
+
+func (fake *FakeDropletRunner) DropletMetadataReturns(result1 *droplet_runner.DropletMetadata, result2 error) {
+	fake.DropletMetadataStub = nil
+	fake.dropletMetadataReturns = struct {
+		result1 *droplet_runner.DropletMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) ProcessCommand(dropletRef, processType string) (string, error) {
+	fake.processCommandMutex.Lock()
+	fake.processCommandArgsForCall = append(fake.processCommandArgsForCall, struct {
+		dropletRef  string
+		processType string
+	}{dropletRef, processType})
+	fake.processCommandMutex.Unlock()
+	if fake.ProcessCommandStub != nil {
+		return fake.ProcessCommandStub(dropletRef, processType)
+	} else {
+		return fake.processCommandReturns.result1, fake.processCommandReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) ProcessCommandCallCount() int {
+	fake.processCommandMutex.RLock()
+	defer fake.processCommandMutex.RUnlock()
+	return len(fake.processCommandArgsForCall)
+}
+
+func (fake *FakeDropletRunner) ProcessCommandArgsForCall(i int) (string, string) {
+	fake.processCommandMutex.RLock()
+	defer fake.processCommandMutex.RUnlock()
+	return fake.processCommandArgsForCall[i].dropletRef, fake.processCommandArgsForCall[i].processType
+}
+
+func (fake *FakeDropletRunner) ProcessCommandReturns(result1 string, result2 error) {
+	fake.ProcessCommandStub = nil
+	fake.processCommandReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) ListDropletMetadata() (map[string]droplet_runner.DropletMetadata, error) {
+	fake.listDropletMetadataMutex.Lock()
+	fake.listDropletMetadataArgsForCall = append(fake.listDropletMetadataArgsForCall, struct{}{})
+	fake.listDropletMetadataMutex.Unlock()
+	if fake.ListDropletMetadataStub != nil {
+		return fake.ListDropletMetadataStub()
+	} else {
+		return fake.listDropletMetadataReturns.result1, fake.listDropletMetadataReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) ListDropletMetadataCallCount() int {
+	fake.listDropletMetadataMutex.RLock()
+	defer fake.listDropletMetadataMutex.RUnlock()
+	return len(fake.listDropletMetadataArgsForCall)
+}
+
+func (fake *FakeDropletRunner) ListDropletMetadataReturns(result1 map[string]droplet_runner.DropletMetadata, result2 error) {
+	fake.ListDropletMetadataStub = nil
+	fake.listDropletMetadataReturns = struct {
+		result1 map[string]droplet_runner.DropletMetadata
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) NewDropletVersion(dropletName string) (string, error) {
+	fake.newDropletVersionMutex.Lock()
+	fake.newDropletVersionArgsForCall = append(fake.newDropletVersionArgsForCall, struct {
+		dropletName string
+	}{dropletName})
+	fake.newDropletVersionMutex.Unlock()
+	if fake.NewDropletVersionStub != nil {
+		return fake.NewDropletVersionStub(dropletName)
+	} else {
+		return fake.newDropletVersionReturns.result1, fake.newDropletVersionReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) NewDropletVersionCallCount() int {
+	fake.newDropletVersionMutex.RLock()
+	defer fake.newDropletVersionMutex.RUnlock()
+	return len(fake.newDropletVersionArgsForCall)
+}
+
+func (fake *FakeDropletRunner) NewDropletVersionArgsForCall(i int) string {
+	fake.newDropletVersionMutex.RLock()
+	defer fake.newDropletVersionMutex.RUnlock()
+	return fake.newDropletVersionArgsForCall[i].dropletName
+}
+
+func (fake *FakeDropletRunner) NewDropletVersionReturns(result1 string, result2 error) {
+	fake.NewDropletVersionStub = nil
+	fake.newDropletVersionReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) ResolveDroplet(dropletRef string) (string, error) {
+	fake.resolveDropletMutex.Lock()
+	fake.resolveDropletArgsForCall = append(fake.resolveDropletArgsForCall, struct {
+		dropletRef string
+	}{dropletRef})
+	fake.resolveDropletMutex.Unlock()
+	if fake.ResolveDropletStub != nil {
+		return fake.ResolveDropletStub(dropletRef)
+	} else {
+		return fake.resolveDropletReturns.result1, fake.resolveDropletReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) ResolveDropletCallCount() int {
+	fake.resolveDropletMutex.RLock()
+	defer fake.resolveDropletMutex.RUnlock()
+	return len(fake.resolveDropletArgsForCall)
+}
+
+func (fake *FakeDropletRunner) ResolveDropletArgsForCall(i int) string {
+	fake.resolveDropletMutex.RLock()
+	defer fake.resolveDropletMutex.RUnlock()
+	return fake.resolveDropletArgsForCall[i].dropletRef
+}
+
+func (fake *FakeDropletRunner) ResolveDropletReturns(result1 string, result2 error) {
+	fake.ResolveDropletStub = nil
+	fake.resolveDropletReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) TagDroplet(dropletRef, tag string) error {
+	fake.tagDropletMutex.Lock()
+	fake.tagDropletArgsForCall = append(fake.tagDropletArgsForCall, struct {
+		dropletRef string
+		tag        string
+	}{dropletRef, tag})
+	fake.tagDropletMutex.Unlock()
+	if fake.TagDropletStub != nil {
+		return fake.TagDropletStub(dropletRef, tag)
+	} else {
+		return fake.tagDropletReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) TagDropletCallCount() int {
+	fake.tagDropletMutex.RLock()
+	defer fake.tagDropletMutex.RUnlock()
+	return len(fake.tagDropletArgsForCall)
+}
+
+func (fake *FakeDropletRunner) TagDropletArgsForCall(i int) (string, string) {
+	fake.tagDropletMutex.RLock()
+	defer fake.tagDropletMutex.RUnlock()
+	return fake.tagDropletArgsForCall[i].dropletRef, fake.tagDropletArgsForCall[i].tag
+}
+
+func (fake *FakeDropletRunner) TagDropletReturns(result1 error) {
+	fake.TagDropletStub = nil
+	fake.tagDropletReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDropletRunner) DropletHistory(dropletName string) ([]droplet_runner.Droplet, error) {
+	fake.dropletHistoryMutex.Lock()
+	fake.dropletHistoryArgsForCall = append(fake.dropletHistoryArgsForCall, struct {
+		dropletName string
+	}{dropletName})
+	fake.dropletHistoryMutex.Unlock()
+	if fake.DropletHistoryStub != nil {
+		return fake.DropletHistoryStub(dropletName)
+	} else {
+		return fake.dropletHistoryReturns.result1, fake.dropletHistoryReturns.result2
+	}
+}
+
+func (fake *FakeDropletRunner) DropletHistoryCallCount() int {
+	fake.dropletHistoryMutex.RLock()
+	defer fake.dropletHistoryMutex.RUnlock()
+	return len(fake.dropletHistoryArgsForCall)
+}
+
+func (fake *FakeDropletRunner) DropletHistoryArgsForCall(i int) string {
+	fake.dropletHistoryMutex.RLock()
+	defer fake.dropletHistoryMutex.RUnlock()
+	return fake.dropletHistoryArgsForCall[i].dropletName
+}
+
+func (fake *FakeDropletRunner) DropletHistoryReturns(result1 []droplet_runner.Droplet, result2 error) {
+	fake.DropletHistoryStub = nil
+	fake.dropletHistoryReturns = struct {
+		result1 []droplet_runner.Droplet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDropletRunner) ClearBuildCache(dropletName string) error {
+	fake.clearBuildCacheMutex.Lock()
+	fake.clearBuildCacheArgsForCall = append(fake.clearBuildCacheArgsForCall, struct {
+		dropletName string
+	}{dropletName})
+	fake.clearBuildCacheMutex.Unlock()
+	if fake.ClearBuildCacheStub != nil {
+		return fake.ClearBuildCacheStub(dropletName)
+	} else {
+		return fake.clearBuildCacheReturns.result1
+	}
+}
+
+func (fake *FakeDropletRunner) ClearBuildCacheCallCount() int {
+	fake.clearBuildCacheMutex.RLock()
+	defer fake.clearBuildCacheMutex.RUnlock()
+	return len(fake.clearBuildCacheArgsForCall)
+}
+
+func (fake *FakeDropletRunner) ClearBuildCacheArgsForCall(i int) string {
+	fake.clearBuildCacheMutex.RLock()
+	defer fake.clearBuildCacheMutex.RUnlock()
+	return fake.clearBuildCacheArgsForCall[i].dropletName
+}
+
+func (fake *FakeDropletRunner) ClearBuildCacheReturns(result1 error) {
+	fake.ClearBuildCacheStub = nil
+	fake.clearBuildCacheReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ droplet_runner.DropletRunner = new(FakeDropletRunner)
@@ -8,11 +8,17 @@ import (
 )
 
 type HTTPProxyConfReader struct {
-	URL string
+	URL    string
+	Client *http.Client
 }
 
 func (p *HTTPProxyConfReader) ProxyConf() (ProxyConf, error) {
-	resp, err := http.Get(p.URL)
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(p.URL)
 	if err != nil {
 		return ProxyConf{}, err
 	}
@@ -0,0 +1,13 @@
+package file_helpers_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFileHelpers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FileHelpers Suite")
+}
@@ -0,0 +1,49 @@
+package file_helpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/ltc/file_helpers"
+)
+
+var _ = Describe("ReplaceFile", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "replace-file")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("moves the file over an existing one", func() {
+		from := filepath.Join(tmpDir, "from")
+		to := filepath.Join(tmpDir, "to")
+		Expect(ioutil.WriteFile(from, []byte("new"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(to, []byte("old contents"), 0644)).To(Succeed())
+
+		Expect(file_helpers.ReplaceFile(from, to)).To(Succeed())
+
+		Expect(ioutil.ReadFile(to)).To(Equal([]byte("new")))
+		_, err := os.Stat(from)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("moves the file where there is none yet", func() {
+		from := filepath.Join(tmpDir, "from")
+		to := filepath.Join(tmpDir, "to")
+		Expect(ioutil.WriteFile(from, []byte("new"), 0644)).To(Succeed())
+
+		Expect(file_helpers.ReplaceFile(from, to)).To(Succeed())
+
+		Expect(ioutil.ReadFile(to)).To(Equal([]byte("new")))
+	})
+})
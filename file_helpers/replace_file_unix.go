@@ -0,0 +1,10 @@
+// +build !windows
+
+package file_helpers
+
+import "os"
+
+// ReplaceFile moves from over to, replacing any file already there.
+func ReplaceFile(from, to string) error {
+	return os.Rename(from, to)
+}
@@ -0,0 +1,37 @@
+// +build windows
+
+package file_helpers
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	movefileReplaceExisting = 0x1
+	movefileWriteThrough    = 0x8
+)
+
+var procMoveFileExW = syscall.NewLazyDLL("kernel32.dll").NewProc("MoveFileExW")
+
+// ReplaceFile moves from over to, replacing any file already there.  It calls
+// MoveFileEx, since os.Rename fails on Windows when the destination exists.
+func ReplaceFile(from, to string) error {
+	fromPtr, err := syscall.UTF16PtrFromString(from)
+	if err != nil {
+		return err
+	}
+
+	toPtr, err := syscall.UTF16PtrFromString(to)
+	if err != nil {
+		return err
+	}
+
+	result, _, err := procMoveFileExW.Call(uintptr(unsafe.Pointer(fromPtr)), uintptr(unsafe.Pointer(toPtr)), movefileReplaceExisting|movefileWriteThrough)
+	if result == 0 {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: err}
+	}
+
+	return nil
+}
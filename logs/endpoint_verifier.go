@@ -0,0 +1,62 @@
+package logs
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+)
+
+// EndpointVerifier checks that the configured doppler endpoint is reachable
+// and accepts the log-stream token from TokenSource.
+type EndpointVerifier struct {
+	TokenSource auth.TokenSource
+}
+
+func (v EndpointVerifier) Verify(config *config_package.Config) (authorized bool, err error) {
+	scheme := "http"
+	if config.DopplerScheme() == "wss" {
+		scheme = "https"
+	}
+
+	recentLogsURL := url.URL{
+		Scheme: scheme,
+		Host:   config.Loggregator(),
+		Path:   "/apps/ltc-target-check/recentlogs",
+	}
+
+	req, err := http.NewRequest("GET", recentLogsURL.String(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	token, err := v.TokenSource.Token()
+	if err != nil {
+		return false, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", auth.AuthorizationHeader(token))
+	}
+
+	client, err := config.TLS().HTTPClient()
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return false, nil
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("log endpoint returned %s", resp.Status)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package logs_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_source"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/logs"
+)
+
+var _ = Describe("EndpointVerifier", func() {
+	var (
+		config          *config_package.Config
+		fakeServer      *ghttp.Server
+		fakeTokenSource *fake_token_source.FakeTokenSource
+		verifier        logs.EndpointVerifier
+	)
+
+	targetFakeServer := func() {
+		fakeServerURL, err := url.Parse(fakeServer.URL())
+		Expect(err).NotTo(HaveOccurred())
+		serverHost, serverPort, err := net.SplitHostPort(fakeServerURL.Host)
+		Expect(err).NotTo(HaveOccurred())
+
+		config.SetDoppler(config_package.DopplerConfig{Host: serverHost, Port: serverPort})
+	}
+
+	BeforeEach(func() {
+		config = config_package.New(nil)
+		config.SetTarget("lattice.example.com")
+
+		fakeServer = ghttp.NewServer()
+		targetFakeServer()
+
+		fakeTokenSource = &fake_token_source.FakeTokenSource{}
+		verifier = logs.EndpointVerifier{TokenSource: fakeTokenSource}
+	})
+
+	AfterEach(func() {
+		if fakeServer != nil {
+			fakeServer.Close()
+		}
+	})
+
+	It("returns authorized when doppler serves recent logs", func() {
+		fakeServer.RouteToHandler("GET", "/apps/ltc-target-check/recentlogs", ghttp.RespondWith(http.StatusOK, ""))
+
+		authorized, err := verifier.Verify(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorized).To(BeTrue())
+
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		Expect(fakeServer.ReceivedRequests()[0].Header).NotTo(HaveKey("Authorization"))
+	})
+
+	It("sends the log-stream token", func() {
+		fakeTokenSource.TokenReturns("some-token", nil)
+		fakeServer.RouteToHandler("GET", "/apps/ltc-target-check/recentlogs", ghttp.CombineHandlers(
+			ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+			ghttp.RespondWith(http.StatusOK, ""),
+		))
+
+		authorized, err := verifier.Verify(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorized).To(BeTrue())
+	})
+
+	It("returns unauthorized when doppler rejects the token", func() {
+		fakeServer.RouteToHandler("GET", "/apps/ltc-target-check/recentlogs", ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+		authorized, err := verifier.Verify(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorized).To(BeFalse())
+	})
+
+	It("returns an error for other failures", func() {
+		fakeServer.RouteToHandler("GET", "/apps/ltc-target-check/recentlogs", ghttp.RespondWith(http.StatusBadGateway, ""))
+
+		_, err := verifier.Verify(config)
+		Expect(err).To(MatchError("log endpoint returned 502 Bad Gateway"))
+	})
+
+	It("returns errors getting the token", func() {
+		fakeTokenSource.TokenReturns("", errors.New("Not logged in. Run ltc login."))
+
+		_, err := verifier.Verify(config)
+		Expect(err).To(MatchError("Not logged in. Run ltc login."))
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	Context("when doppler is served over wss", func() {
+		BeforeEach(func() {
+			fakeServer.Close()
+			fakeServer = ghttp.NewTLSServer()
+			targetFakeServer()
+
+			fakeServer.RouteToHandler("GET", "/apps/ltc-target-check/recentlogs", ghttp.RespondWith(http.StatusOK, ""))
+			config.SetScheme("https")
+		})
+
+		It("uses the target's TLS settings", func() {
+			config.SetTLS(config_package.TLSConfig{SkipVerify: true})
+
+			authorized, err := verifier.Verify(config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(authorized).To(BeTrue())
+		})
+
+		It("returns an error when the certificate is not trusted", func() {
+			_, err := verifier.Verify(config)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when doppler is inaccessible", func() {
+		It("returns an error", func() {
+			fakeServer.Close()
+			fakeServer = nil
+
+			_, err := verifier.Verify(config)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package logs
 
-import "github.com/cloudfoundry/sonde-go/events"
+import (
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	"github.com/cloudfoundry/sonde-go/events"
+)
 
 type LogReader interface {
 	TailLogs(appGuid string, logCallback func(*events.LogMessage), errorCallback func(error))
@@ -13,14 +16,16 @@ type logConsumer interface {
 }
 
 type logReader struct {
-	consumer logConsumer
-	stopChan chan struct{}
+	consumer    logConsumer
+	tokenSource auth.TokenSource
+	stopChan    chan struct{}
 }
 
-func NewLogReader(consumer logConsumer) LogReader {
+func NewLogReader(consumer logConsumer, tokenSource auth.TokenSource) LogReader {
 	return &logReader{
-		consumer: consumer,
-		stopChan: make(chan struct{}),
+		consumer:    consumer,
+		tokenSource: tokenSource,
+		stopChan:    make(chan struct{}),
 	}
 }
 
@@ -28,7 +33,11 @@ func (l *logReader) TailLogs(appGuid string, logCallback func(*events.LogMessage
 	outputChan := make(chan *events.LogMessage, 10)
 	errorChan := make(chan error, 10)
 
-	go l.consumer.TailingLogs(appGuid, "", outputChan, errorChan)
+	if token, err := l.tokenSource.Token(); err != nil {
+		errorCallback(err)
+	} else {
+		go l.consumer.TailingLogs(appGuid, auth.AuthorizationHeader(token), outputChan, errorChan)
+	}
 
 	l.readChannels(outputChan, errorChan, logCallback, errorCallback)
 }
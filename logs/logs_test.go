@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_source"
 	"github.com/cloudfoundry-incubator/ltc/logs"
 	"github.com/cloudfoundry/sonde-go/events"
 )
@@ -20,12 +21,18 @@ func NewFakeConsumer() *fakeConsumer {
 }
 
 type fakeConsumer struct {
+	sync.RWMutex
 	inboundLogStream   chan *events.LogMessage
 	inboundErrorStream chan error
 	stopChan           chan struct{}
+	authToken          string
 }
 
 func (consumer *fakeConsumer) TailingLogs(appGuid string, authToken string, outputChan chan<- *events.LogMessage, errorChan chan<- error) {
+	consumer.Lock()
+	consumer.authToken = authToken
+	consumer.Unlock()
+
 	for {
 		select {
 		case <-consumer.stopChan:
@@ -45,6 +52,12 @@ func (consumer *fakeConsumer) Close() error {
 	return nil
 }
 
+func (consumer *fakeConsumer) getAuthToken() string {
+	defer consumer.RUnlock()
+	consumer.RLock()
+	return consumer.authToken
+}
+
 func (consumer *fakeConsumer) sendToInboundLogStream(logMessage *events.LogMessage) {
 	consumer.inboundLogStream <- logMessage
 }
@@ -89,13 +102,15 @@ func (e *errorReceiver) GetErrors() []error {
 
 var _ = Describe("Logs", func() {
 	var (
-		consumer  *fakeConsumer
-		logReader logs.LogReader
+		consumer        *fakeConsumer
+		fakeTokenSource *fake_token_source.FakeTokenSource
+		logReader       logs.LogReader
 	)
 
 	BeforeEach(func() {
 		consumer = NewFakeConsumer()
-		logReader = logs.NewLogReader(consumer)
+		fakeTokenSource = &fake_token_source.FakeTokenSource{}
+		logReader = logs.NewLogReader(consumer, fakeTokenSource)
 	})
 
 	Describe("TailLogs", func() {
@@ -149,6 +164,34 @@ var _ = Describe("Logs", func() {
 
 			Consistently(errorReceiver.GetErrors).ShouldNot(ContainElement(errorThree))
 		})
+
+		It("sends the log-stream token to doppler", func() {
+			fakeTokenSource.TokenReturns("some-token", nil)
+
+			go logReader.TailLogs("app-guid", func(*events.LogMessage) {}, func(error) {})
+
+			Eventually(consumer.getAuthToken).Should(Equal("bearer some-token"))
+
+			logReader.StopTailing()
+		})
+
+		It("provides the errorCallback with errors getting the log-stream token", func() {
+			errorReceiver := &errorReceiver{}
+			fakeTokenSource.TokenReturns("", errors.New("Not logged in. Run ltc login."))
+
+			doneChan := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+
+				logReader.TailLogs("app-guid", func(*events.LogMessage) {}, errorReceiver.AppendError)
+				close(doneChan)
+			}()
+
+			Eventually(errorReceiver.GetErrors).Should(Equal([]error{errors.New("Not logged in. Run ltc login.")}))
+
+			go logReader.StopTailing()
+			Eventually(doneChan).Should(BeClosed())
+		})
 	})
 
 	Describe("StopTailing", func() {
@@ -3,6 +3,8 @@ package receptor_client
 import (
 	"net/http"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/receptor"
 )
 
@@ -11,15 +13,31 @@ type Creator interface {
 	CreateReceptorClient(target string) receptor.Client
 }
 
-type ProxyAwareCreator struct{}
+type ProxyAwareCreator struct {
+	Config      *config_package.Config
+	TokenSource auth.TokenSource
+}
 
-func (ProxyAwareCreator) CreateReceptorClient(target string) receptor.Client {
+func (p ProxyAwareCreator) CreateReceptorClient(target string) receptor.Client {
 	receptorClient := receptor.NewClient(target)
 
 	transport := receptorClient.GetClient().Transport
 	httpTransport := transport.(*http.Transport)
 	httpTransport.Proxy = http.ProxyFromEnvironment
-	receptorClient.GetClient().Transport = httpTransport
+
+	// Invalid TLS settings are reported by ltc target, and keep other
+	// commands from running, so no client is used with them.
+	if p.Config != nil {
+		if tlsConfig, err := p.Config.TLS().ClientConfig(); err == nil {
+			httpTransport.TLSClientConfig = tlsConfig
+		}
+	}
+
+	if p.TokenSource != nil {
+		receptorClient.GetClient().Transport = &auth.Transport{Base: httpTransport, TokenSource: p.TokenSource}
+	} else {
+		receptorClient.GetClient().Transport = httpTransport
+	}
 
 	return receptorClient
 }
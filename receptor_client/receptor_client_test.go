@@ -4,6 +4,10 @@ import (
 	"net/http"
 	"reflect"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_source"
+	config_package "github.com/cloudfoundry-incubator/ltc/config"
+	"github.com/cloudfoundry-incubator/ltc/config/persister"
 	"github.com/cloudfoundry-incubator/ltc/receptor_client"
 
 	. "github.com/onsi/ginkgo"
@@ -26,5 +30,28 @@ var _ = Describe("ReceptorClientCreator", func() {
 			expectedProxyFunc := http.ProxyFromEnvironment
 			Expect(reflect.ValueOf(actualProxyFunc).Pointer()).To(Equal(reflect.ValueOf(expectedProxyFunc).Pointer()))
 		})
+
+		It("should apply the target's TLS settings to the http client", func() {
+			config := config_package.New(persister.NewMemPersister())
+			config.SetTLS(config_package.TLSConfig{SkipVerify: true})
+			receptorClientCreator.Config = config
+
+			client := receptorClientCreator.CreateReceptorClient("https://targethost")
+			tlsConfig := client.GetClient().Transport.(*http.Transport).TLSClientConfig
+			Expect(tlsConfig.InsecureSkipVerify).To(BeTrue())
+		})
+
+		It("should send bearer tokens from the token source", func() {
+			fakeTokenSource := &fake_token_source.FakeTokenSource{}
+			receptorClientCreator.TokenSource = fakeTokenSource
+
+			client := receptorClientCreator.CreateReceptorClient("targethost")
+			transport, ok := client.GetClient().Transport.(*auth.Transport)
+			Expect(ok).To(BeTrue())
+			Expect(transport.TokenSource).To(Equal(fakeTokenSource))
+
+			actualProxyFunc := transport.Base.(*http.Transport).Proxy
+			Expect(reflect.ValueOf(actualProxyFunc).Pointer()).To(Equal(reflect.ValueOf(http.ProxyFromEnvironment).Pointer()))
+		})
 	})
 })
@@ -5,6 +5,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	"github.com/cloudfoundry-incubator/ltc/cli_app_factory"
 	"github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/config/config_helpers"
@@ -12,21 +13,25 @@ import (
 	"github.com/cloudfoundry-incubator/ltc/config/target_verifier"
 	"github.com/cloudfoundry-incubator/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/ltc/receptor_client"
+	"github.com/cloudfoundry-incubator/ltc/terminal"
 	"github.com/codegangsta/cli"
+	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 )
 
 var latticeVersion, diegoVersion string // provided by linker argument at compile-time
 
 func NewCliApp() *cli.App {
-	config := config.New(persister.NewFilePersister(config_helpers.ConfigFileLocation(ltcConfigRoot())))
+	filePersister := persister.NewFilePersister(config_helpers.ConfigFileLocation(ltcConfigRoot()))
+	config := config.New(persister.NewEncryptedPersister(filePersister, config.SecretFields, configPassphrase))
 
 	signalChan := make(chan os.Signal)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGHUP, syscall.SIGTERM)
 	exitHandler := exit_handler.New(signalChan, os.Exit)
 	go exitHandler.Run()
 
-	receptorClientCreator := receptor_client.ProxyAwareCreator{}
+	tokenManager := auth.NewTokenManager(config, auth.NewTokenClient(config), clock.NewClock())
+	receptorClientCreator := receptor_client.ProxyAwareCreator{Config: config, TokenSource: tokenManager}
 
 	return cli_app_factory.MakeCliApp(
 		diegoVersion,
@@ -37,6 +42,7 @@ func NewCliApp() *cli.App {
 		logger(),
 		receptorClientCreator,
 		target_verifier.New(receptorClientCreator),
+		tokenManager,
 		os.Stdout,
 	)
 }
@@ -55,6 +61,14 @@ func logger() lager.Logger {
 	return logger
 }
 
+func configPassphrase() string {
+	if passphrase := os.Getenv("LTC_CONFIG_PASSPHRASE"); passphrase != "" {
+		return passphrase
+	}
+
+	return terminal.NewPasswordReader().PromptForPassword("Config passphrase")
+}
+
 func ltcConfigRoot() string {
 	var homeVar string
 
@@ -4,17 +4,29 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/ssh/sshapi"
 )
 
-type AppDialer struct{}
+type AppDialer struct {
+	TokenSource auth.TokenSource
+}
 
-func (*AppDialer) Dial(appName string, instanceIndex int, config *config_package.Config) (Client, error) {
+func (d *AppDialer) Dial(appName string, instanceIndex int, config *config_package.Config) (Client, error) {
 	diegoSSHUser := fmt.Sprintf("diego:%s/%d", appName, instanceIndex)
 	address := fmt.Sprintf("%s:2222", config.Target())
 
-	client, err := sshapi.New(diegoSSHUser, config.Username(), config.Password(), address)
+	password := fmt.Sprintf("%s:%s", config.Username(), config.Password())
+	if d.TokenSource != nil && config.Auth().TokenMode() {
+		token, err := d.TokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		password = auth.AuthorizationHeader(token)
+	}
+
+	client, err := sshapi.New(diegoSSHUser, password, address)
 	if err != nil {
 		return nil, err
 	}
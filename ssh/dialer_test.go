@@ -4,6 +4,7 @@ import (
 	"errors"
 	"reflect"
 
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_source"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	sshp "github.com/cloudfoundry-incubator/ltc/ssh"
 	"github.com/cloudfoundry-incubator/ltc/ssh/sshapi"
@@ -57,6 +58,35 @@ var _ = Describe("AppDialer", func() {
 			Expect(dialCalled).To(BeTrue())
 		})
 
+		Context("when the target uses token auth", func() {
+			var fakeTokenSource *fake_token_source.FakeTokenSource
+
+			BeforeEach(func() {
+				config.SetAuthMode(config_package.TokenAuth, "https://login.some-host/oauth/token")
+				fakeTokenSource = &fake_token_source.FakeTokenSource{}
+			})
+
+			It("should send the bearer token as the password", func() {
+				fakeTokenSource.TokenReturns("some-token", nil)
+
+				sshapi.DialFunc = func(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+					actualSecret := reflect.ValueOf(config.Auth[0]).Call([]reflect.Value{})[0].Interface()
+					Expect(actualSecret).To(Equal("bearer some-token"))
+					return &ssh.Client{}, nil
+				}
+
+				_, err := (&sshp.AppDialer{TokenSource: fakeTokenSource}).Dial("some-app-name", 100, config)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should return an error when no token is available", func() {
+				fakeTokenSource.TokenReturns("", errors.New("Not logged in. Run ltc login."))
+
+				_, err := (&sshp.AppDialer{TokenSource: fakeTokenSource}).Dial("some-app-name", 100, config)
+				Expect(err).To(MatchError("Not logged in. Run ltc login."))
+			})
+		})
+
 		Context("when dialing fails", func() {
 			It("should return an error", func() {
 				origDial := sshapi.DialFunc
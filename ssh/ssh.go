@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/exit_handler"
 	"github.com/cloudfoundry-incubator/ltc/ssh/sigwinch"
@@ -49,10 +50,10 @@ type SSH struct {
 	client          Client
 }
 
-func New(exitHandler exit_handler.ExitHandler) *SSH {
+func New(exitHandler exit_handler.ExitHandler, tokenSource auth.TokenSource) *SSH {
 	return &SSH{
 		Listener:        &ChannelListener{},
-		ClientDialer:    &AppDialer{},
+		ClientDialer:    &AppDialer{TokenSource: tokenSource},
 		Term:            &terminal.DockerTerm{},
 		SessionFactory:  &SSHAPISessionFactory{},
 		SigWinchChannel: make(chan os.Signal),
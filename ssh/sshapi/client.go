@@ -1,7 +1,6 @@
 package sshapi
 
 import (
-	"fmt"
 	"io"
 	"net"
 	"os"
@@ -31,10 +30,10 @@ type Client struct {
 	Stderr            io.Writer
 }
 
-func New(user, authUser, authPassword, address string) (*Client, error) {
+func New(user, password, address string) (*Client, error) {
 	config := &ssh.ClientConfig{
 		User: user,
-		Auth: []ssh.AuthMethod{ssh.Password(fmt.Sprintf("%s:%s", authUser, authPassword))},
+		Auth: []ssh.AuthMethod{ssh.Password(password)},
 	}
 
 	client, err := DialFunc("tcp", address, config)
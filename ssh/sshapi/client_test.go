@@ -39,7 +39,7 @@ var _ = Describe(".New", func() {
 			return sshClient, nil
 		}
 
-		client, err := sshapi.New("some-ssh-user", "some-user", "some-password", "some-host")
+		client, err := sshapi.New("some-ssh-user", "some-user:some-password", "some-host")
 		Expect(err).NotTo(HaveOccurred())
 		Expect(client.Dialer == sshClient).To(BeTrue())
 		Expect(client.SSHSessionFactory.(*sshapi.CryptoSSHSessionFactory).Client == sshClient).To(BeTrue())
@@ -59,7 +59,7 @@ var _ = Describe(".New", func() {
 				return &ssh.Client{}, errors.New("some error")
 			}
 
-			_, err := sshapi.New("some-ssh-user", "some-user", "some-password", "some-host")
+			_, err := sshapi.New("some-ssh-user", "some-user:some-password", "some-host")
 			Expect(err).To(MatchError("some error"))
 		})
 	})
@@ -29,6 +29,10 @@ func NewCreateTaskParams(action *models.Action, taskGuid, rootFS, domain, logSou
 	}
 }
 
+func (c *CreateTaskParams) SetResultFile(resultFile string) {
+	c.receptorRequest.ResultFile = resultFile
+}
+
 func (c *CreateTaskParams) GetReceptorRequest() receptor.TaskCreateRequest {
 	return c.receptorRequest
 }
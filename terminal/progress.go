@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/pivotal-golang/bytefmt"
+
+	"github.com/cloudfoundry-incubator/ltc/terminal/cursor"
+)
+
+// Progress shows a transfer's progress on a single line of ui, rewriting it
+// in place.
+type Progress struct {
+	ui   UI
+	line string
+}
+
+func NewProgress(ui UI) *Progress {
+	return &Progress{ui: ui}
+}
+
+// Update has the signature of blob.Progress.  It only writes to the terminal
+// when the displayed figures change.
+func (p *Progress) Update(transferred, total int64) {
+	line := bytefmt.ByteSize(uint64(transferred))
+	if total > 0 {
+		line = fmt.Sprintf("%s of %s (%d%%)", line, bytefmt.ByteSize(uint64(total)), transferred*100/total)
+	}
+
+	if line == p.line {
+		return
+	}
+
+	p.line = line
+	p.ui.Say("\r%s%s", cursor.ClearToEndOfLine(), line)
+}
+
+// Done ends the progress line, if one was shown.
+func (p *Progress) Done() {
+	if p.line != "" {
+		p.ui.SayNewLine()
+		p.line = ""
+	}
+}
@@ -0,0 +1,60 @@
+package terminal_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/ltc/terminal"
+	"github.com/cloudfoundry-incubator/ltc/terminal/mocks"
+)
+
+var _ = Describe("Progress", func() {
+	var (
+		outputBuffer *gbytes.Buffer
+		progress     *terminal.Progress
+		previousTerm string
+	)
+
+	BeforeEach(func() {
+		previousTerm = os.Getenv("TERM")
+		Expect(os.Setenv("TERM", "")).To(Succeed())
+
+		outputBuffer = gbytes.NewBuffer()
+		progress = terminal.NewProgress(terminal.NewUI(nil, outputBuffer, &mocks.FakePasswordReader{}))
+	})
+
+	AfterEach(func() {
+		Expect(os.Setenv("TERM", previousTerm)).To(Succeed())
+	})
+
+	It("rewrites the line with the bytes transferred and the percentage", func() {
+		progress.Update(512*1024, 2*1024*1024)
+		progress.Update(1024*1024, 2*1024*1024)
+		progress.Done()
+
+		Expect(string(outputBuffer.Contents())).To(Equal("\r512K of 2M (25%)\r1M of 2M (50%)\n"))
+	})
+
+	It("shows only the bytes transferred when the total is unknown", func() {
+		progress.Update(2048, -1)
+		progress.Done()
+
+		Expect(string(outputBuffer.Contents())).To(Equal("\r2K\n"))
+	})
+
+	It("only writes when the figures change", func() {
+		progress.Update(2048, -1)
+		progress.Update(2049, -1)
+
+		Expect(string(outputBuffer.Contents())).To(Equal("\r2K"))
+	})
+
+	It("writes nothing when no progress was shown", func() {
+		progress.Done()
+
+		Expect(outputBuffer.Contents()).To(BeEmpty())
+	})
+})
@@ -3,9 +3,9 @@ package version
 import (
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 
+	"github.com/cloudfoundry-incubator/ltc/auth"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/receptor_client"
 )
@@ -39,13 +39,15 @@ type versionManager struct {
 	receptorClientCreator receptor_client.Creator
 	fileSwapper           FileSwapper
 	latticeVersion        string
+	tokenSource           auth.TokenSource
 }
 
-func NewVersionManager(receptorClientCreator receptor_client.Creator, fileSwapper FileSwapper, latticeVersion string) *versionManager {
+func NewVersionManager(receptorClientCreator receptor_client.Creator, fileSwapper FileSwapper, latticeVersion string, tokenSource auth.TokenSource) *versionManager {
 	return &versionManager{
 		receptorClientCreator,
 		fileSwapper,
 		latticeVersion,
+		tokenSource,
 	}
 }
 
@@ -67,7 +69,17 @@ func (v *versionManager) ServerVersions(receptorTarget string) (ServerVersions,
 }
 
 func (s *versionManager) SyncLTC(ltcPath string, arch string, config *config_package.Config) error {
-	response, err := http.DefaultClient.Get(fmt.Sprintf("%s/v1/sync/%s/ltc", config.Receptor(), arch))
+	client, err := config.TLS().HTTPClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to receptor: %s", err.Error())
+	}
+
+	// the receptor URL only carries credentials in basic auth mode
+	if s.tokenSource != nil {
+		client.Transport = &auth.Transport{Base: client.Transport, TokenSource: s.tokenSource}
+	}
+
+	response, err := client.Get(fmt.Sprintf("%s/v1/sync/%s/ltc", config.Receptor(), arch))
 	if err != nil {
 		return fmt.Errorf("failed to connect to receptor: %s", err.Error())
 	}
@@ -12,6 +12,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 
+	"github.com/cloudfoundry-incubator/ltc/auth/fake_token_source"
 	config_package "github.com/cloudfoundry-incubator/ltc/config"
 	"github.com/cloudfoundry-incubator/ltc/receptor_client/fake_receptor_client_creator"
 	"github.com/cloudfoundry-incubator/ltc/version"
@@ -28,6 +29,7 @@ var _ = Describe("VersionManager", func() {
 		versionManager            version.VersionManager
 		fakeReceptorClientCreator *fake_receptor_client_creator.FakeCreator
 		fakeReceptorClient        *fake_receptor.FakeClient
+		fakeTokenSource           *fake_token_source.FakeTokenSource
 
 		ltcTempFile *os.File
 	)
@@ -48,10 +50,11 @@ var _ = Describe("VersionManager", func() {
 		fakeReceptorClient = &fake_receptor.FakeClient{}
 		fakeReceptorClientCreator = &fake_receptor_client_creator.FakeCreator{}
 		fakeReceptorClientCreator.CreateReceptorClientReturns(fakeReceptorClient)
+		fakeTokenSource = &fake_token_source.FakeTokenSource{}
 
 		config = config_package.New(nil)
 		config.SetTarget(fakeServerHost + ".xip.io:" + fakeServerPort)
-		versionManager = version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "")
+		versionManager = version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "", fakeTokenSource)
 	})
 
 	AfterEach(func() {
@@ -134,6 +137,39 @@ var _ = Describe("VersionManager", func() {
 			Expect(bytes).To(Equal([]byte{0x01, 0x02, 0x03}))
 		})
 
+		Context("when logged in with a token", func() {
+			BeforeEach(func() {
+				config.SetAuthMode(config_package.TokenAuth, "https://login.some-host/oauth/token")
+				fakeTokenSource.TokenReturns("some-token", nil)
+			})
+
+			It("sends the token with the request", func() {
+				fakeServer.RouteToHandler("GET", "/v1/sync/amiga/ltc", ghttp.CombineHandlers(
+					ghttp.VerifyHeader(http.Header{"Authorization": []string{"bearer some-token"}}),
+					ghttp.RespondWith(200, []byte{0x01, 0x02, 0x03}),
+				))
+
+				tmpFile, err := ioutil.TempFile("", "")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Remove(tmpFile.Name())
+
+				fakeFileSwapper.GetTempFileReturns(tmpFile, nil)
+
+				Expect(versionManager.SyncLTC(ltcTempFile.Name(), "amiga", config)).To(Succeed())
+
+				Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+				Expect(fakeTokenSource.TokenCallCount()).To(Equal(1))
+			})
+
+			It("returns an error when there is no token", func() {
+				fakeTokenSource.TokenReturns("", errors.New("Not logged in. Run ltc login."))
+
+				err := versionManager.SyncLTC(ltcTempFile.Name(), "amiga", config)
+				Expect(err).To(MatchError(ContainSubstring("Not logged in. Run ltc login.")))
+				Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
 		Context("when the http request returns a non-200 status", func() {
 			It("should return an error", func() {
 				fakeServer.RouteToHandler("GET", "/v1/sync/amiga/ltc", ghttp.CombineHandlers(
@@ -216,7 +252,7 @@ var _ = Describe("VersionManager", func() {
 
 	Describe("#LatticeVersion", func() {
 		It("should return its latticeVersion", func() {
-			versionManager := version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "some-version")
+			versionManager := version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "some-version", nil)
 			Expect(versionManager.LatticeVersion()).To(Equal("some-version"))
 		})
 	})
@@ -237,7 +273,7 @@ var _ = Describe("VersionManager", func() {
 
 		Context("when the local lattice version matches the server's expected version", func() {
 			It("should return true", func() {
-				versionManager := version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "v223")
+				versionManager := version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "v223", nil)
 				Expect(versionManager.LtcMatchesServer("some-target")).To(BeTrue())
 				Expect(fakeReceptorClientCreator.CreateReceptorClientCallCount()).To(Equal(1))
 				Expect(fakeReceptorClientCreator.CreateReceptorClientArgsForCall(0)).To(Equal("some-target"))
@@ -246,7 +282,7 @@ var _ = Describe("VersionManager", func() {
 
 		Context("when the local lattice version does not match the server's expected version", func() {
 			It("should return false", func() {
-				versionManager := version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "mismatched-version")
+				versionManager := version.NewVersionManager(fakeReceptorClientCreator, fakeFileSwapper, "mismatched-version", nil)
 				Expect(versionManager.LtcMatchesServer("some-target")).To(BeFalse())
 				Expect(fakeReceptorClientCreator.CreateReceptorClientCallCount()).To(Equal(1))
 				Expect(fakeReceptorClientCreator.CreateReceptorClientArgsForCall(0)).To(Equal("some-target"))